@@ -0,0 +1,150 @@
+// cbrstub - локальная заглушка SOAP веб-сервиса ЦБ РФ (метод KeyRate) для работы без доступа к cbr.ru.
+//
+// Запуск:
+//
+//	go run ./cmd/cbrstub -addr :8089 -rate 21 -fail 2
+//
+// и CBR_URL=http://localhost:8089/DailyInfoWebServ/DailyInfo.asmx в .env сервиса.
+// Флаг -fail задает число первых запросов, на которые вернется 503 (проверка повторов),
+// -fault - код SOAP Fault, который возвращается на каждый запрос (Sender или Receiver).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+func main() {
+	addr := flag.String("addr", ":8089", "адрес для входящих запросов")
+	rate := flag.Float64("rate", 21, "ключевая ставка, которую возвращает заглушка")
+	changeDate := flag.String("change-date", "", "дата изменения ставки (YYYY-MM-DD); до нее возвращается -prev-rate")
+	prevRate := flag.Float64("prev-rate", 0, "ставка до даты изменения")
+	fail := flag.Int("fail", 0, "число первых запросов, на которые возвращается 503")
+	fault := flag.String("fault", "", "код SOAP Fault для всех ответов: Sender или Receiver")
+	flag.Parse()
+
+	var changeAt time.Time
+	if *changeDate != "" {
+		parsed, err := time.Parse("2006-01-02", *changeDate)
+		if err != nil {
+			log.Fatalf("Неверная дата изменения ставки: %v", err)
+		}
+		changeAt = parsed
+	}
+
+	var requests int64
+	http.HandleFunc("/DailyInfoWebServ/DailyInfo.asmx", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&requests, 1)
+		if n <= int64(*fail) {
+			log.Printf("Запрос %d: возвращаем 503", n)
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		if *fault != "" {
+			log.Printf("Запрос %d: возвращаем SOAP Fault %s", n, *fault)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, faultResponse(*fault, "Заглушка вернула ошибку"))
+			return
+		}
+
+		from, to, err := parseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, faultResponse("Sender", err.Error()))
+			return
+		}
+
+		log.Printf("Запрос %d: ставка за период %s - %s", n, from.Format("2006-01-02"), to.Format("2006-01-02"))
+		fmt.Fprint(w, keyRateResponse(from, to, func(day time.Time) float64 {
+			if !changeAt.IsZero() && day.Before(changeAt) {
+				return *prevRate
+			}
+			return *rate
+		}))
+	})
+
+	log.Printf("Заглушка ЦБ РФ слушает %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// parseRequest извлекает период из SOAP-запроса KeyRate
+func parseRequest(body []byte) (time.Time, time.Time, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(body); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректный XML: %v", err)
+	}
+
+	fromEl := doc.FindElement("//KeyRate/fromDate")
+	toEl := doc.FindElement("//KeyRate/ToDate")
+	if fromEl == nil || toEl == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("не указан период")
+	}
+
+	from, err := time.Parse("2006-01-02", strings.TrimSpace(fromEl.Text()))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверная дата fromDate: %v", err)
+	}
+	to, err := time.Parse("2006-01-02", strings.TrimSpace(toEl.Text()))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверная дата ToDate: %v", err)
+	}
+	return from, to, nil
+}
+
+// keyRateResponse формирует ответ в формате ЦБ: значения за рабочие дни, от новых к старым
+func keyRateResponse(from, to time.Time, rateOn func(time.Time) float64) string {
+	var rows strings.Builder
+	for day := to; !day.Before(from); day = day.AddDate(0, 0, -1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		fmt.Fprintf(&rows, `
+                        <KR diffgr:id="KR%s" msdata:rowOrder="0">
+                            <DT>%sT00:00:00+03:00</DT>
+                            <Rate>%.2f</Rate>
+                        </KR>`, day.Format("20060102"), day.Format("2006-01-02"), rateOn(day))
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+    <soap:Body>
+        <KeyRateResponse xmlns="http://web.cbr.ru/">
+            <KeyRateResult>
+                <diffgr:diffgram xmlns:msdata="urn:schemas-microsoft-com:xml-msdata" xmlns:diffgr="urn:schemas-microsoft-com:xml-diffgram-v1">
+                    <KeyRate xmlns="">` + rows.String() + `
+                    </KeyRate>
+                </diffgr:diffgram>
+            </KeyRateResult>
+        </KeyRateResponse>
+    </soap:Body>
+</soap:Envelope>`
+}
+
+// faultResponse формирует SOAP 1.2 Fault
+func faultResponse(code, reason string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+    <soap:Body>
+        <soap:Fault>
+            <soap:Code><soap:Value>soap:%s</soap:Value></soap:Code>
+            <soap:Reason><soap:Text xml:lang="ru">%s</soap:Text></soap:Reason>
+        </soap:Fault>
+    </soap:Body>
+</soap:Envelope>`, code, reason)
+}
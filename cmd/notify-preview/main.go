@@ -0,0 +1,68 @@
+// notify-preview формирует все уведомления по шаблонам на тестовых данных и сохраняет
+// письма в .html и .txt для просмотра в браузере, в том числе с шаблонами из
+// NOTIFY_TEMPLATES_DIR:
+//
+//	go run ./cmd/notify-preview -templates ./my-templates -out /tmp/preview
+//
+// Сравнение с эталонами выполняется тестом TestNotificationTemplates в internal/service.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/notify"
+	"banking-api/internal/service"
+)
+
+func main() {
+	templatesDir := flag.String("templates", "", "каталог шаблонов, заменяющих встроенные (как NOTIFY_TEMPLATES_DIR)")
+	out := flag.String("out", "", "каталог для сохранения писем (.html и .txt)")
+	flag.Parse()
+	if *out == "" {
+		log.Fatal("Укажите каталог для сохранения писем флагом -out")
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	templates, err := notify.NewTemplates(*templatesDir, logger)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки шаблонов: %v", err)
+	}
+	recorder := notify.NewRecorder("")
+	sender := service.NewNotificationSender(recorder, templates, nil, true, logger)
+	sender.SetClock(func() time.Time { return service.PreviewClock })
+
+	count := 0
+	for _, locale := range notify.Locales {
+		dir := filepath.Join(*out, locale)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Fatalf("Ошибка создания каталога: %v", err)
+		}
+
+		to := service.Recipient{Email: "client@example.com", Locale: locale}
+		for _, preview := range service.NotificationPreviews(recorder) {
+			msg, err := preview.Build(sender, to)
+			if err != nil {
+				log.Fatalf("%s/%s: %v", locale, preview.Name, err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, preview.Name+".html"), []byte(msg.HTML), 0o644); err != nil {
+				log.Fatalf("Ошибка записи: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, preview.Name+".txt"), []byte(msg.Subject+"\n\n"+msg.Text+"\n"), 0o644); err != nil {
+				log.Fatalf("Ошибка записи: %v", err)
+			}
+			count++
+		}
+	}
+
+	fmt.Printf("Сохранено писем: %d в %s\n", count, *out)
+}
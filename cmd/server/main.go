@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
@@ -17,10 +18,15 @@ import (
 	"banking-api/internal/config"
 	"banking-api/internal/crypto"
 	"banking-api/internal/handler"
+	"banking-api/internal/model"
+	"banking-api/internal/notify"
 	"banking-api/internal/repository"
 	"banking-api/internal/service"
 )
 
+// shutdownTimeout - время на завершение фоновых задач и активных HTTP запросов при остановке
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	logger := logrus.New()
 	// Уровень логирования (Debug для разработки, Info для продакшена)
@@ -34,10 +40,11 @@ func main() {
 	}
 
 	// Подключение к PostgreSQL
-	db, err := sql.Open("postgres", fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
-	))
+	)
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		logger.Fatalf("Ошибка подключения к базе данных: %v", err)
 	}
@@ -55,6 +62,13 @@ func main() {
 	}
 
 	pgpKey := pgpManager.GetEntity()
+
+	// Ключи подписи JWT: активный ключ подписывает токены, остальные только проверяют
+	jwtKeys, err := crypto.NewJWTKeySet(cfg.JWTKeysDir, cfg.JWTActiveKeyID, cfg.JWTAlgorithm)
+	if err != nil {
+		logger.Fatalf("Ошибка инициализации ключей JWT: %v", err)
+	}
+	logger.WithField("kid", jwtKeys.ActiveKeyID()).Info("Токены подписываются активным ключом JWT")
 	hmacKey := []byte(os.Getenv("HMAC_SECRET"))
 	if len(hmacKey) == 0 {
 		logger.Fatal("Переменная окружения HMAC_SECRET не установлена")
@@ -66,40 +80,182 @@ func main() {
 	// Инициализация репозиториев
 	logger.Info("Инициализация репозиториев...")
 	userRepo := repository.NewUserRepository(db, logger)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db, logger)
+	twoFactorRepo := repository.NewTwoFactorRepository(db, logger)
 	accountRepo := repository.NewAccountRepository(db, logger)
 	transactionRepo := repository.NewTransactionRepository(db, logger)
 	cardRepo := repository.NewCardRepository(db, logger)
 	creditRepo := repository.NewCreditRepository(db, logger)
-	emailSender := service.NewEmailSender(logger)
+	creditLineRepo := repository.NewCreditLineRepository(db, logger)
+	keyRateRepo := repository.NewKeyRateRepository(db, logger)
+	creditProductRepo := repository.NewCreditProductRepository(db, logger)
+	collectionRepo := repository.NewCollectionRepository(db, logger)
+	jobRunRepo := repository.NewJobRunRepository(db, logger)
+	passwordResetRepo := repository.NewPasswordResetRepository(db, logger)
+	outboxRepo := repository.NewOutboxRepository(db, logger)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db, logger)
+	auditRepo := repository.NewAuditRepository(db, logger)
+	apiKeyRepo := repository.NewAPIKeyRepository(db, logger)
+	notificationRepo := repository.NewNotificationRepository(db, logger)
+	webhookRepo := repository.NewWebhookRepository(db, logger)
+	categoryRepo := repository.NewCategoryRepository(db, logger)
+	budgetRepo := repository.NewBudgetRepository(db, logger)
+	notifier, closeNotifier, err := newNotifier(cfg, logger)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки каналов уведомлений: %v", err)
+	}
+	defer closeNotifier()
+	templates, err := notify.NewTemplates(cfg.NotifyTemplatesDir, logger)
+	if err != nil {
+		logger.Fatalf("Ошибка загрузки шаблонов уведомлений: %v", err)
+	}
+	notifications := service.NewNotificationSender(notifier, templates, notificationRepo, cfg.EmailEnabled, logger)
+
+	// Поток событий /api/events: при EVENTS_BACKEND=postgres события расходятся
+	// по всем экземплярам через LISTEN/NOTIFY
+	eventHub := service.NewEventHub()
+	eventBroker, err := service.NewEventBroker(cfg.EventsBackend, db, dsn, eventHub, logger)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки потока событий: %v", err)
+	}
+	events := service.NewEventPublisher(eventBroker, accountRepo, logger)
 
 	// Инициализация сервисов
 	logger.Info("Инициализация сервисов...")
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret, cfg.TokenExpiry, logger)
-	accountService := service.NewAccountService(userRepo, accountRepo, transactionRepo, emailSender, logger)
-	cardService := service.NewCardService(userRepo, cardRepo, accountRepo, transactionRepo, emailSender, pgpKey, hmacKey, logger)
-	cbrClient := service.NewCBRClient(logger)
-	creditService := service.NewCreditService(
+	twoFactorService := service.NewTwoFactorService(userRepo, twoFactorRepo, pgpKey, hmacKey, cfg.TOTPIssuer, logger)
+	loginLimiter, err := service.NewLoginLimiter(cfg.LoginLimiter, loginAttemptRepo)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки ограничения попыток входа: %v", err)
+	}
+	// Для учетной записи: первые 3 ошибки без задержки, затем 1с, 2с, 4с... до блокировки.
+	// Для IP адреса пороги выше, так как за одним адресом может быть много пользователей.
+	loginGuard := service.NewLoginGuard(
+		loginLimiter,
+		service.LoginPolicy{
+			FreeAttempts:    3,
+			BaseDelay:       time.Second,
+			MaxDelay:        5 * time.Minute,
+			LockoutAfter:    cfg.LoginMaxAttempts,
+			LockoutDuration: cfg.LoginLockoutDuration,
+			ResetAfter:      time.Hour,
+		},
+		service.LoginPolicy{
+			FreeAttempts:    20,
+			BaseDelay:       time.Second,
+			MaxDelay:        5 * time.Minute,
+			LockoutAfter:    cfg.LoginMaxAttempts * 10,
+			LockoutDuration: cfg.LoginLockoutDuration,
+			ResetAfter:      time.Hour,
+		},
+		notifications,
+		logger,
+	)
+	authService := service.NewAuthService(
 		userRepo,
+		refreshTokenRepo,
+		passwordResetRepo,
+		twoFactorService,
+		loginGuard,
+		notifications,
+		jwtKeys,
+		hmacKey,
+		cfg.AppBaseURL,
+		cfg.TokenExpiry,
+		cfg.RefreshTokenExpiry,
+		logger,
+	)
+	categoryService := service.NewCategoryService(categoryRepo, transactionRepo, logger)
+	accountService := service.NewAccountService(userRepo, accountRepo, transactionRepo, outboxRepo, events, logger)
+	cardService := service.NewCardService(userRepo, cardRepo, accountRepo, transactionRepo, creditLineRepo, outboxRepo, categoryService, events, pgpKey, hmacKey, logger)
+	cbrClient := service.NewCBRClient(cfg.CBRURL, cfg.CBRTimeout, cfg.CBRMaxRetries, logger)
+	creditService := service.NewCreditService(
 		creditRepo,
+		creditProductRepo,
+		keyRateRepo,
 		accountRepo,
 		transactionRepo,
-		emailSender,
+		outboxRepo,
+		events,
 		cbrClient,
 		logger,
 	)
+	creditLineService := service.NewCreditLineService(
+		creditLineRepo,
+		cardRepo,
+		accountRepo,
+		transactionRepo,
+		outboxRepo,
+		events,
+		keyRateRepo,
+		logger,
+	)
+	creditProductService := service.NewCreditProductService(creditProductRepo, logger)
+	webhookService := service.NewWebhookService(webhookRepo, pgpKey, cfg.WebhookAllowLocalhost, logger)
+	collectionService := service.NewCollectionService(
+		collectionRepo,
+		creditRepo,
+		accountRepo,
+		transactionRepo,
+		outboxRepo,
+		webhookService,
+		events,
+		logger,
+	)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, accountRepo, logger)
+	profileService := service.NewProfileService(
+		userRepo,
+		accountRepo,
+		transactionRepo,
+		creditRepo,
+		creditLineRepo,
+		refreshTokenRepo,
+		passwordResetRepo,
+		twoFactorRepo,
+		apiKeyRepo,
+		notificationRepo,
+		webhookRepo,
+		categoryRepo,
+		budgetRepo,
+		cardService,
+		twoFactorService,
+		notifications,
+		logger,
+	)
+	jobRunner := service.NewJobRunner(jobRunRepo, logger)
+	notificationService := service.NewNotificationService(notificationRepo, accountRepo, userRepo, notifications, logger)
 	analyticsService := service.NewAnalyticService(
 		transactionRepo,
 		creditRepo,
 		accountRepo,
 		logger,
 	)
+	budgetService := service.NewBudgetService(budgetRepo, outboxRepo, analyticsService, logger)
+	outboxDispatcher := service.NewOutboxDispatcher(outboxRepo, userRepo, notifications, notificationService, webhookService, budgetService, logger)
+	adminService := service.NewAdminService(userRepo, accountRepo, transactionRepo, refreshTokenRepo, auditRepo, events, logger)
+	if err := adminService.BootstrapAdmins(context.Background(), cfg.AdminIDs); err != nil {
+		logger.Fatalf("Ошибка назначения администраторов: %v", err)
+	}
 
 	// Инициализация HTTP обработчиков
 	logger.Info("Инициализация обработчиков API...")
 	authHandler := handler.NewAuthHandler(authService, logger)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService, logger)
 	accountHandler := handler.NewAccountHandler(accountService, logger)
 	cardHandler := handler.NewCardHandler(cardService, logger)
 	creditHandler := handler.NewCreditHandler(creditService, logger)
+	creditLineHandler := handler.NewCreditLineHandler(creditLineService, logger)
+	creditProductHandler := handler.NewCreditProductHandler(creditProductService, logger)
+	collectionHandler := handler.NewCollectionHandler(collectionService, logger)
+	jobHandler := handler.NewJobHandler(jobRunner, logger)
+	outboxHandler := handler.NewOutboxHandler(outboxDispatcher, logger)
+	adminHandler := handler.NewAdminHandler(adminService, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, logger)
+	profileHandler := handler.NewProfileHandler(profileService, authService, logger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, logger)
+	webhookHandler := handler.NewWebhookHandler(webhookService, logger)
+	categoryHandler := handler.NewCategoryHandler(categoryService, logger)
+	budgetHandler := handler.NewBudgetHandler(budgetService, logger)
+	eventHandler := handler.NewEventHandler(eventHub, logger)
 	analyticsHandler := handler.NewAnalyticsHandler(
 		accountService,
 		creditService,
@@ -112,48 +268,200 @@ func main() {
 
 	// 1. Публичные маршруты для аутентификации
 	publicRouter := router.PathPrefix("/auth").Subrouter()
-	authHandler.RegisterRoutes(publicRouter) // Регистрация /signup и /signin
+	authHandler.RegisterRoutes(publicRouter) // Регистрация, вход, обновление токенов, подтверждение email и сброс пароля
+
+	// Открытые ключи для проверки access токенов другими сервисами
+	router.HandleFunc("/.well-known/jwks.json", authHandler.JWKS).Methods("GET")
 
-	// 2. Защищенные API маршруты (требуется JWT токен)
+	// 2. Защищенные API маршруты (требуется JWT токен; часть маршрутов доступна по API ключу)
 	apiRouter := router.PathPrefix("/api").Subrouter()
-	apiRouter.Use(handler.AuthMiddleware(authService, logger))
+	apiRouter.Use(handler.AuthMiddleware(authService, apiKeyService, logger))
+
+	// Операции со средствами доступны только после подтверждения email
+	requireVerified := handler.VerifiedEmailMiddleware(authService, logger)
+
+	// Повторная отправка ссылки подтверждения email
+	emailRouter := apiRouter.PathPrefix("/email").Subrouter()
+	authHandler.RegisterEmailRoutes(emailRouter)
+
+	// Активные сессии пользователя
+	sessionRouter := apiRouter.PathPrefix("/sessions").Subrouter()
+	authHandler.RegisterSessionRoutes(sessionRouter)
+
+	// Профиль: имя, email, пароль, выгрузка данных и удаление учетной записи
+	profileRouter := apiRouter.PathPrefix("/profile").Subrouter()
+	profileHandler.RegisterRoutes(profileRouter)
+
+	// Настройки уведомлений и пороговые оповещения
+	notificationRouter := apiRouter.PathPrefix("/notifications").Subrouter()
+	notificationHandler.RegisterRoutes(notificationRouter)
+
+	// Поток событий счетов (SSE или WebSocket)
+	eventRouter := apiRouter.PathPrefix("/events").Subrouter()
+	eventHandler.RegisterRoutes(eventRouter)
+
+	// Webhook подписки и журнал доставок
+	webhookRouter := apiRouter.PathPrefix("/webhooks").Subrouter()
+	webhookHandler.RegisterRoutes(webhookRouter)
+
+	// Категории расходов и правила категорий
+	categoryRouter := apiRouter.PathPrefix("/categories").Subrouter()
+	categoryHandler.RegisterRoutes(categoryRouter)
+
+	// Месячные бюджеты расходов
+	budgetRouter := apiRouter.PathPrefix("/budgets").Subrouter()
+	budgetHandler.RegisterRoutes(budgetRouter)
+
+	// Сервисные учетные записи и API ключи интеграций
+	serviceAccountRouter := apiRouter.PathPrefix("/service-accounts").Subrouter()
+	apiKeyHandler.RegisterRoutes(serviceAccountRouter)
+
+	// Двухфакторная аутентификация
+	twoFactorRouter := apiRouter.PathPrefix("/2fa").Subrouter()
+	twoFactorHandler.RegisterRoutes(twoFactorRouter)
 
 	// Маршруты для работы со счетами
 	accountRouter := apiRouter.PathPrefix("/accounts").Subrouter()
-	accountHandler.RegisterRoutes(accountRouter)
+	accountHandler.RegisterRoutes(accountRouter, requireVerified)
 
 	// Маршруты для работы с картами
 	cardRouter := apiRouter.PathPrefix("/cards").Subrouter()
-	cardHandler.RegisterRoutes(cardRouter)
+	cardHandler.RegisterRoutes(cardRouter, requireVerified)
 
 	// Маршруты для работы с кредитами
 	creditRouter := apiRouter.PathPrefix("/credits").Subrouter()
-	creditHandler.RegisterRoutes(creditRouter)
+	creditHandler.RegisterRoutes(creditRouter, requireVerified)
+
+	// Маршруты для работы с кредитными линиями карт
+	creditLineRouter := apiRouter.PathPrefix("/credit-lines").Subrouter()
+	creditLineHandler.RegisterRoutes(creditLineRouter, requireVerified)
+
+	// Каталог кредитных продуктов
+	creditProductRouter := apiRouter.PathPrefix("/credit-products").Subrouter()
+	creditProductHandler.RegisterRoutes(creditProductRouter)
 
 	analyticsRouter := apiRouter.PathPrefix("/analytics").Subrouter()
 	analyticsHandler.RegisterRoutes(analyticsRouter)
 
-	// Настройка планировщика для автоматической обработки платежей
+	// 3. Административные маршруты (JWT токен + право, которое дает роль пользователя)
+	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
+	adminHandler.RegisterRoutes(adminRouter) // Пользователи, счета и журнал: права проверяются для каждого маршрута
+
+	// Обращения к остальным разделам записываются в журнал действий middleware
+	auditMiddleware := handler.AuditMiddleware(adminService, logger)
+	adminSection := func(prefix string, permission model.Permission) *mux.Router {
+		section := adminRouter.PathPrefix(prefix).Subrouter()
+		section.Use(handler.RequirePermission(permission, logger), auditMiddleware)
+		return section
+	}
+	creditProductHandler.RegisterAdminRoutes(adminSection("/credit-products", model.PermissionCreditProducts))
+	creditHandler.RegisterAdminRoutes(adminSection("/credit-requests", model.PermissionCreditRequests))
+	collectionHandler.RegisterAdminRoutes(adminSection("/collections", model.PermissionCollectionsManage))
+	outboxHandler.RegisterAdminRoutes(adminSection("/outbox", model.PermissionOutboxManage))
+	jobRouter := adminRouter.PathPrefix("/jobs").Subrouter()
+	jobRouter.Use(handler.RequirePermission(model.PermissionJobsRead, logger))
+	jobHandler.RegisterAdminRoutes(jobRouter)
+
+	// Настройка планировщика для автоматической обработки платежей.
+	// Задачи получают контекст, отменяемый при остановке сервера, и выполняются
+	// под advisory-блокировкой, поэтому при нескольких экземплярах запускаются один раз.
 	logger.Info("Настройка планировщика обработки платежей...")
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
 	c := cron.New()
 	_, err = c.AddFunc("0 */12 * * *", func() {
-		logger.Info("Запуск автоматической обработки платежей по кредитам")
-		if err := creditService.ProcessPayments(context.Background()); err != nil {
-			logger.WithError(err).Error("Ошибка обработки платежей")
-		} else {
-			logger.Info("Автоматическая обработка платежей завершена успешно")
-		}
+		jobRunner.Run(jobsCtx, "credit_payments", creditService.ProcessPayments)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("0 9 * * *", func() {
+		jobRunner.Run(jobsCtx, "key_rate_sync", creditService.SyncKeyRate)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("0 1 * * *", func() {
+		jobRunner.Run(jobsCtx, "credit_lines", func(ctx context.Context) (model.JobStats, error) {
+			return model.JobStats{}, creditLineService.ProcessCreditLines(ctx)
+		})
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("0 10 * * *", func() {
+		jobRunner.Run(jobsCtx, "collections", func(ctx context.Context) (model.JobStats, error) {
+			return model.JobStats{}, collectionService.ProcessCollections(ctx)
+		})
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("30 3 * * *", func() {
+		jobRunner.Run(jobsCtx, "auth_tokens_cleanup", authService.CleanupTokens)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("15 * * * *", func() {
+		jobRunner.Run(jobsCtx, "login_attempts_cleanup", loginGuard.Cleanup)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("45 3 * * *", func() {
+		jobRunner.Run(jobsCtx, "outbox_cleanup", outboxDispatcher.Cleanup)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("0 8 * * *", func() {
+		jobRunner.Run(jobsCtx, "notification_digest", notificationService.SendDigests)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("50 3 * * *", func() {
+		jobRunner.Run(jobsCtx, "webhook_cleanup", webhookService.Cleanup)
+	})
+	if err != nil {
+		logger.Fatalf("Ошибка настройки планировщика: %v", err)
+	}
+	_, err = c.AddFunc("55 3 * * *", func() {
+		jobRunner.Run(jobsCtx, "notification_inbox_cleanup", notificationService.CleanupInbox)
 	})
 	if err != nil {
 		logger.Fatalf("Ошибка настройки планировщика: %v", err)
 	}
 	c.Start()
 
+	// Доставка уведомлений из outbox. Каждое событие захватывается одним экземпляром,
+	// поэтому обработчик запускается на всех экземплярах сервиса.
+	outboxDone := make(chan struct{})
+	go func() {
+		outboxDispatcher.Start(jobsCtx, cfg.OutboxPollInterval)
+		close(outboxDone)
+	}()
+
+	// Доставка webhook. Доставки захватываются так же, как события outbox.
+	webhookDone := make(chan struct{})
+	go func() {
+		webhookService.Start(jobsCtx, cfg.OutboxPollInterval)
+		close(webhookDone)
+	}()
+
+	// Прием событий потока от других экземпляров (для EVENTS_BACKEND=postgres)
+	go eventBroker.Start(jobsCtx)
+
+	// Кредиты читают ставку из истории, поэтому при старте догружаем пропущенные значения
+	go jobRunner.Run(jobsCtx, "key_rate_sync", creditService.SyncKeyRate)
+
 	// Настройка и запуск HTTP сервера
 	server := &http.Server{
 		Addr:    ":8080",
 		Handler: router,
 	}
+	// Потоковые ответы не завершаются сами, поэтому при остановке закрываем подписки
+	server.RegisterOnShutdown(eventHub.Close)
 
 	go func() {
 		logger.Info("Запуск сервера на порту :8080")
@@ -168,8 +476,88 @@ func main() {
 	<-quit
 
 	logger.Info("Завершение работы сервера...")
-	if err := server.Shutdown(context.Background()); err != nil {
+
+	// Останавливаем планировщик: новые задачи не запускаются, текущие получают отмену
+	// и завершают обработку на границе транзакции
+	cancelJobs()
+	select {
+	case <-c.Stop().Done():
+		logger.Info("Фоновые задачи остановлены")
+	case <-time.After(shutdownTimeout):
+		logger.Warn("Фоновые задачи не завершились за отведенное время")
+	}
+	select {
+	case <-outboxDone:
+	case <-time.After(shutdownTimeout):
+		logger.Warn("Обработчик outbox не завершился за отведенное время")
+	}
+	select {
+	case <-webhookDone:
+	case <-time.After(shutdownTimeout):
+		logger.Warn("Обработчик webhook не завершился за отведенное время")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Errorf("Ошибка при завершении работы сервера: %v", err)
 	}
 	logger.Info("Сервер успешно остановлен")
 }
+
+// newNotifier создает каналы уведомлений, заданные в конфигурации, и маршрутизатор событий.
+// Канал log доступен всегда. Без NOTIFY_ROUTES все события отправляются письмом, а при
+// отключенной отправке писем - в журнал. Возвращаемая функция закрывает файл канала file.
+func newNotifier(cfg *config.Config, logger *logrus.Logger) (*notify.Router, func(), error) {
+	channels := []notify.Notifier{notify.NewLogNotifier(logger)}
+	closeFn := func() {}
+
+	if cfg.EmailEnabled {
+		smtp, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:               cfg.SMTPHost,
+			Port:               cfg.SMTPPort,
+			User:               cfg.SMTPUser,
+			Password:           cfg.SMTPPassword,
+			InsecureSkipVerify: cfg.SMTPInsecureSkipVerify,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		channels = append(channels, smtp)
+	}
+	if cfg.NotifyWebhookURL != "" {
+		channels = append(channels, notify.NewWebhookNotifier(cfg.NotifyWebhookURL, cfg.NotifyWebhookSecret))
+	}
+	if cfg.TelegramBotToken != "" {
+		channels = append(channels, notify.NewTelegramNotifier(cfg.TelegramAPIURL, cfg.TelegramBotToken, cfg.TelegramChatID))
+	}
+	if cfg.NotifyFilePath != "" {
+		file, err := notify.NewFileNotifier(cfg.NotifyFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		channels = append(channels, file)
+		closeFn = func() { file.Close() }
+	}
+
+	routes, err := notify.ParseRoutes(cfg.NotifyRoutes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := routes[notify.DefaultRoute]; !ok {
+		if cfg.EmailEnabled {
+			routes[notify.DefaultRoute] = []string{"email"}
+		} else {
+			logger.Warn("Отправка писем отключена, уведомления выводятся в журнал")
+			routes[notify.DefaultRoute] = []string{"log"}
+		}
+	}
+
+	router, err := notify.NewRouter(channels, routes)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	logger.WithField("routes", router.Describe()).Info("Каналы уведомлений настроены")
+	return router, closeFn, nil
+}
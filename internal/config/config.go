@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,10 +17,51 @@ type Config struct {
 	DBUser      string        // Пользователь базы данных
 	DBPassword  string        // Пароль базы данных
 	DBName      string        // Имя базы данных
-	JWTSecret   string        // Секрет для JWT
-	TokenExpiry time.Duration // Время жизни токена
+	TokenExpiry time.Duration // Время жизни access токена
+	AdminIDs    []string      // ID пользователей, которым при запуске назначается роль admin
+	AppBaseURL  string        // Внешний адрес API для ссылок в письмах
+
+	JWTKeysDir     string // Каталог ключей подписи JWT
+	JWTActiveKeyID string // Идентификатор (kid) ключа, которым подписываются новые токены
+	JWTAlgorithm   string // Алгоритм ключа, создаваемого при пустом каталоге: EdDSA или RS256
+
+	RefreshTokenExpiry time.Duration // Время жизни refresh токена
+	TOTPIssuer         string        // Название сервиса в приложении-аутентификаторе
+
+	LoginLimiter         string        // Хранилище счетчиков попыток входа: postgres или memory
+	LoginMaxAttempts     int           // Неудачных попыток входа до блокировки учетной записи
+	LoginLockoutDuration time.Duration // Длительность блокировки входа
+
+	CBRURL        string        // Адрес SOAP веб-сервиса ЦБ РФ
+	CBRTimeout    time.Duration // Таймаут одного запроса к ЦБ РФ
+	CBRMaxRetries int           // Число повторов запроса к ЦБ РФ
+
+	OutboxPollInterval time.Duration // Интервал опроса outbox обработчиком уведомлений
+
+	EventsBackend string // Доставка событий потока /api/events: memory или postgres (LISTEN/NOTIFY)
+
+	EmailEnabled           bool   // Отправка писем через SMTP
+	SMTPHost               string // Адрес SMTP сервера
+	SMTPPort               int    // Порт SMTP сервера
+	SMTPUser               string // Пользователь SMTP, он же адрес отправителя
+	SMTPPassword           string // Пароль SMTP
+	SMTPInsecureSkipVerify bool   // Не проверять сертификат SMTP сервера
+
+	NotifyRoutes        string // Маршруты уведомлений по типам событий, например "*=email;security.*=email,telegram"
+	NotifyWebhookURL    string // Адрес канала webhook
+	NotifyWebhookSecret string // Секрет подписи запросов канала webhook
+	TelegramBotToken    string // Токен бота канала Telegram
+	TelegramChatID      string // Чат Telegram для уведомлений без личного чата получателя
+	TelegramAPIURL      string // Адрес Telegram Bot API
+	NotifyFilePath      string // Файл канала file (JSON Lines)
+	NotifyTemplatesDir  string // Каталог шаблонов уведомлений, заменяющих встроенные
+
+	WebhookAllowLocalhost bool // Разрешить webhook подписки на http://localhost (только для разработки)
 }
 
+// insecureJWTSecret - прежнее значение JWT_SECRET по умолчанию, опубликованное в исходном коде
+const insecureJWTSecret = "default-secret-key"
+
 // LoadConfig загружает конфигурацию из .env файла
 func LoadConfig() (*Config, error) {
 	// Загружаем переменные окружения из .env файла
@@ -25,10 +69,60 @@ func LoadConfig() (*Config, error) {
 		logrus.Warn("Файл .env не найден")
 	}
 
-	// Парсим время жизни токена
+	// Токены подписываются ключами из JWT_KEYS_DIR. Общеизвестный секрет в окружении означает,
+	// что конфигурация не проверялась, поэтому запуск с ним запрещен.
+	switch os.Getenv("JWT_SECRET") {
+	case "":
+	case insecureJWTSecret:
+		return nil, fmt.Errorf("JWT_SECRET имеет небезопасное значение по умолчанию, удалите переменную и настройте JWT_KEYS_DIR")
+	default:
+		logrus.Warn("JWT_SECRET больше не используется: токены подписываются ключами из JWT_KEYS_DIR")
+	}
+
+	// Парсим время жизни токенов
 	expiry, err := time.ParseDuration(os.Getenv("TOKEN_EXPIRY"))
 	if err != nil {
-		expiry = 24 * time.Hour // По умолчанию 24 часа
+		expiry = 15 * time.Minute // Access токен короткоживущий, продлевается через /auth/refresh
+	}
+
+	refreshExpiry, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_EXPIRY"))
+	if err != nil {
+		refreshExpiry = 30 * 24 * time.Hour // По умолчанию 30 дней
+	}
+
+	loginMaxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || loginMaxAttempts <= 0 {
+		loginMaxAttempts = 10
+	}
+
+	loginLockout, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION"))
+	if err != nil {
+		loginLockout = 30 * time.Minute
+	}
+
+	cbrTimeout, err := time.ParseDuration(os.Getenv("CBR_TIMEOUT"))
+	if err != nil {
+		cbrTimeout = 10 * time.Second
+	}
+
+	cbrRetries, err := strconv.Atoi(os.Getenv("CBR_MAX_RETRIES"))
+	if err != nil || cbrRetries < 0 {
+		cbrRetries = 3
+	}
+
+	outboxPoll, err := time.ParseDuration(os.Getenv("OUTBOX_POLL_INTERVAL"))
+	if err != nil || outboxPoll <= 0 {
+		outboxPoll = 5 * time.Second
+	}
+
+	// Порт SMTP обязателен только при включенной отправке писем
+	emailEnabled := os.Getenv("EMAIL_SENDER_ENABLED") == "true"
+	var smtpPort int
+	if emailEnabled {
+		smtpPort, err = strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil || smtpPort <= 0 {
+			return nil, fmt.Errorf("некорректное значение SMTP_PORT: %q", os.Getenv("SMTP_PORT"))
+		}
 	}
 
 	// Создаем объект конфигурации
@@ -38,8 +132,46 @@ func LoadConfig() (*Config, error) {
 		DBUser:      getEnv("DB_USER", "postgres"),
 		DBPassword:  getEnv("DB_PASSWORD", "postgres"),
 		DBName:      getEnv("DB_NAME", "auth_service"),
-		JWTSecret:   getEnv("JWT_SECRET", "default-secret-key"),
 		TokenExpiry: expiry,
+		AdminIDs:    splitList(os.Getenv("ADMIN_USER_IDS")),
+		AppBaseURL:  getEnv("APP_BASE_URL", "http://localhost:8080"),
+
+		JWTKeysDir:     getEnv("JWT_KEYS_DIR", "config/jwt"),
+		JWTActiveKeyID: os.Getenv("JWT_ACTIVE_KEY_ID"),
+		JWTAlgorithm:   getEnv("JWT_ALGORITHM", "EdDSA"),
+
+		RefreshTokenExpiry: refreshExpiry,
+		TOTPIssuer:         getEnv("TOTP_ISSUER", "Banking API"),
+
+		LoginLimiter:         getEnv("LOGIN_LIMITER", "postgres"),
+		LoginMaxAttempts:     loginMaxAttempts,
+		LoginLockoutDuration: loginLockout,
+
+		CBRURL:        os.Getenv("CBR_URL"), // если не задан, используется адрес ЦБ РФ
+		CBRTimeout:    cbrTimeout,
+		CBRMaxRetries: cbrRetries,
+
+		OutboxPollInterval: outboxPoll,
+
+		EventsBackend: getEnv("EVENTS_BACKEND", "memory"),
+
+		EmailEnabled:           emailEnabled,
+		SMTPHost:               os.Getenv("SMTP_HOST"),
+		SMTPPort:               smtpPort,
+		SMTPUser:               os.Getenv("SMTP_USER"),
+		SMTPPassword:           os.Getenv("SMTP_PASS"),
+		SMTPInsecureSkipVerify: os.Getenv("INSECURE_SKIP_VERIFY") == "true",
+
+		NotifyRoutes:        os.Getenv("NOTIFY_ROUTES"),
+		NotifyWebhookURL:    os.Getenv("NOTIFY_WEBHOOK_URL"),
+		NotifyWebhookSecret: os.Getenv("NOTIFY_WEBHOOK_SECRET"),
+		TelegramBotToken:    os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramChatID:      os.Getenv("TELEGRAM_CHAT_ID"),
+		TelegramAPIURL:      os.Getenv("TELEGRAM_API_URL"), // если не задан, используется api.telegram.org
+		NotifyFilePath:      os.Getenv("NOTIFY_FILE_PATH"),
+		NotifyTemplatesDir:  os.Getenv("NOTIFY_TEMPLATES_DIR"),
+
+		WebhookAllowLocalhost: os.Getenv("WEBHOOK_ALLOW_LOCALHOST") == "true",
 	}
 
 	return config, nil
@@ -53,3 +185,14 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// splitList разбирает список значений, перечисленных через запятую
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,325 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Поддерживаемые алгоритмы подписи JWT
+const (
+	JWTAlgorithmEdDSA = "EdDSA"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+const (
+	jwtRSABits       = 3072 // размер генерируемого RSA ключа
+	jwtMinRSABits    = 2048 // минимальный размер загружаемого RSA ключа
+	jwtPublicKeyExt  = ".pub.pem"
+	jwtPrivateKeyExt = ".pem"
+)
+
+// JWTKey - ключ подписи JWT. Для ключей, оставленных только для проверки
+// уже выданных токенов, закрытая часть отсутствует.
+type JWTKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// CanSign сообщает, есть ли у ключа закрытая часть
+func (k *JWTKey) CanSign() bool {
+	return k.private != nil
+}
+
+// JWTKeySet хранит ключи подписи JWT. Токены подписываются активным ключом, а проверяются
+// любым ключом из набора по заголовку kid, поэтому ключи можно менять без разлогинивания.
+//
+// Ключи читаются из каталога: файл <kid>.pem содержит закрытый ключ (PKCS#8 Ed25519 или RSA,
+// либо PKCS#1 RSA), файл <kid>.pub.pem - открытый ключ, по которому только проверяются токены.
+type JWTKeySet struct {
+	dir    string
+	active *JWTKey
+	keys   map[string]*JWTKey
+}
+
+// NewJWTKeySet загружает ключи из каталога dir. activeKID задает ключ подписи; если он не указан,
+// используется единственный закрытый ключ каталога. Если каталог пуст, генерируется новый ключ
+// алгоритма algorithm (EdDSA или RS256) и сохраняется в каталог.
+func NewJWTKeySet(dir, activeKID, algorithm string) (*JWTKeySet, error) {
+	set := &JWTKeySet{dir: dir, keys: make(map[string]*JWTKey)}
+
+	if err := set.load(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить ключи JWT: %w", err)
+	}
+
+	if len(set.keys) == 0 {
+		kid := activeKID
+		if kid == "" {
+			kid = time.Now().UTC().Format("20060102-150405")
+		}
+		if err := set.generateAndSaveKey(kid, algorithm); err != nil {
+			return nil, fmt.Errorf("не удалось создать ключ JWT: %w", err)
+		}
+	}
+
+	if err := set.selectActive(activeKID); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// ActiveKeyID возвращает идентификатор ключа, которым подписываются новые токены
+func (s *JWTKeySet) ActiveKeyID() string {
+	return s.active.ID
+}
+
+// Methods возвращает алгоритмы, которыми могут быть подписаны принимаемые токены
+func (s *JWTKeySet) Methods() []string {
+	return []string{JWTAlgorithmEdDSA, JWTAlgorithmRS256}
+}
+
+// Sign подписывает claims активным ключом и добавляет заголовок kid
+func (s *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.private)
+}
+
+// Keyfunc выбирает ключ проверки по заголовку kid. Алгоритм токена должен совпадать
+// с алгоритмом ключа, иначе токен отклоняется.
+func (s *JWTKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+
+	return key.public, nil
+}
+
+// JWK - открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS - набор открытых ключей, по которому другие сервисы проверяют токены
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые части всех ключей набора. Активный ключ идет первым.
+func (s *JWTKeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		if id != s.active.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	ids = append([]string{s.active.ID}, ids...)
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		set.Keys = append(set.Keys, s.keys[id].jwk())
+	}
+	return set
+}
+
+func (k *JWTKey) jwk() JWK {
+	jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return jwk
+}
+
+// load читает все ключи из каталога. Отсутствующий каталог считается пустым.
+func (s *JWTKeySet) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("не удалось прочитать каталог %s: %w", s.dir, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, jwtPrivateKeyExt) {
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, jwtPublicKeyExt)
+		if kid == name {
+			kid = strings.TrimSuffix(name, jwtPrivateKeyExt)
+		}
+		if _, exists := s.keys[kid]; exists {
+			return fmt.Errorf("ключ %s задан несколькими файлами", kid)
+		}
+
+		key, err := loadJWTKey(filepath.Join(s.dir, name), kid)
+		if err != nil {
+			return err
+		}
+		s.keys[kid] = key
+	}
+
+	return nil
+}
+
+// selectActive выбирает ключ подписи
+func (s *JWTKeySet) selectActive(activeKID string) error {
+	if activeKID != "" {
+		key, ok := s.keys[activeKID]
+		if !ok {
+			return fmt.Errorf("активный ключ JWT %s не найден в каталоге %s", activeKID, s.dir)
+		}
+		if !key.CanSign() {
+			return fmt.Errorf("для активного ключа JWT %s нет закрытого ключа", activeKID)
+		}
+		s.active = key
+		return nil
+	}
+
+	for _, key := range s.keys {
+		if !key.CanSign() {
+			continue
+		}
+		if s.active != nil {
+			return fmt.Errorf("в каталоге %s несколько закрытых ключей JWT, укажите активный в JWT_ACTIVE_KEY_ID", s.dir)
+		}
+		s.active = key
+	}
+	if s.active == nil {
+		return fmt.Errorf("в каталоге %s нет закрытого ключа JWT", s.dir)
+	}
+	return nil
+}
+
+// loadJWTKey читает ключ из PEM файла и определяет алгоритм по типу ключа
+func loadJWTKey(path, kid string) (*JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать ключ %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("файл %s не содержит PEM блок", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM блока %q в файле %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ключ %s: %w", path, err)
+	}
+
+	key := &JWTKey{ID: kid}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.public = jwt.SigningMethodEdDSA, k
+	case *rsa.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, k, k.Public()
+	case *rsa.PublicKey:
+		key.Method, key.public = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("ключ %s: поддерживаются только Ed25519 и RSA", path)
+	}
+
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < jwtMinRSABits {
+		return nil, fmt.Errorf("ключ %s: размер RSA ключа должен быть не меньше %d бит", path, jwtMinRSABits)
+	}
+
+	return key, nil
+}
+
+// generateAndSaveKey генерирует закрытый ключ и сохраняет его в каталог
+func (s *JWTKeySet) generateAndSaveKey(kid, algorithm string) error {
+	var (
+		private crypto.Signer
+		method  jwt.SigningMethod
+		err     error
+	)
+	switch algorithm {
+	case JWTAlgorithmEdDSA, "":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+		method = jwt.SigningMethodEdDSA
+	case JWTAlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, jwtRSABits)
+		method = jwt.SigningMethodRS256
+	default:
+		return fmt.Errorf("неподдерживаемый алгоритм подписи JWT: %s", algorithm)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации ключа: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("не удалось создать каталог %s: %w", s.dir, err)
+	}
+	path := filepath.Join(s.dir, kid+jwtPrivateKeyExt)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл ключа: %w", err)
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return fmt.Errorf("не удалось записать ключ: %w", err)
+	}
+
+	s.keys[kid] = &JWTKey{ID: kid, Method: method, private: private, public: private.Public()}
+	return nil
+}
@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// EncryptMessage шифрует данные ключом entity и возвращает ASCII-armored PGP сообщение
+func EncryptMessage(entity *openpgp.Entity, data []byte) (string, error) {
+	buf := new(bytes.Buffer)
+
+	armorWriter, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать armor writer: %w", err)
+	}
+
+	config := &packet.Config{
+		DefaultHash:   crypto.SHA256,
+		DefaultCipher: packet.CipherAES256,
+	}
+
+	plaintextWriter, err := openpgp.Encrypt(armorWriter, []*openpgp.Entity{entity}, nil, nil, config)
+	if err != nil {
+		armorWriter.Close()
+		return "", fmt.Errorf("не удалось создать writer для шифрования: %w", err)
+	}
+
+	if _, err := plaintextWriter.Write(data); err != nil {
+		armorWriter.Close()
+		return "", fmt.Errorf("ошибка при записи открытого текста: %w", err)
+	}
+
+	if err := plaintextWriter.Close(); err != nil {
+		armorWriter.Close()
+		return "", fmt.Errorf("ошибка при закрытии writer текста: %w", err)
+	}
+
+	if err := armorWriter.Close(); err != nil {
+		return "", fmt.Errorf("ошибка при закрытии armor writer: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// DecryptMessage расшифровывает ASCII-armored PGP сообщение ключом entity
+func DecryptMessage(entity *openpgp.Entity, message string) ([]byte, error) {
+	block, err := armor.Decode(strings.NewReader(message))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать armor: %w", err)
+	}
+
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать расшифрованные данные: %w", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,83 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret - ключ "12345678901234567890" из тестовых векторов RFC 6238 в base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCode проверяет коды на векторах RFC 6238 для SHA1: в RFC приведены
+// 8-значные коды, 6-значный код - их последние шесть цифр
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("T=%d: код %s, ожидался %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("не base32", 1); err == nil {
+		t.Fatal("ожидалась ошибка для некорректного секрета")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("шаг %d: %v", step, err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "текущий интервал", code: codeAt(current), skew: 1, wantStep: current, wantOK: true},
+		{name: "пробелы вокруг кода", code: " " + codeAt(current) + "\n", skew: 1, wantStep: current, wantOK: true},
+		{name: "предыдущий интервал в допуске", code: codeAt(current - 1), skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "следующий интервал в допуске", code: codeAt(current + 1), skew: 1, wantStep: current + 1, wantOK: true},
+		{name: "за пределами допуска", code: codeAt(current - 2), skew: 1},
+		{name: "без допуска", code: codeAt(current - 1), skew: 0},
+		{name: "повтор использованного кода", code: codeAt(current), skew: 1, lastStep: current},
+		{name: "код старше использованного", code: codeAt(current - 1), skew: 1, lastStep: current},
+		{name: "код новее использованного", code: codeAt(current + 1), skew: 1, lastStep: current, wantStep: current + 1, wantOK: true},
+		{name: "неверный код", code: "000000", skew: 1},
+		{name: "неверная длина", code: codeAt(current)[:5], skew: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, now, tt.skew, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("получено (%d, %v), ожидалось (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
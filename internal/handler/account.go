@@ -27,12 +27,12 @@ func NewAccountHandler(accountService *service.AccountService, logger *logrus.Lo
 }
 
 // RegisterRoutes регистрирует маршруты для работы с аккаунтами
-func (h *AccountHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("", h.CreateAccount).Methods("POST")     // Маршрут для создания аккаунта
-	router.HandleFunc("", h.GetUserAccounts).Methods("GET")    // Маршрут для получения аккаунтов пользователя
-	router.HandleFunc("/transfer", h.Transfer).Methods("POST") // Маршрут для перевода средств
-	router.HandleFunc("/deposit", h.Deposit).Methods("POST")   // Маршрут для пополнения счета
-	router.HandleFunc("/credit", h.Credit).Methods("POST")     // Маршрут для снятия средств
+func (h *AccountHandler) RegisterRoutes(router *mux.Router, requireVerified mux.MiddlewareFunc) {
+	router.HandleFunc("", h.CreateAccount).Methods("POST")                                    // Маршрут для создания аккаунта
+	router.HandleFunc("", h.GetUserAccounts).Methods("GET")                                   // Маршрут для получения аккаунтов пользователя
+	router.Handle("/transfer", requireVerified(http.HandlerFunc(h.Transfer))).Methods("POST") // Маршрут для перевода средств
+	router.Handle("/deposit", requireVerified(http.HandlerFunc(h.Deposit))).Methods("POST")   // Маршрут для пополнения счета
+	router.Handle("/credit", requireVerified(http.HandlerFunc(h.Credit))).Methods("POST")     // Маршрут для снятия средств
 }
 
 // CreateAccount обрабатывает запрос на создание нового аккаунта
@@ -127,6 +127,13 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ключ интеграции может быть ограничен одним счетом списания
+	if key, ok := currentAPIKey(r); ok && key.AccountID != nil && *key.AccountID != req.FromAccountID {
+		h.logger.Warnf("Перевод отклонен: API ключ %s не допускает списание со счета %s", key.ID, req.FromAccountID)
+		http.Error(w, "API ключ не допускает списание с этого счета", http.StatusForbidden)
+		return
+	}
+
 	// Выполняем перевод средств
 	if err := h.accountService.Transfer(r.Context(), req.FromAccountID, req.ToAccountID, req.Amount, userUUID); err != nil {
 		h.logger.WithError(err).Error("Не удалось выполнить перевод средств")
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// AdminHandler обрабатывает запросы сотрудников банка
+type AdminHandler struct {
+	adminService *service.AdminService
+	logger       *logrus.Logger
+}
+
+func NewAdminHandler(adminService *service.AdminService, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{adminService: adminService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты работы с пользователями, счетами и журналом.
+// Каждый маршрут требует отдельного права.
+func (h *AdminHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle("/users", h.require(model.PermissionUsersRead, h.SearchUsers)).Methods("GET")
+	router.Handle("/users/{id}", h.require(model.PermissionUsersRead, h.GetUser)).Methods("GET")
+	router.Handle("/users/{id}/role", h.require(model.PermissionRolesManage, h.ChangeRole)).Methods("PUT")
+	router.Handle("/accounts/{id}/transactions", h.require(model.PermissionTransactionsRead, h.GetTransactions)).Methods("GET")
+	router.Handle("/accounts/{id}/freeze", h.require(model.PermissionAccountsFreeze, h.FreezeAccount)).Methods("POST")
+	router.Handle("/accounts/{id}/unfreeze", h.require(model.PermissionAccountsFreeze, h.UnfreezeAccount)).Methods("POST")
+	router.Handle("/accounts/{id}/adjustments", h.require(model.PermissionBalanceAdjust, h.AdjustBalance)).Methods("POST")
+	router.Handle("/audit", h.require(model.PermissionAuditRead, h.ListAudit)).Methods("GET")
+}
+
+func (h *AdminHandler) require(permission model.Permission, fn http.HandlerFunc) http.Handler {
+	return RequirePermission(permission, h.logger)(fn)
+}
+
+// SearchUsers ищет пользователей (?q=&role=&limit=&offset=)
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(r)
+	if !ok {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	filter := model.UserSearchFilter{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Role:   model.Role(query.Get("role")),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	users, err := h.adminService.SearchUsers(r.Context(), actor, filter)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// GetUser возвращает карточку пользователя со счетами
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := h.actorAndID(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.adminService.GetUser(r.Context(), actor, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// ChangeRole меняет роль пользователя
+func (h *AdminHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := h.actorAndID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.adminService.ChangeRole(r.Context(), actor, userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetTransactions возвращает операции по счету (?from=2006-01-02&to=2006-01-02)
+func (h *AdminHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	actor, accountID, ok := h.actorAndID(w, r)
+	if !ok {
+		return
+	}
+
+	var from, to time.Time
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "Неверный формат даты from, ожидается YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "Неверный формат даты to, ожидается YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transactions, err := h.adminService.GetAccountTransactions(r.Context(), actor, accountID, from, to)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// FreezeAccount блокирует счет
+func (h *AdminHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, h.adminService.FreezeAccount)
+}
+
+// UnfreezeAccount снимает блокировку со счета
+func (h *AdminHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, h.adminService.UnfreezeAccount)
+}
+
+type accountStatusFunc func(
+	ctx context.Context,
+	actor model.Actor,
+	accountID uuid.UUID,
+	req model.FreezeAccountRequest,
+) (*model.Account, error)
+
+func (h *AdminHandler) changeAccountStatus(w http.ResponseWriter, r *http.Request, change accountStatusFunc) {
+	actor, accountID, ok := h.actorAndID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.FreezeAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	account, err := change(r.Context(), actor, accountID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// AdjustBalance корректирует баланс счета
+func (h *AdminHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	actor, accountID, ok := h.actorAndID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.BalanceAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.adminService.AdjustBalance(r.Context(), actor, accountID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListAudit возвращает журнал действий (?actor_id=&action=&target_type=&target_id=&limit=)
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := model.AuditFilter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Limit:      limit,
+	}
+	if v := query.Get("actor_id"); v != "" {
+		actorID, err := uuid.Parse(v)
+		if err != nil {
+			http.Error(w, "Неверный ID сотрудника", http.StatusBadRequest)
+			return
+		}
+		filter.ActorID = &actorID
+	}
+
+	entries, err := h.adminService.ListAudit(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Ошибка получения журнала", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *AdminHandler) actorAndID(w http.ResponseWriter, r *http.Request) (model.Actor, uuid.UUID, bool) {
+	actor, ok := currentActor(r)
+	if !ok {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return model.Actor{}, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return model.Actor{}, uuid.Nil, false
+	}
+	return actor, id, true
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "ошибка записи журнала"):
+		h.logger.WithError(err).Error("Действие отклонено: не удалось записать журнал")
+		http.Error(w, "Ошибка записи журнала действий", http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
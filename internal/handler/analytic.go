@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"banking-api/internal/model"
 	"banking-api/internal/service"
 )
 
@@ -38,6 +40,7 @@ func (h *AnalyticsHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/stats", h.GetFinancialStats).Methods("GET")
 	router.HandleFunc("/credit-load", h.GetCreditLoad).Methods("GET")
 	router.HandleFunc("/forecast", h.GetBalanceForecast).Methods("GET")
+	router.HandleFunc("/timeseries", h.GetSpendingSeries).Methods("GET")
 }
 
 // GetFinancialStats возвращает статистику по доходам/расходам
@@ -159,6 +162,65 @@ func (h *AnalyticsHandler) GetBalanceForecast(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// GetSpendingSeries возвращает доходы и расходы по интервалам
+// (?start=2024-01-01&end=2024-03-31&interval=week&account_id=...&compare=true)
+func (h *AnalyticsHandler) GetSpendingSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	startDate, endDate, err := h.parseDateRange(r)
+	if err != nil {
+		h.logger.WithError(err).Warn("Неверные параметры даты")
+		http.Error(w, "Неверный формат даты (используйте YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.SpendingSeriesFilter{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Interval:  query.Get("interval"),
+	}
+	if accountParam := query.Get("account_id"); accountParam != "" {
+		accountID, err := uuid.Parse(accountParam)
+		if err != nil {
+			http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+			return
+		}
+		filter.AccountID = &accountID
+	}
+	if compareParam := query.Get("compare"); compareParam != "" {
+		compare, err := strconv.ParseBool(compareParam)
+		if err != nil {
+			http.Error(w, "Неверное значение compare", http.StatusBadRequest)
+			return
+		}
+		filter.Compare = compare
+	}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := h.analyticService.GetSpendingSeries(r.Context(), userID, filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Ошибка получения временного ряда операций")
+		http.Error(w, "Ошибка получения статистики", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		h.logger.WithError(err).Error("Ошибка кодирования временного ряда операций")
+	}
+}
+
 // parseDateRange парсит даты из параметров запроса
 func (h *AnalyticsHandler) parseDateRange(r *http.Request) (time.Time, time.Time, error) {
 	now := time.Now()
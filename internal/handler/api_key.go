@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// APIKeyHandler обрабатывает запросы управления сервисными учетными записями и API ключами
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	logger        *logrus.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты сервисных учетных записей (требуется JWT токен)
+func (h *APIKeyHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.CreateServiceAccount).Methods("POST")
+	router.HandleFunc("", h.ListServiceAccounts).Methods("GET")
+	router.HandleFunc("/{id}", h.DisableServiceAccount).Methods("DELETE")
+	router.HandleFunc("/{id}/keys", h.CreateKey).Methods("POST")
+	router.HandleFunc("/{id}/keys", h.ListKeys).Methods("GET")
+	router.HandleFunc("/{id}/keys/{keyId}", h.RevokeKey).Methods("DELETE")
+}
+
+// CreateServiceAccount создает сервисную учетную запись
+func (h *APIKeyHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.apiKeyService.CreateServiceAccount(r.Context(), userID, req)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось создать сервисную учетную запись")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+// ListServiceAccounts возвращает сервисные учетные записи пользователя
+func (h *APIKeyHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.apiKeyService.ListServiceAccounts(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить сервисные учетные записи")
+		http.Error(w, "Ошибка получения сервисных учетных записей", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// DisableServiceAccount отключает сервисную учетную запись и отзывает ее ключи
+func (h *APIKeyHandler) DisableServiceAccount(w http.ResponseWriter, r *http.Request) {
+	userID, serviceAccountID, ok := h.userAndServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyService.DisableServiceAccount(r.Context(), userID, serviceAccountID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateKey выпускает API ключ. Ключ показывается только в этом ответе.
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	userID, serviceAccountID, ok := h.userAndServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(r.Context(), userID, serviceAccountID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// ListKeys возвращает ключи сервисной учетной записи
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	userID, serviceAccountID, ok := h.userAndServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(r.Context(), userID, serviceAccountID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeKey отзывает API ключ
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	userID, serviceAccountID, ok := h.userAndServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(mux.Vars(r)["keyId"])
+	if err != nil {
+		http.Error(w, "Неверный ID ключа", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(r.Context(), userID, serviceAccountID, keyID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIKeyHandler) userAndServiceAccount(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID сервисной учетной записи", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, id, true
+}
+
+func (h *APIKeyHandler) writeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.logger.WithError(err).Error("Ошибка операции с API ключами")
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
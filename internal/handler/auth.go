@@ -2,9 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
@@ -27,6 +33,31 @@ func NewAuthHandler(authService *service.AuthService, logger *logrus.Logger) *Au
 func (h *AuthHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/signup", h.SignUp).Methods("POST") // Маршрут для регистрации
 	router.HandleFunc("/signin", h.SignIn).Methods("POST") // Маршрут для входа
+	router.HandleFunc("/signin/2fa", h.SignInTwoFactor).Methods("POST")
+	router.HandleFunc("/refresh", h.Refresh).Methods("POST")
+	router.HandleFunc("/logout", h.Logout).Methods("POST")
+	router.HandleFunc("/verify-email", h.VerifyEmail).Methods("GET")
+	router.HandleFunc("/forgot-password", h.ForgotPassword).Methods("POST")
+	router.HandleFunc("/reset-password", h.ResetPassword).Methods("POST")
+}
+
+// RegisterEmailRoutes регистрирует маршруты подтверждения email (требуется JWT токен)
+func (h *AuthHandler) RegisterEmailRoutes(router *mux.Router) {
+	router.HandleFunc("/verification", h.ResendVerification).Methods("POST")
+}
+
+// RegisterSessionRoutes регистрирует маршруты управления сессиями (требуется JWT токен)
+func (h *AuthHandler) RegisterSessionRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListSessions).Methods("GET")
+	router.HandleFunc("/{sessionId}", h.DeleteSession).Methods("DELETE")
+}
+
+// JWKS отдает открытые ключи подписи токенов (RFC 7517). Ответ можно кэшировать:
+// новый ключ публикуется заранее, до того как им начнут подписывать токены.
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.authService.JWKS())
 }
 
 // SignUp обрабатывает запрос на регистрацию нового пользователя
@@ -81,20 +112,232 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Выполняем вход пользователя
-	token, err := h.authService.SignIn(r.Context(), input)
+	result, err := h.authService.SignIn(r.Context(), input, deviceInfo(r))
 	if err != nil {
 		h.logger.WithError(err).Error("Не удалось войти в систему")
+		if writeLoginThrottled(w, err) {
+			return
+		}
 		http.Error(w, "Неверные учетные данные", http.StatusUnauthorized)
 		return
 	}
 
-	// Формируем ответ с токеном
-	response := map[string]string{
-		"token": token,
-	}
-
 	// Устанавливаем заголовок и код ответа
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response) // Отправляем ответ
+	if result.TwoFactorRequired {
+		// Второй шаг: POST /auth/signin/2fa с промежуточным токеном и кодом
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	json.NewEncoder(w).Encode(result.Tokens) // Отправляем ответ
+}
+
+// SignInTwoFactor завершает вход с 2FA: принимает промежуточный токен и код
+func (h *AuthHandler) SignInTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input model.TwoFactorSignInInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.TwoFactorToken == "" || input.Code == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authService.CompleteTwoFactor(r.Context(), input, deviceInfo(r))
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось завершить вход с 2FA")
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Refresh обменивает refresh токен на новую пару токенов
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var input model.RefreshTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authService.Refresh(r.Context(), input.RefreshToken, deviceInfo(r))
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось обновить токены")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout завершает сессию: отзывает refresh токен и выданные по нему access токены.
+// Access токен из заголовка Authorization, если он передан, также отзывается.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var input model.RefreshTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), input.RefreshToken); err != nil {
+		h.logger.WithError(err).Warn("Не удалось завершить сессию")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if token, ok := bearerToken(r); ok {
+		if claims, err := h.authService.ParseToken(r.Context(), token); err == nil {
+			if err := h.authService.RevokeAccessToken(r.Context(), claims); err != nil {
+				h.logger.WithError(err).Warn("Не удалось отозвать access токен")
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail подтверждает email по ссылке из письма
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Отсутствует токен подтверждения", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), token); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"email_verified": true,
+	})
+}
+
+// ResendVerification повторно отправляет ссылку подтверждения email
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.SendEmailVerification(r.Context(), userID); err != nil {
+		h.logger.WithError(err).Warn("Не удалось отправить ссылку подтверждения email")
+		if strings.Contains(err.Error(), "уже подтвержден") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Ошибка отправки письма", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ForgotPassword запрашивает письмо для сброса пароля. Ответ не зависит от того,
+// зарегистрирован ли email.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var input model.ForgotPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Email == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), input.Email, deviceInfo(r)); err != nil {
+		h.logger.WithError(err).Error("Ошибка обработки запроса сброса пароля")
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var input model.ResetPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), input, deviceInfo(r)); err != nil {
+		h.logger.WithError(err).Warn("Не удалось сбросить пароль")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSessions возвращает активные входы пользователя
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	sessions, err := h.authService.ListSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		http.Error(w, "Ошибка получения сессий", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// DeleteSession завершает сессию на другом устройстве
+func (h *AuthHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(mux.Vars(r)["sessionId"])
+	if err != nil {
+		http.Error(w, "Неверный ID сессии", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.DeleteSession(r.Context(), userID, sessionID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Сессия не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeLoginThrottled отвечает 429 с заголовком Retry-After, если вход временно запрещен
+func writeLoginThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, throttled.Error(), http.StatusTooManyRequests)
+	return true
+}
+
+// deviceInfo извлекает данные устройства клиента из запроса
+func deviceInfo(r *http.Request) model.DeviceInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return model.DeviceInfo{UserAgent: r.UserAgent(), IP: ip}
 }
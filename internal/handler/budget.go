@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// BudgetHandler обрабатывает запросы месячных бюджетов
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+	logger        *logrus.Logger
+}
+
+func NewBudgetHandler(budgetService *service.BudgetService, logger *logrus.Logger) *BudgetHandler {
+	return &BudgetHandler{budgetService: budgetService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты бюджетов (требуется JWT токен)
+func (h *BudgetHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListBudgets).Methods("GET")
+	router.HandleFunc("", h.CreateBudget).Methods("POST")
+	router.HandleFunc("/{id}", h.UpdateBudget).Methods("PUT")
+	router.HandleFunc("/{id}", h.DeleteBudget).Methods("DELETE")
+}
+
+// ListBudgets возвращает бюджеты с расходами за месяц (параметр month=YYYY-MM, по умолчанию текущий)
+func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	month := time.Now().UTC()
+	if monthParam := r.URL.Query().Get("month"); monthParam != "" {
+		t, err := time.Parse(model.BudgetMonthLayout, monthParam)
+		if err != nil {
+			http.Error(w, "Неверный формат месяца (используйте YYYY-MM)", http.StatusBadRequest)
+			return
+		}
+		if t.After(month) {
+			http.Error(w, "Месяц не может быть в будущем", http.StatusBadRequest)
+			return
+		}
+		month = t
+	}
+
+	progress, err := h.budgetService.ListProgress(r.Context(), userID, month)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить бюджеты")
+		http.Error(w, "Ошибка получения бюджетов", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// CreateBudget создает бюджет по категории или общий бюджет
+func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.CreateBudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := h.budgetService.Create(r.Context(), userID, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			http.Error(w, "Бюджет этой категории уже создан", http.StatusConflict)
+			return
+		}
+		h.logger.WithError(err).Warn("Не удалось создать бюджет")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(budget)
+}
+
+// UpdateBudget меняет сумму бюджета
+func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := h.budgetParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateBudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := h.budgetService.Update(r.Context(), userID, budgetID, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Бюджет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Warn("Не удалось изменить бюджет")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budget)
+}
+
+// DeleteBudget удаляет бюджет
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := h.budgetParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.budgetService.Delete(r.Context(), userID, budgetID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Бюджет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Не удалось удалить бюджет")
+		http.Error(w, "Ошибка удаления бюджета", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// budgetParams возвращает текущего пользователя и ID бюджета из пути
+func (h *BudgetHandler) budgetParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	budgetID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID бюджета", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, budgetID, true
+}
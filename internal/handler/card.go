@@ -25,11 +25,11 @@ func NewCardHandler(cardService *service.CardService, logger *logrus.Logger) *Ca
 	}
 }
 
-func (h *CardHandler) RegisterRoutes(router *mux.Router) {
+func (h *CardHandler) RegisterRoutes(router *mux.Router, requireVerified mux.MiddlewareFunc) {
 	router.HandleFunc("", h.CreateCard).Methods("POST")
 	router.HandleFunc("", h.ListCards).Methods("GET")
 	router.HandleFunc("/{id}", h.GetCard).Methods("GET")
-	router.HandleFunc("/payments", h.ProcessPayment).Methods("POST")
+	router.Handle("/payments", requireVerified(http.HandlerFunc(h.ProcessPayment))).Methods("POST")
 }
 
 func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// CategoryHandler обрабатывает запросы категорий расходов и правил категорий
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+	logger          *logrus.Logger
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService, logger *logrus.Logger) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты категорий (требуется JWT токен)
+func (h *CategoryHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListCategories).Methods("GET")
+	router.HandleFunc("/rules", h.ListRules).Methods("GET")
+	router.HandleFunc("/rules", h.CreateRule).Methods("POST")
+	router.HandleFunc("/rules/{id}", h.UpdateRule).Methods("PUT")
+	router.HandleFunc("/rules/{id}", h.DeleteRule).Methods("DELETE")
+}
+
+// ListCategories возвращает категории, назначаемые оплатам картой по MCC
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	if _, ok := currentUserID(w, r); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.SpendingCategories)
+}
+
+// ListRules возвращает правила категорий в порядке применения
+func (h *CategoryHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := h.categoryService.ListRules(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить правила категорий")
+		http.Error(w, "Ошибка получения правил", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule создает правило и возвращает его с числом перенесенных в другую категорию оплат
+func (h *CategoryHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.CategoryRuleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.categoryService.CreateRule(r.Context(), userID, input)
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось создать правило категории")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// UpdateRule меняет условия, категорию или приоритет правила
+func (h *CategoryHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ruleID, ok := h.ruleParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.CategoryRuleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.categoryService.UpdateRule(r.Context(), userID, ruleID, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Правило не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Warn("Не удалось изменить правило категории")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DeleteRule удаляет правило
+func (h *CategoryHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	userID, ruleID, ok := h.ruleParams(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.categoryService.DeleteRule(r.Context(), userID, ruleID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Правило не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Не удалось удалить правило категории")
+		http.Error(w, "Ошибка удаления правила", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ruleParams возвращает текущего пользователя и ID правила из пути
+func (h *CategoryHandler) ruleParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	ruleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID правила", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, ruleID, true
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+type CollectionHandler struct {
+	collectionService *service.CollectionService
+	logger            *logrus.Logger
+}
+
+func NewCollectionHandler(collectionService *service.CollectionService, logger *logrus.Logger) *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: collectionService,
+		logger:            logger,
+	}
+}
+
+// RegisterAdminRoutes регистрирует маршруты работы сотрудников с делами по взысканию
+func (h *CollectionHandler) RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListCases).Methods("GET")
+	router.HandleFunc("/{id}", h.GetCase).Methods("GET")
+	router.HandleFunc("/{id}/notes", h.AddNote).Methods("POST")
+}
+
+// ListCases возвращает дела по статусу (?status=open|resolved|defaulted)
+func (h *CollectionHandler) ListCases(w http.ResponseWriter, r *http.Request) {
+	cases, err := h.collectionService.ListCases(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Ошибка получения дел", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cases)
+}
+
+// GetCase возвращает дело с журналом событий
+func (h *CollectionHandler) GetCase(w http.ResponseWriter, r *http.Request) {
+	caseID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID дела", http.StatusBadRequest)
+		return
+	}
+
+	details, err := h.collectionService.GetCase(r.Context(), caseID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// AddNote добавляет заметку сотрудника в журнал дела
+func (h *CollectionHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	caseID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID дела", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CollectionNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.collectionService.AddNote(r.Context(), caseID, authorID, req.Message)
+	if err != nil {
+		h.logger.WithError(err).Error("Ошибка добавления заметки к делу")
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(event)
+}
+
+func (h *CollectionHandler) writeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, "Дело не найдено", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -24,11 +26,22 @@ func NewCreditHandler(creditService *service.CreditService, logger *logrus.Logge
 	}
 }
 
-func (h *CreditHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("", h.CreateCredit).Methods("POST")
+func (h *CreditHandler) RegisterRoutes(router *mux.Router, requireVerified mux.MiddlewareFunc) {
+	router.Handle("", requireVerified(http.HandlerFunc(h.CreateCredit))).Methods("POST")
 	router.HandleFunc("", h.GetUserCredits).Methods("GET")
 	router.HandleFunc("/{creditId}/schedule", h.GetPaymentSchedule).Methods("GET")
-	router.HandleFunc("/pay", h.MakePayment).Methods("POST") // Новый эндпоинт
+	router.HandleFunc("/{creditId}/rate-changes", h.GetRateChanges).Methods("GET")
+	router.Handle("/{creditId}/early-repayment", requireVerified(http.HandlerFunc(h.EarlyRepayment))).Methods("POST")
+	router.HandleFunc("/{creditId}/restructuring-requests", h.RequestRestructuring).Methods("POST")
+	router.HandleFunc("/{creditId}/restructuring-requests", h.GetRestructuringRequests).Methods("GET")
+	router.Handle("/pay", requireVerified(http.HandlerFunc(h.MakePayment))).Methods("POST") // Новый эндпоинт
+}
+
+// RegisterAdminRoutes регистрирует маршруты рассмотрения заявок на изменение условий кредита
+func (h *CreditHandler) RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListRestructuringRequests).Methods("GET")
+	router.HandleFunc("/{requestId}/approve", h.ApproveRestructuring).Methods("POST")
+	router.HandleFunc("/{requestId}/reject", h.RejectRestructuring).Methods("POST")
 }
 
 func (h *CreditHandler) CreateCredit(w http.ResponseWriter, r *http.Request) {
@@ -108,7 +121,7 @@ func (h *CreditHandler) GetPaymentSchedule(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	schedule, err := h.creditService.GetPaymentSchedule(r.Context(), creditID, userUUID)
+	schedule, err := h.creditService.GetScheduleOverview(r.Context(), creditID, userUUID)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get payment schedule")
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -120,6 +133,178 @@ func (h *CreditHandler) GetPaymentSchedule(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(schedule)
 }
 
+func (h *CreditHandler) GetRateChanges(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	creditID, err := uuid.Parse(mux.Vars(r)["creditId"])
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.creditService.GetRateChanges(r.Context(), creditID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rate changes")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (h *CreditHandler) EarlyRepayment(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	creditID, err := uuid.Parse(mux.Vars(r)["creditId"])
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var req model.EarlyRepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Error("Ошибка декодирования запроса на досрочное погашение")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.creditService.EarlyRepayment(r.Context(), creditID, userUUID, req.Amount)
+	if err != nil {
+		h.logger.WithError(err).Error("Ошибка досрочного погашения")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *CreditHandler) RequestRestructuring(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	creditID, err := uuid.Parse(mux.Vars(r)["creditId"])
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CreateRestructuringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Error("Ошибка декодирования заявки на реструктуризацию")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	request, err := h.creditService.RequestRestructuring(r.Context(), creditID, userUUID, req)
+	if err != nil {
+		h.logger.WithError(err).Error("Ошибка создания заявки на реструктуризацию")
+		if strings.Contains(err.Error(), "already pending") {
+			http.Error(w, "По кредиту уже есть заявка на рассмотрении", http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(request)
+}
+
+func (h *CreditHandler) GetRestructuringRequests(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	creditID, err := uuid.Parse(mux.Vars(r)["creditId"])
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	requests, err := h.creditService.GetRestructuringRequests(r.Context(), creditID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).Error("Ошибка получения заявок на реструктуризацию")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+// ListRestructuringRequests возвращает заявки по статусу (?status=, по умолчанию pending)
+func (h *CreditHandler) ListRestructuringRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.creditService.ListRestructuringRequests(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Ошибка получения заявок", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+func (h *CreditHandler) ApproveRestructuring(w http.ResponseWriter, r *http.Request) {
+	h.reviewRestructuring(w, r, h.creditService.ApproveRestructuring)
+}
+
+func (h *CreditHandler) RejectRestructuring(w http.ResponseWriter, r *http.Request) {
+	h.reviewRestructuring(w, r, h.creditService.RejectRestructuring)
+}
+
+func (h *CreditHandler) reviewRestructuring(
+	w http.ResponseWriter,
+	r *http.Request,
+	review func(ctx context.Context, requestID, reviewerID uuid.UUID, req model.ReviewRestructuringRequest) (*model.CreditRestructuringRequest, error),
+) {
+	reviewerID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(mux.Vars(r)["requestId"])
+	if err != nil {
+		http.Error(w, "Неверный ID заявки", http.StatusBadRequest)
+		return
+	}
+
+	var req model.ReviewRestructuringRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+	}
+
+	request, err := review(r.Context(), requestID, reviewerID, req)
+	if err != nil {
+		h.logger.WithError(err).Errorf("Ошибка рассмотрения заявки %s", requestID)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Заявка не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
 func (h *CreditHandler) MakePayment(w http.ResponseWriter, r *http.Request) {
 	var req model.CreditPaymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
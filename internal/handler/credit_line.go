@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+type CreditLineHandler struct {
+	creditLineService *service.CreditLineService
+	logger            *logrus.Logger
+}
+
+func NewCreditLineHandler(creditLineService *service.CreditLineService, logger *logrus.Logger) *CreditLineHandler {
+	return &CreditLineHandler{
+		creditLineService: creditLineService,
+		logger:            logger,
+	}
+}
+
+func (h *CreditLineHandler) RegisterRoutes(router *mux.Router, requireVerified mux.MiddlewareFunc) {
+	router.Handle("", requireVerified(http.HandlerFunc(h.OpenCreditLine))).Methods("POST")
+	router.HandleFunc("", h.ListCreditLines).Methods("GET")
+	router.HandleFunc("/{id}", h.GetCreditLine).Methods("GET")
+	router.HandleFunc("/{id}/statements", h.GetStatements).Methods("GET")
+	router.Handle("/{id}/repay", requireVerified(http.HandlerFunc(h.Repay))).Methods("POST")
+}
+
+// OpenCreditLine открывает кредитную линию для карты
+func (h *CreditLineHandler) OpenCreditLine(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateCreditLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Warn("Ошибка декодирования запроса на открытие кредитной линии")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.creditLineService.OpenCreditLine(r.Context(), userUUID, req)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось открыть кредитную линию")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(line)
+}
+
+// ListCreditLines возвращает кредитные линии пользователя
+func (h *CreditLineHandler) ListCreditLines(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lines, err := h.creditLineService.GetUserCreditLines(r.Context(), userUUID)
+	if err != nil {
+		http.Error(w, "Ошибка получения кредитных линий", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lines)
+}
+
+// GetCreditLine возвращает кредитную линию по ID
+func (h *CreditLineHandler) GetCreditLine(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lineID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID кредитной линии", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.creditLineService.GetCreditLine(r.Context(), lineID, userUUID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+// GetStatements возвращает выписки по кредитной линии
+func (h *CreditLineHandler) GetStatements(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lineID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID кредитной линии", http.StatusBadRequest)
+		return
+	}
+
+	statements, err := h.creditLineService.GetStatements(r.Context(), lineID, userUUID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statements)
+}
+
+// Repay погашает задолженность по кредитной линии
+func (h *CreditLineHandler) Repay(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lineID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID кредитной линии", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CreditLineRepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Warn("Ошибка декодирования запроса на погашение")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.creditLineService.Repay(r.Context(), lineID, userUUID, req); err != nil {
+		h.logger.WithError(err).Error("Ошибка погашения кредитной линии")
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "Погашение выполнено"})
+}
+
+func (h *CreditLineHandler) writeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, "Кредитная линия не найдена", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
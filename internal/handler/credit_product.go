@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+type CreditProductHandler struct {
+	productService *service.CreditProductService
+	logger         *logrus.Logger
+}
+
+func NewCreditProductHandler(productService *service.CreditProductService, logger *logrus.Logger) *CreditProductHandler {
+	return &CreditProductHandler{
+		productService: productService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes регистрирует клиентские маршруты каталога
+func (h *CreditProductHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListActiveProducts).Methods("GET")
+	router.HandleFunc("/{id}", h.GetProduct).Methods("GET")
+}
+
+// RegisterAdminRoutes регистрирует маршруты управления каталогом
+func (h *CreditProductHandler) RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListAllProducts).Methods("GET")
+	router.HandleFunc("", h.CreateProduct).Methods("POST")
+	router.HandleFunc("/{id}", h.GetProduct).Methods("GET")
+	router.HandleFunc("/{id}", h.UpdateProduct).Methods("PUT")
+	router.HandleFunc("/{id}", h.DeactivateProduct).Methods("DELETE")
+}
+
+// ListActiveProducts возвращает продукты, доступные для оформления
+func (h *CreditProductHandler) ListActiveProducts(w http.ResponseWriter, r *http.Request) {
+	h.listProducts(w, r, true)
+}
+
+// ListAllProducts возвращает весь каталог, включая снятые с продажи продукты
+func (h *CreditProductHandler) ListAllProducts(w http.ResponseWriter, r *http.Request) {
+	h.listProducts(w, r, false)
+}
+
+func (h *CreditProductHandler) listProducts(w http.ResponseWriter, r *http.Request, activeOnly bool) {
+	products, err := h.productService.ListProducts(r.Context(), activeOnly)
+	if err != nil {
+		http.Error(w, "Ошибка получения кредитных продуктов", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+func (h *CreditProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID продукта", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.productService.GetProduct(r.Context(), productID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+func (h *CreditProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req model.CreditProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Warn("Ошибка декодирования кредитного продукта")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.productService.CreateProduct(r.Context(), req)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось создать кредитный продукт")
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(product)
+}
+
+func (h *CreditProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID продукта", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CreditProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithError(err).Warn("Ошибка декодирования кредитного продукта")
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.productService.UpdateProduct(r.Context(), productID, req)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось обновить кредитный продукт")
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+func (h *CreditProductHandler) DeactivateProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID продукта", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.productService.DeactivateProduct(r.Context(), productID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "Продукт снят с продажи"})
+}
+
+func (h *CreditProductHandler) writeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, "Кредитный продукт не найден", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/service"
+)
+
+const (
+	streamKeepAlive    = 25 * time.Second // интервал комментариев SSE и ping WebSocket
+	streamWriteTimeout = 10 * time.Second
+	streamRetry        = 3000 // рекомендуемая задержка переподключения SSE клиента, мс
+)
+
+// streamUpgrader принимает WebSocket подключения с любого Origin: поток доступен только
+// по токену в заголовке Authorization, который браузер не подставляет сам
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// EventHandler передает клиенту события его счетов в реальном времени
+type EventHandler struct {
+	hub    *service.EventHub
+	logger *logrus.Logger
+}
+
+func NewEventHandler(hub *service.EventHub, logger *logrus.Logger) *EventHandler {
+	return &EventHandler{hub: hub, logger: logger}
+}
+
+// RegisterRoutes регистрирует поток событий (требуется JWT токен)
+func (h *EventHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.Stream).Methods("GET")
+}
+
+// Stream отдает поток событий по WebSocket, если клиент запросил Upgrade, иначе по SSE
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.hub.Subscribe(userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Warn("Подключение к потоку событий отклонено")
+		if strings.Contains(err.Error(), "останавливается") {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer sub.Close()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.streamWebSocket(w, r, sub)
+		return
+	}
+	h.streamSSE(w, r, sub)
+}
+
+// streamSSE передает события в формате text/event-stream
+func (h *EventHandler) streamSSE(w http.ResponseWriter, r *http.Request, sub *service.EventSubscription) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Потоковая передача не поддерживается", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // отключает буферизацию ответа в nginx
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.logger.WithError(err).Error("Ошибка сериализации события потока")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// streamWebSocket передает события JSON сообщениями. Сообщения клиента не ожидаются,
+// соединение читается только для обработки pong и закрытия.
+func (h *EventHandler) streamWebSocket(w http.ResponseWriter, r *http.Request, sub *service.EventSubscription) {
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту ошибкой
+		h.logger.WithError(err).Warn("Не удалось установить WebSocket соединение")
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * streamKeepAlive))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * streamKeepAlive))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(streamWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
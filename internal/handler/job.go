@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/service"
+)
+
+type JobHandler struct {
+	jobRunner *service.JobRunner
+	logger    *logrus.Logger
+}
+
+func NewJobHandler(jobRunner *service.JobRunner, logger *logrus.Logger) *JobHandler {
+	return &JobHandler{
+		jobRunner: jobRunner,
+		logger:    logger,
+	}
+}
+
+// RegisterAdminRoutes регистрирует маршрут просмотра журнала фоновых задач
+func (h *JobHandler) RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListRuns).Methods("GET")
+}
+
+// ListRuns возвращает последние запуски задач (?job=credit_payments&limit=50)
+func (h *JobHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 {
+			http.Error(w, "Неверное значение limit", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	runs, err := h.jobRunner.ListRuns(r.Context(), r.URL.Query().Get("job"), limit)
+	if err != nil {
+		http.Error(w, "Ошибка получения журнала задач", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
@@ -5,44 +5,277 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"banking-api/internal/model"
 	"banking-api/internal/service"
 )
 
-// AuthMiddleware проверяет наличие и валидность JWT токена в заголовке Authorization
-func AuthMiddleware(authService *service.AuthService, logger *logrus.Logger) mux.MiddlewareFunc {
+// AuthMiddleware проверяет JWT токен из заголовка Authorization либо API ключ сервисной
+// учетной записи (заголовок X-API-Key или Authorization: Bearer bk_...)
+func AuthMiddleware(authService *service.AuthService, apiKeyService *service.APIKeyService, logger *logrus.Logger) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := apiKeyFromRequest(r); ok {
+				authenticateAPIKey(w, r, next, apiKeyService, key, logger)
+				return
+			}
+
 			// Получаем заголовок Authorization
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
+			if r.Header.Get("Authorization") == "" {
 				logger.Error("Отсутствует заголовок Authorization")
 				http.Error(w, "Заголовок Authorization обязателен", http.StatusUnauthorized)
 				return
 			}
 
 			// Проверяем формат заголовка
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
+			token, ok := bearerToken(r)
+			if !ok {
 				logger.Error("Неверный формат заголовка Authorization")
 				http.Error(w, "Неверный формат заголовка Authorization", http.StatusUnauthorized)
 				return
 			}
 
-			token := parts[1]
-			// Парсим токен и проверяем его валидность
-			userID, err := authService.ParseToken(token)
+			// Парсим токен и проверяем его валидность и отсутствие в списке отозванных
+			claims, err := authService.ParseToken(r.Context(), token)
 			if err != nil {
 				logger.WithError(err).Error("Неверный токен")
 				http.Error(w, "Неверный токен", http.StatusUnauthorized)
 				return
 			}
 
-			// Добавляем userID в контекст
-			ctx := context.WithValue(r.Context(), "userID", userID)
+			// Добавляем userID, ID сессии и роль в контекст
+			ctx := context.WithValue(r.Context(), "userID", claims.Subject)
+			ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+			ctx = context.WithValue(ctx, "role", claims.Role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// apiKeyScopes - маршруты, доступные по API ключу, и необходимые для них права.
+// Остальные маршруты (управление ключами, сессиями, административное API) по ключу недоступны.
+var apiKeyScopes = map[string]model.APIKeyScope{
+	"GET /api/accounts":                        model.ScopeAccountsRead,
+	"POST /api/accounts/transfer":              model.ScopeTransfers,
+	"GET /api/cards":                           model.ScopeCardsRead,
+	"GET /api/cards/{id}":                      model.ScopeCardsRead,
+	"GET /api/credits":                         model.ScopeCreditsRead,
+	"GET /api/credits/{creditId}/schedule":     model.ScopeCreditsRead,
+	"GET /api/credits/{creditId}/rate-changes": model.ScopeCreditsRead,
+	"GET /api/analytics/stats":                 model.ScopeAnalyticsRead,
+	"GET /api/analytics/credit-load":           model.ScopeAnalyticsRead,
+	"GET /api/analytics/forecast":              model.ScopeAnalyticsRead,
+	"GET /api/analytics/timeseries":            model.ScopeAnalyticsRead,
+	"GET /api/budgets":                         model.ScopeAnalyticsRead,
+
+	"POST /api/webhooks":                                        model.ScopeWebhooks,
+	"GET /api/webhooks":                                         model.ScopeWebhooks,
+	"GET /api/webhooks/{id}":                                    model.ScopeWebhooks,
+	"PUT /api/webhooks/{id}":                                    model.ScopeWebhooks,
+	"DELETE /api/webhooks/{id}":                                 model.ScopeWebhooks,
+	"POST /api/webhooks/{id}/rotate-secret":                     model.ScopeWebhooks,
+	"GET /api/webhooks/{id}/deliveries":                         model.ScopeWebhooks,
+	"GET /api/webhooks/{id}/deliveries/{deliveryId}":            model.ScopeWebhooks,
+	"POST /api/webhooks/{id}/deliveries/{deliveryId}/redeliver": model.ScopeWebhooks,
+}
+
+// authenticateAPIKey проверяет ключ и права ключа на маршрут. Запрос выполняется
+// от имени владельца сервисной учетной записи с ролью customer.
+func authenticateAPIKey(
+	w http.ResponseWriter,
+	r *http.Request,
+	next http.Handler,
+	apiKeyService *service.APIKeyService,
+	key string,
+	logger *logrus.Logger,
+) {
+	principal, err := apiKeyService.Authenticate(r.Context(), key, deviceInfo(r).IP)
+	if err != nil {
+		logger.WithError(err).Warn("Неверный API ключ")
+		http.Error(w, "Неверный API ключ", http.StatusUnauthorized)
+		return
+	}
+
+	route := r.Method
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			route += " " + tpl
+		}
+	}
+	scope, ok := apiKeyScopes[route]
+	if !ok || !principal.Key.HasScope(scope) {
+		logger.WithFields(logrus.Fields{
+			"key_id": principal.Key.ID,
+			"route":  route,
+		}).Warn("Отказано в доступе по API ключу")
+		http.Error(w, "Недостаточно прав API ключа", http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "userID", principal.ServiceAccount.OwnerID.String())
+	ctx = context.WithValue(ctx, "role", model.RoleCustomer)
+	ctx = context.WithValue(ctx, "apiKey", principal.Key)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// apiKeyFromRequest извлекает API ключ из X-API-Key или из Authorization: Bearer
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+	if token, ok := bearerToken(r); ok && strings.HasPrefix(token, service.APIKeyPrefix) {
+		return token, true
+	}
+	return "", false
+}
+
+// currentAPIKey возвращает API ключ, по которому выполнен запрос
+func currentAPIKey(r *http.Request) (*model.APIKey, bool) {
+	key, ok := r.Context().Value("apiKey").(*model.APIKey)
+	return key, ok
+}
+
+// bearerToken извлекает токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) (string, bool) {
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// RequirePermission пропускает только пользователей, роль которых дает указанное право.
+// Должен подключаться после AuthMiddleware.
+func RequirePermission(permission model.Permission, logger *logrus.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value("userID").(string)
+			if !ok {
+				http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+				return
+			}
+
+			if role := currentRole(r); !role.Can(permission) {
+				logger.Warnf("Отказано в доступе: пользователь %s, роль %s, требуется %s", userID, role, permission)
+				http.Error(w, "Недостаточно прав", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuditMiddleware записывает в журнал обращения сотрудников к маршрутам,
+// обработчики которых не ведут журнал сами
+func AuditMiddleware(adminService *service.AdminService, logger *logrus.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			actor, ok := currentActor(r)
+			if !ok {
+				return
+			}
+
+			action := r.Method
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					action += " " + tpl
+				}
+			}
+
+			var targetID string
+			for _, key := range []string{"id", "requestId"} {
+				if v := mux.Vars(r)[key]; v != "" {
+					targetID = v
+				}
+			}
+
+			if err := adminService.RecordRequest(r.Context(), actor, action, targetID, recorder.status); err != nil {
+				logger.WithError(err).Error("Не удалось записать обращение в журнал действий")
+			}
+		})
+	}
+}
+
+// statusRecorder запоминает код ответа обработчика
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// VerifiedEmailMiddleware пропускает только пользователей с подтвержденным email.
+// Подключается к операциям с движением денег после AuthMiddleware.
+func VerifiedEmailMiddleware(authService *service.AuthService, logger *logrus.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := currentUserID(w, r)
+			if !ok {
+				return
+			}
+
+			verified, err := authService.IsEmailVerified(r.Context(), userID)
+			if err != nil {
+				logger.WithError(err).Error("Ошибка проверки подтверждения email")
+				http.Error(w, "Ошибка проверки пользователя", http.StatusInternalServerError)
+				return
+			}
+			if !verified {
+				logger.Warnf("Операция отклонена: email пользователя %s не подтвержден", userID)
+				http.Error(w, "Подтвердите email, чтобы выполнять операции со средствами", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// currentUserID извлекает ID пользователя, добавленный AuthMiddleware.
+// При ошибке ответ клиенту уже записан.
+func currentUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		http.Error(w, "Неверный ID пользователя", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// currentRole извлекает роль пользователя, добавленную AuthMiddleware
+func currentRole(r *http.Request) model.Role {
+	role, ok := r.Context().Value("role").(model.Role)
+	if !ok {
+		return model.RoleCustomer
+	}
+	return role
+}
+
+// currentActor собирает данные сотрудника для журнала действий
+func currentActor(r *http.Request) (model.Actor, bool) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		return model.Actor{}, false
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return model.Actor{}, false
+	}
+	return model.Actor{ID: id, Role: currentRole(r), IP: deviceInfo(r).IP}, true
+}
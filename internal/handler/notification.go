@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// NotificationHandler обрабатывает запросы настроек уведомлений, пороговых оповещений
+// и входящих уведомлений в приложении
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	logger              *logrus.Logger
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, logger *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты уведомлений (требуется JWT токен)
+func (h *NotificationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/settings", h.GetSettings).Methods("GET")
+	router.HandleFunc("/settings", h.UpdateSettings).Methods("PUT")
+	router.HandleFunc("/alerts", h.ListAlerts).Methods("GET")
+	router.HandleFunc("/alerts", h.CreateAlert).Methods("POST")
+	router.HandleFunc("/alerts/{id}", h.DeleteAlert).Methods("DELETE")
+	router.HandleFunc("/inbox", h.ListInbox).Methods("GET")
+	router.HandleFunc("/inbox/unread-count", h.UnreadCount).Methods("GET")
+	router.HandleFunc("/inbox/read-all", h.MarkAllRead).Methods("POST")
+	router.HandleFunc("/inbox/{id}/read", h.MarkRead).Methods("POST")
+}
+
+// GetSettings возвращает способы доставки уведомлений по типам и каналам
+func (h *NotificationHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.notificationService.GetSettings(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить настройки уведомлений")
+		http.Error(w, "Ошибка получения настроек уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettings меняет личный чат Telegram и способы доставки уведомлений
+func (h *NotificationHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateNotificationSettingsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(r.Context(), userID, input)
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось изменить настройки уведомлений")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// ListAlerts возвращает пороговые оповещения пользователя
+func (h *NotificationHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	alerts, err := h.notificationService.ListAlerts(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить оповещения")
+		http.Error(w, "Ошибка получения оповещений", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// CreateAlert создает пороговое оповещение
+func (h *NotificationHandler) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.CreateAlertInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	alert, err := h.notificationService.CreateAlert(r.Context(), userID, input)
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось создать оповещение")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(alert)
+}
+
+// DeleteAlert удаляет пороговое оповещение
+func (h *NotificationHandler) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	alertID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID оповещения", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notificationService.DeleteAlert(r.Context(), userID, alertID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Оповещение не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Не удалось удалить оповещение")
+		http.Error(w, "Ошибка удаления оповещения", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListInbox возвращает входящие уведомления (?unread=true&limit=50&offset=0)
+func (h *NotificationHandler) ListInbox(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var filter model.InboxFilter
+	if param := query.Get("limit"); param != "" {
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			http.Error(w, "Неверное значение limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	if param := query.Get("offset"); param != "" {
+		offset, err := strconv.Atoi(param)
+		if err != nil {
+			http.Error(w, "Неверное значение offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+	if param := query.Get("unread"); param != "" {
+		unread, err := strconv.ParseBool(param)
+		if err != nil {
+			http.Error(w, "Неверное значение unread", http.StatusBadRequest)
+			return
+		}
+		filter.UnreadOnly = unread
+	}
+
+	items, err := h.notificationService.ListInbox(r.Context(), userID, filter)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить входящие уведомления")
+		http.Error(w, "Ошибка получения уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// UnreadCount возвращает число непрочитанных уведомлений
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить число непрочитанных уведомлений")
+		http.Error(w, "Ошибка получения уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID уведомления", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.notificationService.MarkRead(r.Context(), userID, itemID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Уведомление не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Не удалось отметить уведомление прочитанным")
+		http.Error(w, "Ошибка изменения уведомления", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// MarkAllRead отмечает прочитанными все уведомления
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось отметить уведомления прочитанными")
+		http.Error(w, "Ошибка изменения уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
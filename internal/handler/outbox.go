@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/service"
+)
+
+// OutboxHandler обрабатывает административные запросы к очереди уведомлений
+type OutboxHandler struct {
+	dispatcher *service.OutboxDispatcher
+	logger     *logrus.Logger
+}
+
+func NewOutboxHandler(dispatcher *service.OutboxDispatcher, logger *logrus.Logger) *OutboxHandler {
+	return &OutboxHandler{dispatcher: dispatcher, logger: logger}
+}
+
+// RegisterAdminRoutes регистрирует маршруты просмотра и повтора недоставленных событий
+func (h *OutboxHandler) RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("", h.ListEvents).Methods("GET")
+	router.HandleFunc("/{id}/retry", h.RetryEvent).Methods("POST")
+}
+
+// ListEvents возвращает события outbox (?status=dead&limit=50)
+func (h *OutboxHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 {
+			http.Error(w, "Неверное значение limit", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	events, err := h.dispatcher.ListEvents(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.logger.WithError(err).Warn("Ошибка получения событий outbox")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// RetryEvent возвращает недоставленное событие в очередь
+func (h *OutboxHandler) RetryEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID события", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.dispatcher.Retry(r.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Недоставленное событие не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.WithError(err).Error("Ошибка повтора события outbox")
+		http.Error(w, "Ошибка повтора события", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
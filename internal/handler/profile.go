@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// ProfileHandler обрабатывает запросы управления профилем пользователя
+type ProfileHandler struct {
+	profileService *service.ProfileService
+	authService    *service.AuthService
+	logger         *logrus.Logger
+}
+
+func NewProfileHandler(profileService *service.ProfileService, authService *service.AuthService, logger *logrus.Logger) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService, authService: authService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты профиля (требуется JWT токен)
+func (h *ProfileHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.GetProfile).Methods("GET")
+	router.HandleFunc("", h.DeleteProfile).Methods("DELETE")
+	router.HandleFunc("/username", h.UpdateUsername).Methods("PUT")
+	router.HandleFunc("/locale", h.UpdateLocale).Methods("PUT")
+	router.HandleFunc("/email", h.ChangeEmail).Methods("POST")
+	router.HandleFunc("/password", h.ChangePassword).Methods("PUT")
+	router.HandleFunc("/export", h.Export).Methods("GET")
+}
+
+// GetProfile возвращает профиль текущего пользователя
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.profileService.GetProfile(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateUsername меняет имя пользователя
+func (h *ProfileHandler) UpdateUsername(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateUsernameInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profileService.UpdateUsername(r.Context(), userID, input)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateLocale меняет язык уведомлений
+func (h *ProfileHandler) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateLocaleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profileService.UpdateLocale(r.Context(), userID, input)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// ChangeEmail запрашивает смену email. Новый адрес начинает действовать после подтверждения.
+func (h *ProfileHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.ChangeEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.RequestEmailChange(r.Context(), userID, input); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "Ссылка для подтверждения отправлена на новый email",
+	})
+}
+
+// ChangePassword меняет пароль. Сессии на других устройствах завершаются.
+func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.ChangePasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	if err := h.authService.ChangePassword(r.Context(), userID, sessionID, input, deviceInfo(r)); err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Export отдает выгрузку персональных данных в виде JSON файла
+func (h *ProfileHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.profileService.Export(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("personal-data-%s.json", export.ExportedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(export)
+}
+
+// DeleteProfile удаляет учетную запись. При остатках на счетах или задолженности
+// возвращает 409 со списком препятствий.
+func (h *ProfileHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.DeleteProfileInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	err := h.profileService.Delete(r.Context(), userID, input)
+	var blocked *service.ProfileBlockedError
+	if errors.As(err, &blocked) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    blocked.Error(),
+			"blockers": blocked.Blockers,
+		})
+		return
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProfileHandler) writeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+		return
+	}
+	h.logger.WithError(err).Warn("Ошибка операции с профилем")
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+	logger           *logrus.Logger
+}
+
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService, logger *logrus.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		logger:           logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты управления двухфакторной аутентификацией
+func (h *TwoFactorHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.Status).Methods("GET")
+	router.HandleFunc("/setup", h.Setup).Methods("POST")
+	router.HandleFunc("/confirm", h.Confirm).Methods("POST")
+	router.HandleFunc("/disable", h.Disable).Methods("POST")
+	router.HandleFunc("/recovery-codes", h.RegenerateRecoveryCodes).Methods("POST")
+}
+
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.twoFactorService.Status(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Ошибка получения настроек 2FA", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Setup выдает секрет, otpauth:// URI и коды восстановления
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Ошибка подключения 2FA")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(setup)
+}
+
+// Confirm включает 2FA после проверки первого кода из приложения
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.TwoFactorCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.twoFactorService.Confirm(r.Context(), userID, input.Code); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "Двухфакторная аутентификация подключена"})
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.TwoFactorDisableInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Password == "" || input.Code == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), userID, input.Password, input.Code); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "Двухфакторная аутентификация отключена"})
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.TwoFactorCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), userID, input.Code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/service"
+)
+
+// WebhookHandler обрабатывает запросы управления webhook подписками и журналом доставок
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *logrus.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService, logger: logger}
+}
+
+// RegisterRoutes регистрирует маршруты webhook (требуется JWT токен или API ключ)
+func (h *WebhookHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("", h.CreateEndpoint).Methods("POST")
+	router.HandleFunc("", h.ListEndpoints).Methods("GET")
+	router.HandleFunc("/{id}", h.GetEndpoint).Methods("GET")
+	router.HandleFunc("/{id}", h.UpdateEndpoint).Methods("PUT")
+	router.HandleFunc("/{id}", h.DeleteEndpoint).Methods("DELETE")
+	router.HandleFunc("/{id}/rotate-secret", h.RotateSecret).Methods("POST")
+	router.HandleFunc("/{id}/deliveries", h.ListDeliveries).Methods("GET")
+	router.HandleFunc("/{id}/deliveries/{deliveryId}", h.GetDelivery).Methods("GET")
+	router.HandleFunc("/{id}/deliveries/{deliveryId}/redeliver", h.Redeliver).Methods("POST")
+}
+
+// CreateEndpoint создает подписку и возвращает секрет подписи
+func (h *WebhookHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.CreateWebhookEndpointInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(r.Context(), userID, input)
+	if err != nil {
+		h.logger.WithError(err).Warn("Не удалось создать webhook подписку")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// ListEndpoints возвращает подписки пользователя
+func (h *WebhookHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(r.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Не удалось получить webhook подписки")
+		http.Error(w, "Ошибка получения подписок", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoints)
+}
+
+// GetEndpoint возвращает подписку
+func (h *WebhookHandler) GetEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(r.Context(), userID, endpointID)
+	if err != nil {
+		h.writeError(w, err, "Не удалось получить webhook подписку")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// UpdateEndpoint меняет адрес, описание, типы событий или активность подписки
+func (h *WebhookHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateWebhookEndpointInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(r.Context(), userID, endpointID, input)
+	if err != nil {
+		h.writeError(w, err, "Не удалось изменить webhook подписку")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// DeleteEndpoint удаляет подписку
+func (h *WebhookHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(r.Context(), userID, endpointID); err != nil {
+		h.writeError(w, err, "Не удалось удалить webhook подписку")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateSecret выпускает новый секрет подписи
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.RotateSecret(r.Context(), userID, endpointID)
+	if err != nil {
+		h.writeError(w, err, "Не удалось заменить секрет webhook подписки")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// ListDeliveries возвращает доставки подписки (?status=failed&limit=50)
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil {
+			http.Error(w, "Неверное значение limit", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), userID, endpointID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.writeError(w, err, "Не удалось получить доставки webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetDelivery возвращает доставку с журналом попыток
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(mux.Vars(r)["deliveryId"])
+	if err != nil {
+		http.Error(w, "Неверный ID доставки", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), userID, endpointID, deliveryID)
+	if err != nil {
+		h.writeError(w, err, "Не удалось получить доставку webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// Redeliver возвращает доставку в очередь
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	userID, endpointID, ok := h.endpointParams(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(mux.Vars(r)["deliveryId"])
+	if err != nil {
+		http.Error(w, "Неверный ID доставки", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), userID, endpointID, deliveryID)
+	if err != nil {
+		h.writeError(w, err, "Не удалось повторить доставку webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// endpointParams возвращает текущего пользователя и ID подписки из пути
+func (h *WebhookHandler) endpointParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	endpointID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неверный ID подписки", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, endpointID, true
+}
+
+func (h *WebhookHandler) writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "webhook endpoint not found"):
+		http.Error(w, "Подписка не найдена", http.StatusNotFound)
+	case strings.Contains(err.Error(), "webhook delivery not found"):
+		http.Error(w, "Доставка не найдена", http.StatusNotFound)
+	case strings.Contains(err.Error(), "in progress"):
+		http.Error(w, "Доставка выполняется, повторите позже", http.StatusConflict)
+	default:
+		h.logger.WithError(err).Warn(message)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
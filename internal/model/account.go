@@ -6,13 +6,32 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen" // заблокирован сотрудником банка, операции клиента запрещены
+	AccountStatusClosed = "closed" // закрыт при удалении учетной записи, операции запрещены
+)
+
 type Account struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Balance   float64   `json:"balance" db:"balance"`
-	Currency  string    `json:"currency" db:"currency"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Balance      float64    `json:"balance" db:"balance"`
+	Currency     string     `json:"currency" db:"currency"`
+	Status       string     `json:"status" db:"status"`
+	FrozenAt     *time.Time `json:"frozen_at,omitempty" db:"frozen_at"`
+	FreezeReason *string    `json:"freeze_reason,omitempty" db:"freeze_reason"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsFrozen сообщает, заблокирован ли счет
+func (a *Account) IsFrozen() bool {
+	return a.Status == AccountStatusFrozen
+}
+
+// IsClosed сообщает, закрыт ли счет
+func (a *Account) IsClosed() bool {
+	return a.Status == AccountStatusClosed
 }
 
 type CreateAccountRequest struct {
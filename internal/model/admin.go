@@ -0,0 +1,74 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды причин для действий сотрудников. Код обязателен, комментарий - для кода other.
+var (
+	FreezeReasonCodes     = []string{"fraud_suspected", "court_order", "customer_request", "compliance_review", "other"}
+	UnfreezeReasonCodes   = []string{"investigation_closed", "court_order_lifted", "customer_request", "other"}
+	AdjustmentReasonCodes = []string{"error_correction", "compensation", "fee_refund", "chargeback", "fraud_recovery", "other"}
+	RoleChangeReasonCodes = []string{"hired", "role_change", "terminated", "other"}
+)
+
+// AdminReason - код причины и комментарий сотрудника
+type AdminReason struct {
+	ReasonCode string `json:"reason_code"`
+	Comment    string `json:"comment"`
+}
+
+// Validate проверяет, что код причины допустим для действия
+func (r AdminReason) Validate(allowed []string) error {
+	for _, code := range allowed {
+		if r.ReasonCode == code {
+			if code == "other" && strings.TrimSpace(r.Comment) == "" {
+				return fmt.Errorf("для кода причины other требуется комментарий")
+			}
+			if len(r.Comment) > 1000 {
+				return fmt.Errorf("комментарий не должен превышать 1000 символов")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("недопустимый код причины, ожидается один из: %s", strings.Join(allowed, ", "))
+}
+
+// UserSearchFilter - условия поиска пользователей сотрудником
+type UserSearchFilter struct {
+	Query  string // часть email или username, либо точный ID
+	Role   Role
+	Limit  int
+	Offset int
+}
+
+// AdminUserDetails - карточка пользователя для сотрудника
+type AdminUserDetails struct {
+	User     User      `json:"user"`
+	Accounts []Account `json:"accounts"`
+}
+
+// ChangeRoleRequest - запрос на изменение роли пользователя
+type ChangeRoleRequest struct {
+	Role Role `json:"role"`
+	AdminReason
+}
+
+// FreezeAccountRequest - запрос на блокировку или разблокировку счета
+type FreezeAccountRequest struct {
+	AdminReason
+}
+
+// BalanceAdjustmentRequest - ручная корректировка баланса. Положительная сумма
+// зачисляется на счет, отрицательная списывается.
+type BalanceAdjustmentRequest struct {
+	Amount float64 `json:"amount"`
+	AdminReason
+}
+
+// BalanceAdjustmentResult - результат корректировки баланса
+type BalanceAdjustmentResult struct {
+	Account     Account     `json:"account"`
+	Transaction Transaction `json:"transaction"`
+}
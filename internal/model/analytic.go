@@ -1,6 +1,11 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // AnalyticsRequest - запрос на получение аналитики
 type AnalyticsRequest struct {
@@ -38,3 +43,127 @@ type BalanceForecast struct {
 	ProjectedBalance float64   `json:"projected_balance"`
 	PlannedPayments  float64   `json:"planned_payments"`
 }
+
+// Интервалы временных рядов аналитики. Границы интервалов считаются по UTC,
+// неделя начинается с понедельника.
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week"
+	AnalyticsIntervalMonth = "month"
+)
+
+// MaxSpendingBuckets ограничивает число интервалов в одном временном ряду
+const MaxSpendingBuckets = 400
+
+// TruncateToInterval возвращает начало интервала, в который попадает t (как date_trunc в PostgreSQL)
+func TruncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case AnalyticsIntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case AnalyticsIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// NextInterval возвращает начало интервала, следующего за интервалом, начинающимся в start
+func NextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case AnalyticsIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case AnalyticsIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// SpendingSeriesFilter - параметры временного ряда доходов и расходов. Даты включительно.
+type SpendingSeriesFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Interval  string
+	AccountID *uuid.UUID // только операции по счету
+	Compare   bool       // сравнить с предыдущим периодом такой же длины
+}
+
+// Validate проверяет параметры и подставляет интервал по умолчанию (день)
+func (f *SpendingSeriesFilter) Validate() error {
+	switch f.Interval {
+	case "":
+		f.Interval = AnalyticsIntervalDay
+	case AnalyticsIntervalDay, AnalyticsIntervalWeek, AnalyticsIntervalMonth:
+	default:
+		return fmt.Errorf("unknown interval: %s", f.Interval)
+	}
+	if f.StartDate.After(f.EndDate) {
+		return fmt.Errorf("start date must not be after end date")
+	}
+
+	buckets := 0
+	end := f.EndDate.AddDate(0, 0, 1)
+	for start := TruncateToInterval(f.StartDate, f.Interval); start.Before(end); start = NextInterval(start, f.Interval) {
+		if buckets++; buckets > MaxSpendingBuckets {
+			return fmt.Errorf("period contains more than %d intervals, use a longer interval", MaxSpendingBuckets)
+		}
+	}
+	return nil
+}
+
+// TransactionAggregate - суммы операций одной категории по счету за интервал
+type TransactionAggregate struct {
+	Bucket    time.Time
+	AccountID uuid.UUID
+	Category  string
+	Income    float64
+	Expenses  float64
+	Count     int
+}
+
+// SpendingTotals - доходы и расходы за период или интервал
+type SpendingTotals struct {
+	Income   float64 `json:"income"`
+	Expenses float64 `json:"expenses"`
+	Net      float64 `json:"net"`
+	Count    int     `json:"count"`
+}
+
+// SpendingBucket - доходы и расходы за интервал с разбивкой по категориям и счетам
+type SpendingBucket struct {
+	Start time.Time `json:"start"`
+	SpendingTotals
+	ByCategory map[string]CategoryStats  `json:"by_category"`
+	ByAccount  map[string]SpendingTotals `json:"by_account"`
+}
+
+// SpendingChange - изменение относительно предыдущего периода в процентах.
+// Значение не заполняется, если в предыдущем периоде оно было нулевым.
+type SpendingChange struct {
+	Income   *float64 `json:"income"`
+	Expenses *float64 `json:"expenses"`
+	Net      *float64 `json:"net"`
+}
+
+// SpendingComparison - итоги предыдущего периода такой же длины
+type SpendingComparison struct {
+	StartDate time.Time      `json:"start_date"`
+	EndDate   time.Time      `json:"end_date"`
+	Totals    SpendingTotals `json:"totals"`
+	Change    SpendingChange `json:"change_percent"`
+}
+
+// SpendingSeries - временной ряд доходов и расходов
+type SpendingSeries struct {
+	Interval   string                    `json:"interval"`
+	StartDate  time.Time                 `json:"start_date"`
+	EndDate    time.Time                 `json:"end_date"`
+	AccountID  *uuid.UUID                `json:"account_id,omitempty"`
+	Totals     SpendingTotals            `json:"totals"`
+	ByCategory map[string]CategoryStats  `json:"by_category"`
+	ByAccount  map[string]SpendingTotals `json:"by_account"`
+	Buckets    []SpendingBucket          `json:"buckets"`
+	Previous   *SpendingComparison       `json:"previous,omitempty"`
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyScope - право API ключа на группу маршрутов
+type APIKeyScope string
+
+const (
+	ScopeAccountsRead  APIKeyScope = "accounts:read"   // список счетов
+	ScopeTransfers     APIKeyScope = "transfers:write" // переводы между счетами
+	ScopeCardsRead     APIKeyScope = "cards:read"      // просмотр карт
+	ScopeCreditsRead   APIKeyScope = "credits:read"    // кредиты и графики платежей
+	ScopeAnalyticsRead APIKeyScope = "analytics:read"  // аналитика
+	ScopeWebhooks      APIKeyScope = "webhooks:manage" // webhook подписки и журнал доставок
+)
+
+// APIKeyScopes - все права, которые можно выдать ключу
+var APIKeyScopes = []APIKeyScope{
+	ScopeAccountsRead,
+	ScopeTransfers,
+	ScopeCardsRead,
+	ScopeCreditsRead,
+	ScopeAnalyticsRead,
+	ScopeWebhooks,
+}
+
+// ServiceAccount - учетная запись интеграции. Действует от имени владельца,
+// но обращается к API только по API ключам.
+type ServiceAccount struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	OwnerID     uuid.UUID  `json:"owner_id" db:"owner_id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+}
+
+// APIKey - ключ сервисной учетной записи, хранится в виде SHA-256 хеша
+type APIKey struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	ServiceAccountID uuid.UUID     `json:"service_account_id" db:"service_account_id"`
+	Name             string        `json:"name" db:"name"`
+	Prefix           string        `json:"prefix" db:"prefix"`
+	KeyHash          string        `json:"-" db:"key_hash"`
+	Scopes           []APIKeyScope `json:"scopes" db:"scopes"`
+	AccountID        *uuid.UUID    `json:"account_id,omitempty" db:"account_id"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	ExpiresAt        time.Time     `json:"expires_at" db:"expires_at"`
+	LastUsedAt       *time.Time    `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP       *string       `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	RevokedAt        *time.Time    `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope сообщает, выдано ли ключу право
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyPrincipal - результат проверки API ключа
+type APIKeyPrincipal struct {
+	Key            *APIKey
+	ServiceAccount *ServiceAccount
+}
+
+// CreateServiceAccountRequest - запрос на создание сервисной учетной записи
+type CreateServiceAccountRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r *CreateServiceAccountRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return fmt.Errorf("название должно содержать от 1 до 100 символов")
+	}
+	if len(r.Description) > 500 {
+		return fmt.Errorf("описание не должно превышать 500 символов")
+	}
+	return nil
+}
+
+// CreateAPIKeyRequest - запрос на выпуск API ключа. AccountID ограничивает переводы одним счетом.
+type CreateAPIKeyRequest struct {
+	Name          string        `json:"name"`
+	Scopes        []APIKeyScope `json:"scopes"`
+	AccountID     *uuid.UUID    `json:"account_id,omitempty"`
+	ExpiresInDays int           `json:"expires_in_days"` // по умолчанию 90, не более 365
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return fmt.Errorf("название должно содержать от 1 до 100 символов")
+	}
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("укажите хотя бы одно право ключа")
+	}
+
+	names := make([]string, len(APIKeyScopes))
+	for i, scope := range APIKeyScopes {
+		names[i] = string(scope)
+	}
+	for _, scope := range r.Scopes {
+		known := false
+		for _, s := range APIKeyScopes {
+			if scope == s {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("неизвестное право %q, допустимые: %s", scope, strings.Join(names, ", "))
+		}
+	}
+
+	if r.AccountID != nil {
+		key := APIKey{Scopes: r.Scopes}
+		if !key.HasScope(ScopeTransfers) {
+			return fmt.Errorf("ограничение счетом применяется только к ключам с правом %s", ScopeTransfers)
+		}
+	}
+
+	if r.ExpiresInDays == 0 {
+		r.ExpiresInDays = 90
+	}
+	if r.ExpiresInDays < 1 || r.ExpiresInDays > 365 {
+		return fmt.Errorf("срок действия ключа должен быть от 1 до 365 дней")
+	}
+	return nil
+}
+
+// CreatedAPIKey - ответ на выпуск ключа. Ключ показывается только один раз.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry - запись журнала действий сотрудников
+type AuditEntry struct {
+	ID         uuid.UUID       `json:"id" db:"id"`
+	ActorID    uuid.UUID       `json:"actor_id" db:"actor_id"`
+	ActorRole  Role            `json:"actor_role" db:"actor_role"`
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type,omitempty" db:"target_type"`
+	TargetID   string          `json:"target_id,omitempty" db:"target_id"`
+	ReasonCode string          `json:"reason_code,omitempty" db:"reason_code"`
+	Comment    string          `json:"comment,omitempty" db:"comment"`
+	Details    json.RawMessage `json:"details,omitempty" db:"details"`
+	IP         string          `json:"ip,omitempty" db:"ip"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter - условия выборки журнала
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Limit      int
+}
+
+// Действия, записываемые в журнал
+const (
+	AuditUsersSearch       = "users.search"
+	AuditUserView          = "user.view"
+	AuditUserRoleChange    = "user.role_change"
+	AuditTransactionsView  = "account.transactions_view"
+	AuditAccountFreeze     = "account.freeze"
+	AuditAccountUnfreeze   = "account.unfreeze"
+	AuditBalanceAdjustment = "account.balance_adjustment"
+)
+
+// Actor - сотрудник, выполняющий действие, и адрес, с которого пришел запрос
+type Actor struct {
+	ID   uuid.UUID
+	Role Role
+	IP   string
+}
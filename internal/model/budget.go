@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Пороги оповещений о расходовании бюджета, в процентах от суммы бюджета
+const (
+	BudgetAlertWarning  = 80
+	BudgetAlertExceeded = 100
+)
+
+// BudgetAlertThresholds - пороги оповещений по возрастанию
+var BudgetAlertThresholds = []int{BudgetAlertWarning, BudgetAlertExceeded}
+
+// Budget - месячный бюджет пользователя по категории расходов. Бюджет без категории
+// общий: учитывает оплаты картой во всех категориях.
+type Budget struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"-" db:"user_id"`
+	Category  *string   `json:"category" db:"category"`
+	Amount    float64   `json:"amount" db:"amount"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Covers сообщает, учитываются ли в бюджете расходы категории category
+func (b *Budget) Covers(category string) bool {
+	if b.Category == nil {
+		return IsSpendingCategory(category)
+	}
+	return *b.Category == category
+}
+
+// CreateBudgetInput - создание бюджета. Пустая категория - общий бюджет.
+type CreateBudgetInput struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+func (i *CreateBudgetInput) Validate() error {
+	i.Category = strings.TrimSpace(i.Category)
+	if i.Category != "" {
+		if err := ValidateSpendingCategory(i.Category); err != nil {
+			return err
+		}
+	}
+	if i.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	return nil
+}
+
+// UpdateBudgetInput - изменение суммы бюджета
+type UpdateBudgetInput struct {
+	Amount float64 `json:"amount"`
+}
+
+func (i *UpdateBudgetInput) Validate() error {
+	if i.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	return nil
+}
+
+// BudgetProgress - расходование бюджета за месяц. Remaining отрицателен, если бюджет
+// превышен. Projected - ожидаемые расходы к концу месяца при текущем темпе;
+// для прошедших месяцев совпадает с Spent.
+type BudgetProgress struct {
+	Budget
+	Month       string  `json:"month"`
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	PercentUsed float64 `json:"percent_used"`
+	Projected   float64 `json:"projected"`
+	OverBudget  bool    `json:"over_budget"`           // расходы превысили бюджет
+	OnTrack     bool    `json:"on_track"`              // прогноз не превышает бюджет
+	AlertLevel  int     `json:"alert_level,omitempty"` // достигнутый порог оповещения, %
+}
+
+// BudgetMonthLayout - формат месяца в параметрах и ответах бюджетов
+const BudgetMonthLayout = "2006-01"
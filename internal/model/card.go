@@ -31,17 +31,21 @@ type CardResponse struct {
 }
 
 type PaymentRequest struct {
-	CardID uuid.UUID `json:"card_id" validate:"required"`
-	Amount float64   `json:"amount" validate:"required,gt=0"`
+	CardID       uuid.UUID `json:"card_id" validate:"required"`
+	Amount       float64   `json:"amount" validate:"required,gt=0"`
+	MerchantName string    `json:"merchant_name"` // продавец, необязательно
+	MCC          string    `json:"mcc"`           // код категории продавца, 4 цифры, необязательно
 }
 
 type PaymentResponse struct {
-	PaymentID   uuid.UUID `json:"payment_id"`
-	CardID      uuid.UUID `json:"card_id"`
-	AccountID   uuid.UUID `json:"account_id"`
-	Amount      float64   `json:"amount"`
-	Status      string    `json:"status"` // pending, completed, failed
-	ProcessedAt time.Time `json:"processed_at"`
+	PaymentID        uuid.UUID `json:"payment_id"`
+	CardID           uuid.UUID `json:"card_id"`
+	AccountID        uuid.UUID `json:"account_id"`
+	Amount           float64   `json:"amount"`
+	CreditLineAmount float64   `json:"credit_line_amount,omitempty"` // часть суммы, покрытая кредитной линией
+	Category         string    `json:"category,omitempty"`           // категория расходов
+	Status           string    `json:"status"`                       // pending, completed, failed
+	ProcessedAt      time.Time `json:"processed_at"`
 }
 
 type CardData struct {
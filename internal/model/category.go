@@ -0,0 +1,224 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Категории расходов по оплатам картой. Остальные операции относятся к категории,
+// совпадающей с типом операции (deposit, transfer, credit_payment и т.д.).
+const (
+	CategoryGroceries     = "groceries"     // продукты
+	CategoryRestaurants   = "restaurants"   // кафе и рестораны
+	CategoryTransport     = "transport"     // общественный транспорт и такси
+	CategoryFuel          = "fuel"          // топливо
+	CategoryTravel        = "travel"        // авиабилеты, отели, аренда автомобилей
+	CategoryEntertainment = "entertainment" // кино, развлечения, цифровые товары
+	CategoryHealth        = "health"        // аптеки и медицина
+	CategoryShopping      = "shopping"      // одежда, электроника, товары для дома
+	CategoryUtilities     = "utilities"     // коммунальные услуги
+	CategoryTelecom       = "telecom"       // связь и интернет
+	CategoryEducation     = "education"     // образование
+	CategoryCash          = "cash"          // снятие наличных
+	CategoryOther         = "other"         // MCC не указан или не распознан
+)
+
+// SpendingCategories - категории, назначаемые по MCC
+var SpendingCategories = []string{
+	CategoryGroceries,
+	CategoryRestaurants,
+	CategoryTransport,
+	CategoryFuel,
+	CategoryTravel,
+	CategoryEntertainment,
+	CategoryHealth,
+	CategoryShopping,
+	CategoryUtilities,
+	CategoryTelecom,
+	CategoryEducation,
+	CategoryCash,
+	CategoryOther,
+}
+
+// operationCategories - категории операций, кроме оплат картой: совпадают с типом операции
+var operationCategories = map[string]bool{
+	string(TransactionTypeTransfer):          true,
+	string(TransactionTypeDeposit):           true,
+	string(TransactionTypeWithdrawal):        true,
+	string(TransactionTypeCredit):            true,
+	string(TransactionTypeCreditPayment):     true,
+	string(TransactionTypeCardPayment):       true,
+	string(TransactionTypeCreditLinePayment): true,
+	string(TransactionTypeCollectionSweep):   true,
+	string(TransactionTypeAdjustmentCredit):  true,
+	string(TransactionTypeAdjustmentDebit):   true,
+}
+
+// IsSpendingCategory сообщает, является ли категория категорией расходов по оплатам картой
+// (по MCC или правилу пользователя), а не категорией операции другого типа
+func IsSpendingCategory(category string) bool {
+	return !operationCategories[category]
+}
+
+// ValidateSpendingCategory проверяет название категории расходов
+func ValidateSpendingCategory(category string) error {
+	if !categoryPattern.MatchString(category) {
+		return fmt.Errorf("category must be 1-50 lowercase latin letters, digits or underscores starting with a letter")
+	}
+	if !IsSpendingCategory(category) {
+		return fmt.Errorf("category %s is an operation type and cannot be used for card payments", category)
+	}
+	return nil
+}
+
+// mccRange - диапазон MCC кодов одной категории
+type mccRange struct {
+	from, to int
+	category string
+}
+
+// mccCategories - категории по умолчанию для MCC кодов (ISO 18245)
+var mccCategories = []mccRange{
+	{3000, 3350, CategoryTravel}, // авиакомпании
+	{3351, 3500, CategoryTravel}, // аренда автомобилей
+	{3501, 3999, CategoryTravel}, // отели
+	{4011, 4011, CategoryTransport},
+	{4111, 4131, CategoryTransport},
+	{4411, 4411, CategoryTravel},
+	{4511, 4511, CategoryTravel},
+	{4722, 4722, CategoryTravel},
+	{4784, 4789, CategoryTransport},
+	{4812, 4816, CategoryTelecom},
+	{4899, 4899, CategoryTelecom},
+	{4900, 4900, CategoryUtilities},
+	{5200, 5200, CategoryShopping},
+	{5262, 5262, CategoryShopping},
+	{5300, 5399, CategoryShopping},
+	{5411, 5411, CategoryGroceries},
+	{5422, 5499, CategoryGroceries},
+	{5541, 5542, CategoryFuel},
+	{5600, 5699, CategoryShopping},
+	{5712, 5735, CategoryShopping},
+	{5812, 5814, CategoryRestaurants},
+	{5815, 5818, CategoryEntertainment},
+	{5912, 5912, CategoryHealth},
+	{5940, 5999, CategoryShopping},
+	{5983, 5983, CategoryFuel},
+	{6010, 6011, CategoryCash},
+	{7011, 7011, CategoryTravel},
+	{7512, 7512, CategoryTravel},
+	{7832, 7841, CategoryEntertainment},
+	{7911, 7999, CategoryEntertainment},
+	{8011, 8099, CategoryHealth},
+	{8211, 8299, CategoryEducation},
+}
+
+var (
+	mccPattern      = regexp.MustCompile(`^[0-9]{4}$`)
+	categoryPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+)
+
+// CategoryForMCC возвращает категорию по умолчанию для MCC кода. Если код не указан
+// или не распознан, возвращается CategoryOther. Более узкий диапазон имеет приоритет.
+func CategoryForMCC(mcc string) string {
+	code, err := strconv.Atoi(mcc)
+	if err != nil || !mccPattern.MatchString(mcc) {
+		return CategoryOther
+	}
+
+	category, width := CategoryOther, -1
+	for _, r := range mccCategories {
+		if code >= r.from && code <= r.to && (width < 0 || r.to-r.from < width) {
+			category, width = r.category, r.to-r.from
+		}
+	}
+	return category
+}
+
+// ValidateMCC проверяет MCC код: четыре цифры. Пустой код допустим.
+func ValidateMCC(mcc string) error {
+	if mcc != "" && !mccPattern.MatchString(mcc) {
+		return fmt.Errorf("mcc must be 4 digits")
+	}
+	return nil
+}
+
+// CategoryRule - правило пользователя для категории оплат картой. Правило срабатывает,
+// если выполнены все заданные условия: название продавца содержит MerchantContains
+// (без учета регистра) и MCC совпадает.
+type CategoryRule struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	UserID           uuid.UUID `json:"-" db:"user_id"`
+	MerchantContains string    `json:"merchant_contains,omitempty" db:"merchant_contains"`
+	MCC              string    `json:"mcc,omitempty" db:"mcc"`
+	Category         string    `json:"category" db:"category"`
+	Priority         int       `json:"priority" db:"priority"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Matches сообщает, подходит ли правило для оплаты у продавца merchant с кодом mcc
+func (r *CategoryRule) Matches(merchant, mcc string) bool {
+	if r.MCC != "" && r.MCC != mcc {
+		return false
+	}
+	if r.MerchantContains != "" && !strings.Contains(strings.ToLower(merchant), strings.ToLower(r.MerchantContains)) {
+		return false
+	}
+	return true
+}
+
+// CategorizeCardPayment возвращает категорию оплаты картой: по первому подходящему правилу
+// пользователя (rules упорядочены по убыванию приоритета), иначе по MCC
+func CategorizeCardPayment(rules []CategoryRule, merchant, mcc string) string {
+	for i := range rules {
+		if rules[i].Matches(merchant, mcc) {
+			return rules[i].Category
+		}
+	}
+	return CategoryForMCC(mcc)
+}
+
+// CategoryRuleInput - создание или изменение правила категории
+type CategoryRuleInput struct {
+	MerchantContains string `json:"merchant_contains"`
+	MCC              string `json:"mcc"`
+	Category         string `json:"category"`
+	Priority         int    `json:"priority"`
+}
+
+func (i *CategoryRuleInput) Validate() error {
+	i.MerchantContains = strings.TrimSpace(i.MerchantContains)
+	i.MCC = strings.TrimSpace(i.MCC)
+	i.Category = strings.TrimSpace(i.Category)
+
+	if i.MerchantContains == "" && i.MCC == "" {
+		return fmt.Errorf("merchant_contains or mcc is required")
+	}
+	if len([]rune(i.MerchantContains)) > 100 {
+		return fmt.Errorf("merchant_contains must be at most 100 characters")
+	}
+	if err := ValidateMCC(i.MCC); err != nil {
+		return err
+	}
+	return ValidateSpendingCategory(i.Category)
+}
+
+// CategoryRuleResult - правило и число оплат, категория которых изменилась после его применения
+type CategoryRuleResult struct {
+	CategoryRule
+	Recategorized int `json:"recategorized"`
+}
+
+// CardPaymentCategory - данные оплаты картой для повторного определения категории
+type CardPaymentCategory struct {
+	TransactionID uuid.UUID
+	MerchantName  string
+	MCC           string
+	Category      string
+}
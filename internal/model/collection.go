@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CollectionCase - дело по взысканию просроченной задолженности по кредиту
+type CollectionCase struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	CreditID        uuid.UUID  `json:"credit_id" db:"credit_id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	Status          string     `json:"status" db:"status"` // open, resolved, defaulted
+	OverdueSince    time.Time  `json:"overdue_since" db:"overdue_since"`
+	OverdueAmount   float64    `json:"overdue_amount" db:"overdue_amount"`
+	SweptAmount     float64    `json:"swept_amount" db:"swept_amount"`
+	LastReminderDay int        `json:"last_reminder_day" db:"last_reminder_day"`
+	OpenedAt        time.Time  `json:"opened_at" db:"opened_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CollectionEvent - запись журнала дела: автоматические действия и заметки сотрудников
+type CollectionEvent struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	CaseID    uuid.UUID  `json:"case_id" db:"case_id"`
+	EventType string     `json:"event_type" db:"event_type"` // opened, reminder, sweep, note, defaulted, resolved
+	Message   string     `json:"message" db:"message"`
+	Amount    *float64   `json:"amount,omitempty" db:"amount"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty" db:"author_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CollectionCaseDetails - дело вместе с журналом
+type CollectionCaseDetails struct {
+	Case   CollectionCase    `json:"case"`
+	Events []CollectionEvent `json:"events"`
+}
+
+// OverdueCredit - сводка просроченной задолженности по кредиту
+type OverdueCredit struct {
+	CreditID      uuid.UUID
+	OverdueSince  time.Time
+	OverdueAmount float64
+}
+
+type CollectionNoteRequest struct {
+	Message string `json:"message" validate:"required"`
+}
@@ -8,18 +8,26 @@ import (
 
 // Withdraw models
 type Credit struct {
-	ID             uuid.UUID `json:"id" db:"id"`
-	AccountID      uuid.UUID `json:"account_id" db:"account_id"`
-	UserID         uuid.UUID `json:"user_id" db:"user_id"`
-	Amount         float64   `json:"amount" db:"amount"`
-	InterestRate   float64   `json:"interest_rate" db:"interest_rate"`
-	TermMonths     int       `json:"term_months" db:"term_months"`
-	MonthlyPayment float64   `json:"monthly_payment" db:"monthly_payment"`
-	StartDate      time.Time `json:"start_date" db:"start_date"`
-	EndDate        time.Time `json:"end_date" db:"end_date"`
-	Status         string    `json:"status" db:"status"` // active, paid, overdue, defaulted
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID  `json:"id" db:"id"`
+	AccountID        uuid.UUID  `json:"account_id" db:"account_id"`
+	UserID           uuid.UUID  `json:"user_id" db:"user_id"`
+	Amount           float64    `json:"amount" db:"amount"`
+	InterestRate     float64    `json:"interest_rate" db:"interest_rate"`
+	TermMonths       int        `json:"term_months" db:"term_months"`
+	MonthlyPayment   float64    `json:"monthly_payment" db:"monthly_payment"`
+	StartDate        time.Time  `json:"start_date" db:"start_date"`
+	EndDate          time.Time  `json:"end_date" db:"end_date"`
+	Status           string     `json:"status" db:"status"` // active, paid, overdue, defaulted
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	RateType         string     `json:"rate_type" db:"rate_type"`       // fixed, floating
+	RateMargin       float64    `json:"rate_margin" db:"rate_margin"`   // маржа к ключевой ставке, %
+	ProductID        *uuid.UUID `json:"product_id" db:"product_id"`     // nil для кредитов, выданных до появления продуктов
+	PenaltyType      string     `json:"penalty_type" db:"penalty_type"` // none, percent, fixed (копия условий продукта)
+	PenaltyValue     float64    `json:"penalty_value" db:"penalty_value"`
+	ScheduleType     string     `json:"schedule_type" db:"schedule_type"` // annuity, differentiated
+	ScheduleVersion  int        `json:"schedule_version" db:"schedule_version"`
+	AutoSweepAllowed bool       `json:"auto_sweep_allowed" db:"auto_sweep_allowed"` // списание просрочки с других счетов заемщика
 }
 
 type PaymentSchedule struct {
@@ -30,19 +38,37 @@ type PaymentSchedule struct {
 	Amount        float64    `json:"amount" db:"amount"`
 	Principal     float64    `json:"principal" db:"principal"`
 	Interest      float64    `json:"interest" db:"interest"`
-	Status        string     `json:"status" db:"status"` // pending, paid, overdue
+	Status        string     `json:"status" db:"status"` // pending, paid, overdue, prepaid, superseded
 	PaidAt        *time.Time `json:"paid_at" db:"paid_at"`
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	Version       int        `json:"version" db:"version"` // версия графика, в которой создан платеж
 }
 
 type CreateCreditRequest struct {
+	ProductID  uuid.UUID `json:"product_id" validate:"required"`
 	AccountID  uuid.UUID `json:"account_id" validate:"required"`
-	Amount     float64   `json:"amount" validate:"required,gt=0"`
-	TermMonths int       `json:"term_months" validate:"required,gte=6,lte=60"`
+	Amount     float64   `json:"amount" validate:"required,gt=0"`      // границы задаются продуктом
+	TermMonths int       `json:"term_months" validate:"required,gt=0"` // границы задаются продуктом
+	// AutoSweepConsent - согласие на списание просроченной задолженности с других счетов
+	AutoSweepConsent bool `json:"auto_sweep_consent"`
 }
 
 type CreditPaymentRequest struct {
 	CreditID uuid.UUID `json:"credit_id" validate:"required"`
 	Amount   float64   `json:"amount" validate:"required,gt=0"`
 }
+
+type EarlyRepaymentRequest struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// EarlyRepaymentResult - итог досрочного погашения
+type EarlyRepaymentResult struct {
+	CreditID           uuid.UUID `json:"credit_id"`
+	Amount             float64   `json:"amount"`
+	Fee                float64   `json:"fee"`
+	RemainingPrincipal float64   `json:"remaining_principal"`
+	MonthlyPayment     float64   `json:"monthly_payment"`
+	CreditStatus       string    `json:"credit_status"`
+}
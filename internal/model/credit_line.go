@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreditLine - возобновляемая кредитная линия, привязанная к карте
+type CreditLine struct {
+	ID                uuid.UUID `json:"id" db:"id"`
+	UserID            uuid.UUID `json:"user_id" db:"user_id"`
+	CardID            uuid.UUID `json:"card_id" db:"card_id"`
+	AccountID         uuid.UUID `json:"account_id" db:"account_id"`
+	CreditLimit       float64   `json:"credit_limit" db:"credit_limit"`
+	UsedAmount        float64   `json:"used_amount" db:"used_amount"`
+	AccruedInterest   float64   `json:"accrued_interest" db:"accrued_interest"`
+	InterestRate      float64   `json:"interest_rate" db:"interest_rate"`
+	MinPaymentPercent float64   `json:"min_payment_percent" db:"min_payment_percent"`
+	GracePeriodDays   int       `json:"grace_period_days" db:"grace_period_days"`
+	GraceActive       bool      `json:"grace_active" db:"grace_active"`
+	LastAccruedAt     time.Time `json:"last_accrued_at" db:"last_accrued_at"`
+	NextStatementDate time.Time `json:"next_statement_date" db:"next_statement_date"`
+	Status            string    `json:"status" db:"status"` // active, closed
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AvailableAmount возвращает неиспользованный остаток лимита
+func (l *CreditLine) AvailableAmount() float64 {
+	available := l.CreditLimit - l.UsedAmount
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// CreditLineStatement - ежемесячная выписка по кредитной линии
+type CreditLineStatement struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	CreditLineID     uuid.UUID `json:"credit_line_id" db:"credit_line_id"`
+	PeriodStart      time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd        time.Time `json:"period_end" db:"period_end"`
+	StatementBalance float64   `json:"statement_balance" db:"statement_balance"`
+	MinPayment       float64   `json:"min_payment" db:"min_payment"`
+	DeferredInterest float64   `json:"deferred_interest" db:"deferred_interest"` // списывается, если выписка не погашена полностью
+	PaidAmount       float64   `json:"paid_amount" db:"paid_amount"`
+	DueDate          time.Time `json:"due_date" db:"due_date"`
+	Status           string    `json:"status" db:"status"` // open, paid, partially_paid, overdue
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreditLineOperation - движение по кредитной линии
+type CreditLineOperation struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	CreditLineID  uuid.UUID  `json:"credit_line_id" db:"credit_line_id"`
+	OperationType string     `json:"operation_type" db:"operation_type"` // draw, repayment, interest
+	Amount        float64    `json:"amount" db:"amount"`
+	ReferenceID   *uuid.UUID `json:"reference_id" db:"reference_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreateCreditLineRequest struct {
+	CardID      uuid.UUID `json:"card_id" validate:"required"`
+	CreditLimit float64   `json:"credit_limit" validate:"required,gt=0"`
+}
+
+type CreditLineRepaymentRequest struct {
+	AccountID uuid.UUID `json:"account_id" validate:"required"`
+	Amount    float64   `json:"amount" validate:"required,gt=0"`
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreditProduct - кредитный продукт, задающий условия выдачи кредита
+type CreditProduct struct {
+	ID                       uuid.UUID `json:"id" db:"id"`
+	Name                     string    `json:"name" db:"name"`
+	Description              string    `json:"description" db:"description"`
+	MinAmount                float64   `json:"min_amount" db:"min_amount"`
+	MaxAmount                float64   `json:"max_amount" db:"max_amount"`
+	MinTermMonths            int       `json:"min_term_months" db:"min_term_months"`
+	MaxTermMonths            int       `json:"max_term_months" db:"max_term_months"`
+	RateType                 string    `json:"rate_type" db:"rate_type"`             // fixed, floating
+	FixedRate                *float64  `json:"fixed_rate,omitempty" db:"fixed_rate"` // nil: ключевая ставка + маржа
+	RateMargin               float64   `json:"rate_margin" db:"rate_margin"`         // маржа к ключевой ставке, %
+	PenaltyType              string    `json:"penalty_type" db:"penalty_type"`       // none, percent, fixed
+	PenaltyValue             float64   `json:"penalty_value" db:"penalty_value"`     // % от просроченного платежа или сумма
+	ScheduleType             string    `json:"schedule_type" db:"schedule_type"`     // annuity, differentiated
+	EarlyRepaymentAllowed    bool      `json:"early_repayment_allowed" db:"early_repayment_allowed"`
+	EarlyRepaymentFeePercent float64   `json:"early_repayment_fee_percent" db:"early_repayment_fee_percent"`
+	EarlyRepaymentMinMonths  int       `json:"early_repayment_min_months" db:"early_repayment_min_months"` // мораторий с даты выдачи
+	Active                   bool      `json:"active" db:"active"`
+	CreatedAt                time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreditProductRequest - параметры создания и изменения кредитного продукта
+type CreditProductRequest struct {
+	Name                     string   `json:"name" validate:"required,max=100"`
+	Description              string   `json:"description"`
+	MinAmount                float64  `json:"min_amount" validate:"required,gt=0"`
+	MaxAmount                float64  `json:"max_amount" validate:"required,gtefield=MinAmount"`
+	MinTermMonths            int      `json:"min_term_months" validate:"required,gt=0"`
+	MaxTermMonths            int      `json:"max_term_months" validate:"required,gtefield=MinTermMonths"`
+	RateType                 string   `json:"rate_type" validate:"required,oneof=fixed floating"`
+	FixedRate                *float64 `json:"fixed_rate,omitempty"`
+	RateMargin               float64  `json:"rate_margin" validate:"gte=0"`
+	PenaltyType              string   `json:"penalty_type" validate:"required,oneof=none percent fixed"`
+	PenaltyValue             float64  `json:"penalty_value" validate:"gte=0"`
+	ScheduleType             string   `json:"schedule_type" validate:"required,oneof=annuity differentiated"`
+	EarlyRepaymentAllowed    bool     `json:"early_repayment_allowed"`
+	EarlyRepaymentFeePercent float64  `json:"early_repayment_fee_percent" validate:"gte=0,lte=100"`
+	EarlyRepaymentMinMonths  int      `json:"early_repayment_min_months" validate:"gte=0"`
+	Active                   bool     `json:"active"`
+}
+
+func (p *CreditProductRequest) Validate() error {
+	if p.Name == "" || len(p.Name) > 100 {
+		return fmt.Errorf("name is required and must be at most 100 characters")
+	}
+	if p.MinAmount <= 0 || p.MaxAmount < p.MinAmount {
+		return fmt.Errorf("invalid amount bounds")
+	}
+	if p.MinTermMonths <= 0 || p.MaxTermMonths < p.MinTermMonths {
+		return fmt.Errorf("invalid term bounds")
+	}
+
+	switch p.RateType {
+	case "fixed":
+		if p.FixedRate != nil && *p.FixedRate <= 0 {
+			return fmt.Errorf("fixed rate must be positive")
+		}
+	case "floating":
+		if p.FixedRate != nil {
+			return fmt.Errorf("floating product cannot have a fixed rate")
+		}
+	default:
+		return fmt.Errorf("rate type must be fixed or floating")
+	}
+	if p.RateMargin < 0 {
+		return fmt.Errorf("rate margin cannot be negative")
+	}
+
+	switch p.PenaltyType {
+	case "none", "percent", "fixed":
+	default:
+		return fmt.Errorf("penalty type must be none, percent or fixed")
+	}
+	if p.PenaltyValue < 0 {
+		return fmt.Errorf("penalty value cannot be negative")
+	}
+
+	if p.ScheduleType != "annuity" && p.ScheduleType != "differentiated" {
+		return fmt.Errorf("schedule type must be annuity or differentiated")
+	}
+	if p.EarlyRepaymentFeePercent < 0 || p.EarlyRepaymentFeePercent > 100 {
+		return fmt.Errorf("early repayment fee must be between 0 and 100 percent")
+	}
+	if p.EarlyRepaymentMinMonths < 0 {
+		return fmt.Errorf("early repayment moratorium cannot be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreditRestructuringRequest - заявка на кредитные каникулы или реструктуризацию
+type CreditRestructuringRequest struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	CreditID        uuid.UUID  `json:"credit_id" db:"credit_id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	RequestType     string     `json:"request_type" db:"request_type"`     // holiday, restructuring
+	HolidayMonths   int        `json:"holiday_months" db:"holiday_months"` // количество отсрочиваемых платежей
+	ExtraTermMonths int        `json:"extra_term_months" db:"extra_term_months"`
+	NewRate         *float64   `json:"new_rate,omitempty" db:"new_rate"`
+	Reason          string     `json:"reason" db:"reason"`
+	Status          string     `json:"status" db:"status"` // pending, approved, rejected
+	ReviewerID      *uuid.UUID `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	ReviewComment   string     `json:"review_comment" db:"review_comment"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ScheduleVersion *int       `json:"schedule_version,omitempty" db:"schedule_version"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateRestructuringRequest struct {
+	RequestType     string   `json:"request_type" validate:"required,oneof=holiday restructuring"`
+	HolidayMonths   int      `json:"holiday_months" validate:"gte=0"`
+	ExtraTermMonths int      `json:"extra_term_months" validate:"gte=0"`
+	NewRate         *float64 `json:"new_rate,omitempty"`
+	Reason          string   `json:"reason" validate:"required"`
+}
+
+type ReviewRestructuringRequest struct {
+	Comment string   `json:"comment"`
+	NewRate *float64 `json:"new_rate,omitempty"` // ставка, утвержденная банком вместо запрошенной
+}
+
+// CreditScheduleOverview - действующий и первоначальный графики платежей по кредиту
+type CreditScheduleOverview struct {
+	CreditID       uuid.UUID                    `json:"credit_id"`
+	CurrentVersion int                          `json:"current_version"`
+	Current        []PaymentSchedule            `json:"current"`
+	Original       []PaymentSchedule            `json:"original"`
+	Changes        []CreditRestructuringRequest `json:"changes"` // одобренные заявки, создавшие новые версии
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobRun - запись о запуске фоновой задачи планировщика
+type JobRun struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	JobName    string     `json:"job_name" db:"job_name"`
+	Instance   string     `json:"instance" db:"instance"`
+	Status     string     `json:"status" db:"status"` // running, succeeded, failed, cancelled
+	Processed  int        `json:"processed" db:"processed"`
+	Succeeded  int        `json:"succeeded" db:"succeeded"`
+	Failed     int        `json:"failed" db:"failed"`
+	Skipped    int        `json:"skipped" db:"skipped"`
+	Error      *string    `json:"error,omitempty" db:"error"`
+	StartedAt  time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// JobStats - статистика одного запуска задачи
+type JobStats struct {
+	Processed int
+	Succeeded int
+	Failed    int
+	Skipped   int
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyRate - значение ключевой ставки ЦБ РФ на дату
+type KeyRate struct {
+	Date      time.Time `json:"date" db:"rate_date"`
+	Rate      float64   `json:"rate" db:"rate"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreditRateChange - запись о пересмотре ставки плавающего кредита
+type CreditRateChange struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	CreditID      uuid.UUID `json:"credit_id" db:"credit_id"`
+	KeyRate       float64   `json:"key_rate" db:"key_rate"`
+	OldRate       float64   `json:"old_rate" db:"old_rate"`
+	NewRate       float64   `json:"new_rate" db:"new_rate"`
+	OldPayment    float64   `json:"old_payment" db:"old_payment"`
+	NewPayment    float64   `json:"new_payment" db:"new_payment"`
+	EffectiveFrom time.Time `json:"effective_from" db:"effective_from"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// LoginAttempts - состояние счетчика неудачных попыток входа для email или IP адреса
+type LoginAttempts struct {
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty" db:"blocked_until"`
+}
@@ -0,0 +1,224 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы уведомлений. По типу выбираются каналы доставки (NOTIFY_ROUTES).
+const (
+	NotificationTransferCompleted       = OutboxTransferCompleted
+	NotificationCardPayment             = OutboxCardPayment
+	NotificationCreditPayment           = OutboxCreditPayment
+	NotificationCreditLineStatement     = OutboxCreditLineStatement
+	NotificationCreditRateChanged       = OutboxCreditRateChanged
+	NotificationRestructuringDecision   = OutboxRestructuringDecision
+	NotificationCollectionReminder      = OutboxCollectionReminder
+	NotificationCreditDefaulted         = OutboxCreditDefaulted
+	NotificationEmailVerification       = "auth.email_verification"
+	NotificationEmailChangeConfirmation = "auth.email_change_confirmation"
+	NotificationPasswordReset           = "auth.password_reset"
+	NotificationLoginLocked             = "security.login_locked"
+	NotificationPasswordChanged         = "security.password_changed"
+	NotificationEmailChanged            = "security.email_changed"
+	NotificationAccountDeleted          = "account.deleted"
+)
+
+// Уведомления, которые пользователь настраивает сам. Уведомления безопасности
+// (вход, смена пароля и email) отключить нельзя, они всегда отправляются письмом.
+const (
+	NotificationIncomingFunds   = "account.incoming_funds"
+	NotificationThresholdAlert  = "alert.threshold"
+	NotificationBudgetAlert     = OutboxBudgetAlert
+	NotificationDailyDigest     = "digest.daily"
+	NotificationChannelEmail    = "email"
+	NotificationChannelTelegram = "telegram"
+)
+
+// Способы доставки уведомления по каналу
+const (
+	NotificationModeImmediate = "immediate" // сразу после события
+	NotificationModeDigest    = "digest"    // в ежедневной сводке (только email)
+	NotificationModeOff       = "off"       // не отправлять
+)
+
+// ConfigurableNotifications - типы уведомлений, доступные в настройках пользователя
+var ConfigurableNotifications = []string{
+	NotificationTransferCompleted,
+	NotificationCardPayment,
+	NotificationCreditPayment,
+	NotificationIncomingFunds,
+	NotificationThresholdAlert,
+	NotificationBudgetAlert,
+}
+
+// NotificationChannels - каналы, которые пользователь выбирает сам
+var NotificationChannels = []string{NotificationChannelEmail, NotificationChannelTelegram}
+
+// DefaultNotificationMode возвращает способ доставки, если пользователь его не менял:
+// письма отправляются сразу, кроме уведомлений о зачислениях, Telegram отключен
+func DefaultNotificationMode(eventType, channel string) string {
+	if channel != NotificationChannelEmail || eventType == NotificationIncomingFunds {
+		return NotificationModeOff
+	}
+	return NotificationModeImmediate
+}
+
+// NotificationPreference - способ доставки уведомления одного типа по одному каналу
+type NotificationPreference struct {
+	EventType string `json:"event_type" db:"event_type"`
+	Channel   string `json:"channel" db:"channel"`
+	Mode      string `json:"mode" db:"mode"`
+}
+
+func (p *NotificationPreference) Validate() error {
+	if !containsString(ConfigurableNotifications, p.EventType) {
+		return fmt.Errorf("unknown notification event type: %s", p.EventType)
+	}
+	if !containsString(NotificationChannels, p.Channel) {
+		return fmt.Errorf("unknown notification channel: %s", p.Channel)
+	}
+	switch p.Mode {
+	case NotificationModeImmediate, NotificationModeOff:
+	case NotificationModeDigest:
+		if p.Channel != NotificationChannelEmail {
+			return fmt.Errorf("digest mode is available only for email")
+		}
+	default:
+		return fmt.Errorf("unknown notification mode: %s", p.Mode)
+	}
+	return nil
+}
+
+// NotificationSettings - настройки уведомлений пользователя. Preferences содержит
+// все типы уведомлений и каналы с учетом значений по умолчанию.
+type NotificationSettings struct {
+	TelegramChatID *string                  `json:"telegram_chat_id"`
+	Preferences    []NotificationPreference `json:"preferences"`
+}
+
+// Mode возвращает способ доставки уведомления по каналу
+func (s *NotificationSettings) Mode(eventType, channel string) string {
+	for _, p := range s.Preferences {
+		if p.EventType == eventType && p.Channel == channel {
+			return p.Mode
+		}
+	}
+	return DefaultNotificationMode(eventType, channel)
+}
+
+// UpdateNotificationSettingsInput - изменение настроек уведомлений. Перечисляются только
+// изменяемые пары тип/канал. TelegramChatID: nil - не менять, пустая строка - удалить.
+type UpdateNotificationSettingsInput struct {
+	TelegramChatID *string                  `json:"telegram_chat_id"`
+	Preferences    []NotificationPreference `json:"preferences"`
+}
+
+func (i *UpdateNotificationSettingsInput) Validate() error {
+	if i.TelegramChatID != nil {
+		chatID := strings.TrimSpace(*i.TelegramChatID)
+		if chatID != "" {
+			if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+				return fmt.Errorf("telegram chat id must be numeric")
+			}
+		}
+		i.TelegramChatID = &chatID
+	}
+	for idx := range i.Preferences {
+		if err := i.Preferences[idx].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Типы пороговых оповещений
+const (
+	AlertBalanceBelow     = "balance_below"      // баланс счета опустился ниже порога
+	AlertCardPaymentAbove = "card_payment_above" // оплата картой на сумму выше порога
+)
+
+// NotificationAlert - пороговое оповещение. Для balance_below можно указать счет,
+// без счета оповещение действует для всех счетов пользователя.
+type NotificationAlert struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"-" db:"user_id"`
+	AlertType string     `json:"alert_type" db:"alert_type"`
+	AccountID *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	Threshold float64    `json:"threshold" db:"threshold"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateAlertInput - создание порогового оповещения
+type CreateAlertInput struct {
+	AlertType string     `json:"alert_type"`
+	AccountID *uuid.UUID `json:"account_id"`
+	Threshold float64    `json:"threshold"`
+}
+
+func (i *CreateAlertInput) Validate() error {
+	switch i.AlertType {
+	case AlertBalanceBelow:
+	case AlertCardPaymentAbove:
+		if i.AccountID != nil {
+			return fmt.Errorf("account_id is not supported for card payment alerts")
+		}
+	default:
+		return fmt.Errorf("unknown alert type: %s", i.AlertType)
+	}
+	if i.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	return nil
+}
+
+// DigestItem - уведомление, ожидающее ежедневной сводки
+type DigestItem struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	SourceID  uuid.UUID `db:"source_id"`
+	EventType string    `db:"event_type"`
+	Subject   string    `db:"subject"`
+	Body      string    `db:"body"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// InboxItem - уведомление во входящих пользователя в приложении
+type InboxItem struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	UserID    uuid.UUID       `json:"-" db:"user_id"`
+	SourceID  *uuid.UUID      `json:"-" db:"source_id"`
+	EventType string          `json:"event_type" db:"event_type"`
+	Subject   string          `json:"subject" db:"subject"`
+	Body      string          `json:"body" db:"body"`
+	Data      json.RawMessage `json:"data,omitempty" db:"data"`
+	Read      bool            `json:"read"`
+	ReadAt    *time.Time      `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// InboxFilter - условия выборки входящих уведомлений
+type InboxFilter struct {
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+// InboxUnreadCount - число непрочитанных уведомлений
+type InboxUnreadCount struct {
+	Unread int `json:"unread"`
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
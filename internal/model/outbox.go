@@ -0,0 +1,135 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы события outbox
+const (
+	OutboxStatusPending = "pending" // ожидает доставки
+	OutboxStatusSent    = "sent"    // доставлено
+	OutboxStatusDead    = "dead"    // попытки исчерпаны, требуется ручной повтор
+)
+
+// Типы событий outbox
+const (
+	OutboxTransferCompleted     = "transfer.completed"
+	OutboxCardPayment           = "card.payment"
+	OutboxCreditPayment         = "credit.payment"
+	OutboxBalanceChanged        = "account.balance_changed"
+	OutboxCreditRateChanged     = "credit.rate_changed"
+	OutboxCollectionReminder    = "credit.collection_reminder"
+	OutboxCreditLineStatement   = "credit_line.statement"
+	OutboxRestructuringDecision = "credit.restructuring_decision"
+	OutboxCreditDefaulted       = "credit.defaulted"
+	OutboxBudgetAlert           = "alert.budget"
+)
+
+// OutboxEvent - событие, записанное в одной транзакции с изменением данных
+// и доставляемое фоновым обработчиком
+type OutboxEvent struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id" db:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedUntil   *time.Time      `json:"-" db:"locked_until"`
+	LastError     *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	SentAt        *time.Time      `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// TransferCompletedEvent - перевод между счетами выполнен
+type TransferCompletedEvent struct {
+	UserID        uuid.UUID `json:"user_id"`
+	TransferID    uuid.UUID `json:"transfer_id"`
+	FromAccountID uuid.UUID `json:"from_account_id"`
+	ToAccountID   uuid.UUID `json:"to_account_id"`
+	Amount        float64   `json:"amount"`
+}
+
+// CardPaymentEvent - оплата картой выполнена
+type CardPaymentEvent struct {
+	UserID           uuid.UUID `json:"user_id"`
+	PaymentID        uuid.UUID `json:"payment_id"`
+	CardID           uuid.UUID `json:"card_id"`
+	Amount           float64   `json:"amount"`
+	CreditLineAmount float64   `json:"credit_line_amount,omitempty"`
+	Category         string    `json:"category,omitempty"`
+}
+
+// CreditPaymentEvent - списан платеж по кредиту
+type CreditPaymentEvent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreditID  uuid.UUID `json:"credit_id"`
+	PaymentID uuid.UUID `json:"payment_id"`
+	Amount    float64   `json:"amount"`
+}
+
+// CreditLineStatementEvent - сформирована выписка по кредитной линии
+type CreditLineStatementEvent struct {
+	UserID           uuid.UUID `json:"user_id"`
+	CreditLineID     uuid.UUID `json:"credit_line_id"`
+	StatementID      uuid.UUID `json:"statement_id"`
+	StatementBalance float64   `json:"statement_balance"`
+	MinPayment       float64   `json:"min_payment"`
+	DueDate          time.Time `json:"due_date"`
+}
+
+// CreditRateChangedEvent - пересмотрена плавающая ставка по кредиту
+type CreditRateChangedEvent struct {
+	UserID        uuid.UUID `json:"user_id"`
+	CreditID      uuid.UUID `json:"credit_id"`
+	OldRate       float64   `json:"old_rate"`
+	NewRate       float64   `json:"new_rate"`
+	NewPayment    float64   `json:"new_payment"`
+	EffectiveFrom time.Time `json:"effective_from"` // дата первого пересчитанного платежа
+}
+
+// RestructuringDecisionEvent - рассмотрена заявка на кредитные каникулы или реструктуризацию
+type RestructuringDecisionEvent struct {
+	UserID     uuid.UUID `json:"user_id"`
+	CreditID   uuid.UUID `json:"credit_id"`
+	RequestID  uuid.UUID `json:"request_id"`
+	Approved   bool      `json:"approved"`
+	Comment    string    `json:"comment,omitempty"`
+	NewPayment float64   `json:"new_payment,omitempty"` // новый ежемесячный платеж при одобрении
+}
+
+// CollectionReminderEvent - напоминание заемщику о просроченной задолженности
+type CollectionReminderEvent struct {
+	UserID        uuid.UUID `json:"user_id"`
+	CreditID      uuid.UUID `json:"credit_id"`
+	CaseID        uuid.UUID `json:"case_id"`
+	OverdueAmount float64   `json:"overdue_amount"`
+	DaysPastDue   int       `json:"days_past_due"`
+}
+
+// CreditDefaultedEvent - кредит переведен в дефолт
+type CreditDefaultedEvent struct {
+	UserID        uuid.UUID `json:"user_id"`
+	CreditID      uuid.UUID `json:"credit_id"`
+	CaseID        uuid.UUID `json:"case_id"`
+	OverdueAmount float64   `json:"overdue_amount"`
+}
+
+// BudgetAlertEvent - расходы достигли порога threshold процентов месячного бюджета
+type BudgetAlertEvent struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	Progress  BudgetProgress `json:"progress"`
+	Threshold int            `json:"threshold"`
+}
+
+// BalanceChangedEvent - изменился баланс счета. Записывается при каждом движении средств,
+// по нему проверяются пороговые оповещения и отправляются уведомления о зачислениях.
+type BalanceChangedEvent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	AccountID uuid.UUID `json:"account_id"`
+	Amount    float64   `json:"amount"`  // изменение: положительное при зачислении
+	Balance   float64   `json:"balance"` // баланс после изменения
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// UpdateUsernameInput - запрос на смену имени пользователя
+type UpdateUsernameInput struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+}
+
+func (i *UpdateUsernameInput) Validate() error {
+	i.Username = strings.TrimSpace(i.Username)
+	if n := utf8.RuneCountInString(i.Username); n < 3 || n > 50 {
+		return fmt.Errorf("username length must be between 3 and 50 characters")
+	}
+	return nil
+}
+
+// Языки уведомлений
+const (
+	LocaleRU = "ru"
+	LocaleEN = "en"
+)
+
+// UpdateLocaleInput - смена языка уведомлений
+type UpdateLocaleInput struct {
+	Locale string `json:"locale" validate:"required,oneof=ru en"`
+}
+
+func (i *UpdateLocaleInput) Validate() error {
+	i.Locale = strings.ToLower(strings.TrimSpace(i.Locale))
+	if i.Locale != LocaleRU && i.Locale != LocaleEN {
+		return fmt.Errorf("unsupported locale: %s", i.Locale)
+	}
+	return nil
+}
+
+// ChangeEmailInput - запрос на смену email. Новый адрес начинает действовать
+// после перехода по ссылке из письма, отправленного на него.
+type ChangeEmailInput struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (i *ChangeEmailInput) Validate() error {
+	i.Email = strings.TrimSpace(i.Email)
+	if !isValidEmail(i.Email) {
+		return fmt.Errorf("invalid email format")
+	}
+	if i.Password == "" {
+		return fmt.Errorf("password is required")
+	}
+	return nil
+}
+
+// ChangePasswordInput - смена пароля с проверкой текущего
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=64"`
+}
+
+func (i *ChangePasswordInput) Validate() error {
+	if i.CurrentPassword == "" {
+		return fmt.Errorf("current password is required")
+	}
+	if len(i.NewPassword) < 8 || len(i.NewPassword) > 64 {
+		return fmt.Errorf("password length must be between 8 and 64 characters")
+	}
+	if !isValidPassword(i.NewPassword) {
+		return fmt.Errorf("password must contain at least one uppercase letter, one lowercase letter, one number and one special character")
+	}
+	if i.NewPassword == i.CurrentPassword {
+		return fmt.Errorf("new password must differ from the current one")
+	}
+	return nil
+}
+
+// DeleteProfileInput - подтверждение удаления учетной записи паролем
+// и, если подключена 2FA, кодом из приложения
+type DeleteProfileInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code,omitempty"`
+}
+
+// ProfileDeletionBlockers - причины, по которым учетную запись нельзя удалить
+type ProfileDeletionBlockers struct {
+	AccountsWithBalance []string `json:"accounts_with_balance,omitempty"`
+	ActiveCredits       []string `json:"active_credits,omitempty"`
+	CreditLinesWithDebt []string `json:"credit_lines_with_debt,omitempty"`
+}
+
+// Empty сообщает, что препятствий для удаления нет
+func (b ProfileDeletionBlockers) Empty() bool {
+	return len(b.AccountsWithBalance) == 0 && len(b.ActiveCredits) == 0 && len(b.CreditLinesWithDebt) == 0
+}
+
+// CreditExport - кредит с графиком платежей в выгрузке персональных данных
+type CreditExport struct {
+	Credit
+	Schedule []PaymentSchedule `json:"schedule"`
+}
+
+// PersonalDataExport - выгрузка персональных данных пользователя. Номера карт маскируются.
+type PersonalDataExport struct {
+	ExportedAt   time.Time         `json:"exported_at"`
+	Profile      User              `json:"profile"`
+	Accounts     []Account         `json:"accounts"`
+	Cards        []CardResponse    `json:"cards"`
+	Credits      []CreditExport    `json:"credits"`
+	CreditLines  []CreditLine      `json:"credit_lines"`
+	Transactions []Transaction     `json:"transactions"`
+	Sessions     []Session         `json:"sessions"`
+	Webhooks     []WebhookEndpoint `json:"webhooks"`
+
+	Notifications      NotificationSettings `json:"notification_settings"`
+	NotificationAlerts []NotificationAlert  `json:"notification_alerts"`
+	NotificationInbox  []InboxItem          `json:"notification_inbox"`
+	CategoryRules      []CategoryRule       `json:"category_rules"`
+	Budgets            []Budget             `json:"budgets"`
+}
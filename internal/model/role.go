@@ -0,0 +1,70 @@
+package model
+
+// Role - роль пользователя, передается в access токене
+type Role string
+
+const (
+	RoleCustomer Role = "customer" // клиент банка
+	RoleOperator Role = "operator" // сотрудник поддержки
+	RoleAdmin    Role = "admin"    // администратор
+)
+
+// Permission - право на выполнение группы операций административного API
+type Permission string
+
+const (
+	PermissionUsersRead         Permission = "users:read"
+	PermissionRolesManage       Permission = "roles:manage"
+	PermissionTransactionsRead  Permission = "transactions:read"
+	PermissionAccountsFreeze    Permission = "accounts:freeze"
+	PermissionBalanceAdjust     Permission = "balance:adjust"
+	PermissionCreditProducts    Permission = "credit_products:manage"
+	PermissionCreditRequests    Permission = "credit_requests:manage"
+	PermissionCollectionsManage Permission = "collections:manage"
+	PermissionJobsRead          Permission = "jobs:read"
+	PermissionAuditRead         Permission = "audit:read"
+	PermissionOutboxManage      Permission = "outbox:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOperator: {
+		PermissionUsersRead,
+		PermissionTransactionsRead,
+		PermissionAccountsFreeze,
+		PermissionCreditRequests,
+		PermissionCollectionsManage,
+	},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionRolesManage,
+		PermissionTransactionsRead,
+		PermissionAccountsFreeze,
+		PermissionBalanceAdjust,
+		PermissionCreditProducts,
+		PermissionCreditRequests,
+		PermissionCollectionsManage,
+		PermissionJobsRead,
+		PermissionAuditRead,
+		PermissionOutboxManage,
+	},
+}
+
+// Valid сообщает, известна ли роль
+func (r Role) Valid() bool {
+	return r == RoleCustomer || r == RoleOperator || r == RoleAdmin
+}
+
+// Can сообщает, есть ли у роли право
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff сообщает, относится ли роль к сотрудникам банка
+func (r Role) IsStaff() bool {
+	return r == RoleOperator || r == RoleAdmin
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken - refresh токен, хранится в виде SHA-256 хеша
+type RefreshToken struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID        uuid.UUID  `json:"family_id" db:"family_id"`
+	ParentID        *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	TokenHash       string     `json:"-" db:"token_hash"`
+	UserAgent       string     `json:"user_agent" db:"user_agent"`
+	IP              string     `json:"ip" db:"ip"`
+	AccessJTI       uuid.UUID  `json:"-" db:"access_jti"`
+	AccessExpiresAt time.Time  `json:"-" db:"access_expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt          *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokeReason    *string    `json:"revoke_reason,omitempty" db:"revoke_reason"`
+}
+
+// Session - активный вход пользователя (семейство refresh токенов)
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	StartedAt  time.Time `json:"started_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// DeviceInfo - данные устройства, с которого выполнен вход
+type DeviceInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair - ответ на вход и обновление токенов
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
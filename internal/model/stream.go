@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий потока /api/events
+const (
+	StreamBalanceUpdated     = "balance.updated"     // изменился баланс счета
+	StreamTransactionCreated = "transaction.created" // новая операция по счету
+	StreamCreditPayment      = "credit.payment"      // результат списания платежа по кредиту
+)
+
+// StreamEvent - событие потока /api/events. Доставляется только владельцу (UserID)
+// и не сохраняется: клиент, подключившийся позже, получает текущее состояние через API.
+type StreamEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// BalanceUpdatedData - данные события balance.updated
+type BalanceUpdatedData struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Balance   float64   `json:"balance"`
+}
+
+// CreditPaymentOutcome - данные события credit.payment
+type CreditPaymentOutcome struct {
+	CreditID  uuid.UUID `json:"credit_id"`
+	PaymentID uuid.UUID `json:"payment_id"`
+	Amount    float64   `json:"amount"`
+	Status    string    `json:"status"` // paid - списан, overdue - не хватило средств, платеж просрочен
+}
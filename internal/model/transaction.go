@@ -9,19 +9,60 @@ import (
 type TransactionType string
 
 const (
-	TransactionTypeTransfer      TransactionType = "transfer"       // перевод между счетами
-	TransactionTypeDeposit       TransactionType = "deposit"        // пополнение счета
-	TransactionTypeWithdrawal    TransactionType = "withdrawal"     // вывод средств со счета
-	TransactionTypeCredit        TransactionType = "credit"         // выдача кредита
-	TransactionTypeCreditPayment TransactionType = "credit_payment" // платеж по кредиту
-	TransactionTypeCardPayment   TransactionType = "card_payment"   // платеж картой
+	TransactionTypeTransfer          TransactionType = "transfer"            // перевод между счетами
+	TransactionTypeDeposit           TransactionType = "deposit"             // пополнение счета
+	TransactionTypeWithdrawal        TransactionType = "withdrawal"          // вывод средств со счета
+	TransactionTypeCredit            TransactionType = "credit"              // выдача кредита
+	TransactionTypeCreditPayment     TransactionType = "credit_payment"      // платеж по кредиту
+	TransactionTypeCardPayment       TransactionType = "card_payment"        // платеж картой
+	TransactionTypeCreditLinePayment TransactionType = "credit_line_payment" // погашение кредитной линии
+	TransactionTypeCollectionSweep   TransactionType = "collection_sweep"    // списание просроченной задолженности
+	TransactionTypeAdjustmentCredit  TransactionType = "adjustment_credit"   // зачисление при корректировке сотрудником
+	TransactionTypeAdjustmentDebit   TransactionType = "adjustment_debit"    // списание при корректировке сотрудником
 )
 
+// TransactionDirection - направление операции относительно счета. Сумма операции всегда
+// положительна: зачисление или списание определяется направлением.
+type TransactionDirection string
+
+const (
+	TransactionDirectionIn  TransactionDirection = "in"  // зачисление на счет
+	TransactionDirectionOut TransactionDirection = "out" // списание со счета
+)
+
+// Direction возвращает направление операций этого типа. У перевода направление зависит
+// от его части (списание или зачисление) и задается при создании операции.
+func (t TransactionType) Direction() TransactionDirection {
+	switch t {
+	case TransactionTypeTransfer:
+		return ""
+	case TransactionTypeDeposit, TransactionTypeCredit, TransactionTypeAdjustmentCredit:
+		return TransactionDirectionIn
+	default:
+		return TransactionDirectionOut
+	}
+}
+
 type Transaction struct {
-	ID              uuid.UUID       `json:"id" db:"id"`
-	AccountID       uuid.UUID       `json:"account_id" db:"account_id"`
-	Amount          float64         `json:"amount" db:"amount"`
-	TransactionType TransactionType `json:"transaction_type" db:"transaction_type"`
-	ReferenceID     *uuid.UUID      `json:"reference_id" db:"reference_id"`
-	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	ID               uuid.UUID            `json:"id" db:"id"`
+	AccountID        uuid.UUID            `json:"account_id" db:"account_id"`
+	Amount           float64              `json:"amount" db:"amount"`                                   // сумма по счету
+	CreditLineAmount float64              `json:"credit_line_amount,omitempty" db:"credit_line_amount"` // часть оплаты картой из кредитной линии, в Amount не входит
+	TransactionType  TransactionType      `json:"transaction_type" db:"transaction_type"`
+	Direction        TransactionDirection `json:"direction,omitempty" db:"direction"` // пуст у переводов, записанных до появления направления
+	ReferenceID      *uuid.UUID           `json:"reference_id" db:"reference_id"`
+	MerchantName     string               `json:"merchant_name,omitempty" db:"merchant_name"` // продавец, только для оплат картой
+	MCC              string               `json:"mcc,omitempty" db:"mcc"`                     // код категории продавца (ISO 18245)
+	Category         string               `json:"category" db:"category"`                     // категория расходов, по умолчанию - тип операции
+	CreatedAt        time.Time            `json:"created_at" db:"created_at"`
+}
+
+// Expense возвращает сумму операции, учитываемую в расходах. Оплата картой учитывается
+// полностью, включая часть из кредитной линии, поэтому погашение линии в расходы не входит:
+// иначе покупка в кредит была бы учтена дважды.
+func (t *Transaction) Expense() float64 {
+	if t.Direction != TransactionDirectionOut || t.TransactionType == TransactionTypeCreditLinePayment {
+		return 0
+	}
+	return t.Amount + t.CreditLineAmount
 }
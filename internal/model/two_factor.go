@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor - настройки TOTP пользователя. Секрет хранится только в зашифрованном виде.
+type TwoFactor struct {
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	EncryptedSecret string     `json:"-" db:"encrypted_secret"`
+	SecretHMAC      string     `json:"-" db:"secret_hmac"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	LastUsedStep    int64      `json:"-" db:"last_used_step"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+}
+
+// TwoFactorSetup - ответ на подключение 2FA. Секрет и коды восстановления показываются один раз.
+type TwoFactorSetup struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TwoFactorSignInInput - второй шаг входа: промежуточный токен и код из приложения или код восстановления
+type TwoFactorSignInInput struct {
+	TwoFactorToken string `json:"two_factor_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+// SignInResult - результат проверки пароля: либо токены, либо промежуточный токен для второго шага
+type SignInResult struct {
+	Tokens            *TokenPair `json:"-"`
+	TwoFactorRequired bool       `json:"two_factor_required"`
+	TwoFactorToken    string     `json:"two_factor_token,omitempty"`
+	ExpiresAt         time.Time  `json:"expires_at,omitempty"`
+}
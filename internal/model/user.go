@@ -10,12 +10,18 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Username        string     `json:"username" db:"username"`
+	Email           string     `json:"email" db:"email"`
+	Password        string     `json:"-" db:"password"`
+	EmailVerified   bool       `json:"email_verified" db:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	PendingEmail    *string    `json:"pending_email,omitempty" db:"pending_email"` // новый адрес, ожидающий подтверждения
+	Role            Role       `json:"role" db:"role"`
+	Locale          string     `json:"locale" db:"locale"` // язык уведомлений: ru или en
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type SignUpInput struct {
@@ -29,6 +35,39 @@ type SignInInput struct {
 	Password string `json:"password" validate:"required,min=8,max=64"`
 }
 
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=64"`
+}
+
+func (i *ResetPasswordInput) Validate() error {
+	if i.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if len(i.Password) < 8 || len(i.Password) > 64 {
+		return fmt.Errorf("password length must be between 8 and 64 characters")
+	}
+	if !isValidPassword(i.Password) {
+		return fmt.Errorf("password must contain at least one uppercase letter, one lowercase letter, one number and one special character")
+	}
+	return nil
+}
+
+// PasswordResetToken - одноразовый токен сброса пароля
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	IP        string     `json:"ip" db:"ip"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
 func (u *SignUpInput) Validate() error {
 	// Проверка email
 	if !isValidEmail(u.Email) {
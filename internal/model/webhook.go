@@ -0,0 +1,199 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий, на которые подписываются webhook
+const (
+	WebhookTransactionCreated     = "transaction.created"      // движение средств по счету
+	WebhookTransferCompleted      = "transfer.completed"       // перевод между счетами
+	WebhookCardPaymentCompleted   = "card.payment.completed"   // оплата картой
+	WebhookCreditPaymentCompleted = "credit.payment.completed" // списан платеж по кредиту
+	WebhookCreditPaymentOverdue   = "credit.payment.overdue"   // напоминание о просрочке
+	WebhookCreditDefaulted        = "credit.defaulted"         // кредит переведен в дефолт
+
+	// WebhookAllEvents подписывает на все типы событий, в том числе добавленные позже
+	WebhookAllEvents = "*"
+)
+
+// WebhookEventTypes - типы событий, доступные для подписки
+var WebhookEventTypes = []string{
+	WebhookTransactionCreated,
+	WebhookTransferCompleted,
+	WebhookCardPaymentCompleted,
+	WebhookCreditPaymentCompleted,
+	WebhookCreditPaymentOverdue,
+	WebhookCreditDefaulted,
+}
+
+// Статусы доставки webhook
+const (
+	WebhookDeliveryPending   = "pending"   // ожидает отправки или повтора
+	WebhookDeliveryDelivered = "delivered" // получатель ответил 2xx
+	WebhookDeliveryFailed    = "failed"    // попытки исчерпаны, доступна ручная повторная отправка
+)
+
+// WebhookEndpoint - адрес, на который отправляются события пользователя
+type WebhookEndpoint struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	UserID          uuid.UUID `json:"-" db:"user_id"`
+	URL             string    `json:"url" db:"url"`
+	Description     string    `json:"description" db:"description"`
+	EventTypes      []string  `json:"event_types" db:"event_types"`
+	EncryptedSecret string    `json:"-" db:"encrypted_secret"`
+	Active          bool      `json:"active" db:"active"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreatedWebhookEndpoint - ответ на создание подписки и смену секрета.
+// Секрет подписи показывается только один раз.
+type CreatedWebhookEndpoint struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookEndpointInput - создание подписки
+type CreateWebhookEndpointInput struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+}
+
+func (i *CreateWebhookEndpointInput) Validate() error {
+	i.URL = strings.TrimSpace(i.URL)
+	if err := validateWebhookURL(i.URL); err != nil {
+		return err
+	}
+	if len(i.Description) > 500 {
+		return fmt.Errorf("описание не должно превышать 500 символов")
+	}
+	return validateWebhookEventTypes(i.EventTypes)
+}
+
+// UpdateWebhookEndpointInput - изменение подписки. Незаданные поля не меняются.
+type UpdateWebhookEndpointInput struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Active      *bool    `json:"active"`
+}
+
+func (i *UpdateWebhookEndpointInput) Validate() error {
+	if i.URL != nil {
+		trimmed := strings.TrimSpace(*i.URL)
+		i.URL = &trimmed
+		if err := validateWebhookURL(trimmed); err != nil {
+			return err
+		}
+	}
+	if i.Description != nil && len(*i.Description) > 500 {
+		return fmt.Errorf("описание не должно превышать 500 символов")
+	}
+	if i.EventTypes != nil {
+		return validateWebhookEventTypes(i.EventTypes)
+	}
+	return nil
+}
+
+// Apply переносит заданные поля в подписку
+func (i *UpdateWebhookEndpointInput) Apply(endpoint *WebhookEndpoint) {
+	if i.URL != nil {
+		endpoint.URL = *i.URL
+	}
+	if i.Description != nil {
+		endpoint.Description = *i.Description
+	}
+	if i.EventTypes != nil {
+		endpoint.EventTypes = i.EventTypes
+	}
+	if i.Active != nil {
+		endpoint.Active = *i.Active
+	}
+}
+
+// validateWebhookURL проверяет формат адреса. Схема и допустимость узла (https, запрет
+// адресов внутренней сети) проверяются при сохранении подписки в WebhookService.
+func validateWebhookURL(raw string) error {
+	if raw == "" || len(raw) > 500 {
+		return fmt.Errorf("адрес должен содержать от 1 до 500 символов")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("неверный адрес webhook")
+	}
+	if u.User != nil {
+		return fmt.Errorf("адрес webhook не должен содержать учетные данные")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("адрес webhook должен использовать https")
+	}
+	return nil
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("укажите хотя бы один тип события")
+	}
+	for _, eventType := range eventTypes {
+		if eventType != WebhookAllEvents && !containsString(WebhookEventTypes, eventType) {
+			return fmt.Errorf("неизвестный тип события %q, допустимые: %s или %s",
+				eventType, strings.Join(WebhookEventTypes, ", "), WebhookAllEvents)
+		}
+	}
+	return nil
+}
+
+// WebhookEvent - тело запроса webhook
+type WebhookEvent struct {
+	ID        uuid.UUID       `json:"id"` // одинаков для всех попыток, по нему получатель отбрасывает повторы
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CreditOverdueWebhookData - данные события credit.payment.overdue и credit.defaulted
+type CreditOverdueWebhookData struct {
+	UserID        uuid.UUID `json:"user_id"`
+	CreditID      uuid.UUID `json:"credit_id"`
+	CaseID        uuid.UUID `json:"case_id"`
+	OverdueAmount float64   `json:"overdue_amount"`
+	DaysPastDue   int       `json:"days_past_due"`
+}
+
+// WebhookDelivery - доставка события на адрес подписки
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	EndpointID     uuid.UUID       `json:"endpoint_id" db:"endpoint_id"`
+	EventID        uuid.UUID       `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedUntil    *time.Time      `json:"-" db:"locked_until"`
+	LastStatusCode *int            `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+
+	AttemptLog []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookDeliveryAttempt - запись журнала попыток доставки
+type WebhookDeliveryAttempt struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	DeliveryID uuid.UUID `json:"-" db:"delivery_id"`
+	Attempt    int       `json:"attempt" db:"attempt"`
+	StatusCode *int      `json:"status_code,omitempty" db:"status_code"`
+	Error      *string   `json:"error,omitempty" db:"error"`
+	DurationMs int       `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogNotifier пишет уведомления в журнал приложения. Используется при разработке
+// и как канал по умолчанию, когда отправка писем отключена.
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Name() string { return "log" }
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	entry := n.logger.WithFields(logrus.Fields{
+		"event":   msg.Event,
+		"email":   msg.Email,
+		"subject": msg.Subject,
+	})
+	if msg.Sensitive {
+		entry.Info("Уведомление (содержимое скрыто)")
+		return nil
+	}
+	entry.WithField("text", msg.Text).Info("Уведомление")
+	return nil
+}
+
+// FileNotifier дописывает уведомления в файл в формате JSON Lines
+type FileNotifier struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл уведомлений %s: %w", path, err)
+	}
+	return &FileNotifier{file: file}, nil
+}
+
+func (n *FileNotifier) Name() string { return "file" }
+
+// Notify записывает уведомление одной строкой. У уведомлений с секретами
+// сохраняются только тип события, получатель и тема.
+func (n *FileNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Sensitive {
+		msg = Message{Event: msg.Event, Email: msg.Email, Subject: msg.Subject, Sensitive: true, CreatedAt: msg.CreatedAt}
+	}
+	msg.HTML = ""
+
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации уведомления: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := n.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи уведомления в файл: %w", err)
+	}
+	return nil
+}
+
+// Close закрывает файл
+func (n *FileNotifier) Close() error {
+	return n.file.Close()
+}
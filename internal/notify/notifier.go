@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message - уведомление, доставляемое через один или несколько каналов
+type Message struct {
+	Event   string            `json:"event"`             // тип события, по которому выбираются каналы
+	Email   string            `json:"email,omitempty"`   // адрес получателя для канала email
+	ChatID  string            `json:"chat_id,omitempty"` // чат Telegram получателя; если пуст, используется чат из настроек
+	Subject string            `json:"subject"`
+	Text    string            `json:"text"`           // текстовая версия для мессенджеров и журналов
+	HTML    string            `json:"html,omitempty"` // HTML версия для email
+	Summary string            `json:"-"`              // текст без служебной подписи для ежедневной сводки
+	Data    map[string]string `json:"data,omitempty"` // структурированные поля события
+	// Sensitive помечает уведомления с секретами (коды сброса пароля, ссылки подтверждения).
+	// Каналы, которые не доставляют сообщение лично получателю, не передают их содержимое.
+	Sensitive bool      `json:"sensitive,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Channels - личные каналы (email, telegram), выбранные получателем в настройках.
+	// nil означает, что каналы определяются только маршрутом события.
+	Channels []string `json:"-"`
+	// UserID и SourceID не передаются в каналы: по ним уведомление сохраняется во входящих
+	// получателя. SourceID - событие outbox, из которого получено уведомление (uuid.Nil,
+	// если уведомление отправлено напрямую).
+	UserID   uuid.UUID `json:"-"`
+	SourceID uuid.UUID `json:"-"`
+}
+
+// Имена личных каналов: доставляют уведомление самому получателю
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+)
+
+// IsPersonal сообщает, доставляет ли канал уведомление лично получателю
+func IsPersonal(channel string) bool {
+	return channel == ChannelEmail || channel == ChannelTelegram
+}
+
+// Notifier - канал доставки уведомлений
+type Notifier interface {
+	// Name возвращает имя канала, используемое в настройках маршрутизации
+	Name() string
+	// Notify доставляет уведомление. Ошибка означает, что доставку стоит повторить.
+	Notify(ctx context.Context, msg Message) error
+}
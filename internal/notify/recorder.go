@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Recorder сохраняет уведомления в памяти. Предназначен для тестов: позволяет
+// проверить, какие уведомления были отправлены, без SMTP сервера и внешних сервисов.
+type Recorder struct {
+	mu       sync.Mutex
+	name     string
+	messages []Message
+	err      error
+}
+
+// NewRecorder создает канал с именем name (по умолчанию "recorder")
+func NewRecorder(name string) *Recorder {
+	if name == "" {
+		name = "recorder"
+	}
+	return &Recorder{name: name}
+}
+
+func (r *Recorder) Name() string { return r.name }
+
+// Notify сохраняет уведомление и возвращает ошибку, заданную через FailWith
+func (r *Recorder) Notify(ctx context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// FailWith заставляет последующие вызовы Notify возвращать err (nil отменяет)
+func (r *Recorder) FailWith(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Messages возвращает копию сохраненных уведомлений
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...)
+}
+
+// ByEvent возвращает сохраненные уведомления с типом события event
+func (r *Recorder) ByEvent(event string) []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []Message
+	for _, msg := range r.messages {
+		if msg.Event == event {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Reset удаляет сохраненные уведомления
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultRoute - ключ маршрута для событий, не указанных в настройках явно
+const DefaultRoute = "*"
+
+// Router рассылает уведомление по каналам, выбранным для типа события.
+// Маршрут ищется по точному типу события ("credit.payment"), затем по группе
+// ("credit.*"), затем используется маршрут по умолчанию ("*").
+// Если получатель выбрал личные каналы (Message.Channels), личные каналы маршрута
+// заменяются выбранными, а остальные (webhook, журнал, файл) сохраняются.
+type Router struct {
+	channels map[string]Notifier
+	routes   map[string][]Notifier
+}
+
+// NewRouter строит маршрутизатор. routes сопоставляет тип события (или группу, или "*")
+// с именами каналов из channels. Неизвестное имя канала - ошибка конфигурации.
+func NewRouter(channels []Notifier, routes map[string][]string) (*Router, error) {
+	byName := make(map[string]Notifier, len(channels))
+	for _, ch := range channels {
+		byName[ch.Name()] = ch
+	}
+
+	r := &Router{channels: byName, routes: make(map[string][]Notifier, len(routes))}
+	for event, names := range routes {
+		r.routes[event] = []Notifier{}
+		for _, name := range names {
+			ch, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("канал уведомлений %q для события %q не настроен", name, event)
+			}
+			r.routes[event] = append(r.routes[event], ch)
+		}
+	}
+	return r, nil
+}
+
+// ParseRoutes разбирает настройку маршрутов вида
+// "*=email;security.*=email,telegram;transfer.completed=email,webhook".
+// Пустой список каналов ("card.payment=") отключает уведомления о событии.
+func ParseRoutes(spec string) (map[string][]string, error) {
+	routes := make(map[string][]string)
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		event, list, ok := strings.Cut(part, "=")
+		event = strings.TrimSpace(event)
+		if !ok || event == "" {
+			return nil, fmt.Errorf("некорректный маршрут уведомлений %q, ожидается событие=канал,канал", part)
+		}
+		names := []string{}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		routes[event] = names
+	}
+	return routes, nil
+}
+
+func (r *Router) Name() string { return "router" }
+
+// Channels возвращает каналы, выбранные для типа события
+func (r *Router) Channels(event string) []Notifier {
+	if chs, ok := r.routes[event]; ok {
+		return chs
+	}
+	if i := strings.LastIndex(event, "."); i > 0 {
+		if chs, ok := r.routes[event[:i]+".*"]; ok {
+			return chs
+		}
+	}
+	return r.routes[DefaultRoute]
+}
+
+// Notify отправляет уведомление во все каналы маршрута. Ошибка одного канала не
+// прерывает отправку в остальные; возвращаются ошибки всех неудачных каналов.
+func (r *Router) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, ch := range r.targets(msg) {
+		if err := ch.Notify(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// targets возвращает каналы для уведомления с учетом выбора получателя
+func (r *Router) targets(msg Message) []Notifier {
+	route := r.Channels(msg.Event)
+	if msg.Channels == nil {
+		return route
+	}
+
+	targets := make([]Notifier, 0, len(route)+len(msg.Channels))
+	for _, ch := range route {
+		if !IsPersonal(ch.Name()) {
+			targets = append(targets, ch)
+		}
+	}
+	for _, name := range msg.Channels {
+		if ch, ok := r.channels[name]; ok && IsPersonal(name) {
+			targets = append(targets, ch)
+		}
+	}
+	return targets
+}
+
+// Describe возвращает маршруты в читаемом виде для журнала запуска
+func (r *Router) Describe() string {
+	events := make([]string, 0, len(r.routes))
+	for event := range r.routes {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	parts := make([]string, 0, len(events))
+	for _, event := range events {
+		names := make([]string, 0, len(r.routes[event]))
+		for _, ch := range r.routes[event] {
+			names = append(names, ch.Name())
+		}
+		parts = append(parts, event+"="+strings.Join(names, ","))
+	}
+	return strings.Join(parts, ";")
+}
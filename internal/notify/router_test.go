@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// newTestRouter строит маршрутизатор из каналов Recorder с именами email, telegram,
+// webhook и log
+func newTestRouter(t *testing.T, spec string) (*Router, map[string]*Recorder) {
+	t.Helper()
+	recorders := map[string]*Recorder{}
+	var channels []Notifier
+	for _, name := range []string{ChannelEmail, ChannelTelegram, "webhook", "log"} {
+		recorders[name] = NewRecorder(name)
+		channels = append(channels, recorders[name])
+	}
+
+	routes, err := ParseRoutes(spec)
+	if err != nil {
+		t.Fatalf("разбор маршрутов: %v", err)
+	}
+	router, err := NewRouter(channels, routes)
+	if err != nil {
+		t.Fatalf("создание маршрутизатора: %v", err)
+	}
+	return router, recorders
+}
+
+// received возвращает отсортированные имена каналов, получивших уведомление о событии
+func received(recorders map[string]*Recorder, event string) []string {
+	names := []string{}
+	for name, rec := range recorders {
+		if len(rec.ByEvent(event)) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRouterRoutesByEvent(t *testing.T) {
+	const spec = "*=email;security.*=email,telegram;transfer.completed=email,webhook;card.payment="
+
+	tests := []struct {
+		event    string
+		channels []string // выбор получателя, nil - только маршрут
+		want     []string
+	}{
+		{event: "transfer.completed", want: []string{"email", "webhook"}},
+		{event: "security.login", want: []string{"email", "telegram"}},
+		{event: "security.password_reset", want: []string{"email", "telegram"}},
+		{event: "credit.payment", want: []string{"email"}},
+		{event: "card.payment", want: []string{}},
+		// Личные каналы маршрута заменяются выбором получателя, webhook сохраняется
+		{event: "transfer.completed", channels: []string{ChannelTelegram}, want: []string{"telegram", "webhook"}},
+		{event: "transfer.completed", channels: []string{}, want: []string{"webhook"}},
+		// Выбрать можно только личный канал
+		{event: "credit.payment", channels: []string{"log", ChannelEmail}, want: []string{"email"}},
+	}
+
+	for _, tt := range tests {
+		router, recorders := newTestRouter(t, spec)
+		if err := router.Notify(context.Background(), Message{Event: tt.event, Channels: tt.channels}); err != nil {
+			t.Fatalf("%s: отправка: %v", tt.event, err)
+		}
+		if got := received(recorders, tt.event); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (выбор %v): доставлено в %v, ожидалось %v", tt.event, tt.channels, got, tt.want)
+		}
+	}
+}
+
+func TestRouterWithoutDefaultRoute(t *testing.T) {
+	router, recorders := newTestRouter(t, "transfer.completed=email")
+	if err := router.Notify(context.Background(), Message{Event: "credit.payment"}); err != nil {
+		t.Fatalf("отправка: %v", err)
+	}
+	if got := received(recorders, "credit.payment"); len(got) != 0 {
+		t.Fatalf("событие без маршрута доставлено в %v", got)
+	}
+}
+
+func TestRouterChannelFailure(t *testing.T) {
+	router, recorders := newTestRouter(t, "*=email,telegram,log")
+	recorders[ChannelTelegram].FailWith(errors.New("чат недоступен"))
+
+	err := router.Notify(context.Background(), Message{Event: "transfer.completed"})
+	if err == nil {
+		t.Fatal("ожидалась ошибка канала telegram")
+	}
+	// Ошибка одного канала не прерывает доставку в остальные
+	if got := received(recorders, "transfer.completed"); !reflect.DeepEqual(got, []string{"email", "log"}) {
+		t.Fatalf("доставлено в %v, ожидалось [email log]", got)
+	}
+}
+
+func TestNewRouterUnknownChannel(t *testing.T) {
+	if _, err := NewRouter([]Notifier{NewRecorder(ChannelEmail)}, map[string][]string{"*": {"sms"}}); err == nil {
+		t.Fatal("ожидалась ошибка для ненастроенного канала")
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(" *=email ; card.payment= ;security.*=email, telegram;")
+	if err != nil {
+		t.Fatalf("разбор: %v", err)
+	}
+	want := map[string][]string{
+		"*":            {"email"},
+		"card.payment": {},
+		"security.*":   {"email", "telegram"},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Fatalf("получено %v, ожидалось %v", routes, want)
+	}
+
+	if _, err := ParseRoutes("email"); err == nil {
+		t.Fatal("ожидалась ошибка для маршрута без события")
+	}
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/go-mail/mail/v2"
+)
+
+// SMTPConfig - настройки SMTP сервера
+type SMTPConfig struct {
+	Host               string
+	Port               int
+	User               string
+	Password           string
+	From               string // адрес отправителя, по умолчанию User
+	InsecureSkipVerify bool
+}
+
+// SMTPNotifier отправляет уведомления письмом на Message.Email
+type SMTPNotifier struct {
+	dialer *mail.Dialer
+	from   string
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) (*SMTPNotifier, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("не задан SMTP сервер")
+	}
+	if cfg.Port <= 0 {
+		return nil, fmt.Errorf("некорректный порт SMTP: %d", cfg.Port)
+	}
+
+	d := mail.NewDialer(cfg.Host, cfg.Port, cfg.User, cfg.Password)
+	d.TLSConfig = &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	from := cfg.From
+	if from == "" {
+		from = cfg.User
+	}
+	return &SMTPNotifier{dialer: d, from: from}, nil
+}
+
+func (n *SMTPNotifier) Name() string { return ChannelEmail }
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return nil
+	}
+
+	m := mail.NewMessage()
+	m.SetHeader("From", n.from)
+	m.SetHeader("To", msg.Email)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Text)
+	if msg.HTML != "" {
+		m.AddAlternative("text/html", msg.HTML)
+	}
+
+	if err := n.dialer.DialAndSend(m); err != nil {
+		return fmt.Errorf("не удалось отправить email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	telegramAPIURL  = "https://api.telegram.org"
+	telegramTimeout = 10 * time.Second
+)
+
+// TelegramNotifier отправляет уведомления через Telegram Bot API (метод sendMessage)
+// в чат получателя или, если он не указан, в чат из настроек
+type TelegramNotifier struct {
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegramNotifier создает канал Telegram. Пустой apiURL означает api.telegram.org.
+func NewTelegramNotifier(apiURL, token, chatID string) *TelegramNotifier {
+	if apiURL == "" {
+		apiURL = telegramAPIURL
+	}
+	return &TelegramNotifier{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: telegramTimeout},
+	}
+}
+
+func (n *TelegramNotifier) Name() string { return ChannelTelegram }
+
+// Notify отправляет уведомление. Уведомления с секретами и уведомления, для которых
+// получатель выбрал каналы сам, отправляются только в личный чат получателя.
+func (n *TelegramNotifier) Notify(ctx context.Context, msg Message) error {
+	chatID := msg.ChatID
+	if chatID == "" {
+		if msg.Sensitive || msg.Channels != nil {
+			return nil
+		}
+		chatID = n.chatID
+	}
+	if chatID == "" {
+		return nil
+	}
+
+	text := msg.Text
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + text
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации сообщения Telegram: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.apiURL, n.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка формирования запроса к Telegram: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Адрес запроса содержит токен бота, поэтому в ошибку попадает только операция
+		return fmt.Errorf("ошибка запроса к Telegram Bot API: %s", strings.ReplaceAll(err.Error(), n.token, "***"))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("некорректный ответ Telegram Bot API (статус %d): %w", resp.StatusCode, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram Bot API вернул ошибку (статус %d): %s", resp.StatusCode, result.Description)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultLocale - язык шаблонов для получателей с неизвестным языком
+const DefaultLocale = "ru"
+
+// Locales - языки, для которых должны существовать все шаблоны
+var Locales = []string{"ru", "en"}
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// embedded формирует уведомления только по встроенным шаблонам
+var embedded = &Templates{}
+
+// Файлы шаблона name для языка locale:
+//   - <locale>/<name>.txt  - text/template с блоками "subject", "body" и "footer";
+//   - <locale>/<name>.html - html/template, который подставляется в общий макет
+//     <locale>/layout.html вместо {{template "content" .}}.
+const (
+	textTemplateExt = ".txt"
+	htmlTemplateExt = ".html"
+	layoutTemplate  = "layout.html"
+)
+
+// Rendered - уведомление, сформированное по шаблону
+type Rendered struct {
+	Subject string
+	Text    string // текстовая версия: body и footer
+	HTML    string
+	Summary string // блок body без подписи, используется в ежедневной сводке
+}
+
+// Templates формирует уведомления по шаблонам. Шаблоны встроены в сборку; файлы из
+// каталога overrideDir с тем же относительным путем заменяют встроенные и перечитываются
+// при каждом формировании уведомления, поэтому их можно менять без перезапуска сервиса.
+// Если замененный шаблон содержит ошибку, используется встроенный.
+type Templates struct {
+	overrideDir string
+	logger      *logrus.Logger
+}
+
+// NewTemplates проверяет, что встроенные шаблоны разбираются для всех языков,
+// и что каталог замены (если задан) существует
+func NewTemplates(overrideDir string, logger *logrus.Logger) (*Templates, error) {
+	if overrideDir != "" {
+		info, err := os.Stat(overrideDir)
+		if err != nil {
+			return nil, fmt.Errorf("каталог шаблонов уведомлений недоступен: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s не является каталогом", overrideDir)
+		}
+	}
+
+	t := &Templates{overrideDir: overrideDir, logger: logger}
+	for _, locale := range Locales {
+		for _, name := range t.Names() {
+			if _, _, err := embedded.parse(locale, name); err != nil {
+				return nil, fmt.Errorf("встроенный шаблон %s/%s: %w", locale, name, err)
+			}
+		}
+	}
+	return t, nil
+}
+
+// Names возвращает имена встроенных шаблонов
+func (t *Templates) Names() []string {
+	entries, err := fs.ReadDir(embeddedTemplates, path.Join("templates", DefaultLocale))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), textTemplateExt); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render формирует уведомление по шаблону name на языке locale. В данных шаблона
+// дополнительно доступно поле Locale.
+func (t *Templates) Render(name, locale string, data map[string]interface{}) (*Rendered, error) {
+	if !isLocale(locale) {
+		locale = DefaultLocale
+	}
+
+	values := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		values[k] = v
+	}
+	values["Locale"] = locale
+
+	if t.overrideDir != "" {
+		rendered, err := t.execute(locale, name, values)
+		if err == nil {
+			return rendered, nil
+		}
+		if t.logger != nil {
+			t.logger.WithError(err).WithField("template", locale+"/"+name).Error("Ошибка шаблона уведомления, используется встроенный шаблон")
+		}
+	}
+	return embedded.execute(locale, name, values)
+}
+
+func (t *Templates) execute(locale, name string, data map[string]interface{}) (*Rendered, error) {
+	text, html, err := t.parse(locale, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, body, footer, content bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("ошибка формирования темы: %w", err)
+	}
+	if err := text.ExecuteTemplate(&body, "body", data); err != nil {
+		return nil, fmt.Errorf("ошибка формирования текста: %w", err)
+	}
+	if err := text.ExecuteTemplate(&footer, "footer", data); err != nil {
+		return nil, fmt.Errorf("ошибка формирования подписи: %w", err)
+	}
+
+	r := &Rendered{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Summary: strings.TrimSpace(body.String()),
+	}
+	r.Text = r.Summary
+	if f := strings.TrimSpace(footer.String()); f != "" {
+		r.Text += "\n\n" + f
+	}
+
+	data["Subject"] = r.Subject
+	if err := html.ExecuteTemplate(&content, layoutTemplate, data); err != nil {
+		return nil, fmt.Errorf("ошибка формирования HTML: %w", err)
+	}
+	r.HTML = content.String()
+	return r, nil
+}
+
+// parse разбирает текстовый и HTML шаблоны
+func (t *Templates) parse(locale, name string) (*texttemplate.Template, *htmltemplate.Template, error) {
+	funcs := templateFuncs(locale)
+
+	textSrc, err := t.read(locale, name+textTemplateExt)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := texttemplate.New(name + textTemplateExt).Funcs(funcs).Option("missingkey=error").Parse(textSrc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layoutSrc, err := t.read(locale, layoutTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	contentSrc, err := t.read(locale, name+htmlTemplateExt)
+	if err != nil {
+		return nil, nil, err
+	}
+	html, err := htmltemplate.New(layoutTemplate).Funcs(funcs).Option("missingkey=error").Parse(layoutSrc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := html.New("content").Parse(contentSrc); err != nil {
+		return nil, nil, err
+	}
+	return text, html, nil
+}
+
+// read возвращает содержимое файла шаблона: из каталога замены, если файл там есть,
+// иначе встроенный
+func (t *Templates) read(locale, file string) (string, error) {
+	if t.overrideDir != "" {
+		data, err := os.ReadFile(filepath.Join(t.overrideDir, locale, file))
+		if err == nil {
+			return string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	data, err := embeddedTemplates.ReadFile(path.Join("templates", locale, file))
+	if err != nil {
+		return "", fmt.Errorf("шаблон %s/%s не найден", locale, file)
+	}
+	return string(data), nil
+}
+
+func isLocale(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// templateFuncs возвращает функции форматирования для языка шаблона
+func templateFuncs(locale string) map[string]interface{} {
+	dateLayout, dateTimeLayout := "02.01.2006", "02.01.2006 15:04"
+	if locale == "en" {
+		dateLayout, dateTimeLayout = "Jan 2, 2006", "Jan 2, 2006 15:04"
+	}
+
+	return map[string]interface{}{
+		"money": func(amount float64) string {
+			return fmt.Sprintf("%.2f RUB", amount)
+		},
+		"percent": func(rate float64) string {
+			return fmt.Sprintf("%.2f%%", rate)
+		},
+		"lines": func(text string) []string {
+			return strings.Split(text, "\n")
+		},
+		"date": func(t time.Time) string {
+			return t.Format(dateLayout)
+		},
+		"datetime": func(t time.Time) string {
+			return t.Format(dateTimeLayout)
+		},
+	}
+}
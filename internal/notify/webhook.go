@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier отправляет уведомления POST запросом с JSON телом на заданный адрес.
+// Если задан секрет, тело подписывается HMAC-SHA256 в заголовке X-Signature.
+type WebhookNotifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// webhookPayload - тело запроса. HTML версия не передается.
+type webhookPayload struct {
+	Event     string            `json:"event"`
+	Email     string            `json:"email,omitempty"`
+	Subject   string            `json:"subject"`
+	Text      string            `json:"text"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+// Notify отправляет уведомление. Уведомления с секретами не отправляются: адрес
+// webhook принадлежит не получателю, а внешней системе.
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Sensitive {
+		return nil
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:     msg.Event,
+		Email:     msg.Email,
+		Subject:   msg.Subject,
+		Text:      msg.Text,
+		Data:      msg.Data,
+		CreatedAt: msg.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации уведомления: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка формирования запроса webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", msg.Event)
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook вернул статус %d", resp.StatusCode)
+	}
+	return nil
+}
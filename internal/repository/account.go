@@ -21,10 +21,31 @@ func NewAccountRepository(db *sql.DB, logger *logrus.Logger) *AccountRepository
 	return &AccountRepository{db: db, logger: logger}
 }
 
+const accountColumns = `id, user_id, balance, currency, status, frozen_at, freeze_reason, created_at, updated_at`
+
+func scanAccount(row rowScanner) (*model.Account, error) {
+	var account model.Account
+	err := row.Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Balance,
+		&account.Currency,
+		&account.Status,
+		&account.FrozenAt,
+		&account.FreezeReason,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
 func (r *AccountRepository) Create(ctx context.Context, account *model.Account) error {
 	query := `
-		INSERT INTO accounts (id, user_id, balance, currency, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO accounts (id, user_id, balance, currency, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	_, err := r.db.ExecContext(
@@ -34,6 +55,7 @@ func (r *AccountRepository) Create(ctx context.Context, account *model.Account)
 		account.UserID,
 		account.Balance,
 		account.Currency,
+		account.Status,
 		account.CreatedAt,
 		account.UpdatedAt,
 	)
@@ -51,22 +73,9 @@ func (r *AccountRepository) Create(ctx context.Context, account *model.Account)
 }
 
 func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error) {
-	query := `
-        SELECT id, user_id, balance, currency, created_at, updated_at
-        FROM accounts
-        WHERE id = $1
-    `
-
-	var account model.Account
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&account.ID,
-		&account.UserID,
-		&account.Balance,
-		&account.Currency,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
 
+	account, err := scanAccount(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("account not found")
@@ -74,40 +83,43 @@ func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.A
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
-	return &account, nil
+	return account, nil
 }
 
 func (r *AccountRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.Account, error) {
-	query := `
-        SELECT id, user_id, balance, currency, created_at, updated_at
-        FROM accounts
-        WHERE id = $1
-        FOR UPDATE
-    `
-
-	var account model.Account
-	err := tx.QueryRowContext(ctx, query, id).Scan(
-		&account.ID,
-		&account.UserID,
-		&account.Balance,
-		&account.Currency,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1 FOR UPDATE`
 
+	account, err := scanAccount(tx.QueryRowContext(ctx, query, id))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account not found")
+		}
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
-	return &account, nil
+	return account, nil
 }
 
+// balanceChangedEvent дописывается к запросу изменения баланса: событие account.balance_changed
+// записывается в outbox тем же запросом, поэтому ни одно движение средств не остается без события.
+// Запрос с этим окончанием должен начинаться с "WITH updated AS (UPDATE accounts ... RETURNING id, user_id, balance)".
+const balanceChangedEvent = `
+        INSERT INTO outbox_events (id, event_type, aggregate_id, payload, status, next_attempt_at, created_at)
+        SELECT gen_random_uuid(), '` + model.OutboxBalanceChanged + `', id,
+               jsonb_build_object('user_id', user_id, 'account_id', id, 'amount', $1::numeric, 'balance', balance),
+               'pending', NOW(), NOW()
+        FROM updated
+    `
+
+// UpdateBalanceTx изменяет баланс счета. Операции по заблокированному и закрытому счету запрещены.
 func (r *AccountRepository) UpdateBalanceTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, amount float64) error {
 	query := `
-        UPDATE accounts
-        SET balance = balance + $1,
-            updated_at = NOW()
-        WHERE id = $2
-    `
+        WITH updated AS (
+            UPDATE accounts
+            SET balance = balance + $1,
+                updated_at = NOW()
+            WHERE id = $2 AND status = 'active'
+            RETURNING id, user_id, balance
+        )` + balanceChangedEvent
 
 	result, err := tx.ExecContext(ctx, query, amount, id)
 	if err != nil {
@@ -119,6 +131,69 @@ func (r *AccountRepository) UpdateBalanceTx(ctx context.Context, tx *sql.Tx, id
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
+	if rowsAffected == 0 {
+		var status string
+		err := tx.QueryRowContext(ctx, `SELECT status FROM accounts WHERE id = $1`, id).Scan(&status)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("account not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get account status: %w", err)
+		}
+		return fmt.Errorf("account is %s", status)
+	}
+
+	return nil
+}
+
+// AdjustBalanceTx изменяет баланс по решению сотрудника банка, в том числе
+// на заблокированном счете. Баланс не может стать отрицательным.
+func (r *AccountRepository) AdjustBalanceTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, amount float64) error {
+	query := `
+        WITH updated AS (
+            UPDATE accounts
+            SET balance = balance + $1,
+                updated_at = NOW()
+            WHERE id = $2 AND balance + $1 >= 0
+            RETURNING id, user_id, balance
+        )` + balanceChangedEvent
+
+	result, err := tx.ExecContext(ctx, query, amount, id)
+	if err != nil {
+		return fmt.Errorf("failed to adjust balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("insufficient balance for adjustment")
+	}
+
+	return nil
+}
+
+// SetStatusTx блокирует или разблокирует счет
+func (r *AccountRepository) SetStatusTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, status string, reason *string) error {
+	query := `
+        UPDATE accounts
+        SET status = $2,
+            frozen_at = CASE WHEN $2 = 'frozen' THEN NOW() END,
+            freeze_reason = $3,
+            updated_at = NOW()
+        WHERE id = $1
+    `
+
+	result, err := tx.ExecContext(ctx, query, id, status, reason)
+	if err != nil {
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
 	if rowsAffected == 0 {
 		return fmt.Errorf("account not found")
 	}
@@ -126,16 +201,45 @@ func (r *AccountRepository) UpdateBalanceTx(ctx context.Context, tx *sql.Tx, id
 	return nil
 }
 
+// GetUserAccountsForUpdate возвращает счета пользователя и блокирует их до конца транзакции
+func (r *AccountRepository) GetUserAccountsForUpdate(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]model.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE user_id = $1 ORDER BY created_at FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []model.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+	return accounts, rows.Err()
+}
+
+// CloseUserAccountsTx закрывает все счета пользователя
+func (r *AccountRepository) CloseUserAccountsTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE accounts SET status = 'closed', updated_at = NOW()
+        WHERE user_id = $1 AND status <> 'closed'
+    `, userID)
+	if err != nil {
+		return fmt.Errorf("failed to close accounts: %w", err)
+	}
+	return nil
+}
+
 func (r *AccountRepository) GetDB() *sql.DB {
 	return r.db
 }
 
 func (r *AccountRepository) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]model.Account, error) {
-	query := `
-		SELECT id, user_id, balance, currency, created_at, updated_at
-		FROM accounts
-		WHERE user_id = $1
-	`
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE user_id = $1`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -145,18 +249,11 @@ func (r *AccountRepository) GetUserAccounts(ctx context.Context, userID uuid.UUI
 
 	var accounts []model.Account
 	for rows.Next() {
-		var account model.Account
-		if err := rows.Scan(
-			&account.ID,
-			&account.UserID,
-			&account.Balance,
-			&account.Currency,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		); err != nil {
+		account, err := scanAccount(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan account: %w", err)
 		}
-		accounts = append(accounts, account)
+		accounts = append(accounts, *account)
 	}
 
 	return accounts, nil
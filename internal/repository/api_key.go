@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// APIKeyRepository хранит сервисные учетные записи и их API ключи
+type APIKeyRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, logger *logrus.Logger) *APIKeyRepository {
+	return &APIKeyRepository{db: db, logger: logger}
+}
+
+const serviceAccountColumns = `id, owner_id, name, description, created_at, disabled_at`
+
+func scanServiceAccount(row rowScanner) (*model.ServiceAccount, error) {
+	var a model.ServiceAccount
+	err := row.Scan(&a.ID, &a.OwnerID, &a.Name, &a.Description, &a.CreatedAt, &a.DisabledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+const apiKeyColumns = `id, service_account_id, name, prefix, key_hash, scopes, account_id,
+               created_at, expires_at, last_used_at, last_used_ip, revoked_at`
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var k model.APIKey
+	var scopes []string
+	err := row.Scan(
+		&k.ID,
+		&k.ServiceAccountID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		pq.Array(&scopes),
+		&k.AccountID,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+		&k.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
+		k.Scopes = append(k.Scopes, model.APIKeyScope(s))
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) CreateServiceAccount(ctx context.Context, a *model.ServiceAccount) error {
+	query := `
+        INSERT INTO service_accounts (id, owner_id, name, description, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, a.ID, a.OwnerID, a.Name, a.Description, a.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create service account: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) GetServiceAccount(ctx context.Context, id uuid.UUID) (*model.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE id = $1`
+
+	a, err := scanServiceAccount(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("service account not found")
+		}
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+	return a, nil
+}
+
+// ListServiceAccounts возвращает сервисные учетные записи владельца, включая отключенные
+func (r *APIKeyRepository) ListServiceAccounts(ctx context.Context, ownerID uuid.UUID) ([]model.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE owner_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []model.ServiceAccount{}
+	for rows.Next() {
+		a, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service account: %w", err)
+		}
+		accounts = append(accounts, *a)
+	}
+	return accounts, rows.Err()
+}
+
+// CountActiveServiceAccounts возвращает число действующих сервисных учетных записей владельца
+func (r *APIKeyRepository) CountActiveServiceAccounts(ctx context.Context, ownerID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM service_accounts WHERE owner_id = $1 AND disabled_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, ownerID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count service accounts: %w", err)
+	}
+	return count, nil
+}
+
+// DisableServiceAccountTx отключает сервисную учетную запись и отзывает все ее ключи
+func (r *APIKeyRepository) DisableServiceAccountTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE service_accounts SET disabled_at = NOW()
+        WHERE id = $1 AND disabled_at IS NULL
+    `, id)
+	if err != nil {
+		return fmt.Errorf("failed to disable service account: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("service account not found")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE api_keys SET revoked_at = NOW()
+        WHERE service_account_id = $1 AND revoked_at IS NULL
+    `, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+	return nil
+}
+
+// DisableOwnerServiceAccountsTx отключает все сервисные учетные записи владельца,
+// отзывает их ключи и удаляет адреса последнего использования
+func (r *APIKeyRepository) DisableOwnerServiceAccountsTx(ctx context.Context, tx *sql.Tx, ownerID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE api_keys
+        SET revoked_at = COALESCE(revoked_at, NOW()),
+            last_used_ip = NULL
+        WHERE service_account_id IN (SELECT id FROM service_accounts WHERE owner_id = $1)
+    `, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE service_accounts SET disabled_at = NOW()
+        WHERE owner_id = $1 AND disabled_at IS NULL
+    `, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to disable service accounts: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) CreateKey(ctx context.Context, k *model.APIKey) error {
+	query := `
+        INSERT INTO api_keys (id, service_account_id, name, prefix, key_hash, scopes, account_id,
+                              created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		k.ID,
+		k.ServiceAccountID,
+		k.Name,
+		k.Prefix,
+		k.KeyHash,
+		pq.Array(scopes),
+		k.AccountID,
+		k.CreatedAt,
+		k.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetKeyByHash находит ключ по SHA-256 хешу
+func (r *APIKeyRepository) GetKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return k, nil
+}
+
+// ListKeys возвращает ключи сервисной учетной записи, новые первыми
+func (r *APIKeyRepository) ListKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE service_account_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// CountActiveKeys возвращает число неотозванных и неистекших ключей сервисной учетной записи
+func (r *APIKeyRepository) CountActiveKeys(ctx context.Context, serviceAccountID uuid.UUID) (int, error) {
+	query := `
+        SELECT COUNT(*) FROM api_keys
+        WHERE service_account_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+    `
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, serviceAccountID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	return count, nil
+}
+
+// RevokeKey отзывает ключ сервисной учетной записи
+func (r *APIKeyRepository) RevokeKey(ctx context.Context, serviceAccountID, keyID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE api_keys SET revoked_at = NOW()
+        WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL
+    `, keyID, serviceAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
+
+// TouchKey запоминает время и адрес последнего использования ключа. Чтобы не писать в БД
+// на каждый запрос, значение обновляется не чаще раза в минуту.
+func (r *APIKeyRepository) TouchKey(ctx context.Context, id uuid.UUID, ip string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute' OR last_used_ip <> $2)
+    `, id, ip)
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) GetDB() *sql.DB {
+	return r.db
+}
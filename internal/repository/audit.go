@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type AuditRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logger *logrus.Logger) *AuditRepository {
+	return &AuditRepository{db: db, logger: logger}
+}
+
+const auditColumns = `id, actor_id, actor_role, action, target_type, target_id, reason_code, comment, details, ip, created_at`
+
+func scanAuditEntry(row rowScanner) (*model.AuditEntry, error) {
+	var e model.AuditEntry
+	var details []byte
+	err := row.Scan(
+		&e.ID,
+		&e.ActorID,
+		&e.ActorRole,
+		&e.Action,
+		&e.TargetType,
+		&e.TargetID,
+		&e.ReasonCode,
+		&e.Comment,
+		&details,
+		&e.IP,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.Details = details
+	return &e, nil
+}
+
+func (r *AuditRepository) Create(ctx context.Context, e *model.AuditEntry) error {
+	return r.insert(ctx, r.db, e)
+}
+
+func (r *AuditRepository) CreateTx(ctx context.Context, tx *sql.Tx, e *model.AuditEntry) error {
+	return r.insert(ctx, tx, e)
+}
+
+func (r *AuditRepository) insert(ctx context.Context, exec execer, e *model.AuditEntry) error {
+	query := `
+        INSERT INTO audit_log (id, actor_id, actor_role, action, target_type, target_id,
+                               reason_code, comment, details, ip, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+
+	var details interface{}
+	if len(e.Details) > 0 {
+		details = []byte(e.Details)
+	}
+
+	_, err := exec.ExecContext(
+		ctx,
+		query,
+		e.ID,
+		e.ActorID,
+		e.ActorRole,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.ReasonCode,
+		e.Comment,
+		details,
+		e.IP,
+		e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List возвращает записи журнала по фильтру, новые первыми
+func (r *AuditRepository) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log
+        WHERE ($1::uuid IS NULL OR actor_id = $1)
+          AND ($2 = '' OR action = $2)
+          AND ($3 = '' OR target_type = $3)
+          AND ($4 = '' OR target_id = $4)
+        ORDER BY created_at DESC
+        LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.ActorID, filter.Action, filter.TargetType, filter.TargetID, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// BudgetRepository хранит месячные бюджеты пользователей и отправленные оповещения о них
+type BudgetRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewBudgetRepository(db *sql.DB, logger *logrus.Logger) *BudgetRepository {
+	return &BudgetRepository{db: db, logger: logger}
+}
+
+func (r *BudgetRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+const budgetColumns = `id, user_id, category, amount, created_at, updated_at`
+
+func scanBudget(row rowScanner) (*model.Budget, error) {
+	var budget model.Budget
+	var category sql.NullString
+	err := row.Scan(
+		&budget.ID,
+		&budget.UserID,
+		&category,
+		&budget.Amount,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if category.Valid {
+		budget.Category = &category.String
+	}
+	return &budget, nil
+}
+
+// ListByUser возвращает бюджеты пользователя: сначала общий, затем по категориям
+func (r *BudgetRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Budget, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+budgetColumns+` FROM budgets
+        WHERE user_id = $1
+        ORDER BY category NULLS FIRST
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []model.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, *budget)
+	}
+	return budgets, rows.Err()
+}
+
+// GetByID возвращает бюджет пользователя
+func (r *BudgetRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*model.Budget, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT `+budgetColumns+` FROM budgets WHERE id = $1 AND user_id = $2
+    `, id, userID)
+	budget, err := scanBudget(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("budget not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return budget, nil
+}
+
+// Create создает бюджет. Если бюджет этой категории у пользователя уже есть, возвращается ошибка.
+func (r *BudgetRepository) Create(ctx context.Context, budget *model.Budget) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO budgets (id, user_id, category, amount, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+    `, budget.ID, budget.UserID, budget.Category, budget.Amount, budget.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("budget for this category already exists")
+		}
+		return fmt.Errorf("failed to create budget: %w", err)
+	}
+	return nil
+}
+
+// UpdateAmountTx меняет сумму бюджета пользователя
+func (r *BudgetRepository) UpdateAmountTx(ctx context.Context, tx *sql.Tx, budget *model.Budget) error {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE budgets SET amount = $3, updated_at = $4 WHERE id = $1 AND user_id = $2
+    `, budget.ID, budget.UserID, budget.Amount, budget.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update budget: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("budget not found")
+	}
+	return nil
+}
+
+// Delete удаляет бюджет пользователя вместе с отметками об оповещениях
+func (r *BudgetRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("budget not found")
+	}
+	return nil
+}
+
+// DeleteForUserTx удаляет бюджеты пользователя
+func (r *BudgetRepository) DeleteForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM budgets WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete budgets: %w", err)
+	}
+	return nil
+}
+
+// MarkAlertsTx отмечает пороги оповещений бюджета за месяц как отправленные и возвращает
+// пороги, которые не были отмечены раньше. Если другая транзакция отмечает те же пороги,
+// вызов ждет ее завершения.
+func (r *BudgetRepository) MarkAlertsTx(ctx context.Context, tx *sql.Tx, budgetID uuid.UUID, month time.Time, thresholds []int) ([]int, error) {
+	values := make([]int64, len(thresholds))
+	for i, t := range thresholds {
+		values[i] = int64(t)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+        INSERT INTO budget_alerts (budget_id, month, threshold)
+        SELECT $1, $2::date, unnest($3::int[])
+        ON CONFLICT DO NOTHING
+        RETURNING threshold
+    `, budgetID, month, pq.Array(values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var marked []int
+	for rows.Next() {
+		var threshold int
+		if err := rows.Scan(&threshold); err != nil {
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		marked = append(marked, threshold)
+	}
+	return marked, rows.Err()
+}
+
+// ResetAlertsTx удаляет отметки об оповещениях бюджета: после изменения суммы
+// пороги срабатывают заново
+func (r *BudgetRepository) ResetAlertsTx(ctx context.Context, tx *sql.Tx, budgetID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM budget_alerts WHERE budget_id = $1`, budgetID); err != nil {
+		return fmt.Errorf("failed to reset budget alerts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// CategoryRepository хранит правила категорий расходов пользователей
+type CategoryRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewCategoryRepository(db *sql.DB, logger *logrus.Logger) *CategoryRepository {
+	return &CategoryRepository{db: db, logger: logger}
+}
+
+func (r *CategoryRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+const categoryRuleColumns = `id, user_id, COALESCE(merchant_contains, ''), COALESCE(mcc, ''), category, priority, created_at, updated_at`
+
+func scanCategoryRule(row rowScanner) (*model.CategoryRule, error) {
+	var rule model.CategoryRule
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.MerchantContains,
+		&rule.MCC,
+		&rule.Category,
+		&rule.Priority,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules возвращает правила пользователя в порядке применения: по убыванию приоритета,
+// при равном приоритете - новые первыми
+func (r *CategoryRepository) ListRules(ctx context.Context, userID uuid.UUID) ([]model.CategoryRule, error) {
+	return r.listRules(ctx, r.db, userID)
+}
+
+// ListRulesTx возвращает правила пользователя внутри транзакции
+func (r *CategoryRepository) ListRulesTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]model.CategoryRule, error) {
+	return r.listRules(ctx, tx, userID)
+}
+
+func (r *CategoryRepository) listRules(ctx context.Context, q queryer, userID uuid.UUID) ([]model.CategoryRule, error) {
+	rows, err := q.QueryContext(ctx, `
+        SELECT `+categoryRuleColumns+` FROM category_rules
+        WHERE user_id = $1
+        ORDER BY priority DESC, created_at DESC, id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list category rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []model.CategoryRule{}
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// CountRules возвращает число правил пользователя
+func (r *CategoryRepository) CountRules(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM category_rules WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count category rules: %w", err)
+	}
+	return count, nil
+}
+
+// CreateRuleTx создает правило
+func (r *CategoryRepository) CreateRuleTx(ctx context.Context, tx *sql.Tx, rule *model.CategoryRule) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO category_rules (id, user_id, merchant_contains, mcc, category, priority, created_at, updated_at)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $7)
+    `, rule.ID, rule.UserID, rule.MerchantContains, rule.MCC, rule.Category, rule.Priority, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create category rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateRuleTx меняет условия, категорию и приоритет правила пользователя
+func (r *CategoryRepository) UpdateRuleTx(ctx context.Context, tx *sql.Tx, rule *model.CategoryRule) error {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE category_rules
+        SET merchant_contains = NULLIF($3, ''), mcc = NULLIF($4, ''), category = $5, priority = $6, updated_at = $7
+        WHERE id = $1 AND user_id = $2
+    `, rule.ID, rule.UserID, rule.MerchantContains, rule.MCC, rule.Category, rule.Priority, rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update category rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("category rule not found")
+	}
+	return nil
+}
+
+// GetRuleTx возвращает правило пользователя
+func (r *CategoryRepository) GetRuleTx(ctx context.Context, tx *sql.Tx, userID, id uuid.UUID) (*model.CategoryRule, error) {
+	row := tx.QueryRowContext(ctx, `
+        SELECT `+categoryRuleColumns+` FROM category_rules WHERE id = $1 AND user_id = $2
+    `, id, userID)
+	rule, err := scanCategoryRule(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("category rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRuleTx удаляет правило пользователя
+func (r *CategoryRepository) DeleteRuleTx(ctx context.Context, tx *sql.Tx, userID, id uuid.UUID) error {
+	result, err := tx.ExecContext(ctx, `DELETE FROM category_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("category rule not found")
+	}
+	return nil
+}
+
+// DeleteForUserTx удаляет правила пользователя
+func (r *CategoryRepository) DeleteForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM category_rules WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete category rules: %w", err)
+	}
+	return nil
+}
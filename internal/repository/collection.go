@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type CollectionRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewCollectionRepository(db *sql.DB, logger *logrus.Logger) *CollectionRepository {
+	return &CollectionRepository{db: db, logger: logger}
+}
+
+const collectionCaseColumns = `id, credit_id, user_id, status, overdue_since, overdue_amount, swept_amount,
+               last_reminder_day, opened_at, closed_at, created_at, updated_at`
+
+func scanCollectionCase(row rowScanner) (*model.CollectionCase, error) {
+	var c model.CollectionCase
+	err := row.Scan(
+		&c.ID,
+		&c.CreditID,
+		&c.UserID,
+		&c.Status,
+		&c.OverdueSince,
+		&c.OverdueAmount,
+		&c.SweptAmount,
+		&c.LastReminderDay,
+		&c.OpenedAt,
+		&c.ClosedAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetOverdueCredits возвращает кредиты с просроченными платежами: дату самой ранней просрочки и сумму долга
+func (r *CollectionRepository) GetOverdueCredits(ctx context.Context) ([]model.OverdueCredit, error) {
+	query := `
+        SELECT credit_id, MIN(payment_date), SUM(amount)
+        FROM payment_schedules
+        WHERE status = 'overdue'
+        GROUP BY credit_id
+    `
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overdue credits: %w", err)
+	}
+	defer rows.Close()
+
+	var credits []model.OverdueCredit
+	for rows.Next() {
+		var credit model.OverdueCredit
+		if err := rows.Scan(&credit.CreditID, &credit.OverdueSince, &credit.OverdueAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan overdue credit: %w", err)
+		}
+		credits = append(credits, credit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return credits, nil
+}
+
+func (r *CollectionRepository) CreateCaseTx(ctx context.Context, tx *sql.Tx, c *model.CollectionCase) error {
+	query := `
+        INSERT INTO collection_cases (id, credit_id, user_id, status, overdue_since, overdue_amount,
+                                      swept_amount, last_reminder_day, opened_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		c.ID,
+		c.CreditID,
+		c.UserID,
+		c.Status,
+		c.OverdueSince,
+		c.OverdueAmount,
+		c.SweptAmount,
+		c.LastReminderDay,
+		c.OpenedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create collection case: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CollectionRepository) UpdateCase(ctx context.Context, c *model.CollectionCase) error {
+	return r.updateCase(ctx, r.db, c)
+}
+
+func (r *CollectionRepository) UpdateCaseTx(ctx context.Context, tx *sql.Tx, c *model.CollectionCase) error {
+	return r.updateCase(ctx, tx, c)
+}
+
+func (r *CollectionRepository) updateCase(ctx context.Context, exec execer, c *model.CollectionCase) error {
+	query := `
+        UPDATE collection_cases
+        SET status = $1,
+            overdue_since = $2,
+            overdue_amount = $3,
+            swept_amount = $4,
+            last_reminder_day = $5,
+            closed_at = $6,
+            updated_at = NOW()
+        WHERE id = $7
+    `
+
+	_, err := exec.ExecContext(
+		ctx,
+		query,
+		c.Status,
+		c.OverdueSince,
+		c.OverdueAmount,
+		c.SweptAmount,
+		c.LastReminderDay,
+		c.ClosedAt,
+		c.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update collection case: %w", err)
+	}
+
+	return nil
+}
+
+// GetOpenCaseByCreditID возвращает открытое дело по кредиту. Если дела нет, возвращает nil.
+func (r *CollectionRepository) GetOpenCaseByCreditID(ctx context.Context, creditID uuid.UUID) (*model.CollectionCase, error) {
+	query := `SELECT ` + collectionCaseColumns + ` FROM collection_cases WHERE credit_id = $1 AND status = 'open'`
+
+	c, err := scanCollectionCase(r.db.QueryRowContext(ctx, query, creditID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get collection case: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *CollectionRepository) GetCaseByID(ctx context.Context, id uuid.UUID) (*model.CollectionCase, error) {
+	query := `SELECT ` + collectionCaseColumns + ` FROM collection_cases WHERE id = $1`
+
+	c, err := scanCollectionCase(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("collection case not found")
+		}
+		return nil, fmt.Errorf("failed to get collection case: %w", err)
+	}
+
+	return c, nil
+}
+
+// ListCases возвращает дела с указанным статусом, самые старые просрочки первыми
+func (r *CollectionRepository) ListCases(ctx context.Context, status string) ([]model.CollectionCase, error) {
+	query := `SELECT ` + collectionCaseColumns + ` FROM collection_cases
+        WHERE status = $1 ORDER BY overdue_since`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection cases: %w", err)
+	}
+	defer rows.Close()
+
+	var cases []model.CollectionCase
+	for rows.Next() {
+		c, err := scanCollectionCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection case: %w", err)
+		}
+		cases = append(cases, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return cases, nil
+}
+
+func (r *CollectionRepository) AddEvent(ctx context.Context, event *model.CollectionEvent) error {
+	return r.addEvent(ctx, r.db, event)
+}
+
+func (r *CollectionRepository) AddEventTx(ctx context.Context, tx *sql.Tx, event *model.CollectionEvent) error {
+	return r.addEvent(ctx, tx, event)
+}
+
+func (r *CollectionRepository) addEvent(ctx context.Context, exec execer, event *model.CollectionEvent) error {
+	query := `
+        INSERT INTO collection_events (id, case_id, event_type, message, amount, author_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	_, err := exec.ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.CaseID,
+		event.EventType,
+		event.Message,
+		event.Amount,
+		event.AuthorID,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create collection event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CollectionRepository) GetEvents(ctx context.Context, caseID uuid.UUID) ([]model.CollectionEvent, error) {
+	query := `
+        SELECT id, case_id, event_type, message, amount, author_id, created_at
+        FROM collection_events
+        WHERE case_id = $1
+        ORDER BY created_at
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.CollectionEvent
+	for rows.Next() {
+		var event model.CollectionEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.CaseID,
+			&event.EventType,
+			&event.Message,
+			&event.Amount,
+			&event.AuthorID,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan collection event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *CollectionRepository) GetDB() *sql.DB {
+	return r.db
+}
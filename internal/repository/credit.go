@@ -23,13 +23,23 @@ func NewCreditRepository(db *sql.DB, logger *logrus.Logger) *CreditRepository {
 }
 
 func (r *CreditRepository) CreateCredit(ctx context.Context, credit *model.Credit) error {
+	return r.insertCredit(ctx, r.db, credit)
+}
+
+func (r *CreditRepository) CreateCreditTx(ctx context.Context, tx *sql.Tx, credit *model.Credit) error {
+	return r.insertCredit(ctx, tx, credit)
+}
+
+func (r *CreditRepository) insertCredit(ctx context.Context, exec execer, credit *model.Credit) error {
 	query := `
         INSERT INTO credits (id, account_id, user_id, amount, interest_rate, term_months, 
-                            monthly_payment, start_date, end_date, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+                            monthly_payment, start_date, end_date, status, created_at, updated_at,
+                            rate_type, rate_margin, product_id, penalty_type, penalty_value, schedule_type,
+                            schedule_version, auto_sweep_allowed)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
     `
 
-	_, err := r.db.ExecContext(
+	_, err := exec.ExecContext(
 		ctx,
 		query,
 		credit.ID,
@@ -44,6 +54,14 @@ func (r *CreditRepository) CreateCredit(ctx context.Context, credit *model.Credi
 		credit.Status,
 		credit.CreatedAt,
 		credit.UpdatedAt,
+		credit.RateType,
+		credit.RateMargin,
+		credit.ProductID,
+		credit.PenaltyType,
+		credit.PenaltyValue,
+		credit.ScheduleType,
+		credit.ScheduleVersion,
+		credit.AutoSweepAllowed,
 	)
 
 	if err != nil {
@@ -59,29 +77,24 @@ func (r *CreditRepository) CreateCredit(ctx context.Context, credit *model.Credi
 }
 
 func (r *CreditRepository) GetCreditByID(ctx context.Context, id uuid.UUID) (*model.Credit, error) {
-	query := `
-        SELECT id, account_id, user_id, amount, interest_rate, term_months, 
-               monthly_payment, start_date, end_date, status, created_at, updated_at
-        FROM credits
-        WHERE id = $1
-    `
+	query := `SELECT ` + creditColumns + ` FROM credits WHERE id = $1`
 
-	var credit model.Credit
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&credit.ID,
-		&credit.AccountID,
-		&credit.UserID,
-		&credit.Amount,
-		&credit.InterestRate,
-		&credit.TermMonths,
-		&credit.MonthlyPayment,
-		&credit.StartDate,
-		&credit.EndDate,
-		&credit.Status,
-		&credit.CreatedAt,
-		&credit.UpdatedAt,
-	)
+	credit, err := scanCredit(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("credit not found")
+		}
+		return nil, fmt.Errorf("failed to get credit: %w", err)
+	}
 
+	return credit, nil
+}
+
+// GetCreditByIDForUpdate блокирует кредит до конца транзакции
+func (r *CreditRepository) GetCreditByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.Credit, error) {
+	query := `SELECT ` + creditColumns + ` FROM credits WHERE id = $1 FOR UPDATE`
+
+	credit, err := scanCredit(tx.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("credit not found")
@@ -89,56 +102,92 @@ func (r *CreditRepository) GetCreditByID(ctx context.Context, id uuid.UUID) (*mo
 		return nil, fmt.Errorf("failed to get credit: %w", err)
 	}
 
-	return &credit, nil
+	return credit, nil
 }
 
 func (r *CreditRepository) GetUserCredits(ctx context.Context, userID uuid.UUID) ([]model.Credit, error) {
-	query := `
-        SELECT id, account_id, user_id, amount, interest_rate, term_months, 
-               monthly_payment, start_date, end_date, status, created_at, updated_at
-        FROM credits
-        WHERE user_id = $1
-    `
+	query := `SELECT ` + creditColumns + ` FROM credits WHERE user_id = $1`
+
+	return r.queryCredits(ctx, query, userID)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+// GetActiveFloatingCredits возвращает непогашенные кредиты с плавающей ставкой, включая просроченные
+func (r *CreditRepository) GetActiveFloatingCredits(ctx context.Context) ([]model.Credit, error) {
+	query := `SELECT ` + creditColumns + ` FROM credits WHERE status IN ('active', 'overdue') AND rate_type = 'floating'`
+
+	return r.queryCredits(ctx, query)
+}
+
+func (r *CreditRepository) queryCredits(ctx context.Context, query string, args ...interface{}) ([]model.Credit, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user credits: %w", err)
+		return nil, fmt.Errorf("failed to query credits: %w", err)
 	}
 	defer rows.Close()
 
 	var credits []model.Credit
 	for rows.Next() {
-		var credit model.Credit
-		if err := rows.Scan(
-			&credit.ID,
-			&credit.AccountID,
-			&credit.UserID,
-			&credit.Amount,
-			&credit.InterestRate,
-			&credit.TermMonths,
-			&credit.MonthlyPayment,
-			&credit.StartDate,
-			&credit.EndDate,
-			&credit.Status,
-			&credit.CreatedAt,
-			&credit.UpdatedAt,
-		); err != nil {
+		credit, err := scanCredit(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan credit: %w", err)
 		}
-		credits = append(credits, credit)
+		credits = append(credits, *credit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
 	return credits, nil
 }
 
-func (r *CreditRepository) CreatePaymentSchedule(ctx context.Context, schedule *model.PaymentSchedule) error {
+const creditColumns = `id, account_id, user_id, amount, interest_rate, term_months,
+               monthly_payment, start_date, end_date, status, created_at, updated_at,
+               rate_type, rate_margin, product_id, penalty_type, penalty_value, schedule_type,
+               schedule_version, auto_sweep_allowed`
+
+func scanCredit(row rowScanner) (*model.Credit, error) {
+	var credit model.Credit
+	err := row.Scan(
+		&credit.ID,
+		&credit.AccountID,
+		&credit.UserID,
+		&credit.Amount,
+		&credit.InterestRate,
+		&credit.TermMonths,
+		&credit.MonthlyPayment,
+		&credit.StartDate,
+		&credit.EndDate,
+		&credit.Status,
+		&credit.CreatedAt,
+		&credit.UpdatedAt,
+		&credit.RateType,
+		&credit.RateMargin,
+		&credit.ProductID,
+		&credit.PenaltyType,
+		&credit.PenaltyValue,
+		&credit.ScheduleType,
+		&credit.ScheduleVersion,
+		&credit.AutoSweepAllowed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &credit, nil
+}
+
+func (r *CreditRepository) CreatePaymentScheduleTx(ctx context.Context, tx *sql.Tx, schedule *model.PaymentSchedule) error {
+	return r.insertPayment(ctx, tx, schedule)
+}
+
+func (r *CreditRepository) insertPayment(ctx context.Context, exec execer, schedule *model.PaymentSchedule) error {
 	query := `
         INSERT INTO payment_schedules (id, credit_id, payment_number, payment_date, 
-                                     amount, principal, interest, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+                                     amount, principal, interest, status, created_at, updated_at, version)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
     `
 
-	_, err := r.db.ExecContext(
+	_, err := exec.ExecContext(
 		ctx,
 		query,
 		schedule.ID,
@@ -151,6 +200,7 @@ func (r *CreditRepository) CreatePaymentSchedule(ctx context.Context, schedule *
 		schedule.Status,
 		schedule.CreatedAt,
 		schedule.UpdatedAt,
+		schedule.Version,
 	)
 
 	if err != nil {
@@ -160,85 +210,58 @@ func (r *CreditRepository) CreatePaymentSchedule(ctx context.Context, schedule *
 	return nil
 }
 
+// GetPaymentSchedule возвращает действующий график: платежи, замененные при реструктуризации, исключаются
 func (r *CreditRepository) GetPaymentSchedule(ctx context.Context, creditID uuid.UUID) ([]model.PaymentSchedule, error) {
 	query := `
-        SELECT id, credit_id, payment_number, payment_date, amount, 
-               principal, interest, status, paid_at, created_at, updated_at
+        SELECT ` + paymentColumns + `
         FROM payment_schedules
-        WHERE credit_id = $1
+        WHERE credit_id = $1 AND status <> 'superseded'
         ORDER BY payment_number
     `
 
-	rows, err := r.db.QueryContext(ctx, query, creditID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query payment schedule: %w", err)
-	}
-	defer rows.Close()
+	return queryPayments(ctx, r.db, query, creditID)
+}
 
-	var schedules []model.PaymentSchedule
-	for rows.Next() {
-		var schedule model.PaymentSchedule
-		if err := rows.Scan(
-			&schedule.ID,
-			&schedule.CreditID,
-			&schedule.PaymentNumber,
-			&schedule.PaymentDate,
-			&schedule.Amount,
-			&schedule.Principal,
-			&schedule.Interest,
-			&schedule.Status,
-			&schedule.PaidAt,
-			&schedule.CreatedAt,
-			&schedule.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan payment schedule: %w", err)
-		}
-		schedules = append(schedules, schedule)
-	}
+// GetScheduleVersion возвращает платежи, созданные указанной версией графика
+func (r *CreditRepository) GetScheduleVersion(ctx context.Context, creditID uuid.UUID, version int) ([]model.PaymentSchedule, error) {
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payment_schedules
+        WHERE credit_id = $1 AND version = $2
+        ORDER BY payment_number
+    `
 
-	return schedules, nil
+	return queryPayments(ctx, r.db, query, creditID, version)
 }
 
 func (r *CreditRepository) GetPendingPayments(ctx context.Context, before time.Time) ([]model.PaymentSchedule, error) {
 	query := `
-        SELECT id, credit_id, payment_number, payment_date, amount, 
-               principal, interest, status, paid_at, created_at, updated_at
+        SELECT ` + paymentColumns + `
         FROM payment_schedules
         WHERE status = 'pending' AND payment_date <= $1
         ORDER BY payment_date
     `
 
-	rows, err := r.db.QueryContext(ctx, query, before)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query pending payments: %w", err)
-	}
-	defer rows.Close()
+	return queryPayments(ctx, r.db, query, before)
+}
 
-	var payments []model.PaymentSchedule
-	for rows.Next() {
-		var payment model.PaymentSchedule
-		if err := rows.Scan(
-			&payment.ID,
-			&payment.CreditID,
-			&payment.PaymentNumber,
-			&payment.PaymentDate,
-			&payment.Amount,
-			&payment.Principal,
-			&payment.Interest,
-			&payment.Status,
-			&payment.PaidAt,
-			&payment.CreatedAt,
-			&payment.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan payment: %w", err)
-		}
-		payments = append(payments, payment)
+func (r *CreditRepository) UpdateCreditStatus(ctx context.Context, creditID uuid.UUID, status string) error {
+	query := `
+        UPDATE credits
+        SET status = $1,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	_, err := r.db.ExecContext(ctx, query, status, creditID)
+	if err != nil {
+		return fmt.Errorf("failed to update credit status: %w", err)
 	}
 
-	return payments, nil
+	return nil
 }
 
-func (r *CreditRepository) UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status string, paidAt *time.Time) error {
+func (r *CreditRepository) UpdatePaymentStatusTx(ctx context.Context, tx *sql.Tx, paymentID uuid.UUID, status string, paidAt *time.Time) error {
 	query := `
         UPDATE payment_schedules
         SET status = $1,
@@ -247,15 +270,14 @@ func (r *CreditRepository) UpdatePaymentStatus(ctx context.Context, paymentID uu
         WHERE id = $3
     `
 
-	_, err := r.db.ExecContext(ctx, query, status, paidAt, paymentID)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, query, status, paidAt, paymentID); err != nil {
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
 	return nil
 }
 
-func (r *CreditRepository) UpdateCreditStatus(ctx context.Context, creditID uuid.UUID, status string) error {
+func (r *CreditRepository) UpdateCreditStatusTx(ctx context.Context, tx *sql.Tx, creditID uuid.UUID, status string) error {
 	query := `
         UPDATE credits
         SET status = $1,
@@ -263,28 +285,229 @@ func (r *CreditRepository) UpdateCreditStatus(ctx context.Context, creditID uuid
         WHERE id = $2
     `
 
-	_, err := r.db.ExecContext(ctx, query, status, creditID)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, query, status, creditID); err != nil {
 		return fmt.Errorf("failed to update credit status: %w", err)
 	}
 
 	return nil
 }
 
-func (r *CreditRepository) GetDB() *sql.DB {
-	return r.db
+// ClaimPaymentTx блокирует неоплаченный платеж для обработки. Строки, уже заблокированные
+// другой транзакцией, пропускаются (SKIP LOCKED): если платеж обрабатывается другим экземпляром
+// или уже оплачен, возвращает nil.
+func (r *CreditRepository) ClaimPaymentTx(ctx context.Context, tx *sql.Tx, paymentID uuid.UUID) (*model.PaymentSchedule, error) {
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payment_schedules
+        WHERE id = $1 AND status IN ('pending', 'overdue')
+        FOR UPDATE SKIP LOCKED
+    `
+
+	payment, err := scanPayment(tx.QueryRowContext(ctx, query, paymentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim payment: %w", err)
+	}
+
+	return payment, nil
 }
 
-func (r *CreditRepository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*model.PaymentSchedule, error) {
+// CountUnpaidPaymentsTx возвращает количество неоплаченных платежей действующего графика
+func (r *CreditRepository) CountUnpaidPaymentsTx(ctx context.Context, tx *sql.Tx, creditID uuid.UUID) (int, error) {
 	query := `
-        SELECT id, credit_id, payment_number, payment_date, amount, 
-               principal, interest, status, paid_at, created_at, updated_at
+        SELECT COUNT(*)
         FROM payment_schedules
-        WHERE id = $1
+        WHERE credit_id = $1 AND status IN ('pending', 'overdue')
     `
 
+	var count int
+	if err := tx.QueryRowContext(ctx, query, creditID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unpaid payments: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetFuturePaymentsForUpdate блокирует ожидающие платежи с датой позже указанной
+func (r *CreditRepository) GetFuturePaymentsForUpdate(ctx context.Context, tx *sql.Tx, creditID uuid.UUID, after time.Time) ([]model.PaymentSchedule, error) {
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payment_schedules
+        WHERE credit_id = $1 AND status = 'pending' AND payment_date > $2
+        ORDER BY payment_number
+        FOR UPDATE
+    `
+
+	return queryPayments(ctx, tx, query, creditID, after)
+}
+
+// GetOverduePaymentsForUpdate блокирует просроченные платежи кредита, начиная с самого старого
+func (r *CreditRepository) GetOverduePaymentsForUpdate(ctx context.Context, tx *sql.Tx, creditID uuid.UUID) ([]model.PaymentSchedule, error) {
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payment_schedules
+        WHERE credit_id = $1 AND status = 'overdue'
+        ORDER BY payment_date
+        FOR UPDATE
+    `
+
+	return queryPayments(ctx, tx, query, creditID)
+}
+
+func (r *CreditRepository) UpdatePaymentAmountsTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	paymentID uuid.UUID,
+	amount, principal, interest float64,
+) error {
+	query := `
+        UPDATE payment_schedules
+        SET amount = $1,
+            principal = $2,
+            interest = $3,
+            updated_at = NOW()
+        WHERE id = $4
+    `
+
+	if _, err := tx.ExecContext(ctx, query, amount, principal, interest, paymentID); err != nil {
+		return fmt.Errorf("failed to update payment amounts: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditRepository) UpdateCreditRateTx(ctx context.Context, tx *sql.Tx, creditID uuid.UUID, rate, monthlyPayment float64) error {
+	query := `
+        UPDATE credits
+        SET interest_rate = $1,
+            monthly_payment = $2,
+            updated_at = NOW()
+        WHERE id = $3
+    `
+
+	if _, err := tx.ExecContext(ctx, query, rate, monthlyPayment, creditID); err != nil {
+		return fmt.Errorf("failed to update credit rate: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCreditTermsTx сохраняет условия кредита после перестроения графика
+func (r *CreditRepository) UpdateCreditTermsTx(ctx context.Context, tx *sql.Tx, credit *model.Credit) error {
+	query := `
+        UPDATE credits
+        SET interest_rate = $1,
+            term_months = $2,
+            monthly_payment = $3,
+            end_date = $4,
+            schedule_version = $5,
+            updated_at = NOW()
+        WHERE id = $6
+    `
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		credit.InterestRate,
+		credit.TermMonths,
+		credit.MonthlyPayment,
+		credit.EndDate,
+		credit.ScheduleVersion,
+		credit.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update credit terms: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditRepository) CreateRateChangeTx(ctx context.Context, tx *sql.Tx, change *model.CreditRateChange) error {
+	query := `
+        INSERT INTO credit_rate_changes (id, credit_id, key_rate, old_rate, new_rate, old_payment,
+                                         new_payment, effective_from, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		change.ID,
+		change.CreditID,
+		change.KeyRate,
+		change.OldRate,
+		change.NewRate,
+		change.OldPayment,
+		change.NewPayment,
+		change.EffectiveFrom,
+		change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create rate change: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditRepository) GetRateChanges(ctx context.Context, creditID uuid.UUID) ([]model.CreditRateChange, error) {
+	query := `
+        SELECT id, credit_id, key_rate, old_rate, new_rate, old_payment, new_payment, effective_from, created_at
+        FROM credit_rate_changes
+        WHERE credit_id = $1
+        ORDER BY created_at
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rate changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []model.CreditRateChange
+	for rows.Next() {
+		var change model.CreditRateChange
+		if err := rows.Scan(
+			&change.ID,
+			&change.CreditID,
+			&change.KeyRate,
+			&change.OldRate,
+			&change.NewRate,
+			&change.OldPayment,
+			&change.NewPayment,
+			&change.EffectiveFrom,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rate change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (r *CreditRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+func (r *CreditRepository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*model.PaymentSchedule, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payment_schedules WHERE id = $1`
+
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("платеж не найден: %w", err)
+	}
+
+	return payment, nil
+}
+
+const paymentColumns = `id, credit_id, payment_number, payment_date, amount,
+               principal, interest, status, paid_at, created_at, updated_at, version`
+
+func scanPayment(row rowScanner) (*model.PaymentSchedule, error) {
 	var payment model.PaymentSchedule
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&payment.ID,
 		&payment.CreditID,
 		&payment.PaymentNumber,
@@ -296,11 +519,33 @@ func (r *CreditRepository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*m
 		&payment.PaidAt,
 		&payment.CreatedAt,
 		&payment.UpdatedAt,
+		&payment.Version,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
 
+func queryPayments(ctx context.Context, q queryer, query string, args ...interface{}) ([]model.PaymentSchedule, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("платеж не найден: %w", err)
+		return nil, fmt.Errorf("failed to query payment schedule: %w", err)
 	}
+	defer rows.Close()
 
-	return &payment, nil
+	var payments []model.PaymentSchedule
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, *payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return payments, nil
 }
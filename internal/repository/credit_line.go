@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type CreditLineRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewCreditLineRepository(db *sql.DB, logger *logrus.Logger) *CreditLineRepository {
+	return &CreditLineRepository{db: db, logger: logger}
+}
+
+// rowScanner - общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer и execer позволяют выполнять один и тот же запрос как через *sql.DB, так и внутри *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+const creditLineColumns = `id, user_id, card_id, account_id, credit_limit, used_amount, accrued_interest,
+               interest_rate, min_payment_percent, grace_period_days, grace_active,
+               last_accrued_at, next_statement_date, status, created_at, updated_at`
+
+func scanCreditLine(row rowScanner) (*model.CreditLine, error) {
+	var line model.CreditLine
+	err := row.Scan(
+		&line.ID,
+		&line.UserID,
+		&line.CardID,
+		&line.AccountID,
+		&line.CreditLimit,
+		&line.UsedAmount,
+		&line.AccruedInterest,
+		&line.InterestRate,
+		&line.MinPaymentPercent,
+		&line.GracePeriodDays,
+		&line.GraceActive,
+		&line.LastAccruedAt,
+		&line.NextStatementDate,
+		&line.Status,
+		&line.CreatedAt,
+		&line.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+func (r *CreditLineRepository) Create(ctx context.Context, line *model.CreditLine) error {
+	query := `
+        INSERT INTO credit_lines (id, user_id, card_id, account_id, credit_limit, used_amount, accrued_interest,
+                                  interest_rate, min_payment_percent, grace_period_days, grace_active,
+                                  last_accrued_at, next_statement_date, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+    `
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		line.ID,
+		line.UserID,
+		line.CardID,
+		line.AccountID,
+		line.CreditLimit,
+		line.UsedAmount,
+		line.AccruedInterest,
+		line.InterestRate,
+		line.MinPaymentPercent,
+		line.GracePeriodDays,
+		line.GraceActive,
+		line.LastAccruedAt,
+		line.NextStatementDate,
+		line.Status,
+		line.CreatedAt,
+		line.UpdatedAt,
+	)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code.Name() == "unique_violation" {
+				return fmt.Errorf("credit line already exists for card")
+			}
+		}
+		return fmt.Errorf("failed to create credit line: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditLineRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE id = $1`
+
+	line, err := scanCreditLine(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("credit line not found")
+		}
+		return nil, fmt.Errorf("failed to get credit line: %w", err)
+	}
+
+	return line, nil
+}
+
+func (r *CreditLineRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE id = $1 FOR UPDATE`
+
+	line, err := scanCreditLine(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("credit line not found")
+		}
+		return nil, fmt.Errorf("failed to get credit line: %w", err)
+	}
+
+	return line, nil
+}
+
+// GetActiveByCardIDForUpdate блокирует активную кредитную линию карты.
+// Если у карты нет активной линии, возвращает nil без ошибки.
+func (r *CreditLineRepository) GetActiveByCardIDForUpdate(ctx context.Context, tx *sql.Tx, cardID uuid.UUID) (*model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE card_id = $1 AND status = 'active' FOR UPDATE`
+
+	line, err := scanCreditLine(tx.QueryRowContext(ctx, query, cardID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get credit line: %w", err)
+	}
+
+	return line, nil
+}
+
+func (r *CreditLineRepository) GetUserCreditLines(ctx context.Context, userID uuid.UUID) ([]model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE user_id = $1 ORDER BY created_at DESC`
+
+	return r.queryLines(ctx, r.db, query, userID)
+}
+
+// GetUserCreditLinesForUpdate блокирует кредитные линии пользователя до конца транзакции
+func (r *CreditLineRepository) GetUserCreditLinesForUpdate(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE user_id = $1 ORDER BY created_at FOR UPDATE`
+
+	return r.queryLines(ctx, tx, query, userID)
+}
+
+// CloseUserLinesTx закрывает активные кредитные линии пользователя
+func (r *CreditLineRepository) CloseUserLinesTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	query := `UPDATE credit_lines SET status = 'closed', updated_at = NOW() WHERE user_id = $1 AND status = 'active'`
+
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to close credit lines: %w", err)
+	}
+	return nil
+}
+
+// GetLinesForAccrual возвращает активные линии, по которым проценты начислены раньше указанной даты
+func (r *CreditLineRepository) GetLinesForAccrual(ctx context.Context, before time.Time) ([]model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE status = 'active' AND last_accrued_at < $1`
+
+	return r.queryLines(ctx, r.db, query, before)
+}
+
+// GetLinesDueForStatement возвращает активные линии, для которых наступила дата формирования выписки
+func (r *CreditLineRepository) GetLinesDueForStatement(ctx context.Context, now time.Time) ([]model.CreditLine, error) {
+	query := `SELECT ` + creditLineColumns + ` FROM credit_lines WHERE status = 'active' AND next_statement_date <= $1`
+
+	return r.queryLines(ctx, r.db, query, now)
+}
+
+func (r *CreditLineRepository) queryLines(ctx context.Context, q queryer, query string, args ...interface{}) ([]model.CreditLine, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credit lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []model.CreditLine
+	for rows.Next() {
+		line, err := scanCreditLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan credit line: %w", err)
+		}
+		lines = append(lines, *line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return lines, nil
+}
+
+// UpdateUsedAmountTx изменяет задолженность по линии на delta (положительное значение - выборка средств)
+func (r *CreditLineRepository) UpdateUsedAmountTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, delta float64) error {
+	query := `
+        UPDATE credit_lines
+        SET used_amount = used_amount + $1,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	result, err := tx.ExecContext(ctx, query, delta, id)
+	if err != nil {
+		return fmt.Errorf("failed to update credit line balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("credit line not found")
+	}
+
+	return nil
+}
+
+// AccrueInterestTx добавляет начисленные проценты и фиксирует дату последнего начисления
+func (r *CreditLineRepository) AccrueInterestTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, interest float64, accruedAt time.Time) error {
+	query := `
+        UPDATE credit_lines
+        SET accrued_interest = accrued_interest + $1,
+            last_accrued_at = $2,
+            updated_at = NOW()
+        WHERE id = $3
+    `
+
+	if _, err := tx.ExecContext(ctx, query, interest, accruedAt, id); err != nil {
+		return fmt.Errorf("failed to accrue interest: %w", err)
+	}
+
+	return nil
+}
+
+// CloseStatementPeriodTx капитализирует проценты (capitalized может быть 0), обнуляет начисления
+// и переносит дату следующей выписки
+func (r *CreditLineRepository) CloseStatementPeriodTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	id uuid.UUID,
+	capitalized float64,
+	nextStatementDate time.Time,
+) error {
+	query := `
+        UPDATE credit_lines
+        SET used_amount = used_amount + $1,
+            accrued_interest = 0,
+            next_statement_date = $2,
+            updated_at = NOW()
+        WHERE id = $3
+    `
+
+	if _, err := tx.ExecContext(ctx, query, capitalized, nextStatementDate, id); err != nil {
+		return fmt.Errorf("failed to close statement period: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditLineRepository) SetGraceActiveTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, active bool) error {
+	query := `
+        UPDATE credit_lines
+        SET grace_active = $1,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	if _, err := tx.ExecContext(ctx, query, active, id); err != nil {
+		return fmt.Errorf("failed to update grace period flag: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditLineRepository) CreateOperationTx(ctx context.Context, tx *sql.Tx, op *model.CreditLineOperation) error {
+	query := `
+        INSERT INTO credit_line_operations (id, credit_line_id, operation_type, amount, reference_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+
+	_, err := tx.ExecContext(ctx, query, op.ID, op.CreditLineID, op.OperationType, op.Amount, op.ReferenceID, op.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create credit line operation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditLineRepository) CreateStatementTx(ctx context.Context, tx *sql.Tx, statement *model.CreditLineStatement) error {
+	query := `
+        INSERT INTO credit_line_statements (id, credit_line_id, period_start, period_end, statement_balance,
+                                            min_payment, deferred_interest, paid_amount, due_date, status,
+                                            created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		statement.ID,
+		statement.CreditLineID,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.StatementBalance,
+		statement.MinPayment,
+		statement.DeferredInterest,
+		statement.PaidAmount,
+		statement.DueDate,
+		statement.Status,
+		statement.CreatedAt,
+		statement.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create credit line statement: %w", err)
+	}
+
+	return nil
+}
+
+const statementColumns = `id, credit_line_id, period_start, period_end, statement_balance, min_payment,
+               deferred_interest, paid_amount, due_date, status, created_at, updated_at`
+
+func scanStatement(row rowScanner) (*model.CreditLineStatement, error) {
+	var statement model.CreditLineStatement
+	err := row.Scan(
+		&statement.ID,
+		&statement.CreditLineID,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.StatementBalance,
+		&statement.MinPayment,
+		&statement.DeferredInterest,
+		&statement.PaidAmount,
+		&statement.DueDate,
+		&statement.Status,
+		&statement.CreatedAt,
+		&statement.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// GetOpenStatementForUpdate блокирует последнюю неоплаченную выписку линии.
+// Если открытой выписки нет, возвращает nil без ошибки.
+func (r *CreditLineRepository) GetOpenStatementForUpdate(ctx context.Context, tx *sql.Tx, lineID uuid.UUID) (*model.CreditLineStatement, error) {
+	query := `SELECT ` + statementColumns + ` FROM credit_line_statements
+        WHERE credit_line_id = $1 AND status = 'open'
+        ORDER BY period_end DESC
+        LIMIT 1
+        FOR UPDATE`
+
+	statement, err := scanStatement(tx.QueryRowContext(ctx, query, lineID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get open statement: %w", err)
+	}
+
+	return statement, nil
+}
+
+func (r *CreditLineRepository) GetStatementForUpdate(ctx context.Context, tx *sql.Tx, statementID uuid.UUID) (*model.CreditLineStatement, error) {
+	query := `SELECT ` + statementColumns + ` FROM credit_line_statements WHERE id = $1 FOR UPDATE`
+
+	statement, err := scanStatement(tx.QueryRowContext(ctx, query, statementID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("statement not found")
+		}
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+
+	return statement, nil
+}
+
+func (r *CreditLineRepository) AddStatementPaymentTx(ctx context.Context, tx *sql.Tx, statementID uuid.UUID, amount float64) error {
+	query := `
+        UPDATE credit_line_statements
+        SET paid_amount = paid_amount + $1,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	if _, err := tx.ExecContext(ctx, query, amount, statementID); err != nil {
+		return fmt.Errorf("failed to update statement payment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditLineRepository) UpdateStatementStatusTx(ctx context.Context, tx *sql.Tx, statementID uuid.UUID, status string) error {
+	query := `
+        UPDATE credit_line_statements
+        SET status = $1,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	if _, err := tx.ExecContext(ctx, query, status, statementID); err != nil {
+		return fmt.Errorf("failed to update statement status: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatementsDue возвращает открытые выписки с наступившим сроком оплаты
+func (r *CreditLineRepository) GetStatementsDue(ctx context.Context, now time.Time) ([]model.CreditLineStatement, error) {
+	query := `SELECT ` + statementColumns + ` FROM credit_line_statements
+        WHERE status = 'open' AND due_date <= $1
+        ORDER BY due_date`
+
+	return r.queryStatements(ctx, query, now)
+}
+
+func (r *CreditLineRepository) GetStatements(ctx context.Context, lineID uuid.UUID) ([]model.CreditLineStatement, error) {
+	query := `SELECT ` + statementColumns + ` FROM credit_line_statements
+        WHERE credit_line_id = $1
+        ORDER BY period_end DESC`
+
+	return r.queryStatements(ctx, query, lineID)
+}
+
+func (r *CreditLineRepository) queryStatements(ctx context.Context, query string, args ...interface{}) ([]model.CreditLineStatement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statements: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []model.CreditLineStatement
+	for rows.Next() {
+		statement, err := scanStatement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statement: %w", err)
+		}
+		statements = append(statements, *statement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return statements, nil
+}
+
+func (r *CreditLineRepository) GetDB() *sql.DB {
+	return r.db
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type CreditProductRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewCreditProductRepository(db *sql.DB, logger *logrus.Logger) *CreditProductRepository {
+	return &CreditProductRepository{db: db, logger: logger}
+}
+
+const creditProductColumns = `id, name, description, min_amount, max_amount, min_term_months, max_term_months,
+               rate_type, fixed_rate, rate_margin, penalty_type, penalty_value, schedule_type,
+               early_repayment_allowed, early_repayment_fee_percent, early_repayment_min_months,
+               active, created_at, updated_at`
+
+func scanCreditProduct(row rowScanner) (*model.CreditProduct, error) {
+	var product model.CreditProduct
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&product.Description,
+		&product.MinAmount,
+		&product.MaxAmount,
+		&product.MinTermMonths,
+		&product.MaxTermMonths,
+		&product.RateType,
+		&product.FixedRate,
+		&product.RateMargin,
+		&product.PenaltyType,
+		&product.PenaltyValue,
+		&product.ScheduleType,
+		&product.EarlyRepaymentAllowed,
+		&product.EarlyRepaymentFeePercent,
+		&product.EarlyRepaymentMinMonths,
+		&product.Active,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *CreditProductRepository) Create(ctx context.Context, product *model.CreditProduct) error {
+	query := `
+        INSERT INTO credit_products (id, name, description, min_amount, max_amount, min_term_months,
+                                     max_term_months, rate_type, fixed_rate, rate_margin, penalty_type,
+                                     penalty_value, schedule_type, early_repayment_allowed,
+                                     early_repayment_fee_percent, early_repayment_min_months, active,
+                                     created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+    `
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		product.ID,
+		product.Name,
+		product.Description,
+		product.MinAmount,
+		product.MaxAmount,
+		product.MinTermMonths,
+		product.MaxTermMonths,
+		product.RateType,
+		product.FixedRate,
+		product.RateMargin,
+		product.PenaltyType,
+		product.PenaltyValue,
+		product.ScheduleType,
+		product.EarlyRepaymentAllowed,
+		product.EarlyRepaymentFeePercent,
+		product.EarlyRepaymentMinMonths,
+		product.Active,
+		product.CreatedAt,
+		product.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("credit product with this name already exists")
+		}
+		return fmt.Errorf("failed to create credit product: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditProductRepository) Update(ctx context.Context, product *model.CreditProduct) error {
+	query := `
+        UPDATE credit_products
+        SET name = $1,
+            description = $2,
+            min_amount = $3,
+            max_amount = $4,
+            min_term_months = $5,
+            max_term_months = $6,
+            rate_type = $7,
+            fixed_rate = $8,
+            rate_margin = $9,
+            penalty_type = $10,
+            penalty_value = $11,
+            schedule_type = $12,
+            early_repayment_allowed = $13,
+            early_repayment_fee_percent = $14,
+            early_repayment_min_months = $15,
+            active = $16,
+            updated_at = NOW()
+        WHERE id = $17
+    `
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		product.Name,
+		product.Description,
+		product.MinAmount,
+		product.MaxAmount,
+		product.MinTermMonths,
+		product.MaxTermMonths,
+		product.RateType,
+		product.FixedRate,
+		product.RateMargin,
+		product.PenaltyType,
+		product.PenaltyValue,
+		product.ScheduleType,
+		product.EarlyRepaymentAllowed,
+		product.EarlyRepaymentFeePercent,
+		product.EarlyRepaymentMinMonths,
+		product.Active,
+		product.ID,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("credit product with this name already exists")
+		}
+		return fmt.Errorf("failed to update credit product: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("credit product not found")
+	}
+
+	return nil
+}
+
+// SetActive включает или выключает продукт. Выключенный продукт недоступен для новых кредитов,
+// но уже выданные кредиты сохраняют ссылку на него.
+func (r *CreditProductRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	query := `UPDATE credit_products SET active = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return fmt.Errorf("failed to update credit product: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("credit product not found")
+	}
+
+	return nil
+}
+
+func (r *CreditProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.CreditProduct, error) {
+	query := `SELECT ` + creditProductColumns + ` FROM credit_products WHERE id = $1`
+
+	product, err := scanCreditProduct(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("credit product not found")
+		}
+		return nil, fmt.Errorf("failed to get credit product: %w", err)
+	}
+
+	return product, nil
+}
+
+// List возвращает продукты по имени. При activeOnly возвращаются только доступные для выдачи.
+func (r *CreditProductRepository) List(ctx context.Context, activeOnly bool) ([]model.CreditProduct, error) {
+	query := `SELECT ` + creditProductColumns + ` FROM credit_products`
+	if activeOnly {
+		query += ` WHERE active = TRUE`
+	}
+	query += ` ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credit products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []model.CreditProduct
+	for rows.Next() {
+		product, err := scanCreditProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan credit product: %w", err)
+		}
+		products = append(products, *product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"banking-api/internal/model"
+)
+
+const restructuringColumns = `id, credit_id, user_id, request_type, holiday_months, extra_term_months,
+               new_rate, reason, status, reviewer_id, review_comment, reviewed_at, schedule_version,
+               created_at, updated_at`
+
+func scanRestructuringRequest(row rowScanner) (*model.CreditRestructuringRequest, error) {
+	var req model.CreditRestructuringRequest
+	err := row.Scan(
+		&req.ID,
+		&req.CreditID,
+		&req.UserID,
+		&req.RequestType,
+		&req.HolidayMonths,
+		&req.ExtraTermMonths,
+		&req.NewRate,
+		&req.Reason,
+		&req.Status,
+		&req.ReviewerID,
+		&req.ReviewComment,
+		&req.ReviewedAt,
+		&req.ScheduleVersion,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *CreditRepository) CreateRestructuringRequest(ctx context.Context, req *model.CreditRestructuringRequest) error {
+	query := `
+        INSERT INTO credit_restructuring_requests (id, credit_id, user_id, request_type, holiday_months,
+                                                   extra_term_months, new_rate, reason, status,
+                                                   created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		req.ID,
+		req.CreditID,
+		req.UserID,
+		req.RequestType,
+		req.HolidayMonths,
+		req.ExtraTermMonths,
+		req.NewRate,
+		req.Reason,
+		req.Status,
+		req.CreatedAt,
+		req.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("restructuring request already pending")
+		}
+		return fmt.Errorf("failed to create restructuring request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CreditRepository) GetRestructuringRequestForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.CreditRestructuringRequest, error) {
+	query := `SELECT ` + restructuringColumns + ` FROM credit_restructuring_requests WHERE id = $1 FOR UPDATE`
+
+	req, err := scanRestructuringRequest(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("restructuring request not found")
+		}
+		return nil, fmt.Errorf("failed to get restructuring request: %w", err)
+	}
+
+	return req, nil
+}
+
+// GetRestructuringRequests возвращает заявки по кредиту в порядке подачи
+func (r *CreditRepository) GetRestructuringRequests(ctx context.Context, creditID uuid.UUID) ([]model.CreditRestructuringRequest, error) {
+	query := `SELECT ` + restructuringColumns + ` FROM credit_restructuring_requests
+        WHERE credit_id = $1 ORDER BY created_at`
+
+	return r.queryRestructuringRequests(ctx, query, creditID)
+}
+
+// ListRestructuringRequests возвращает заявки с указанным статусом, старые первыми
+func (r *CreditRepository) ListRestructuringRequests(ctx context.Context, status string) ([]model.CreditRestructuringRequest, error) {
+	query := `SELECT ` + restructuringColumns + ` FROM credit_restructuring_requests
+        WHERE status = $1 ORDER BY created_at`
+
+	return r.queryRestructuringRequests(ctx, query, status)
+}
+
+func (r *CreditRepository) queryRestructuringRequests(ctx context.Context, query string, args ...interface{}) ([]model.CreditRestructuringRequest, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query restructuring requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []model.CreditRestructuringRequest
+	for rows.Next() {
+		req, err := scanRestructuringRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan restructuring request: %w", err)
+		}
+		requests = append(requests, *req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return requests, nil
+}
+
+// ReviewRestructuringRequestTx сохраняет решение по заявке
+func (r *CreditRepository) ReviewRestructuringRequestTx(ctx context.Context, tx *sql.Tx, req *model.CreditRestructuringRequest) error {
+	query := `
+        UPDATE credit_restructuring_requests
+        SET status = $1,
+            reviewer_id = $2,
+            review_comment = $3,
+            reviewed_at = $4,
+            schedule_version = $5,
+            new_rate = $6,
+            updated_at = NOW()
+        WHERE id = $7
+    `
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		req.Status,
+		req.ReviewerID,
+		req.ReviewComment,
+		req.ReviewedAt,
+		req.ScheduleVersion,
+		req.NewRate,
+		req.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update restructuring request: %w", err)
+	}
+
+	return nil
+}
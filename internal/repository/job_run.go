@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type JobRunRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewJobRunRepository(db *sql.DB, logger *logrus.Logger) *JobRunRepository {
+	return &JobRunRepository{db: db, logger: logger}
+}
+
+// TryLock захватывает сессионную advisory-блокировку Postgres с именем задачи.
+// Блокировка живет на выделенном соединении, поэтому снимается и при падении экземпляра.
+// Если блокировку держит другой экземпляр, возвращает ok = false.
+func (r *JobRunRepository) TryLock(ctx context.Context, name string) (release func(), ok bool, err error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		// Контекст задачи к этому моменту может быть отменен, поэтому снимаем блокировку с фоновым
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, name); err != nil {
+			r.logger.WithError(err).Warnf("Не удалось снять блокировку задачи %s", name)
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+func (r *JobRunRepository) Create(ctx context.Context, run *model.JobRun) error {
+	query := `
+        INSERT INTO job_runs (id, job_name, instance, status, started_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, run.ID, run.JobName, run.Instance, run.Status, run.StartedAt); err != nil {
+		return fmt.Errorf("failed to create job run: %w", err)
+	}
+
+	return nil
+}
+
+func (r *JobRunRepository) Finish(ctx context.Context, run *model.JobRun) error {
+	query := `
+        UPDATE job_runs
+        SET status = $1,
+            processed = $2,
+            succeeded = $3,
+            failed = $4,
+            skipped = $5,
+            error = $6,
+            finished_at = $7
+        WHERE id = $8
+    `
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		run.Status,
+		run.Processed,
+		run.Succeeded,
+		run.Failed,
+		run.Skipped,
+		run.Error,
+		run.FinishedAt,
+		run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	return nil
+}
+
+// List возвращает последние запуски, при пустом jobName - по всем задачам
+func (r *JobRunRepository) List(ctx context.Context, jobName string, limit int) ([]model.JobRun, error) {
+	query := `
+        SELECT id, job_name, instance, status, processed, succeeded, failed, skipped,
+               error, started_at, finished_at
+        FROM job_runs
+        WHERE $1 = '' OR job_name = $1
+        ORDER BY started_at DESC
+        LIMIT $2
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, jobName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []model.JobRun
+	for rows.Next() {
+		var run model.JobRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.JobName,
+			&run.Instance,
+			&run.Status,
+			&run.Processed,
+			&run.Succeeded,
+			&run.Failed,
+			&run.Skipped,
+			&run.Error,
+			&run.StartedAt,
+			&run.FinishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return runs, nil
+}
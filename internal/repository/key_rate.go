@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type KeyRateRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewKeyRateRepository(db *sql.DB, logger *logrus.Logger) *KeyRateRepository {
+	return &KeyRateRepository{db: db, logger: logger}
+}
+
+// Upsert сохраняет значение ключевой ставки на дату (повторная запись перезаписывает ставку)
+func (r *KeyRateRepository) Upsert(ctx context.Context, rate model.KeyRate) error {
+	query := `
+        INSERT INTO key_rates (rate_date, rate, created_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (rate_date) DO UPDATE SET rate = EXCLUDED.rate
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, rate.Date, rate.Rate); err != nil {
+		return fmt.Errorf("failed to save key rate: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatest возвращает последнее сохраненное значение ставки. Если история пуста, возвращает nil.
+func (r *KeyRateRepository) GetLatest(ctx context.Context) (*model.KeyRate, error) {
+	query := `
+        SELECT rate_date, rate, created_at
+        FROM key_rates
+        ORDER BY rate_date DESC
+        LIMIT 1
+    `
+
+	var rate model.KeyRate
+	err := r.db.QueryRowContext(ctx, query).Scan(&rate.Date, &rate.Rate, &rate.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest key rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+// GetEffective возвращает ставку, действующую на дату: последнее значение, установленное не позже нее.
+// Если ставок на эту дату нет, возвращает nil.
+func (r *KeyRateRepository) GetEffective(ctx context.Context, date time.Time) (*model.KeyRate, error) {
+	query := `
+        SELECT rate_date, rate, created_at
+        FROM key_rates
+        WHERE rate_date <= $1
+        ORDER BY rate_date DESC
+        LIMIT 1
+    `
+
+	var rate model.KeyRate
+	err := r.db.QueryRowContext(ctx, query, date).Scan(&rate.Date, &rate.Rate, &rate.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get effective key rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+// GetHistory возвращает значения ставки за период по возрастанию даты
+func (r *KeyRateRepository) GetHistory(ctx context.Context, from, to time.Time) ([]model.KeyRate, error) {
+	query := `
+        SELECT rate_date, rate, created_at
+        FROM key_rates
+        WHERE rate_date BETWEEN $1 AND $2
+        ORDER BY rate_date
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query key rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []model.KeyRate
+	for rows.Next() {
+		var rate model.KeyRate
+		if err := rows.Scan(&rate.Date, &rate.Rate, &rate.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan key rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rates, nil
+}
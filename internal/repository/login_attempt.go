@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// LoginAttemptRepository хранит счетчики неудачных попыток входа в PostgreSQL,
+// поэтому ограничение действует одинаково на всех экземплярах сервиса
+type LoginAttemptRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewLoginAttemptRepository(db *sql.DB, logger *logrus.Logger) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db, logger: logger}
+}
+
+// Get возвращает счетчик по ключу или nil, если неудачных попыток не было
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (*model.LoginAttempts, error) {
+	query := `SELECT key, failures, last_failure_at, blocked_until FROM login_attempts WHERE key = $1`
+
+	var a model.LoginAttempts
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&a.Key, &a.Failures, &a.LastFailureAt, &a.BlockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	return &a, nil
+}
+
+// Increment атомарно увеличивает счетчик. Если с последней неудачной попытки прошло
+// больше resetAfter, отсчет начинается заново. Возвращает число попыток в текущей серии.
+func (r *LoginAttemptRepository) Increment(ctx context.Context, key string, resetAfter time.Duration) (int, error) {
+	query := `
+        INSERT INTO login_attempts (key, failures, last_failure_at)
+        VALUES ($1, 1, NOW())
+        ON CONFLICT (key) DO UPDATE
+        SET failures = CASE
+                WHEN login_attempts.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+                ELSE login_attempts.failures + 1
+            END,
+            last_failure_at = NOW()
+        RETURNING failures
+    `
+
+	var failures int
+	if err := r.db.QueryRowContext(ctx, query, key, resetAfter.Seconds()).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to increment login attempts: %w", err)
+	}
+
+	return failures, nil
+}
+
+// Block запрещает вход по ключу до указанного момента
+func (r *LoginAttemptRepository) Block(ctx context.Context, key string, until time.Time) error {
+	query := `
+        UPDATE login_attempts
+        SET blocked_until = GREATEST(COALESCE(blocked_until, $2), $2)
+        WHERE key = $1
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, key, until); err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+	return nil
+}
+
+// Reset сбрасывает счетчик после успешного входа
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// DeleteStale удаляет счетчики без блокировки, не обновлявшиеся с момента before
+func (r *LoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM login_attempts
+        WHERE last_failure_at < $1 AND (blocked_until IS NULL OR blocked_until < NOW())
+    `, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login attempts: %w", err)
+	}
+
+	return result.RowsAffected()
+}
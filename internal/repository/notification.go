@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// NotificationRepository хранит настройки уведомлений пользователей, пороговые
+// оповещения, уведомления, ожидающие ежедневной сводки, и входящие в приложении
+type NotificationRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(db *sql.DB, logger *logrus.Logger) *NotificationRepository {
+	return &NotificationRepository{db: db, logger: logger}
+}
+
+func (r *NotificationRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+// GetTelegramChatID возвращает личный чат Telegram пользователя (nil, если не задан)
+func (r *NotificationRepository) GetTelegramChatID(ctx context.Context, userID uuid.UUID) (*string, error) {
+	var chatID *string
+	err := r.db.QueryRowContext(ctx, `SELECT telegram_chat_id FROM notification_settings WHERE user_id = $1`, userID).Scan(&chatID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	return chatID, nil
+}
+
+// SetTelegramChatIDTx сохраняет личный чат Telegram; nil удаляет его
+func (r *NotificationRepository) SetTelegramChatIDTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, chatID *string) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO notification_settings (user_id, telegram_chat_id, updated_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (user_id) DO UPDATE SET telegram_chat_id = EXCLUDED.telegram_chat_id, updated_at = NOW()
+    `, userID, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+	return nil
+}
+
+// GetPreferences возвращает измененные пользователем способы доставки
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID uuid.UUID) ([]model.NotificationPreference, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT event_type, channel, mode FROM notification_preferences WHERE user_id = $1
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	prefs := []model.NotificationPreference{}
+	for rows.Next() {
+		var p model.NotificationPreference
+		if err := rows.Scan(&p.EventType, &p.Channel, &p.Mode); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+// UpsertPreferenceTx сохраняет способ доставки уведомления по каналу
+func (r *NotificationRepository) UpsertPreferenceTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, p model.NotificationPreference) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO notification_preferences (user_id, event_type, channel, mode, updated_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (user_id, event_type, channel) DO UPDATE SET mode = EXCLUDED.mode, updated_at = NOW()
+    `, userID, p.EventType, p.Channel, p.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference: %w", err)
+	}
+	return nil
+}
+
+// DeleteForUserTx удаляет настройки, оповещения, несформированную сводку и входящие пользователя
+func (r *NotificationRepository) DeleteForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	for _, query := range []string{
+		`DELETE FROM notification_settings WHERE user_id = $1`,
+		`DELETE FROM notification_preferences WHERE user_id = $1`,
+		`DELETE FROM notification_alerts WHERE user_id = $1`,
+		`DELETE FROM notification_digest_items WHERE user_id = $1`,
+		`DELETE FROM notification_inbox WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to delete notification settings: %w", err)
+		}
+	}
+	return nil
+}
+
+const alertColumns = `id, user_id, alert_type, account_id, threshold, created_at`
+
+func scanAlert(row rowScanner) (*model.NotificationAlert, error) {
+	var a model.NotificationAlert
+	if err := row.Scan(&a.ID, &a.UserID, &a.AlertType, &a.AccountID, &a.Threshold, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateAlert создает пороговое оповещение
+func (r *NotificationRepository) CreateAlert(ctx context.Context, alert *model.NotificationAlert) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO notification_alerts (id, user_id, alert_type, account_id, threshold, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, alert.ID, alert.UserID, alert.AlertType, alert.AccountID, alert.Threshold, alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	return nil
+}
+
+// ListAlerts возвращает пороговые оповещения пользователя. Пустой alertType - все типы.
+func (r *NotificationRepository) ListAlerts(ctx context.Context, userID uuid.UUID, alertType string) ([]model.NotificationAlert, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+alertColumns+` FROM notification_alerts
+        WHERE user_id = $1 AND ($2::text = '' OR alert_type = $2)
+        ORDER BY created_at
+    `, userID, alertType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []model.NotificationAlert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, *a)
+	}
+	return alerts, rows.Err()
+}
+
+// DeleteAlert удаляет оповещение пользователя
+func (r *NotificationRepository) DeleteAlert(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_alerts WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("alert not found")
+	}
+	return nil
+}
+
+// AddDigestItem откладывает уведомление до ежедневной сводки. Повторное добавление
+// уведомления из того же события игнорируется.
+func (r *NotificationRepository) AddDigestItem(ctx context.Context, item *model.DigestItem) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO notification_digest_items (id, user_id, source_id, event_type, subject, body, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (source_id, event_type) DO NOTHING
+    `, item.ID, item.UserID, item.SourceID, item.EventType, item.Subject, item.Body, item.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add digest item: %w", err)
+	}
+	return nil
+}
+
+// DigestUsers возвращает пользователей, для которых накоплены уведомления
+func (r *NotificationRepository) DigestUsers(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT user_id FROM notification_digest_items`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan digest user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TakeDigestItemsTx удаляет и возвращает накопленные уведомления пользователя. Если сводку
+// не удалось отправить, откат транзакции возвращает уведомления в очередь.
+func (r *NotificationRepository) TakeDigestItemsTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]model.DigestItem, error) {
+	rows, err := tx.QueryContext(ctx, `
+        DELETE FROM notification_digest_items WHERE user_id = $1
+        RETURNING id, user_id, source_id, event_type, subject, body, created_at
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take digest items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []model.DigestItem
+	for rows.Next() {
+		var item model.DigestItem
+		if err := rows.Scan(&item.ID, &item.UserID, &item.SourceID, &item.EventType, &item.Subject, &item.Body, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan digest item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+const inboxColumns = `id, user_id, source_id, event_type, subject, body, data, read_at, created_at`
+
+func scanInboxItem(row rowScanner) (*model.InboxItem, error) {
+	var item model.InboxItem
+	var data []byte
+	err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.SourceID,
+		&item.EventType,
+		&item.Subject,
+		&item.Body,
+		&data,
+		&item.ReadAt,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	item.Data = data
+	item.Read = item.ReadAt != nil
+	return &item, nil
+}
+
+// AddInboxItem сохраняет уведомление во входящих. Повторное добавление уведомления
+// из того же события outbox игнорируется.
+func (r *NotificationRepository) AddInboxItem(ctx context.Context, item *model.InboxItem) error {
+	var data interface{}
+	if len(item.Data) > 0 {
+		data = []byte(item.Data)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO notification_inbox (id, user_id, source_id, event_type, subject, body, data, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (source_id, event_type) WHERE source_id IS NOT NULL DO NOTHING
+    `, item.ID, item.UserID, item.SourceID, item.EventType, item.Subject, item.Body, data, item.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add inbox item: %w", err)
+	}
+	return nil
+}
+
+// ListInbox возвращает входящие пользователя, новые первыми. Limit 0 - без ограничения.
+func (r *NotificationRepository) ListInbox(ctx context.Context, userID uuid.UUID, filter model.InboxFilter) ([]model.InboxItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+inboxColumns+` FROM notification_inbox
+        WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+        ORDER BY created_at DESC, id
+        LIMIT NULLIF($3, 0) OFFSET $4
+    `, userID, filter.UnreadOnly, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbox: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.InboxItem{}
+	for rows.Next() {
+		item, err := scanInboxItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inbox item: %w", err)
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// CountUnread возвращает число непрочитанных уведомлений пользователя
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM notification_inbox WHERE user_id = $1 AND read_at IS NULL
+    `, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead отмечает уведомление прочитанным. Время прочтения уже прочитанного
+// уведомления не меняется.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uuid.UUID, readAt time.Time) (*model.InboxItem, error) {
+	row := r.db.QueryRowContext(ctx, `
+        UPDATE notification_inbox SET read_at = COALESCE(read_at, $3)
+        WHERE id = $1 AND user_id = $2
+        RETURNING `+inboxColumns, id, userID, readAt)
+	item, err := scanInboxItem(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("inbox item not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark inbox item read: %w", err)
+	}
+	return item, nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, readAt time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE notification_inbox SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL
+    `, userID, readAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark inbox read: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteInboxBefore удаляет уведомления, полученные раньше before
+func (r *NotificationRepository) DeleteInboxBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_inbox WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete inbox items: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// OutboxRepository хранит события transactional outbox
+type OutboxRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewOutboxRepository(db *sql.DB, logger *logrus.Logger) *OutboxRepository {
+	return &OutboxRepository{db: db, logger: logger}
+}
+
+const outboxColumns = `id, event_type, aggregate_id, payload, status, attempts, next_attempt_at,
+               locked_until, last_error, created_at, sent_at`
+
+func scanOutboxEvent(row rowScanner) (*model.OutboxEvent, error) {
+	var e model.OutboxEvent
+	var payload []byte
+	err := row.Scan(
+		&e.ID,
+		&e.EventType,
+		&e.AggregateID,
+		&payload,
+		&e.Status,
+		&e.Attempts,
+		&e.NextAttemptAt,
+		&e.LockedUntil,
+		&e.LastError,
+		&e.CreatedAt,
+		&e.SentAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = payload
+	return &e, nil
+}
+
+// CreateTx записывает событие в транзакции, изменяющей данные
+func (r *OutboxRepository) CreateTx(ctx context.Context, tx *sql.Tx, event *model.OutboxEvent) error {
+	query := `
+        INSERT INTO outbox_events (id, event_type, aggregate_id, payload, status, next_attempt_at, created_at)
+        VALUES ($1, $2, $3, $4, 'pending', $5, $5)
+    `
+
+	_, err := tx.ExecContext(ctx, query, event.ID, event.EventType, event.AggregateID, []byte(event.Payload), event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+	return nil
+}
+
+// Claim захватывает до limit событий, готовых к доставке, на время lease и увеличивает
+// счетчик попыток. Захваченные другим обработчиком события пропускаются.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxEvent, error) {
+	query := `
+        UPDATE outbox_events
+        SET attempts = attempts + 1,
+            locked_until = NOW() + $2 * INTERVAL '1 second'
+        WHERE id IN (
+            SELECT id FROM outbox_events
+            WHERE status = 'pending'
+              AND next_attempt_at <= NOW()
+              AND (locked_until IS NULL OR locked_until < NOW())
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + outboxColumns
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// MarkSent отмечает событие доставленным. Отметка выполняется только владельцем захвата
+// (по номеру попытки), поэтому событие не может быть отмечено дважды. Возвращает false,
+// если захват уже истек и событие забрал другой обработчик.
+func (r *OutboxRepository) MarkSent(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE outbox_events
+        SET status = 'sent', sent_at = NOW(), locked_until = NULL, last_error = NULL
+        WHERE id = $1 AND attempts = $2 AND status = 'pending'
+    `, id, attempt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark outbox event sent: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// MarkFailed запоминает ошибку доставки и назначает следующую попытку.
+// Если dead = true, событие больше не доставляется автоматически.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempt int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := model.OutboxStatusPending
+	if dead {
+		status = model.OutboxStatusDead
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE outbox_events
+        SET status = $3, last_error = $4, next_attempt_at = $5, locked_until = NULL
+        WHERE id = $1 AND attempts = $2 AND status = 'pending'
+    `, id, attempt, status, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// List возвращает события со статусом status, новые первыми
+func (r *OutboxRepository) List(ctx context.Context, status string, limit int) ([]model.OutboxEvent, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox_events WHERE status = $1 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []model.OutboxEvent{}
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// Requeue возвращает событие из статуса dead в очередь со сброшенным счетчиком попыток
+func (r *OutboxRepository) Requeue(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE outbox_events
+        SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL
+        WHERE id = $1 AND status = 'dead'
+    `, id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue outbox event: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("outbox event not found")
+	}
+	return nil
+}
+
+// DeleteSentBefore удаляет доставленные события старше before
+func (r *OutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE status = 'sent' AND sent_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox events: %w", err)
+	}
+	return result.RowsAffected()
+}
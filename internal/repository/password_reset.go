@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type PasswordResetRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewPasswordResetRepository(db *sql.DB, logger *logrus.Logger) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db, logger: logger}
+}
+
+const passwordResetColumns = `id, user_id, token_hash, ip, expires_at, used_at, created_at`
+
+func scanPasswordResetToken(row rowScanner) (*model.PasswordResetToken, error) {
+	var t model.PasswordResetToken
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.IP,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, t *model.PasswordResetToken) error {
+	query := `
+        INSERT INTO password_reset_tokens (id, user_id, token_hash, ip, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+
+	_, err := r.db.ExecContext(ctx, query, t.ID, t.UserID, t.TokenHash, t.IP, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate находит токен сброса по хешу и блокирует его до конца транзакции
+func (r *PasswordResetRepository) GetByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*model.PasswordResetToken, error) {
+	query := `SELECT ` + passwordResetColumns + ` FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	t, err := scanPasswordResetToken(tx.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return t, nil
+}
+
+// UseAllForUserTx помечает использованными все активные токены пользователя,
+// чтобы после смены пароля старые ссылки из писем перестали работать
+func (r *PasswordResetRepository) UseAllForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	query := `UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteForUserTx удаляет все токены сброса пароля пользователя
+func (r *PasswordResetRepository) DeleteForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+	return nil
+}
+
+// CountRecent возвращает число токенов, выданных пользователю за указанное число минут
+func (r *PasswordResetRepository) CountRecent(ctx context.Context, userID uuid.UUID, minutes int) (int, error) {
+	query := `
+        SELECT COUNT(*) FROM password_reset_tokens
+        WHERE user_id = $1 AND created_at > NOW() - make_interval(mins => $2)
+    `
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, minutes).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count password reset tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteExpired удаляет истекшие и использованные токены
+func (r *PasswordResetRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM password_reset_tokens
+        WHERE expires_at < NOW() OR used_at < NOW() - INTERVAL '1 day'
+    `)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *PasswordResetRepository) GetDB() *sql.DB {
+	return r.db
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type RefreshTokenRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewRefreshTokenRepository(db *sql.DB, logger *logrus.Logger) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db, logger: logger}
+}
+
+const refreshTokenColumns = `id, user_id, family_id, parent_id, token_hash, user_agent, ip, access_jti,
+               access_expires_at, created_at, expires_at, used_at, revoked_at, revoke_reason`
+
+func scanRefreshToken(row rowScanner) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.ParentID,
+		&t.TokenHash,
+		&t.UserAgent,
+		&t.IP,
+		&t.AccessJTI,
+		&t.AccessExpiresAt,
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.RevokedAt,
+		&t.RevokeReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, t *model.RefreshToken) error {
+	return r.insert(ctx, r.db, t)
+}
+
+func (r *RefreshTokenRepository) CreateTx(ctx context.Context, tx *sql.Tx, t *model.RefreshToken) error {
+	return r.insert(ctx, tx, t)
+}
+
+func (r *RefreshTokenRepository) insert(ctx context.Context, exec execer, t *model.RefreshToken) error {
+	query := `
+        INSERT INTO refresh_tokens (id, user_id, family_id, parent_id, token_hash, user_agent, ip,
+                                    access_jti, access_expires_at, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+
+	_, err := exec.ExecContext(
+		ctx,
+		query,
+		t.ID,
+		t.UserID,
+		t.FamilyID,
+		t.ParentID,
+		t.TokenHash,
+		t.UserAgent,
+		t.IP,
+		t.AccessJTI,
+		t.AccessExpiresAt,
+		t.CreatedAt,
+		t.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate находит токен по хешу и блокирует его до конца транзакции
+func (r *RefreshTokenRepository) GetByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*model.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	t, err := scanRefreshToken(tx.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *RefreshTokenRepository) MarkUsedTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	return nil
+}
+
+// RevokeFamilyTx отзывает все токены семейства пользователя и вносит в denylist
+// еще не истекшие access токены, выданные вместе с ними. Возвращает число отозванных токенов.
+func (r *RefreshTokenRepository) RevokeFamilyTx(ctx context.Context, tx *sql.Tx, userID, familyID uuid.UUID, reason string) (int64, error) {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW(),
+            revoke_reason = $1
+        WHERE user_id = $2 AND family_id = $3 AND revoked_at IS NULL
+    `, reason, userID, familyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+        SELECT access_jti, user_id, access_expires_at, NOW()
+        FROM refresh_tokens
+        WHERE user_id = $1 AND family_id = $2 AND access_expires_at > NOW()
+        ON CONFLICT (jti) DO NOTHING
+    `, userID, familyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// RevokeAllForUserTx отзывает все сессии пользователя вместе с их access токенами
+func (r *RefreshTokenRepository) RevokeAllForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, reason string) (int64, error) {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW(),
+            revoke_reason = $1
+        WHERE user_id = $2 AND revoked_at IS NULL
+    `, reason, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+        SELECT access_jti, user_id, access_expires_at, NOW()
+        FROM refresh_tokens
+        WHERE user_id = $1 AND access_expires_at > NOW()
+        ON CONFLICT (jti) DO NOTHING
+    `, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// RevokeOtherSessionsTx отзывает все сессии пользователя, кроме keepFamilyID
+func (r *RefreshTokenRepository) RevokeOtherSessionsTx(ctx context.Context, tx *sql.Tx, userID, keepFamilyID uuid.UUID, reason string) (int64, error) {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW(),
+            revoke_reason = $1
+        WHERE user_id = $2 AND family_id <> $3 AND revoked_at IS NULL
+    `, reason, userID, keepFamilyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+        SELECT access_jti, user_id, access_expires_at, NOW()
+        FROM refresh_tokens
+        WHERE user_id = $1 AND family_id <> $2 AND access_expires_at > NOW()
+        ON CONFLICT (jti) DO NOTHING
+    `, userID, keepFamilyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// EraseDeviceInfoTx удаляет сведения об устройствах из сессий пользователя
+func (r *RefreshTokenRepository) EraseDeviceInfoTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET user_agent = '', ip = '' WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to erase device info: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken вносит access токен в denylist
+func (r *RefreshTokenRepository) RevokeAccessToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.ClaimAccessToken(ctx, jti, userID, expiresAt)
+	return err
+}
+
+// ClaimAccessToken вносит токен в denylist и сообщает, был ли он внесен этим вызовом.
+// Одноразовый токен принимается только тем запросом, который внес его первым.
+func (r *RefreshTokenRepository) ClaimAccessToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) (bool, error) {
+	query := `
+        INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (jti) DO NOTHING
+    `
+
+	result, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *RefreshTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`, jti).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check access token: %w", err)
+	}
+	return revoked, nil
+}
+
+// ListActiveSessions возвращает активные входы пользователя: по одному действующему токену на семейство
+func (r *RefreshTokenRepository) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	query := `
+        SELECT t.family_id, t.user_agent, t.ip,
+               (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id),
+               t.created_at, t.expires_at
+        FROM refresh_tokens t
+        WHERE t.user_id = $1 AND t.used_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > NOW()
+        ORDER BY t.created_at DESC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var s model.Session
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.StartedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteExpired удаляет семейства, в которых истекли все refresh токены, и записи denylist,
+// которые уже не нужны. Семейство удаляется целиком, чтобы не нарушать ссылки parent_id.
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM refresh_tokens
+        WHERE family_id IN (
+            SELECT family_id FROM refresh_tokens
+            GROUP BY family_id
+            HAVING MAX(expires_at) < NOW()
+        )
+    `)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < NOW()`); err != nil {
+		return 0, fmt.Errorf("failed to delete expired access tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *RefreshTokenRepository) GetDB() *sql.DB {
+	return r.db
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 
 	"banking-api/internal/model"
@@ -21,19 +22,35 @@ func NewTransactionRepository(db *sql.DB, logger *logrus.Logger) *TransactionRep
 	return &TransactionRepository{db: db, logger: logger}
 }
 
+// CreateTx записывает операцию. Если направление или категория не заданы, они определяются
+// типом операции.
 func (r *TransactionRepository) CreateTx(ctx context.Context, tx *sql.Tx, transaction *model.Transaction) error {
+	if transaction.Direction == "" {
+		transaction.Direction = transaction.TransactionType.Direction()
+	}
+	if transaction.Direction == "" {
+		return fmt.Errorf("transaction direction is required for %s", transaction.TransactionType)
+	}
+	if transaction.Category == "" {
+		transaction.Category = string(transaction.TransactionType)
+	}
+
 	r.logger.WithFields(logrus.Fields{
 		"transaction_id": transaction.ID,
 		"account_id":     transaction.AccountID,
 		"amount":         transaction.Amount,
+		"credit_line":    transaction.CreditLineAmount,
 		"type":           transaction.TransactionType,
+		"direction":      transaction.Direction,
+		"category":       transaction.Category,
 		"reference_id":   transaction.ReferenceID,
 		"created_at":     transaction.CreatedAt,
 	}).Info("Создание новой транзакции")
 
 	query := `
-        INSERT INTO transactions (id, account_id, amount, transaction_type, reference_id, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO transactions (id, account_id, amount, credit_line_amount, transaction_type, direction,
+                                  reference_id, merchant_name, mcc, category, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11)
     `
 
 	_, err := tx.ExecContext(
@@ -42,8 +59,13 @@ func (r *TransactionRepository) CreateTx(ctx context.Context, tx *sql.Tx, transa
 		transaction.ID,
 		transaction.AccountID,
 		transaction.Amount,
+		transaction.CreditLineAmount,
 		transaction.TransactionType,
+		transaction.Direction,
 		transaction.ReferenceID,
+		transaction.MerchantName,
+		transaction.MCC,
+		transaction.Category,
 		transaction.CreatedAt,
 	)
 
@@ -71,7 +93,8 @@ func (r *TransactionRepository) GetByAccountAndPeriod(
 		"end_date":   endDate.Format("2006-01-02"),
 	}).Debug("Запрос транзакций по счету за период")
 
-	const query = `SELECT id, account_id, amount, transaction_type, reference_id, created_at 
+	const query = `SELECT id, account_id, amount, credit_line_amount, transaction_type, COALESCE(direction, ''), reference_id,
+                         COALESCE(merchant_name, ''), COALESCE(mcc, ''), category, created_at
                   FROM transactions 
                   WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
                   ORDER BY created_at DESC`
@@ -93,8 +116,13 @@ func (r *TransactionRepository) GetByAccountAndPeriod(
 			&tx.ID,
 			&tx.AccountID,
 			&tx.Amount,
+			&tx.CreditLineAmount,
 			&tx.TransactionType,
+			&tx.Direction,
 			&tx.ReferenceID,
+			&tx.MerchantName,
+			&tx.MCC,
+			&tx.Category,
 			&tx.CreatedAt,
 		); err != nil {
 			r.logger.WithError(err).Error("Ошибка чтения строки транзакции")
@@ -111,3 +139,97 @@ func (r *TransactionRepository) GetByAccountAndPeriod(
 	r.logger.WithField("count", len(transactions)).Debug("Транзакции успешно получены")
 	return transactions, nil
 }
+
+// AggregateByPeriod суммирует зачисления и списания по счетам пользователя за период
+// [from, to) с разбивкой по интервалам (date_trunc по UTC), счетам и категориям операций.
+// Расходы считаются как в Transaction.Expense. accountID ограничивает выборку одним счетом.
+// Интервалы без операций не возвращаются.
+func (r *TransactionRepository) AggregateByPeriod(
+	ctx context.Context,
+	userID uuid.UUID,
+	accountID *uuid.UUID,
+	from, to time.Time,
+	interval string,
+) ([]model.TransactionAggregate, error) {
+	const query = `
+        SELECT date_trunc($5::text, t.created_at AT TIME ZONE 'UTC') AS bucket,
+               t.account_id,
+               t.category,
+               COALESCE(SUM(t.amount) FILTER (WHERE t.direction = 'in'), 0),
+               COALESCE(SUM(t.amount + t.credit_line_amount)
+                        FILTER (WHERE t.direction = 'out' AND t.transaction_type <> $6), 0),
+               COUNT(*)
+        FROM transactions t
+                 JOIN accounts a ON a.id = t.account_id
+        WHERE a.user_id = $1
+          AND ($2::uuid IS NULL OR t.account_id = $2)
+          AND t.created_at >= $3
+          AND t.created_at < $4
+        GROUP BY bucket, t.account_id, t.category
+        ORDER BY bucket, t.account_id, t.category
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, userID, accountID, from, to, interval, model.TransactionTypeCreditLinePayment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var aggregates []model.TransactionAggregate
+	for rows.Next() {
+		var a model.TransactionAggregate
+		if err := rows.Scan(&a.Bucket, &a.AccountID, &a.Category, &a.Income, &a.Expenses, &a.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction aggregate: %w", err)
+		}
+		// date_trunc возвращает время без часового пояса: начало интервала по UTC
+		a.Bucket = time.Date(a.Bucket.Year(), a.Bucket.Month(), a.Bucket.Day(), 0, 0, 0, 0, time.UTC)
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
+
+// ListCardPaymentsForUpdateTx возвращает продавца, MCC и категорию оплат картой по счетам
+// пользователя и блокирует их до конца транзакции
+func (r *TransactionRepository) ListCardPaymentsForUpdateTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]model.CardPaymentCategory, error) {
+	rows, err := tx.QueryContext(ctx, `
+        SELECT t.id, COALESCE(t.merchant_name, ''), COALESCE(t.mcc, ''), t.category
+        FROM transactions t
+                 JOIN accounts a ON a.id = t.account_id
+        WHERE a.user_id = $1 AND t.transaction_type = $2
+        FOR UPDATE OF t
+    `, userID, model.TransactionTypeCardPayment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list card payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []model.CardPaymentCategory
+	for rows.Next() {
+		var p model.CardPaymentCategory
+		if err := rows.Scan(&p.TransactionID, &p.MerchantName, &p.MCC, &p.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan card payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// UpdateCategoriesTx меняет категории операций: ids[i] получает categories[i]
+func (r *TransactionRepository) UpdateCategoriesTx(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, categories []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	idStrings := make([]string, len(ids))
+	for i, id := range ids {
+		idStrings[i] = id.String()
+	}
+	_, err := tx.ExecContext(ctx, `
+        UPDATE transactions t SET category = c.category
+        FROM unnest($1::uuid[], $2::text[]) AS c(id, category)
+        WHERE t.id = c.id
+    `, pq.Array(idStrings), pq.Array(categories))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction categories: %w", err)
+	}
+	return nil
+}
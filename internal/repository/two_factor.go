@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+type TwoFactorRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTwoFactorRepository(db *sql.DB, logger *logrus.Logger) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db, logger: logger}
+}
+
+const twoFactorColumns = `user_id, encrypted_secret, secret_hmac, enabled, last_used_step, created_at, confirmed_at`
+
+func scanTwoFactor(row rowScanner) (*model.TwoFactor, error) {
+	var tf model.TwoFactor
+	err := row.Scan(
+		&tf.UserID,
+		&tf.EncryptedSecret,
+		&tf.SecretHMAC,
+		&tf.Enabled,
+		&tf.LastUsedStep,
+		&tf.CreatedAt,
+		&tf.ConfirmedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// GetByUserID возвращает настройки 2FA пользователя. Если 2FA не подключалась, возвращает nil.
+func (r *TwoFactorRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	return r.get(ctx, r.db, `SELECT `+twoFactorColumns+` FROM user_two_factor WHERE user_id = $1`, userID)
+}
+
+// GetByUserIDForUpdate блокирует настройки 2FA до конца транзакции. Если их нет, возвращает nil.
+func (r *TwoFactorRepository) GetByUserIDForUpdate(ctx context.Context, tx *sql.Tx, userID uuid.UUID) (*model.TwoFactor, error) {
+	return r.get(ctx, tx, `SELECT `+twoFactorColumns+` FROM user_two_factor WHERE user_id = $1 FOR UPDATE`, userID)
+}
+
+func (r *TwoFactorRepository) get(ctx context.Context, q queryer, query string, userID uuid.UUID) (*model.TwoFactor, error) {
+	rows, err := q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two factor settings: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get two factor settings: %w", err)
+		}
+		return nil, nil
+	}
+
+	tf, err := scanTwoFactor(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan two factor settings: %w", err)
+	}
+	return tf, nil
+}
+
+// SavePendingTx сохраняет новый секрет до подтверждения. Подключенную 2FA не перезаписывает.
+func (r *TwoFactorRepository) SavePendingTx(ctx context.Context, tx *sql.Tx, tf *model.TwoFactor) error {
+	query := `
+        INSERT INTO user_two_factor (user_id, encrypted_secret, secret_hmac, enabled, last_used_step, created_at)
+        VALUES ($1, $2, $3, FALSE, 0, $4)
+        ON CONFLICT (user_id) DO UPDATE
+        SET encrypted_secret = EXCLUDED.encrypted_secret,
+            secret_hmac = EXCLUDED.secret_hmac,
+            last_used_step = 0,
+            created_at = EXCLUDED.created_at
+        WHERE user_two_factor.enabled = FALSE
+    `
+
+	result, err := tx.ExecContext(ctx, query, tf.UserID, tf.EncryptedSecret, tf.SecretHMAC, tf.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save two factor secret: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("two factor already enabled")
+	}
+
+	return nil
+}
+
+// EnableTx подключает 2FA после проверки первого кода
+func (r *TwoFactorRepository) EnableTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, step int64) error {
+	query := `
+        UPDATE user_two_factor
+        SET enabled = TRUE,
+            last_used_step = $1,
+            confirmed_at = NOW()
+        WHERE user_id = $2
+    `
+
+	if _, err := tx.ExecContext(ctx, query, step, userID); err != nil {
+		return fmt.Errorf("failed to enable two factor: %w", err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) UpdateLastUsedStepTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, step int64) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE user_two_factor SET last_used_step = $1 WHERE user_id = $2`, step, userID); err != nil {
+		return fmt.Errorf("failed to update two factor step: %w", err)
+	}
+	return nil
+}
+
+// DeleteTx отключает 2FA и удаляет коды восстановления
+func (r *TwoFactorRepository) DeleteTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete two factor settings: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodesTx заменяет все коды восстановления пользователя новыми
+func (r *TwoFactorRepository) ReplaceRecoveryCodesTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `
+        INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at)
+        VALUES ($1, $2, $3, NOW())
+    `
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, query, uuid.New(), userID, hash); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCodeTx погашает код восстановления. Возвращает false, если код неверен или уже использован.
+func (r *TwoFactorRepository) UseRecoveryCodeTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, hash string) (bool, error) {
+	query := `
+        UPDATE user_recovery_codes
+        SET used_at = NOW()
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `
+
+	result, err := tx.ExecContext(ctx, query, userID, hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (r *TwoFactorRepository) GetDB() *sql.DB {
+	return r.db
+}
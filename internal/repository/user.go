@@ -21,10 +21,35 @@ func NewUserRepository(db *sql.DB, logger *logrus.Logger) *UserRepository {
 	return &UserRepository{db: db, logger: logger}
 }
 
+const userColumns = `id, username, email, password, email_verified, email_verified_at, pending_email,
+		role, locale, created_at, updated_at, deleted_at`
+
+func scanUser(row rowScanner) (*model.User, error) {
+	var user model.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.EmailVerified,
+		&user.EmailVerifiedAt,
+		&user.PendingEmail,
+		&user.Role,
+		&user.Locale,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (id, username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, username, email, password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	_, err := r.db.ExecContext(
@@ -34,6 +59,7 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -57,20 +83,12 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE email = $1
 	`
 
-	var user model.User
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -79,7 +97,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.
 		return nil, fmt.Errorf("failed to find user by email: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) ExistsByEmailOrUsername(ctx context.Context, email, username string) (bool, error) {
@@ -100,20 +118,12 @@ func (r *UserRepository) ExistsByEmailOrUsername(ctx context.Context, email, use
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
 
-	var user model.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -122,5 +132,278 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User
 		return nil, fmt.Errorf("failed to find user by ID: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// MarkEmailVerified отмечает email пользователя подтвержденным, если он не изменился с момента выдачи ссылки
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `
+		UPDATE users
+		SET email_verified = TRUE, email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND email = $2 AND NOT email_verified
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// UpdatePasswordTx меняет хеш пароля. Успешный сброс по ссылке из письма подтверждает и email
+func (r *UserRepository) UpdatePasswordTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password = $2,
+		    email_verified = TRUE,
+		    email_verified_at = COALESCE(email_verified_at, NOW()),
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := tx.ExecContext(ctx, query, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate находит пользователя и блокирует запись до конца транзакции
+func (r *UserRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 FOR UPDATE`
+
+	user, err := scanUser(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateUsername меняет имя пользователя
+func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, username string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET username = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, username)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("username already exists")
+		}
+		return fmt.Errorf("failed to update username: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// UpdateLocale меняет язык уведомлений
+func (r *UserRepository) UpdateLocale(ctx context.Context, id uuid.UUID, locale string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET locale = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, locale)
+	if err != nil {
+		return fmt.Errorf("failed to update locale: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// SetPendingEmail запоминает новый адрес до его подтверждения
+func (r *UserRepository) SetPendingEmail(ctx context.Context, id uuid.UUID, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET pending_email = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, email)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange заменяет email подтвержденным новым адресом, если он не изменился
+// с момента выдачи ссылки. Возвращает false, если ссылка уже неактуальна.
+func (r *UserRepository) ConfirmEmailChange(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET email = pending_email,
+		    pending_email = NULL,
+		    email_verified = TRUE,
+		    email_verified_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND pending_email = $2 AND deleted_at IS NULL
+	`, id, email)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return false, fmt.Errorf("email already exists")
+		}
+		return false, fmt.Errorf("failed to confirm email change: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ChangePasswordTx меняет хеш пароля пользователя
+func (r *UserRepository) ChangePasswordTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, passwordHash string) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users SET password = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// AnonymizeTx обезличивает удаленного пользователя: email и username заменяются
+// производными от ID, пароль сбрасывается так, что войти с ним невозможно.
+// Запись остается, чтобы не нарушать ссылки из истории операций.
+func (r *UserRepository) AnonymizeTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET username = 'deleted_' || replace(id::text, '-', ''),
+		    email = 'deleted+' || id::text || '@deleted.invalid',
+		    password = '',
+		    email_verified = FALSE,
+		    email_verified_at = NULL,
+		    pending_email = NULL,
+		    role = 'customer',
+		    deleted_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// Search ищет пользователей по части email или username либо по точному ID
+func (r *UserRepository) Search(ctx context.Context, filter model.UserSearchFilter) ([]model.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ($1 = '' OR email ILIKE '%' || $1 || '%' OR username ILIKE '%' || $1 || '%' OR id::text = $1)
+		  AND ($2 = '' OR role = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Query, string(filter.Role), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+// UpdateRoleTx меняет роль пользователя
+func (r *UserRepository) UpdateRoleTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, role model.Role) error {
+	result, err := tx.ExecContext(ctx, `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, id, role)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GrantRole назначает роль перечисленным пользователям. Возвращает число измененных записей.
+func (r *UserRepository) GrantRole(ctx context.Context, ids []uuid.UUID, role model.Role) (int64, error) {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET role = $2, updated_at = NOW()
+		WHERE id = ANY($1::uuid[]) AND role <> $2
+	`, pq.Array(values), role)
+	if err != nil {
+		return 0, fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *UserRepository) GetDB() *sql.DB {
+	return r.db
 }
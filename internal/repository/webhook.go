@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// WebhookRepository хранит webhook подписки, доставки событий и журнал попыток
+type WebhookRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, logger *logrus.Logger) *WebhookRepository {
+	return &WebhookRepository{db: db, logger: logger}
+}
+
+func (r *WebhookRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+const webhookEndpointColumns = `id, user_id, url, description, event_types, encrypted_secret, active, created_at, updated_at`
+
+func scanWebhookEndpoint(row rowScanner) (*model.WebhookEndpoint, error) {
+	var e model.WebhookEndpoint
+	err := row.Scan(
+		&e.ID,
+		&e.UserID,
+		&e.URL,
+		&e.Description,
+		pq.Array(&e.EventTypes),
+		&e.EncryptedSecret,
+		&e.Active,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *WebhookRepository) queryEndpoints(ctx context.Context, query string, args ...interface{}) ([]model.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := []model.WebhookEndpoint{}
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, rows.Err()
+}
+
+// CreateEndpoint создает подписку
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_endpoints (id, user_id, url, description, event_types, encrypted_secret, active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+    `, e.ID, e.UserID, e.URL, e.Description, pq.Array(e.EventTypes), e.EncryptedSecret, e.Active, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// CountEndpoints возвращает число подписок пользователя
+func (r *WebhookRepository) CountEndpoints(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_endpoints WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook endpoints: %w", err)
+	}
+	return count, nil
+}
+
+// ListEndpoints возвращает подписки пользователя
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, userID uuid.UUID) ([]model.WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx, `
+        SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+        WHERE user_id = $1 ORDER BY created_at
+    `, userID)
+}
+
+// GetEndpoint возвращает подписку пользователя
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, userID, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1 AND user_id = $2
+    `, id, userID)
+	e, err := scanWebhookEndpoint(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook endpoint not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+// GetEndpointByID возвращает подписку по ID (для обработчика доставок)
+func (r *WebhookRepository) GetEndpointByID(ctx context.Context, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id)
+	e, err := scanWebhookEndpoint(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook endpoint not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+// SubscribedEndpoints возвращает активные подписки пользователя на тип события
+func (r *WebhookRepository) SubscribedEndpoints(ctx context.Context, userID uuid.UUID, eventType string) ([]model.WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx, `
+        SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+        WHERE user_id = $1 AND active AND ($2 = ANY(event_types) OR '*' = ANY(event_types))
+    `, userID, eventType)
+}
+
+// UpdateEndpoint сохраняет адрес, описание, типы событий и активность подписки
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, e *model.WebhookEndpoint) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_endpoints
+        SET url = $3, description = $4, event_types = $5, active = $6, updated_at = NOW()
+        WHERE id = $1 AND user_id = $2
+    `, e.ID, e.UserID, e.URL, e.Description, pq.Array(e.EventTypes), e.Active)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook endpoint not found")
+	}
+	return nil
+}
+
+// UpdateSecret заменяет секрет подписи
+func (r *WebhookRepository) UpdateSecret(ctx context.Context, userID, id uuid.UUID, encryptedSecret string) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_endpoints SET encrypted_secret = $3, updated_at = NOW()
+        WHERE id = $1 AND user_id = $2
+    `, id, userID, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook secret: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook endpoint not found")
+	}
+	return nil
+}
+
+// DeleteEndpoint удаляет подписку вместе с историей доставок
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook endpoint not found")
+	}
+	return nil
+}
+
+// DeleteForUserTx удаляет все подписки пользователя вместе с историей доставок
+func (r *WebhookRepository) DeleteForUserTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoints: %w", err)
+	}
+	return nil
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+               locked_until, last_status_code, last_error, created_at, delivered_at`
+
+func scanWebhookDelivery(row rowScanner) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&d.ID,
+		&d.EndpointID,
+		&d.EventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LockedUntil,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]model.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// CreateDelivery ставит событие в очередь доставки. Повторная публикация события
+// на тот же адрес игнорируется.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, 'pending', $6, $6)
+        ON CONFLICT (endpoint_id, event_id) DO NOTHING
+    `, d.ID, d.EndpointID, d.EventID, d.EventType, []byte(d.Payload), d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries захватывает до limit доставок активных подписок на время lease
+// и увеличивает счетчик попыток. Захваченные другим обработчиком доставки пропускаются.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, `
+        UPDATE webhook_deliveries
+        SET attempts = attempts + 1,
+            locked_until = NOW() + $2 * INTERVAL '1 second'
+        WHERE id IN (
+            SELECT d.id FROM webhook_deliveries d
+            JOIN webhook_endpoints e ON e.id = d.endpoint_id
+            WHERE d.status = 'pending'
+              AND e.active
+              AND d.next_attempt_at <= NOW()
+              AND (d.locked_until IS NULL OR d.locked_until < NOW())
+            ORDER BY d.next_attempt_at
+            LIMIT $1
+            FOR UPDATE OF d SKIP LOCKED
+        )
+        RETURNING `+webhookDeliveryColumns, limit, lease.Seconds())
+}
+
+// AddAttempt записывает попытку доставки в журнал
+func (r *WebhookRepository) AddAttempt(ctx context.Context, a *model.WebhookDeliveryAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_delivery_attempts (id, delivery_id, attempt, status_code, error, duration_ms, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, a.ID, a.DeliveryID, a.Attempt, a.StatusCode, a.Error, a.DurationMs, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered отмечает доставку выполненной. Отметка выполняется только владельцем
+// захвата (по номеру попытки).
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id uuid.UUID, attempt, statusCode int) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = 'delivered', delivered_at = NOW(), locked_until = NULL,
+            last_status_code = $3, last_error = NULL
+        WHERE id = $1 AND attempts = $2 AND status = 'pending'
+    `, id, attempt, statusCode)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed запоминает результат неудачной попытки и назначает следующую.
+// Если failed = true, доставка больше не повторяется автоматически.
+func (r *WebhookRepository) MarkFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	attempt int,
+	statusCode *int,
+	lastError string,
+	nextAttemptAt time.Time,
+	failed bool,
+) error {
+	status := model.WebhookDeliveryPending
+	if failed {
+		status = model.WebhookDeliveryFailed
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6, locked_until = NULL
+        WHERE id = $1 AND attempts = $2 AND status = 'pending'
+    `, id, attempt, status, statusCode, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries возвращает доставки подписки, новые первыми. Пустой status - все статусы.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID uuid.UUID, status string, limit int) ([]model.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, `
+        SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+        WHERE endpoint_id = $1 AND ($2::text = '' OR status = $2)
+        ORDER BY created_at DESC
+        LIMIT $3
+    `, endpointID, status, limit)
+}
+
+// GetDelivery возвращает доставку подписки
+func (r *WebhookRepository) GetDelivery(ctx context.Context, endpointID, id uuid.UUID) (*model.WebhookDelivery, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1 AND endpoint_id = $2
+    `, id, endpointID)
+	d, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListAttempts возвращает журнал попыток доставки
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]model.WebhookDeliveryAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, delivery_id, attempt, status_code, error, duration_ms, created_at
+        FROM webhook_delivery_attempts WHERE delivery_id = $1
+        ORDER BY created_at
+    `, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []model.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var a model.WebhookDeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// Redeliver возвращает доставку в очередь со сброшенным счетчиком попыток.
+// Доставка, которая выполняется в данный момент, не перезапускается.
+// Принадлежность доставки подписке проверяется вызывающим кодом.
+func (r *WebhookRepository) Redeliver(ctx context.Context, endpointID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL, delivered_at = NULL
+        WHERE id = $1 AND endpoint_id = $2
+          AND NOT (status = 'pending' AND locked_until IS NOT NULL AND locked_until > NOW())
+    `, id, endpointID)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook delivery is in progress")
+	}
+	return nil
+}
+
+// DeleteFinishedBefore удаляет завершенные доставки (выполненные и исчерпавшие попытки) старше before
+func (r *WebhookRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM webhook_deliveries
+        WHERE status IN ('delivered', 'failed') AND created_at < $1
+    `, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -17,10 +17,17 @@ type AccountService struct {
 	userRepo        *repository.UserRepository
 	accountRepo     *repository.AccountRepository
 	transactionRepo *repository.TransactionRepository
-	emailSender     *EmailSender
+	outboxRepo      *repository.OutboxRepository
+	events          *EventPublisher
 	logger          *logrus.Logger
 }
 
+// errAccountFrozen возвращается при попытке операции по счету, заблокированному сотрудником банка
+var errAccountFrozen = fmt.Errorf("операции по счету приостановлены банком")
+
+// errAccountClosed возвращается при попытке операции по счету удаленного пользователя
+var errAccountClosed = fmt.Errorf("счет закрыт")
+
 type TransactionRepository struct {
 	db     *sql.DB
 	logger *logrus.Logger
@@ -30,14 +37,16 @@ func NewAccountService(
 	userRepo *repository.UserRepository,
 	accountRepo *repository.AccountRepository,
 	transactionRepo *repository.TransactionRepository,
-	emailSender *EmailSender,
+	outboxRepo *repository.OutboxRepository,
+	events *EventPublisher,
 	logger *logrus.Logger,
 ) *AccountService {
 	return &AccountService{
 		userRepo:        userRepo,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
-		emailSender:     emailSender,
+		outboxRepo:      outboxRepo,
+		events:          events,
 		logger:          logger,
 	}
 }
@@ -54,6 +63,7 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID uuid.UUID, cu
 		UserID:    userID,
 		Balance:   0,
 		Currency:  currency,
+		Status:    model.AccountStatusActive,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -118,6 +128,16 @@ func (s *AccountService) Transfer(
 		return fmt.Errorf("ошибка получения счета получателя: %w", err)
 	}
 
+	if fromAccount.IsClosed() || toAccount.IsClosed() {
+		s.logger.Warnf("Попытка перевода с участием закрытого счета: %s -> %s", fromAccountID, toAccountID)
+		return errAccountClosed
+	}
+
+	if fromAccount.IsFrozen() || toAccount.IsFrozen() {
+		s.logger.Warnf("Попытка перевода с участием заблокированного счета: %s -> %s", fromAccountID, toAccountID)
+		return errAccountFrozen
+	}
+
 	// Проверяем валюту (только RUB)
 	if fromAccount.Currency != "RUB" || toAccount.Currency != "RUB" {
 		s.logger.Warnf("Попытка перевода между счетами с разными валютами: %s -> %s",
@@ -155,6 +175,7 @@ func (s *AccountService) Transfer(
 		AccountID:       fromAccountID,
 		Amount:          amount,
 		TransactionType: model.TransactionTypeTransfer,
+		Direction:       model.TransactionDirectionOut,
 		ReferenceID:     &transferID,
 		CreatedAt:       now,
 	}
@@ -164,6 +185,7 @@ func (s *AccountService) Transfer(
 		AccountID:       toAccountID,
 		Amount:          amount,
 		TransactionType: model.TransactionTypeTransfer,
+		Direction:       model.TransactionDirectionIn,
 		ReferenceID:     &transferID,
 		CreatedAt:       now,
 	}
@@ -178,28 +200,30 @@ func (s *AccountService) Transfer(
 		return fmt.Errorf("ошибка записи транзакции зачисления: %w", err)
 	}
 
+	// Уведомление записывается в той же транзакции и будет доставлено обработчиком outbox
+	event, err := newOutboxEvent(model.OutboxTransferCompleted, transferID, model.TransferCompletedEvent{
+		UserID:        userID,
+		TransferID:    transferID,
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		s.logger.WithError(err).Error("Ошибка записи события перевода")
+		return fmt.Errorf("ошибка записи уведомления: %w", err)
+	}
+
 	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
 		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
 		return fmt.Errorf("ошибка подтверждения перевода: %w", err)
 	}
+	s.events.Transactions(ctx, debitTransaction, creditTransaction)
 
 	s.logger.Infof("Успешно выполнен перевод %.2f с счета %s на счет %s", amount, fromAccountID, toAccountID)
-
-	// После успешного перевода
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err == nil && user.Email != "" {
-		go func() {
-			if err := s.emailSender.SendTransferNotification(
-				user.Email,
-				amount,
-				fromAccountID.String(),
-				toAccountID.String(),
-			); err != nil {
-				s.logger.WithError(err).Warn("Не удалось отправить email уведомление")
-			}
-		}()
-	}
 	return nil
 }
 
@@ -228,6 +252,16 @@ func (s *AccountService) Deposit(
 		return fmt.Errorf("недостаточно прав: счет не принадлежит пользователю")
 	}
 
+	if account.IsClosed() {
+		s.logger.Warnf("Попытка пополнения закрытого счета %s", accountID)
+		return errAccountClosed
+	}
+
+	if account.IsFrozen() {
+		s.logger.Warnf("Попытка пополнения заблокированного счета %s", accountID)
+		return errAccountFrozen
+	}
+
 	// Проверяем валюту (только RUB)
 	if account.Currency != "RUB" {
 		s.logger.Warnf("Попытка пополнения счета с валютой %s", account.Currency)
@@ -272,6 +306,7 @@ func (s *AccountService) Deposit(
 		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
 		return fmt.Errorf("ошибка подтверждения операции: %w", err)
 	}
+	s.events.Transactions(ctx, transaction)
 
 	s.logger.Infof("Успешно пополнен счет %s на сумму %.2f", accountID, amount)
 	return nil
@@ -302,6 +337,16 @@ func (s *AccountService) Withdraw(
 		return fmt.Errorf("недостаточно прав: счет не принадлежит пользователю")
 	}
 
+	if account.IsClosed() {
+		s.logger.Warnf("Попытка снятия с закрытого счета %s", accountID)
+		return errAccountClosed
+	}
+
+	if account.IsFrozen() {
+		s.logger.Warnf("Попытка снятия с заблокированного счета %s", accountID)
+		return errAccountFrozen
+	}
+
 	// Проверяем достаточность средств
 	if account.Balance < amount {
 		s.logger.Warnf("Недостаточно средств на счете %s: баланс %.2f, требуется %.2f",
@@ -353,6 +398,7 @@ func (s *AccountService) Withdraw(
 		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
 		return fmt.Errorf("ошибка подтверждения операции: %w", err)
 	}
+	s.events.Transactions(ctx, transaction)
 
 	s.logger.Infof("Успешно снято %.2f со счета %s", amount, accountID)
 	return nil
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	defaultAdminListLimit = 50
+	maxAdminListLimit     = 500
+	defaultHistoryPeriod  = 30 * 24 * time.Hour
+)
+
+// AdminService - операции сотрудников банка. Каждое действие записывается в журнал;
+// изменения данных и запись журнала выполняются в одной транзакции.
+type AdminService struct {
+	userRepo         *repository.UserRepository
+	accountRepo      *repository.AccountRepository
+	transactionRepo  *repository.TransactionRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	auditRepo        *repository.AuditRepository
+	events           *EventPublisher
+	logger           *logrus.Logger
+}
+
+func NewAdminService(
+	userRepo *repository.UserRepository,
+	accountRepo *repository.AccountRepository,
+	transactionRepo *repository.TransactionRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	auditRepo *repository.AuditRepository,
+	events *EventPublisher,
+	logger *logrus.Logger,
+) *AdminService {
+	return &AdminService{
+		userRepo:         userRepo,
+		accountRepo:      accountRepo,
+		transactionRepo:  transactionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		auditRepo:        auditRepo,
+		events:           events,
+		logger:           logger,
+	}
+}
+
+// BootstrapAdmins назначает роль admin пользователям из ADMIN_USER_IDS,
+// чтобы в новой установке было кому раздавать роли
+func (s *AdminService) BootstrapAdmins(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("некорректный ID администратора %q: %w", id, err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	granted, err := s.userRepo.GrantRole(ctx, userIDs, model.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("ошибка назначения администраторов: %w", err)
+	}
+	if granted > 0 {
+		s.logger.Infof("Роль admin назначена %d пользователям из ADMIN_USER_IDS", granted)
+	}
+	return nil
+}
+
+// SearchUsers ищет пользователей по email, username или ID
+func (s *AdminService) SearchUsers(ctx context.Context, actor model.Actor, filter model.UserSearchFilter) ([]model.User, error) {
+	if filter.Role != "" && !filter.Role.Valid() {
+		return nil, fmt.Errorf("неизвестная роль: %s", filter.Role)
+	}
+	filter.Limit = clampLimit(filter.Limit)
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	users, err := s.userRepo.Search(ctx, filter)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка поиска пользователей")
+		return nil, fmt.Errorf("ошибка поиска пользователей: %w", err)
+	}
+
+	entry := newAuditEntry(actor, model.AuditUsersSearch, "user", "", model.AdminReason{}, map[string]interface{}{
+		"query":   filter.Query,
+		"role":    filter.Role,
+		"results": len(users),
+	})
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUser возвращает карточку пользователя со счетами
+func (s *AdminService) GetUser(ctx context.Context, actor model.Actor, userID uuid.UUID) (*model.AdminUserDetails, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountRepo.GetUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения счетов: %w", err)
+	}
+
+	entry := newAuditEntry(actor, model.AuditUserView, "user", userID.String(), model.AdminReason{}, nil)
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	return &model.AdminUserDetails{User: *user, Accounts: accounts}, nil
+}
+
+// ChangeRole меняет роль пользователя. Все сессии пользователя завершаются,
+// чтобы новая роль действовала сразу, а не после истечения access токена.
+func (s *AdminService) ChangeRole(ctx context.Context, actor model.Actor, userID uuid.UUID, req model.ChangeRoleRequest) (*model.User, error) {
+	if !req.Role.Valid() {
+		return nil, fmt.Errorf("неизвестная роль: %s", req.Role)
+	}
+	if err := req.Validate(model.RoleChangeReasonCodes); err != nil {
+		return nil, err
+	}
+	if userID == actor.ID {
+		return nil, fmt.Errorf("нельзя изменить собственную роль")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == req.Role {
+		return user, nil
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.UpdateRoleTx(ctx, tx, userID, req.Role); err != nil {
+		return nil, fmt.Errorf("ошибка изменения роли: %w", err)
+	}
+	if _, err := s.refreshTokenRepo.RevokeAllForUserTx(ctx, tx, userID, "role_change"); err != nil {
+		return nil, fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+
+	entry := newAuditEntry(actor, model.AuditUserRoleChange, "user", userID.String(), req.AdminReason, map[string]interface{}{
+		"from": user.Role,
+		"to":   req.Role,
+	})
+	if err := s.auditRepo.CreateTx(ctx, tx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"actor_id": actor.ID,
+		"user_id":  userID,
+		"from":     user.Role,
+		"to":       req.Role,
+	}).Info("Роль пользователя изменена")
+
+	user.Role = req.Role
+	return user, nil
+}
+
+// GetAccountTransactions возвращает операции по любому счету за период (по умолчанию 30 дней)
+func (s *AdminService) GetAccountTransactions(
+	ctx context.Context,
+	actor model.Actor,
+	accountID uuid.UUID,
+	from, to time.Time,
+) ([]model.Transaction, error) {
+	if _, err := s.accountRepo.GetByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultHistoryPeriod)
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("начало периода позже окончания")
+	}
+
+	transactions, err := s.transactionRepo.GetByAccountAndPeriod(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newAuditEntry(actor, model.AuditTransactionsView, "account", accountID.String(), model.AdminReason{}, map[string]interface{}{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+	})
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// FreezeAccount блокирует операции клиента по счету
+func (s *AdminService) FreezeAccount(ctx context.Context, actor model.Actor, accountID uuid.UUID, req model.FreezeAccountRequest) (*model.Account, error) {
+	if err := req.Validate(model.FreezeReasonCodes); err != nil {
+		return nil, err
+	}
+	return s.setAccountStatus(ctx, actor, accountID, model.AccountStatusFrozen, model.AuditAccountFreeze, req.AdminReason)
+}
+
+// UnfreezeAccount снимает блокировку со счета
+func (s *AdminService) UnfreezeAccount(ctx context.Context, actor model.Actor, accountID uuid.UUID, req model.FreezeAccountRequest) (*model.Account, error) {
+	if err := req.Validate(model.UnfreezeReasonCodes); err != nil {
+		return nil, err
+	}
+	return s.setAccountStatus(ctx, actor, accountID, model.AccountStatusActive, model.AuditAccountUnfreeze, req.AdminReason)
+}
+
+func (s *AdminService) setAccountStatus(
+	ctx context.Context,
+	actor model.Actor,
+	accountID uuid.UUID,
+	status, action string,
+	reason model.AdminReason,
+) (*model.Account, error) {
+	tx, err := s.accountRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsClosed() {
+		return nil, fmt.Errorf("счет закрыт, изменение статуса невозможно")
+	}
+	if account.Status == status {
+		return nil, fmt.Errorf("счет уже в статусе %s", status)
+	}
+
+	var freezeReason *string
+	if status == model.AccountStatusFrozen {
+		freezeReason = &reason.ReasonCode
+	}
+	if err := s.accountRepo.SetStatusTx(ctx, tx, accountID, status, freezeReason); err != nil {
+		return nil, fmt.Errorf("ошибка изменения статуса счета: %w", err)
+	}
+
+	entry := newAuditEntry(actor, action, "account", accountID.String(), reason, map[string]interface{}{
+		"user_id": account.UserID,
+		"balance": account.Balance,
+	})
+	if err := s.auditRepo.CreateTx(ctx, tx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	updated, err := s.accountRepo.GetByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"actor_id":    actor.ID,
+		"account_id":  accountID,
+		"status":      status,
+		"reason_code": reason.ReasonCode,
+	}).Warn("Статус счета изменен сотрудником")
+	return updated, nil
+}
+
+// AdjustBalance зачисляет или списывает средства по решению сотрудника.
+// Корректировка отражается в истории операций счета и ссылается на запись журнала.
+func (s *AdminService) AdjustBalance(
+	ctx context.Context,
+	actor model.Actor,
+	accountID uuid.UUID,
+	req model.BalanceAdjustmentRequest,
+) (*model.BalanceAdjustmentResult, error) {
+	amount := roundMoney(req.Amount)
+	if amount == 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, fmt.Errorf("сумма корректировки должна быть ненулевой")
+	}
+	if err := req.Validate(model.AdjustmentReasonCodes); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.accountRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Balance+amount < 0 {
+		return nil, fmt.Errorf("недостаточно средств для списания: баланс %.2f", account.Balance)
+	}
+
+	if err := s.accountRepo.AdjustBalanceTx(ctx, tx, accountID, amount); err != nil {
+		return nil, fmt.Errorf("ошибка корректировки баланса: %w", err)
+	}
+
+	entry := newAuditEntry(actor, model.AuditBalanceAdjustment, "account", accountID.String(), req.AdminReason, nil)
+
+	transactionType := model.TransactionTypeAdjustmentCredit
+	if amount < 0 {
+		transactionType = model.TransactionTypeAdjustmentDebit
+	}
+	transaction := model.Transaction{
+		ID:              uuid.New(),
+		AccountID:       accountID,
+		Amount:          math.Abs(amount),
+		TransactionType: transactionType,
+		ReferenceID:     &entry.ID,
+		CreatedAt:       entry.CreatedAt,
+	}
+	if err := s.transactionRepo.CreateTx(ctx, tx, &transaction); err != nil {
+		return nil, fmt.Errorf("ошибка записи транзакции: %w", err)
+	}
+
+	entry.Details = auditDetails(map[string]interface{}{
+		"user_id":        account.UserID,
+		"amount":         amount,
+		"balance_before": account.Balance,
+		"balance_after":  roundMoney(account.Balance + amount),
+		"transaction_id": transaction.ID,
+	})
+	if err := s.auditRepo.CreateTx(ctx, tx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка записи журнала: %w", err)
+	}
+
+	updated, err := s.accountRepo.GetByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	s.events.Transactions(ctx, &transaction)
+
+	s.logger.WithFields(logrus.Fields{
+		"actor_id":    actor.ID,
+		"account_id":  accountID,
+		"amount":      amount,
+		"reason_code": req.ReasonCode,
+	}).Warn("Баланс счета скорректирован сотрудником")
+
+	return &model.BalanceAdjustmentResult{Account: *updated, Transaction: transaction}, nil
+}
+
+// RecordRequest записывает в журнал обращение сотрудника к административному маршруту
+func (s *AdminService) RecordRequest(ctx context.Context, actor model.Actor, action, targetID string, status int) error {
+	entry := newAuditEntry(actor, action, "", targetID, model.AdminReason{}, map[string]interface{}{
+		"status": status,
+	})
+	return s.auditRepo.Create(ctx, entry)
+}
+
+// ListAudit возвращает записи журнала действий сотрудников
+func (s *AdminService) ListAudit(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	filter.Limit = clampLimit(filter.Limit)
+
+	entries, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения журнала действий")
+		return nil, fmt.Errorf("ошибка получения журнала: %w", err)
+	}
+	return entries, nil
+}
+
+func newAuditEntry(
+	actor model.Actor,
+	action, targetType, targetID string,
+	reason model.AdminReason,
+	details map[string]interface{},
+) *model.AuditEntry {
+	return &model.AuditEntry{
+		ID:         uuid.New(),
+		ActorID:    actor.ID,
+		ActorRole:  actor.Role,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		ReasonCode: reason.ReasonCode,
+		Comment:    reason.Comment,
+		Details:    auditDetails(details),
+		IP:         truncate(actor.IP, 64),
+		CreatedAt:  time.Now(),
+	}
+}
+
+func auditDetails(details map[string]interface{}) json.RawMessage {
+	if len(details) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultAdminListLimit
+	}
+	if limit > maxAdminListLimit {
+		return maxAdminListLimit
+	}
+	return limit
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -88,20 +89,22 @@ func (s *AnalyticService) GetFinancialStats(
 	}
 
 	for _, tx := range allTransactions {
-		category := string(tx.TransactionType)
+		// Оплаты картой относятся к категориям расходов, остальные операции - к типу операции
+		category := tx.Category
 		if _, exists := stats.ByCategory[category]; !exists {
 			stats.ByCategory[category] = model.CategoryStats{}
 		}
 
 		categoryStats := stats.ByCategory[category]
 
-		if tx.Amount > 0 {
+		// Сумма операции всегда положительна, зачисление или списание определяет направление
+		switch tx.Direction {
+		case model.TransactionDirectionIn:
 			stats.TotalIncome += tx.Amount
 			categoryStats.Income += tx.Amount
-		} else {
-			amount := -tx.Amount // Преобразуем отрицательную сумму в положительную
-			stats.TotalExpenses += amount
-			categoryStats.Expenses += amount
+		case model.TransactionDirectionOut:
+			stats.TotalExpenses += tx.Expense()
+			categoryStats.Expenses += tx.Expense()
 		}
 		categoryStats.Count++
 		stats.ByCategory[category] = categoryStats
@@ -121,6 +124,159 @@ func (s *AnalyticService) GetFinancialStats(
 	return stats, nil
 }
 
+// GetSpendingSeries возвращает доходы и расходы по интервалам (день, неделя, месяц) с разбивкой
+// по категориям расходов и счетам. Суммы считаются в базе данных; интервалы без операций
+// возвращаются с нулевыми суммами. При filter.Compare добавляются итоги предыдущего
+// периода такой же длины и изменение в процентах.
+func (s *AnalyticService) GetSpendingSeries(
+	ctx context.Context,
+	userID uuid.UUID,
+	filter model.SpendingSeriesFilter,
+) (*model.SpendingSeries, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	if filter.AccountID != nil {
+		account, err := s.accountRepo.GetByID(ctx, *filter.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if account.UserID != userID {
+			return nil, fmt.Errorf("account not found")
+		}
+	}
+
+	startDate := model.TruncateToInterval(filter.StartDate, model.AnalyticsIntervalDay)
+	endDate := model.TruncateToInterval(filter.EndDate, model.AnalyticsIntervalDay)
+	from, to := startDate, endDate.AddDate(0, 0, 1)
+
+	aggregates, err := s.transactionRepo.AggregateByPeriod(ctx, userID, filter.AccountID, from, to, filter.Interval)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка расчета временного ряда операций")
+		return nil, fmt.Errorf("ошибка расчета статистики: %w", err)
+	}
+
+	series := &model.SpendingSeries{
+		Interval:   filter.Interval,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		AccountID:  filter.AccountID,
+		ByCategory: make(map[string]model.CategoryStats),
+		ByAccount:  make(map[string]model.SpendingTotals),
+		Buckets:    []model.SpendingBucket{},
+	}
+
+	index := make(map[time.Time]int)
+	for start := model.TruncateToInterval(from, filter.Interval); start.Before(to); start = model.NextInterval(start, filter.Interval) {
+		index[start] = len(series.Buckets)
+		series.Buckets = append(series.Buckets, model.SpendingBucket{
+			Start:      start,
+			ByCategory: make(map[string]model.CategoryStats),
+			ByAccount:  make(map[string]model.SpendingTotals),
+		})
+	}
+
+	for _, a := range aggregates {
+		i, ok := index[a.Bucket]
+		if !ok {
+			s.logger.WithField("bucket", a.Bucket).Warn("Интервал операций вне запрошенного периода")
+			continue
+		}
+		bucket := &series.Buckets[i]
+		category := a.Category
+		account := a.AccountID.String()
+
+		addSpending(&bucket.SpendingTotals, a)
+		addSpending(&series.Totals, a)
+		bucket.ByCategory[category] = addCategorySpending(bucket.ByCategory[category], a)
+		series.ByCategory[category] = addCategorySpending(series.ByCategory[category], a)
+
+		totals := bucket.ByAccount[account]
+		addSpending(&totals, a)
+		bucket.ByAccount[account] = totals
+		totals = series.ByAccount[account]
+		addSpending(&totals, a)
+		series.ByAccount[account] = totals
+	}
+
+	if filter.Compare {
+		// Предыдущий период той же длины в днях заканчивается накануне начала запрошенного
+		days := int(to.Sub(from).Hours() / 24)
+		prevFrom := from.AddDate(0, 0, -days)
+		previous, err := s.transactionRepo.AggregateByPeriod(ctx, userID, filter.AccountID, prevFrom, from, filter.Interval)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка расчета статистики за предыдущий период")
+			return nil, fmt.Errorf("ошибка расчета статистики: %w", err)
+		}
+
+		comparison := &model.SpendingComparison{StartDate: prevFrom, EndDate: from.AddDate(0, 0, -1)}
+		for _, a := range previous {
+			addSpending(&comparison.Totals, a)
+		}
+		comparison.Change = model.SpendingChange{
+			Income:   percentChange(series.Totals.Income, comparison.Totals.Income),
+			Expenses: percentChange(series.Totals.Expenses, comparison.Totals.Expenses),
+			Net:      percentChange(series.Totals.Net, comparison.Totals.Net),
+		}
+		series.Previous = comparison
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"interval": filter.Interval,
+		"buckets":  len(series.Buckets),
+		"income":   series.Totals.Income,
+		"expenses": series.Totals.Expenses,
+	}).Debug("Временной ряд операций рассчитан")
+
+	return series, nil
+}
+
+// GetCategorySpending возвращает расходы по категориям за период [from, to) по всем счетам
+// пользователя. Категории без расходов не включаются.
+func (s *AnalyticService) GetCategorySpending(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[string]float64, error) {
+	aggregates, err := s.transactionRepo.AggregateByPeriod(ctx, userID, nil, from, to, model.AnalyticsIntervalMonth)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка расчета расходов по категориям")
+		return nil, fmt.Errorf("ошибка расчета статистики: %w", err)
+	}
+
+	spending := make(map[string]float64)
+	for _, a := range aggregates {
+		if a.Expenses > 0 {
+			spending[a.Category] = roundMoney(spending[a.Category] + a.Expenses)
+		}
+	}
+	return spending, nil
+}
+
+// addSpending добавляет суммы операций к итогам
+func addSpending(totals *model.SpendingTotals, a model.TransactionAggregate) {
+	totals.Income = roundMoney(totals.Income + a.Income)
+	totals.Expenses = roundMoney(totals.Expenses + a.Expenses)
+	totals.Net = roundMoney(totals.Income - totals.Expenses)
+	totals.Count += a.Count
+}
+
+// addCategorySpending добавляет суммы операций к статистике типа операций
+func addCategorySpending(stats model.CategoryStats, a model.TransactionAggregate) model.CategoryStats {
+	stats.Income = roundMoney(stats.Income + a.Income)
+	stats.Expenses = roundMoney(stats.Expenses + a.Expenses)
+	stats.Count += a.Count
+	return stats
+}
+
+// percentChange возвращает изменение current относительно previous в процентах
+// или nil, если previous равно нулю
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := roundMoney((current - previous) / math.Abs(previous) * 100)
+	return &change
+}
+
 // GetCreditLoad возвращает аналитику кредитной нагрузки
 func (s *AnalyticService) GetCreditLoad(
 	ctx context.Context,
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	// APIKeyPrefix - начало каждого API ключа, по нему ключ отличается от JWT
+	APIKeyPrefix = "bk_"
+
+	apiKeyPrefixBytes         = 5  // случайная часть префикса: 8 символов base32
+	apiKeySecretBytes         = 32 // секретная часть ключа
+	maxServiceAccountsPerUser = 10
+	maxActiveKeysPerAccount   = 5
+)
+
+var apiKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// APIKeyService управляет сервисными учетными записями и проверяет API ключи
+type APIKeyService struct {
+	apiKeyRepo  *repository.APIKeyRepository
+	accountRepo *repository.AccountRepository
+	logger      *logrus.Logger
+}
+
+func NewAPIKeyService(
+	apiKeyRepo *repository.APIKeyRepository,
+	accountRepo *repository.AccountRepository,
+	logger *logrus.Logger,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		accountRepo: accountRepo,
+		logger:      logger,
+	}
+}
+
+// CreateServiceAccount создает сервисную учетную запись пользователя
+func (s *APIKeyService) CreateServiceAccount(ctx context.Context, ownerID uuid.UUID, req model.CreateServiceAccountRequest) (*model.ServiceAccount, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	count, err := s.apiKeyRepo.CountActiveServiceAccounts(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки сервисных учетных записей: %w", err)
+	}
+	if count >= maxServiceAccountsPerUser {
+		return nil, fmt.Errorf("достигнуто максимальное число сервисных учетных записей (%d)", maxServiceAccountsPerUser)
+	}
+
+	account := &model.ServiceAccount{
+		ID:          uuid.New(),
+		OwnerID:     ownerID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.apiKeyRepo.CreateServiceAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("ошибка создания сервисной учетной записи: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":            ownerID,
+		"service_account_id": account.ID,
+	}).Info("Создана сервисная учетная запись")
+	return account, nil
+}
+
+// ListServiceAccounts возвращает сервисные учетные записи пользователя
+func (s *APIKeyService) ListServiceAccounts(ctx context.Context, ownerID uuid.UUID) ([]model.ServiceAccount, error) {
+	return s.apiKeyRepo.ListServiceAccounts(ctx, ownerID)
+}
+
+// DisableServiceAccount отключает сервисную учетную запись вместе со всеми ключами
+func (s *APIKeyService) DisableServiceAccount(ctx context.Context, ownerID, serviceAccountID uuid.UUID) error {
+	if _, err := s.ownedServiceAccount(ctx, ownerID, serviceAccountID); err != nil {
+		return err
+	}
+
+	tx, err := s.apiKeyRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.apiKeyRepo.DisableServiceAccountTx(ctx, tx, serviceAccountID); err != nil {
+		return fmt.Errorf("ошибка отключения сервисной учетной записи: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":            ownerID,
+		"service_account_id": serviceAccountID,
+	}).Info("Сервисная учетная запись отключена, ключи отозваны")
+	return nil
+}
+
+// CreateKey выпускает API ключ. Ключ возвращается только в этом ответе, в БД хранится его хеш.
+func (s *APIKeyService) CreateKey(ctx context.Context, ownerID, serviceAccountID uuid.UUID, req model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	serviceAccount, err := s.ownedServiceAccount(ctx, ownerID, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if serviceAccount.DisabledAt != nil {
+		return nil, fmt.Errorf("сервисная учетная запись отключена")
+	}
+
+	if req.AccountID != nil {
+		account, err := s.accountRepo.GetByID(ctx, *req.AccountID)
+		if err != nil || account.UserID != ownerID {
+			return nil, fmt.Errorf("счет не найден")
+		}
+	}
+
+	count, err := s.apiKeyRepo.CountActiveKeys(ctx, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки ключей: %w", err)
+	}
+	if count >= maxActiveKeysPerAccount {
+		return nil, fmt.Errorf("достигнуто максимальное число действующих ключей (%d), отзовите неиспользуемые", maxActiveKeysPerAccount)
+	}
+
+	prefix, raw, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+
+	now := time.Now()
+	key := model.APIKey{
+		ID:               uuid.New(),
+		ServiceAccountID: serviceAccountID,
+		Name:             req.Name,
+		Prefix:           prefix,
+		KeyHash:          hashToken(raw),
+		Scopes:           req.Scopes,
+		AccountID:        req.AccountID,
+		CreatedAt:        now,
+		ExpiresAt:        now.AddDate(0, 0, req.ExpiresInDays),
+	}
+	if err := s.apiKeyRepo.CreateKey(ctx, &key); err != nil {
+		return nil, fmt.Errorf("ошибка создания ключа: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":            ownerID,
+		"service_account_id": serviceAccountID,
+		"key_id":             key.ID,
+		"prefix":             prefix,
+		"scopes":             req.Scopes,
+	}).Info("Выпущен API ключ")
+	return &model.CreatedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+// ListKeys возвращает ключи сервисной учетной записи без секретной части
+func (s *APIKeyService) ListKeys(ctx context.Context, ownerID, serviceAccountID uuid.UUID) ([]model.APIKey, error) {
+	if _, err := s.ownedServiceAccount(ctx, ownerID, serviceAccountID); err != nil {
+		return nil, err
+	}
+	return s.apiKeyRepo.ListKeys(ctx, serviceAccountID)
+}
+
+// RevokeKey отзывает API ключ
+func (s *APIKeyService) RevokeKey(ctx context.Context, ownerID, serviceAccountID, keyID uuid.UUID) error {
+	if _, err := s.ownedServiceAccount(ctx, ownerID, serviceAccountID); err != nil {
+		return err
+	}
+	if err := s.apiKeyRepo.RevokeKey(ctx, serviceAccountID, keyID); err != nil {
+		return fmt.Errorf("ошибка отзыва ключа: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": ownerID,
+		"key_id":  keyID,
+	}).Info("API ключ отозван")
+	return nil
+}
+
+// Authenticate проверяет API ключ и отмечает его использование
+func (s *APIKeyService) Authenticate(ctx context.Context, raw, ip string) (*model.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(raw, APIKeyPrefix) {
+		return nil, fmt.Errorf("недействительный API ключ")
+	}
+
+	key, err := s.apiKeyRepo.GetKeyByHash(ctx, hashToken(raw))
+	if err != nil {
+		return nil, fmt.Errorf("недействительный API ключ")
+	}
+	if key.RevokedAt != nil || time.Now().After(key.ExpiresAt) {
+		return nil, fmt.Errorf("API ключ отозван или истек")
+	}
+
+	serviceAccount, err := s.apiKeyRepo.GetServiceAccount(ctx, key.ServiceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки API ключа: %w", err)
+	}
+	if serviceAccount.DisabledAt != nil {
+		return nil, fmt.Errorf("сервисная учетная запись отключена")
+	}
+
+	if err := s.apiKeyRepo.TouchKey(ctx, key.ID, truncate(ip, 64)); err != nil {
+		s.logger.WithError(err).Error("Не удалось обновить время использования API ключа")
+	}
+
+	return &model.APIKeyPrincipal{Key: key, ServiceAccount: serviceAccount}, nil
+}
+
+// ownedServiceAccount возвращает сервисную учетную запись, если она принадлежит пользователю
+func (s *APIKeyService) ownedServiceAccount(ctx context.Context, ownerID, serviceAccountID uuid.UUID) (*model.ServiceAccount, error) {
+	account, err := s.apiKeyRepo.GetServiceAccount(ctx, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.OwnerID != ownerID {
+		return nil, fmt.Errorf("service account not found")
+	}
+	return account, nil
+}
+
+// generateAPIKey генерирует ключ вида bk_<префикс>_<секрет>. Префикс сохраняется открыто,
+// чтобы пользователь мог узнать ключ в списке.
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := APIKeyPrefix + strings.ToLower(apiKeyEncoding.EncodeToString(prefixBytes))
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
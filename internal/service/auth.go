@@ -2,31 +2,82 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
+	"banking-api/internal/crypto"
 	"banking-api/internal/model"
 	"banking-api/internal/repository"
 )
 
+const (
+	refreshTokenBytes   = 32              // длина случайной части refresh токена
+	twoFactorAudience   = "2fa"           // audience промежуточного токена второго шага входа
+	twoFactorTokenValid = 5 * time.Minute // время на ввод кода 2FA
+)
+
+// AccessClaims - содержимое access токена. SessionID связывает токен с семейством refresh токенов,
+// Role определяет доступ к административному API.
+type AccessClaims struct {
+	SessionID string     `json:"sid,omitempty"`
+	Role      model.Role `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
 type AuthService struct {
-	userRepo    *repository.UserRepository
-	jwtSecret   string
-	tokenExpiry time.Duration
-	logger      *logrus.Logger
+	userRepo           *repository.UserRepository
+	refreshTokenRepo   *repository.RefreshTokenRepository
+	passwordResetRepo  *repository.PasswordResetRepository
+	twoFactorService   *TwoFactorService
+	loginGuard         *LoginGuard
+	notifications      *NotificationSender
+	jwtKeys            *crypto.JWTKeySet
+	hmacKey            []byte
+	appBaseURL         string
+	tokenExpiry        time.Duration
+	refreshTokenExpiry time.Duration
+	logger             *logrus.Logger
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, tokenExpiry time.Duration, logger *logrus.Logger) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	passwordResetRepo *repository.PasswordResetRepository,
+	twoFactorService *TwoFactorService,
+	loginGuard *LoginGuard,
+	notifications *NotificationSender,
+	jwtKeys *crypto.JWTKeySet,
+	hmacKey []byte,
+	appBaseURL string,
+	tokenExpiry time.Duration,
+	refreshTokenExpiry time.Duration,
+	logger *logrus.Logger,
+) *AuthService {
 	return &AuthService{
-		userRepo:    userRepo,
-		jwtSecret:   jwtSecret,
-		tokenExpiry: tokenExpiry,
-		logger:      logger,
+		userRepo:           userRepo,
+		refreshTokenRepo:   refreshTokenRepo,
+		passwordResetRepo:  passwordResetRepo,
+		twoFactorService:   twoFactorService,
+		loginGuard:         loginGuard,
+		notifications:      notifications,
+		jwtKeys:            jwtKeys,
+		hmacKey:            hmacKey,
+		appBaseURL:         strings.TrimRight(appBaseURL, "/"),
+		tokenExpiry:        tokenExpiry,
+		refreshTokenExpiry: refreshTokenExpiry,
+		logger:             logger,
 	}
 }
 
@@ -62,6 +113,7 @@ func (s *AuthService) SignUp(ctx context.Context, input model.SignUpInput) (*mod
 		Username:  input.Username,
 		Email:     input.Email,
 		Password:  string(hashedPassword),
+		Role:      model.RoleCustomer,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -72,70 +124,474 @@ func (s *AuthService) SignUp(ctx context.Context, input model.SignUpInput) (*mod
 	}
 
 	s.logger.WithField("user_id", user.ID).Info("Пользователь успешно зарегистрирован")
+
+	go func() {
+		if err := s.SendEmailVerification(context.Background(), user.ID); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить ссылку подтверждения email")
+		}
+	}()
+
 	return user, nil
 }
 
-// SignIn Авторизация пользователя и генерация JWT токена
-func (s *AuthService) SignIn(ctx context.Context, input model.SignInInput) (string, error) {
+// SignIn Авторизация пользователя. Если у пользователя подключена 2FA, вместо токенов
+// возвращается промежуточный токен, который обменивается на токены в CompleteTwoFactor.
+func (s *AuthService) SignIn(ctx context.Context, input model.SignInInput, device model.DeviceInfo) (*model.SignInResult, error) {
 	s.logger.WithField("email", input.Email).Info("Попытка входа пользователя")
 
+	// Проверка ограничения попыток входа по email и IP
+	if err := s.loginGuard.Check(ctx, input.Email, device.IP); err != nil {
+		return nil, err
+	}
+
 	// Поиск пользователя по email
 	user, err := s.userRepo.FindByEmail(ctx, input.Email)
 	if err != nil {
 		s.logger.WithError(err).Warn("Пользователь не найден или неверные учётные данные")
-		return "", fmt.Errorf("неверные учетные данные")
+		s.registerLoginFailure(ctx, input.Email, device.IP, nil)
+		return nil, fmt.Errorf("неверные учетные данные")
 	}
 
 	// Проверка пароля
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
 		s.logger.Warn("Неверный пароль при попытке входа")
-		return "", fmt.Errorf("неверные учетные данные")
+		s.registerLoginFailure(ctx, input.Email, device.IP, user)
+		return nil, fmt.Errorf("неверные учетные данные")
+	}
+
+	twoFactor, err := s.twoFactorService.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor {
+		expiresAt := time.Now().Add(twoFactorTokenValid)
+		token, err := s.generateTwoFactorToken(user.ID, expiresAt)
+		if err != nil {
+			s.logger.WithError(err).Error("Не удалось сгенерировать промежуточный токен")
+			return nil, fmt.Errorf("ошибка генерации токена: %w", err)
+		}
+
+		s.logger.WithField("user_id", user.ID).Info("Пароль принят, требуется код 2FA")
+		return &model.SignInResult{TwoFactorRequired: true, TwoFactorToken: token, ExpiresAt: expiresAt}, nil
+	}
+
+	if err := s.loginGuard.Succeed(ctx, user.Email); err != nil {
+		s.logger.WithError(err).Error("Не удалось сбросить счетчик попыток входа")
 	}
 
-	// Генерация JWT токена
-	token, err := s.GenerateJWTToken(user.ID.String())
+	pair, err := s.startSession(ctx, user.ID, device)
 	if err != nil {
-		s.logger.WithError(err).Error("Не удалось сгенерировать JWT токен")
-		return "", fmt.Errorf("ошибка генерации токена: %w", err)
+		return nil, err
 	}
 
 	s.logger.WithField("user_id", user.ID).Info("Пользователь успешно вошёл в систему")
-	return token, nil
+	return &model.SignInResult{Tokens: pair}, nil
 }
 
-// GenerateJWTToken Генерация JWT токена
-func (s *AuthService) GenerateJWTToken(userID string) (string, error) {
+// CompleteTwoFactor завершает вход: проверяет промежуточный токен и код 2FA.
+// Промежуточный токен одноразовый и после успешной проверки отзывается.
+func (s *AuthService) CompleteTwoFactor(ctx context.Context, input model.TwoFactorSignInInput, device model.DeviceInfo) (*model.TokenPair, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(
+		input.TwoFactorToken,
+		claims,
+		s.jwtKeys.Keyfunc,
+		jwt.WithValidMethods(s.jwtKeys.Methods()),
+		jwt.WithAudience(twoFactorAudience),
+	)
+	if err != nil || !token.Valid {
+		s.logger.WithError(err).Warn("Невалидный промежуточный токен 2FA")
+		return nil, fmt.Errorf("недействительный или истекший токен входа")
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("некорректные claims токена")
+	}
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("некорректные claims токена")
+	}
+
+	// Использованный токен отклоняется до проверки кода; окончательно токен
+	// принимается только при его атомарном отзыве ниже
+	revoked, err := s.refreshTokenRepo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки токена: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("недействительный или истекший токен входа")
+	}
+
+	// Подбор кода 2FA ограничивается тем же счетчиком, что и подбор пароля
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("недействительный или истекший токен входа")
+	}
+	if err := s.loginGuard.Check(ctx, user.Email, device.IP); err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorService.Verify(ctx, userID, input.Code); err != nil {
+		s.registerLoginFailure(ctx, user.Email, device.IP, user)
+		return nil, err
+	}
+
+	if err := s.loginGuard.Succeed(ctx, user.Email); err != nil {
+		s.logger.WithError(err).Error("Не удалось сбросить счетчик попыток входа")
+	}
+
+	// Параллельные запросы с одним токеном могут пройти проверки выше одновременно:
+	// сессию получает только запрос, отозвавший токен первым
+	claimed, err := s.refreshTokenRepo.ClaimAccessToken(ctx, jti, userID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка отзыва токена: %w", err)
+	}
+	if !claimed {
+		s.logger.WithField("user_id", userID).Warn("Повторное использование промежуточного токена 2FA")
+		return nil, fmt.Errorf("недействительный или истекший токен входа")
+	}
+
+	pair, err := s.startSession(ctx, userID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithField("user_id", userID).Info("Пользователь успешно вошёл в систему с 2FA")
+	return pair, nil
+}
+
+// registerLoginFailure учитывает неудачную попытку входа. Ошибка хранилища счетчиков
+// не должна менять ответ клиенту, поэтому только логируется.
+func (s *AuthService) registerLoginFailure(ctx context.Context, email, ip string, user *model.User) {
+	if err := s.loginGuard.Fail(ctx, email, ip, user); err != nil {
+		s.logger.WithError(err).Error("Не удалось учесть неудачную попытку входа")
+	}
+}
+
+// startSession открывает новое семейство refresh токенов
+func (s *AuthService) startSession(ctx context.Context, userID uuid.UUID, device model.DeviceInfo) (*model.TokenPair, error) {
+	tx, err := s.refreshTokenRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	pair, err := s.issueTokens(ctx, tx, userID, uuid.New(), nil, device)
+	if err != nil {
+		s.logger.WithError(err).Error("Не удалось сгенерировать токены")
+		return nil, fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	return pair, nil
+}
+
+// generateTwoFactorToken выпускает промежуточный токен второго шага входа.
+// Audience "2fa" не позволяет использовать его как access токен.
+func (s *AuthService) generateTwoFactorToken(userID uuid.UUID, expiresAt time.Time) (string, error) {
 	claims := jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenExpiry)),
+		ID:        uuid.New().String(),
+		Subject:   userID.String(),
+		Audience:  jwt.ClaimStrings{twoFactorAudience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return s.jwtKeys.Sign(claims)
+}
+
+// Refresh обменивает refresh токен на новую пару токенов. Предъявленный токен становится
+// использованным; его повторное предъявление означает утечку, и все семейство отзывается.
+func (s *AuthService) Refresh(ctx context.Context, rawToken string, device model.DeviceInfo) (*model.TokenPair, error) {
+	tx, err := s.refreshTokenRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	token, err := s.refreshTokenRepo.GetByHashForUpdate(ctx, tx, hashToken(rawToken))
+	if err != nil {
+		s.logger.WithError(err).Warn("Предъявлен неизвестный refresh токен")
+		return nil, fmt.Errorf("недействительный refresh токен")
+	}
+
+	if token.RevokedAt != nil {
+		s.logger.Warnf("Предъявлен отозванный refresh токен семейства %s", token.FamilyID)
+		return nil, fmt.Errorf("недействительный refresh токен")
+	}
+
+	if token.UsedAt != nil {
+		if _, err := s.refreshTokenRepo.RevokeFamilyTx(ctx, tx, token.UserID, token.FamilyID, "reuse"); err != nil {
+			return nil, fmt.Errorf("ошибка отзыва токенов: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+		}
+		s.logger.WithFields(logrus.Fields{
+			"user_id":   token.UserID,
+			"family_id": token.FamilyID,
+			"ip":        device.IP,
+		}).Warn("Повторное использование refresh токена, все токены сессии отозваны")
+		return nil, fmt.Errorf("недействительный refresh токен")
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, fmt.Errorf("срок действия refresh токена истек")
+	}
+
+	if err := s.refreshTokenRepo.MarkUsedTx(ctx, tx, token.ID); err != nil {
+		return nil, fmt.Errorf("ошибка обновления токена: %w", err)
+	}
+
+	pair, err := s.issueTokens(ctx, tx, token.UserID, token.FamilyID, &token.ID, device)
+	if err != nil {
+		s.logger.WithError(err).Error("Не удалось сгенерировать токены")
+		return nil, fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithField("user_id", token.UserID).Info("Токены обновлены")
+	return pair, nil
+}
+
+// Logout завершает сессию, к которой относится refresh токен: отзывает все ее токены
+func (s *AuthService) Logout(ctx context.Context, rawToken string) error {
+	tx, err := s.refreshTokenRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	token, err := s.refreshTokenRepo.GetByHashForUpdate(ctx, tx, hashToken(rawToken))
+	if err != nil {
+		return fmt.Errorf("недействительный refresh токен")
+	}
+
+	if _, err := s.refreshTokenRepo.RevokeFamilyTx(ctx, tx, token.UserID, token.FamilyID, "logout"); err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithField("user_id", token.UserID).Info("Пользователь вышел из системы")
+	return nil
+}
+
+// RevokeAccessToken отзывает access токен до истечения его срока
+func (s *AuthService) RevokeAccessToken(ctx context.Context, claims *AccessClaims) error {
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return fmt.Errorf("некорректный идентификатор токена")
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return fmt.Errorf("некорректный идентификатор пользователя")
+	}
+
+	return s.refreshTokenRepo.RevokeAccessToken(ctx, jti, userID, claims.ExpiresAt.Time)
+}
+
+// ListSessions возвращает активные входы пользователя. currentSessionID отмечает текущую сессию.
+func (s *AuthService) ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]model.Session, error) {
+	sessions, err := s.refreshTokenRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения сессий пользователя")
+		return nil, fmt.Errorf("ошибка получения сессий: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// DeleteSession завершает сессию пользователя на другом устройстве
+func (s *AuthService) DeleteSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	tx, err := s.refreshTokenRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	revoked, err := s.refreshTokenRepo.RevokeFamilyTx(ctx, tx, userID, sessionID, "session_deleted")
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Сессия %s пользователя %s завершена", sessionID, userID)
+	return nil
 }
 
-// ParseToken Разбор и валидация JWT токена
-func (s *AuthService) ParseToken(tokenString string) (string, error) {
+// CleanupTokens удаляет истекшие refresh токены, записи denylist и токены сброса пароля
+func (s *AuthService) CleanupTokens(ctx context.Context) (model.JobStats, error) {
+	deleted, err := s.refreshTokenRepo.DeleteExpired(ctx)
+	if err != nil {
+		return model.JobStats{}, fmt.Errorf("ошибка очистки токенов: %w", err)
+	}
+	resetDeleted, err := s.passwordResetRepo.DeleteExpired(ctx)
+	if err != nil {
+		return model.JobStats{}, fmt.Errorf("ошибка очистки токенов сброса пароля: %w", err)
+	}
+	deleted += resetDeleted
+	return model.JobStats{Processed: int(deleted), Succeeded: int(deleted)}, nil
+}
+
+// issueTokens выпускает access токен и refresh токен семейства familyID
+func (s *AuthService) issueTokens(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID, familyID uuid.UUID,
+	parentID *uuid.UUID,
+	device model.DeviceInfo,
+) (*model.TokenPair, error) {
+	// Роль читается при каждом выпуске токенов, поэтому изменение роли
+	// вступает в силу не позднее следующего обновления токенов
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	jti := uuid.New()
+	accessExpiresAt := now.Add(s.tokenExpiry)
+
+	accessToken, err := s.GenerateJWTToken(userID.String(), user.Role, jti, familyID, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(rawRefresh); err != nil {
+		return nil, fmt.Errorf("ошибка генерации refresh токена: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(rawRefresh)
+
+	record := &model.RefreshToken{
+		ID:              uuid.New(),
+		UserID:          userID,
+		FamilyID:        familyID,
+		ParentID:        parentID,
+		TokenHash:       hashToken(refreshToken),
+		UserAgent:       truncate(device.UserAgent, 512),
+		IP:              truncate(device.IP, 64),
+		AccessJTI:       jti,
+		AccessExpiresAt: accessExpiresAt,
+		CreatedAt:       now,
+		ExpiresAt:       now.Add(s.refreshTokenExpiry),
+	}
+	if err := s.refreshTokenRepo.CreateTx(ctx, tx, record); err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// JWKS возвращает открытые ключи для проверки токенов другими сервисами
+func (s *AuthService) JWKS() crypto.JWKS {
+	return s.jwtKeys.JWKS()
+}
+
+// GenerateJWTToken Генерация JWT токена, подписанного активным ключом
+func (s *AuthService) GenerateJWTToken(userID string, role model.Role, jti, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := AccessClaims{
+		SessionID: sessionID.String(),
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti.String(),
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return s.jwtKeys.Sign(claims)
+}
+
+// ParseToken Разбор и валидация JWT токена, включая проверку отзыва по jti
+func (s *AuthService) ParseToken(ctx context.Context, tokenString string) (*AccessClaims, error) {
 	s.logger.Debug("Попытка парсинга JWT токена")
 
-	claims := &jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Проверка метода подписи
-		return []byte(s.jwtSecret), nil
-	})
+	claims := &AccessClaims{}
+	// Ключ проверки выбирается по kid; допускаются только асимметричные алгоритмы,
+	// поэтому токен с alg=none или HS256 на открытом ключе будет отклонен
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.jwtKeys.Keyfunc, jwt.WithValidMethods(s.jwtKeys.Methods()))
 
 	if err != nil || !token.Valid {
 		s.logger.WithError(err).Warn("Невалидный JWT токен")
-		return "", fmt.Errorf("невалидный токен: %w", err)
+		return nil, fmt.Errorf("невалидный токен: %w", err)
+	}
+
+	// Промежуточный токен входа с 2FA не дает доступа к API
+	for _, aud := range claims.Audience {
+		if aud == twoFactorAudience {
+			s.logger.Warn("Попытка доступа к API с промежуточным токеном 2FA")
+			return nil, fmt.Errorf("некорректный тип токена")
+		}
 	}
 
 	// Извлечение ID пользователя
-	userID := claims.Subject
-	if userID == "" {
+	if claims.Subject == "" {
 		s.logger.Error("Не удалось извлечь идентификатор пользователя из токена")
-		return "", fmt.Errorf("некорректные claims токена")
+		return nil, fmt.Errorf("некорректные claims токена")
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		s.logger.Warn("Токен без идентификатора jti")
+		return nil, fmt.Errorf("некорректные claims токена")
 	}
 
-	s.logger.WithField("user_id", userID).Info("JWT токен успешно распознан")
-	return userID, nil
+	revoked, err := s.refreshTokenRepo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки отзыва токена")
+		return nil, fmt.Errorf("ошибка проверки токена: %w", err)
+	}
+	if revoked {
+		s.logger.WithField("user_id", claims.Subject).Warn("Предъявлен отозванный токен")
+		return nil, fmt.Errorf("токен отозван")
+	}
+
+	// Токены, выпущенные до появления ролей, относятся к клиенту
+	if !claims.Role.Valid() {
+		claims.Role = model.RoleCustomer
+	}
+
+	s.logger.WithField("user_id", claims.Subject).Info("JWT токен успешно распознан")
+	return claims, nil
+}
+
+// hashToken возвращает SHA-256 хеш токена в hex
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate обрезает строку до max байт с сохранением целых символов
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
 }
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const maxBudgetsPerUser = 50
+
+// BudgetService ведет месячные бюджеты пользователя и отслеживает их расходование.
+// Расходы берутся из агрегатов операций по категориям (AnalyticService); месяц
+// считается по UTC, как интервалы аналитики. Когда оплата картой доводит расходы до 80%
+// или 100% бюджета, пользователю отправляется оповещение - каждое не чаще раза в месяц.
+type BudgetService struct {
+	budgetRepo *repository.BudgetRepository
+	outboxRepo *repository.OutboxRepository
+	analytics  *AnalyticService
+	logger     *logrus.Logger
+}
+
+func NewBudgetService(
+	budgetRepo *repository.BudgetRepository,
+	outboxRepo *repository.OutboxRepository,
+	analytics *AnalyticService,
+	logger *logrus.Logger,
+) *BudgetService {
+	return &BudgetService{
+		budgetRepo: budgetRepo,
+		outboxRepo: outboxRepo,
+		analytics:  analytics,
+		logger:     logger,
+	}
+}
+
+// ListProgress возвращает бюджеты пользователя с расходами за месяц, в который попадает month
+func (s *BudgetService) ListProgress(ctx context.Context, userID uuid.UUID, month time.Time) ([]model.BudgetProgress, error) {
+	budgets, err := s.budgetRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	progress := []model.BudgetProgress{}
+	if len(budgets) == 0 {
+		return progress, nil
+	}
+
+	start := model.TruncateToInterval(month, model.AnalyticsIntervalMonth)
+	spending, err := s.analytics.GetCategorySpending(ctx, userID, start, model.NextInterval(start, model.AnalyticsIntervalMonth))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, budget := range budgets {
+		progress = append(progress, budgetProgress(budget, spending, start, now))
+	}
+	return progress, nil
+}
+
+// Create создает бюджет на категорию или общий бюджет
+func (s *BudgetService) Create(ctx context.Context, userID uuid.UUID, input model.CreateBudgetInput) (*model.Budget, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) >= maxBudgetsPerUser {
+		return nil, fmt.Errorf("достигнуто максимальное число бюджетов (%d)", maxBudgetsPerUser)
+	}
+
+	budget := &model.Budget{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Amount:    roundMoney(input.Amount),
+		CreatedAt: time.Now(),
+	}
+	budget.UpdatedAt = budget.CreatedAt
+	if input.Category != "" {
+		budget.Category = &input.Category
+	}
+	if err := s.budgetRepo.Create(ctx, budget); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"budget_id": budget.ID,
+		"category":  input.Category,
+		"amount":    budget.Amount,
+	}).Info("Создан бюджет")
+	return budget, nil
+}
+
+// Update меняет сумму бюджета. Оповещения о бюджете после этого срабатывают заново.
+func (s *BudgetService) Update(ctx context.Context, userID, budgetID uuid.UUID, input model.UpdateBudgetInput) (*model.Budget, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budgetRepo.GetByID(ctx, userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	budget.Amount = roundMoney(input.Amount)
+	budget.UpdatedAt = time.Now()
+
+	tx, err := s.budgetRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.budgetRepo.UpdateAmountTx(ctx, tx, budget); err != nil {
+		return nil, err
+	}
+	if err := s.budgetRepo.ResetAlertsTx(ctx, tx, budget.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"budget_id": budget.ID,
+		"amount":    budget.Amount,
+	}).Info("Сумма бюджета изменена")
+	return budget, nil
+}
+
+// Delete удаляет бюджет
+func (s *BudgetService) Delete(ctx context.Context, userID, budgetID uuid.UUID) error {
+	if err := s.budgetRepo.Delete(ctx, userID, budgetID); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{"user_id": userID, "budget_id": budgetID}).Info("Бюджет удален")
+	return nil
+}
+
+// OnCardPayment проверяет бюджеты, в которых учитывается оплата картой, и оповещает
+// о достижении порогов за месяц оплаты paidAt. При пересечении обоих порогов одной оплатой
+// отправляется одно оповещение о превышении. Порог отмечается в одной транзакции
+// с записью оповещения в outbox, оповещение доставляется фоновым обработчиком.
+func (s *BudgetService) OnCardPayment(ctx context.Context, user *model.User, sourceID uuid.UUID, event model.CardPaymentEvent, paidAt time.Time) error {
+	// События, записанные до появления категорий, не содержат категории оплаты
+	if event.Category == "" {
+		return nil
+	}
+
+	budgets, err := s.budgetRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	var affected []model.Budget
+	for _, budget := range budgets {
+		if budget.Covers(event.Category) {
+			affected = append(affected, budget)
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+
+	month := model.TruncateToInterval(paidAt, model.AnalyticsIntervalMonth)
+	spending, err := s.analytics.GetCategorySpending(ctx, user.ID, month, model.NextInterval(month, model.AnalyticsIntervalMonth))
+	if err != nil {
+		return err
+	}
+
+	for _, budget := range affected {
+		progress := budgetProgress(budget, spending, month, paidAt)
+		if progress.AlertLevel == 0 {
+			continue
+		}
+		if err := s.sendAlert(ctx, user, sourceID, progress, month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendAlert отмечает достигнутые пороги бюджета и записывает в outbox оповещение
+// о наибольшем из еще не отмеченных
+func (s *BudgetService) sendAlert(ctx context.Context, user *model.User, sourceID uuid.UUID, progress model.BudgetProgress, month time.Time) error {
+	var reached []int
+	for _, threshold := range model.BudgetAlertThresholds {
+		if threshold <= progress.AlertLevel {
+			reached = append(reached, threshold)
+		}
+	}
+
+	tx, err := s.budgetRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	marked, err := s.budgetRepo.MarkAlertsTx(ctx, tx, progress.ID, month, reached)
+	if err != nil {
+		return err
+	}
+	threshold := 0
+	for _, t := range marked {
+		if t > threshold {
+			threshold = t
+		}
+	}
+	if threshold == 0 {
+		return nil
+	}
+
+	// Одна оплата может затронуть бюджет категории и общий бюджет: у каждого
+	// оповещения свое событие, иначе во входящих сохранится только одно из них
+	event, err := newOutboxEvent(model.OutboxBudgetAlert, progress.ID, model.BudgetAlertEvent{
+		UserID:    user.ID,
+		Progress:  progress,
+		Threshold: threshold,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   user.ID,
+		"budget_id": progress.ID,
+		"threshold": threshold,
+		"spent":     progress.Spent,
+		"source_id": sourceID,
+	}).Info("Записано оповещение о расходовании бюджета")
+	return nil
+}
+
+// budgetProgress рассчитывает расходование бюджета за месяц, начинающийся в month.
+// Прогноз на конец текущего месяца - расходы при сохранении среднего дневного темпа.
+func budgetProgress(budget model.Budget, spending map[string]float64, month, now time.Time) model.BudgetProgress {
+	spent := 0.0
+	for category, amount := range spending {
+		if budget.Covers(category) {
+			spent += amount
+		}
+	}
+	spent = roundMoney(spent)
+
+	progress := model.BudgetProgress{
+		Budget:      budget,
+		Month:       month.Format(model.BudgetMonthLayout),
+		Spent:       spent,
+		Remaining:   roundMoney(budget.Amount - spent),
+		PercentUsed: roundMoney(spent / budget.Amount * 100),
+		Projected:   spent,
+	}
+
+	end := model.NextInterval(month, model.AnalyticsIntervalMonth)
+	if now.Before(end) {
+		// Темп считается не меньше чем за сутки: иначе первая оплата месяца
+		// дает завышенный прогноз
+		elapsed := math.Max(now.Sub(month).Hours()/24, 1)
+		days := end.Sub(month).Hours() / 24
+		progress.Projected = roundMoney(spent / elapsed * days)
+	}
+
+	progress.OverBudget = toKopecks(spent) > toKopecks(budget.Amount)
+	progress.OnTrack = toKopecks(progress.Projected) <= toKopecks(budget.Amount)
+	for _, threshold := range model.BudgetAlertThresholds {
+		if toKopecks(spent)*100 >= toKopecks(budget.Amount)*int64(threshold) {
+			progress.AlertLevel = threshold
+		}
+	}
+	return progress
+}
@@ -1,18 +1,14 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"crypto"
 	"crypto/hmac"
 	"crypto/sha256"
 	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/openpgp/armor"
-	"golang.org/x/crypto/openpgp/packet"
-	"io"
+	"math"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -22,6 +18,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/crypto/openpgp"
 
+	"banking-api/internal/crypto"
 	"banking-api/internal/model"
 	"banking-api/internal/repository"
 )
@@ -31,7 +28,10 @@ type CardService struct {
 	cardRepo        *repository.CardRepository
 	accountRepo     *repository.AccountRepository
 	transactionRepo *repository.TransactionRepository
-	emailSender     *EmailSender
+	creditLineRepo  *repository.CreditLineRepository
+	outboxRepo      *repository.OutboxRepository
+	categories      *CategoryService
+	events          *EventPublisher
 	pgpKey          *openpgp.Entity
 	hmacKey         []byte
 	logger          *logrus.Logger
@@ -42,7 +42,10 @@ func NewCardService(
 	cardRepo *repository.CardRepository,
 	accountRepo *repository.AccountRepository,
 	transactionRepo *repository.TransactionRepository,
-	emailSender *EmailSender,
+	creditLineRepo *repository.CreditLineRepository,
+	outboxRepo *repository.OutboxRepository,
+	categories *CategoryService,
+	events *EventPublisher,
 	pgpKey *openpgp.Entity,
 	hmacKey []byte,
 	logger *logrus.Logger,
@@ -52,7 +55,10 @@ func NewCardService(
 		cardRepo:        cardRepo,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
-		emailSender:     emailSender,
+		creditLineRepo:  creditLineRepo,
+		outboxRepo:      outboxRepo,
+		categories:      categories,
+		events:          events,
 		pgpKey:          pgpKey,
 		hmacKey:         hmacKey,
 		logger:          logger,
@@ -94,7 +100,7 @@ func (s *CardService) CreateCard(ctx context.Context, userID uuid.UUID, req *mod
 	// 3. Шифрование данных
 	s.logger.Debug("Шифрование данных карты")
 	cardData := fmt.Sprintf("%s|%s", cardNumber, expiryStr)
-	encryptedData, err := s.encryptData(cardData)
+	encryptedData, err := crypto.EncryptMessage(s.pgpKey, []byte(cardData))
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка при шифровании данных карты")
 		return nil, err
@@ -121,7 +127,7 @@ func (s *CardService) CreateCard(ctx context.Context, userID uuid.UUID, req *mod
 		UserID:        userID,
 		AccountID:     req.AccountID,
 		Name:          req.Name,
-		EncryptedData: string(encryptedData),
+		EncryptedData: encryptedData,
 		CVVHash:       string(cvvHash),
 		HMAC:          hmacValue,
 		CreatedAt:     time.Now(),
@@ -251,19 +257,9 @@ func (s *CardService) verifyHMAC(card *model.Card) (bool, error) {
 }
 
 func (s *CardService) decryptCardData(encrypted string) (*model.CardData, error) {
-	block, err := armor.Decode(strings.NewReader(encrypted))
+	plaintext, err := crypto.DecryptMessage(s.pgpKey, encrypted)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось декодировать armor: %w", err)
-	}
-
-	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{s.pgpKey}, nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка расшифровки: %w", err)
-	}
-
-	plaintext, err := io.ReadAll(md.UnverifiedBody)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось прочитать расшифрованные данные: %w", err)
+		return nil, err
 	}
 
 	parts := strings.Split(string(plaintext), "|")
@@ -289,6 +285,14 @@ func (s *CardService) ProcessPayment(ctx context.Context, payment *model.Payment
 		return nil, fmt.Errorf("сумма должна быть положительной")
 	}
 
+	payment.MerchantName = strings.TrimSpace(payment.MerchantName)
+	if len([]rune(payment.MerchantName)) > 255 {
+		return nil, fmt.Errorf("название продавца не должно превышать 255 символов")
+	}
+	if err := model.ValidateMCC(payment.MCC); err != nil {
+		return nil, fmt.Errorf("неверный MCC: должен состоять из 4 цифр")
+	}
+
 	card, err := s.cardRepo.GetByIDAndUser(ctx, payment.CardID, userID)
 	if err != nil {
 		s.logger.WithError(err).Error("Не удалось найти карту или получить доступ")
@@ -315,12 +319,16 @@ func (s *CardService) ProcessPayment(ctx context.Context, payment *model.Payment
 		return nil, fmt.Errorf("карта не привязана к счёту")
 	}
 
+	// Категория определяется до начала транзакции, чтобы чтение правил не удлиняло блокировку счета
+	category := s.categories.CategorizeCardPayment(ctx, userID, payment.MerchantName, payment.MCC)
+
 	paymentID := uuid.New()
 	paymentResponse := &model.PaymentResponse{
 		PaymentID:   paymentID,
 		CardID:      card.ID,
 		AccountID:   card.AccountID,
 		Amount:      payment.Amount,
+		Category:    category,
 		Status:      "pending",
 		ProcessedAt: time.Now(),
 	}
@@ -337,27 +345,94 @@ func (s *CardService) ProcessPayment(ctx context.Context, payment *model.Payment
 		s.logger.WithError(err).Error("Не удалось начать транзакцию для списания средств")
 		return paymentResponse, fmt.Errorf("ошибка транзакции: %w", err)
 	}
-	defer func() {
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, card.AccountID)
+	if err != nil {
+		paymentResponse.Status = "failed"
+		s.logger.WithError(err).Error("Ошибка получения счета карты")
+		return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+	}
+	if account.IsFrozen() {
+		paymentResponse.Status = "failed"
+		s.logger.Warnf("Попытка оплаты картой с заблокированного счета %s", account.ID)
+		return paymentResponse, errAccountFrozen
+	}
+
+	// Если средств на счете недостаточно, недостающая часть берется из кредитной линии карты
+	fromAccount := payment.Amount
+	var fromLine float64
+	var line *model.CreditLine
+	if account.Balance < payment.Amount {
+		line, err = s.creditLineRepo.GetActiveByCardIDForUpdate(ctx, tx, card.ID)
 		if err != nil {
-			tx.Rollback()
+			paymentResponse.Status = "failed"
+			s.logger.WithError(err).Error("Ошибка получения кредитной линии карты")
+			return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+		}
+		if line == nil {
+			paymentResponse.Status = "failed"
+			s.logger.Warn("Недостаточно средств на счете, кредитная линия не подключена")
+			return paymentResponse, fmt.Errorf("недостаточно средств на счете")
+		}
+
+		fromAccount = math.Max(account.Balance, 0)
+		fromLine = roundMoney(payment.Amount - fromAccount)
+		if line.AvailableAmount() < fromLine {
+			paymentResponse.Status = "failed"
+			s.logger.WithFields(logrus.Fields{
+				"credit_line_id": line.ID,
+				"available":      line.AvailableAmount(),
+				"required":       fromLine,
+			}).Warn("Недостаточно средств с учетом кредитного лимита")
+			return paymentResponse, fmt.Errorf("недостаточно средств с учетом кредитного лимита")
 		}
-	}()
+	}
 
 	// 1. Списание средств со счета
-	if err := s.accountRepo.UpdateBalanceTx(ctx, tx, card.AccountID, -payment.Amount); err != nil {
-		paymentResponse.Status = "failed"
-		s.logger.WithError(err).Error("Ошибка при списании средств")
-		return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+	if fromAccount > 0 {
+		if err := s.accountRepo.UpdateBalanceTx(ctx, tx, card.AccountID, -fromAccount); err != nil {
+			paymentResponse.Status = "failed"
+			s.logger.WithError(err).Error("Ошибка при списании средств")
+			return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+		}
 	}
 
-	// 2. Создание записи о транзакции
+	// 1.1. Выборка недостающей суммы из кредитной линии
+	if fromLine > 0 {
+		if err := s.creditLineRepo.UpdateUsedAmountTx(ctx, tx, line.ID, fromLine); err != nil {
+			paymentResponse.Status = "failed"
+			s.logger.WithError(err).Error("Ошибка при использовании кредитной линии")
+			return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+		}
+		if err := s.creditLineRepo.CreateOperationTx(ctx, tx, &model.CreditLineOperation{
+			ID:            uuid.New(),
+			CreditLineID:  line.ID,
+			OperationType: "draw",
+			Amount:        fromLine,
+			ReferenceID:   &paymentID,
+			CreatedAt:     time.Now(),
+		}); err != nil {
+			paymentResponse.Status = "failed"
+			s.logger.WithError(err).Error("Ошибка при записи операции по кредитной линии")
+			return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+		}
+		paymentResponse.CreditLineAmount = fromLine
+	}
+
+	// 2. Создание записи о транзакции: сумма по счету и часть из кредитной линии
+	// записываются раздельно
 	transaction := &model.Transaction{
-		ID:              paymentID,
-		AccountID:       card.AccountID,
-		Amount:          payment.Amount,
-		TransactionType: model.TransactionTypeCardPayment,
-		ReferenceID:     &card.ID,
-		CreatedAt:       time.Now(),
+		ID:               paymentID,
+		AccountID:        card.AccountID,
+		Amount:           fromAccount,
+		CreditLineAmount: fromLine,
+		TransactionType:  model.TransactionTypeCardPayment,
+		ReferenceID:      &card.ID,
+		MerchantName:     payment.MerchantName,
+		MCC:              payment.MCC,
+		Category:         category,
+		CreatedAt:        time.Now(),
 	}
 	if err := s.transactionRepo.CreateTx(ctx, tx, transaction); err != nil {
 		paymentResponse.Status = "failed"
@@ -365,6 +440,25 @@ func (s *CardService) ProcessPayment(ctx context.Context, payment *model.Payment
 		return paymentResponse, fmt.Errorf("не удалось создать транзакцию: %w", err)
 	}
 
+	// 3. Уведомление о платеже записывается в той же транзакции
+	event, err := newOutboxEvent(model.OutboxCardPayment, paymentID, model.CardPaymentEvent{
+		UserID:           userID,
+		PaymentID:        paymentID,
+		CardID:           card.ID,
+		Amount:           payment.Amount,
+		CreditLineAmount: fromLine,
+		Category:         category,
+	})
+	if err != nil {
+		paymentResponse.Status = "failed"
+		return paymentResponse, err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		paymentResponse.Status = "failed"
+		s.logger.WithError(err).Error("Ошибка при записи события платежа")
+		return paymentResponse, fmt.Errorf("не удалось выполнить платёж: %w", err)
+	}
+
 	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
 		paymentResponse.Status = "failed"
@@ -373,28 +467,12 @@ func (s *CardService) ProcessPayment(ctx context.Context, payment *model.Payment
 	}
 
 	paymentResponse.Status = "completed"
+	s.events.Transactions(ctx, transaction)
 	if err := s.cardRepo.UpdateLastUsed(ctx, card.ID); err != nil {
 		s.logger.WithError(err).Warn("Не удалось обновить дату последнего использования карты")
 	}
 
 	s.logger.Info("Платёж успешно завершён")
-
-	// Отправка email уведомления
-	if paymentResponse.Status == "completed" {
-		// Получаем email пользователя (нужно добавить метод в UserRepository)
-		user, err := s.userRepo.GetByID(ctx, userID)
-		if err == nil && user.Email != "" {
-			go func() {
-				if err := s.emailSender.SendPaymentNotification(
-					user.Email,
-					payment.Amount,
-					"оплата картой",
-				); err != nil {
-					s.logger.WithError(err).Warn("Не удалось отправить email уведомление")
-				}
-			}()
-		}
-	}
 	return paymentResponse, nil
 }
 
@@ -422,43 +500,6 @@ func (s *CardService) generateCardNumber() string {
 	return prefix + strconv.Itoa(checkDigit)
 }
 
-func (s *CardService) encryptData(data string) ([]byte, error) {
-	buf := new(bytes.Buffer)
-
-	armorWriter, err := armor.Encode(buf, "PGP MESSAGE", nil)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось создать armor writer: %w", err)
-	}
-
-	config := &packet.Config{
-		DefaultHash:            crypto.SHA256,
-		DefaultCipher:          packet.CipherAES256,
-		DefaultCompressionAlgo: packet.CompressionZLIB,
-	}
-
-	plaintextWriter, err := openpgp.Encrypt(armorWriter, []*openpgp.Entity{s.pgpKey}, nil, nil, config)
-	if err != nil {
-		armorWriter.Close()
-		return nil, fmt.Errorf("не удалось создать writer для шифрования: %w", err)
-	}
-
-	if _, err := plaintextWriter.Write([]byte(data)); err != nil {
-		armorWriter.Close()
-		return nil, fmt.Errorf("ошибка при записи открытого текста: %w", err)
-	}
-
-	if err := plaintextWriter.Close(); err != nil {
-		armorWriter.Close()
-		return nil, fmt.Errorf("ошибка при закрытии writer текста: %w", err)
-	}
-
-	if err := armorWriter.Close(); err != nil {
-		return nil, fmt.Errorf("ошибка при закрытии armor writer: %w", err)
-	}
-
-	return buf.Bytes(), nil
-}
-
 func maskCardNumber(number string) string {
 	if len(number) < 4 {
 		return "****"
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const maxCategoryRulesPerUser = 100
+
+// CategoryService определяет категории расходов по оплатам картой: по правилам
+// пользователя, а если ни одно не подошло - по MCC продавца. При изменении правил
+// категории ранее проведенных оплат пересчитываются.
+type CategoryService struct {
+	categoryRepo    *repository.CategoryRepository
+	transactionRepo *repository.TransactionRepository
+	logger          *logrus.Logger
+}
+
+func NewCategoryService(
+	categoryRepo *repository.CategoryRepository,
+	transactionRepo *repository.TransactionRepository,
+	logger *logrus.Logger,
+) *CategoryService {
+	return &CategoryService{
+		categoryRepo:    categoryRepo,
+		transactionRepo: transactionRepo,
+		logger:          logger,
+	}
+}
+
+// CategorizeCardPayment возвращает категорию новой оплаты картой. Если правила пользователя
+// получить не удалось, категория определяется по MCC: оплата не должна отклоняться из-за
+// категории, а правила будут применены к ней при следующем изменении.
+func (s *CategoryService) CategorizeCardPayment(ctx context.Context, userID uuid.UUID, merchant, mcc string) string {
+	rules, err := s.categoryRepo.ListRules(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Не удалось получить правила категорий, категория определена по MCC")
+		return model.CategoryForMCC(mcc)
+	}
+	return model.CategorizeCardPayment(rules, merchant, mcc)
+}
+
+// ListRules возвращает правила пользователя в порядке применения
+func (s *CategoryService) ListRules(ctx context.Context, userID uuid.UUID) ([]model.CategoryRule, error) {
+	return s.categoryRepo.ListRules(ctx, userID)
+}
+
+// CreateRule создает правило и пересчитывает категории оплат картой
+func (s *CategoryService) CreateRule(ctx context.Context, userID uuid.UUID, input model.CategoryRuleInput) (*model.CategoryRuleResult, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	count, err := s.categoryRepo.CountRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxCategoryRulesPerUser {
+		return nil, fmt.Errorf("достигнуто максимальное число правил (%d)", maxCategoryRulesPerUser)
+	}
+
+	now := time.Now()
+	rule := &model.CategoryRule{
+		ID:               uuid.New(),
+		UserID:           userID,
+		MerchantContains: input.MerchantContains,
+		MCC:              input.MCC,
+		Category:         input.Category,
+		Priority:         input.Priority,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	recategorized, err := s.changeRules(ctx, userID, func(tx *sql.Tx) error {
+		return s.categoryRepo.CreateRuleTx(ctx, tx, rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID,
+		"rule_id":       rule.ID,
+		"category":      rule.Category,
+		"recategorized": recategorized,
+	}).Info("Создано правило категории")
+	return &model.CategoryRuleResult{CategoryRule: *rule, Recategorized: recategorized}, nil
+}
+
+// UpdateRule меняет правило и пересчитывает категории оплат картой
+func (s *CategoryService) UpdateRule(ctx context.Context, userID, ruleID uuid.UUID, input model.CategoryRuleInput) (*model.CategoryRuleResult, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	var rule *model.CategoryRule
+	recategorized, err := s.changeRules(ctx, userID, func(tx *sql.Tx) error {
+		var err error
+		if rule, err = s.categoryRepo.GetRuleTx(ctx, tx, userID, ruleID); err != nil {
+			return err
+		}
+		rule.MerchantContains = input.MerchantContains
+		rule.MCC = input.MCC
+		rule.Category = input.Category
+		rule.Priority = input.Priority
+		rule.UpdatedAt = time.Now()
+		return s.categoryRepo.UpdateRuleTx(ctx, tx, rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID,
+		"rule_id":       rule.ID,
+		"category":      rule.Category,
+		"recategorized": recategorized,
+	}).Info("Правило категории изменено")
+	return &model.CategoryRuleResult{CategoryRule: *rule, Recategorized: recategorized}, nil
+}
+
+// DeleteRule удаляет правило, пересчитывает категории оплат картой и возвращает
+// число оплат, категория которых изменилась
+func (s *CategoryService) DeleteRule(ctx context.Context, userID, ruleID uuid.UUID) (int, error) {
+	recategorized, err := s.changeRules(ctx, userID, func(tx *sql.Tx) error {
+		return s.categoryRepo.DeleteRuleTx(ctx, tx, userID, ruleID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID,
+		"rule_id":       ruleID,
+		"recategorized": recategorized,
+	}).Info("Правило категории удалено")
+	return recategorized, nil
+}
+
+// changeRules применяет изменение правил и в той же транзакции пересчитывает категории
+// всех оплат картой пользователя. Оплаты блокируются до чтения правил, поэтому
+// одновременные изменения правил пересчитывают категории по очереди.
+func (s *CategoryService) changeRules(ctx context.Context, userID uuid.UUID, change func(tx *sql.Tx) error) (int, error) {
+	tx, err := s.categoryRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return 0, err
+	}
+
+	payments, err := s.transactionRepo.ListCardPaymentsForUpdateTx(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	rules, err := s.categoryRepo.ListRulesTx(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []uuid.UUID
+	var categories []string
+	for _, p := range payments {
+		category := model.CategorizeCardPayment(rules, p.MerchantName, p.MCC)
+		if category != p.Category {
+			ids = append(ids, p.TransactionID)
+			categories = append(categories, category)
+		}
+	}
+	if err := s.transactionRepo.UpdateCategoriesTx(ctx, tx, ids, categories); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	return len(ids), nil
+}
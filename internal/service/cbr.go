@@ -2,34 +2,73 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/beevik/etree"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"banking-api/internal/model"
+)
+
+const (
+	// DefaultCBRURL - адрес веб-сервиса DailyInfo ЦБ РФ
+	DefaultCBRURL = "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx"
+
+	cbrRetryBaseDelay = 500 * time.Millisecond // задержка перед первым повтором, далее удваивается
+	cbrMaxBodySize    = 5 << 20                // ограничение размера ответа
 )
 
 type CBRClient struct {
 	httpClient *http.Client
+	url        string
+	maxRetries int
 	logger     *logrus.Logger
 }
 
-// NewCBRClient создаёт новый экземпляр клиента для взаимодействия с веб-сервисом ЦБ РФ
-func NewCBRClient(logger *logrus.Logger) *CBRClient {
+// SOAPFault - ошибка, возвращенная веб-сервисом в элементе Fault
+type SOAPFault struct {
+	Code   string
+	Reason string
+}
+
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("SOAP Fault %s: %s", f.Code, f.Reason)
+}
+
+// temporary сообщает, можно ли повторить запрос: ошибки на стороне сервиса
+// (Receiver/Server) бывают временными, ошибки в самом запросе - нет
+func (f *SOAPFault) temporary() bool {
+	return strings.Contains(f.Code, "Receiver") || strings.Contains(f.Code, "Server")
+}
+
+// NewCBRClient создаёт новый экземпляр клиента для взаимодействия с веб-сервисом ЦБ РФ.
+// maxRetries - число повторов при сетевых ошибках, 5xx и временных SOAP Fault.
+func NewCBRClient(url string, timeout time.Duration, maxRetries int, logger *logrus.Logger) *CBRClient {
+	if url == "" {
+		url = DefaultCBRURL
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
 	return &CBRClient{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: timeout,
 		},
-		logger: logger,
+		url:        url,
+		maxRetries: maxRetries,
+		logger:     logger,
 	}
 }
 
-// buildSOAPRequest формирует SOAP-запрос для получения ключевой ставки за последние 30 дней
-func buildSOAPRequest() string {
-	fromDate := time.Now().AddDate(0, 0, -30).Format("2006-01-02")
-	toDate := time.Now().Format("2006-01-02")
+// buildSOAPRequest формирует SOAP-запрос для получения ключевой ставки за период
+func buildSOAPRequest(from, to time.Time) string {
+	fromDate := from.Format("2006-01-02")
+	toDate := to.Format("2006-01-02")
 	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
         <soap12:Envelope xmlns:soap12="http://www.w3.org/2003/05/soap-envelope">
             <soap12:Body>
@@ -41,87 +80,173 @@ func buildSOAPRequest() string {
         </soap12:Envelope>`, fromDate, toDate)
 }
 
-// sendRequest отправляет SOAP-запрос в ЦБ РФ и возвращает необработанный ответ
-func sendRequest(soapRequest string) ([]byte, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest(
-		"POST",
-		"https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx",
-		bytes.NewBuffer([]byte(soapRequest)),
-	)
+// sendRequest отправляет SOAP-запрос в ЦБ РФ с повторами и экспоненциальной задержкой
+// и возвращает тело успешного ответа
+func (c *CBRClient) sendRequest(ctx context.Context, soapRequest string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := cbrRetryBaseDelay << (attempt - 1)
+			c.logger.WithError(lastErr).Warnf("Повтор запроса в ЦБ РФ через %s (попытка %d из %d)",
+				delay, attempt+1, c.maxRetries+1)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		rawBody, retry, err := c.doRequest(ctx, soapRequest)
+		if err == nil {
+			return rawBody, nil
+		}
+		if !retry || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("ЦБ РФ недоступен после %d попыток: %w", c.maxRetries+1, lastErr)
+}
+
+// doRequest выполняет одну попытку запроса. retry = true, если ошибку имеет смысл повторить.
+func (c *CBRClient) doRequest(ctx context.Context, soapRequest string) (body []byte, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBufferString(soapRequest))
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// Установка заголовков
 	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
 	req.Header.Set("SOAPAction", "http://web.cbr.ru/KeyRate")
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при выполнении HTTP-запроса: %v", err)
+		return nil, true, fmt.Errorf("ошибка при выполнении HTTP-запроса: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Чтение тела ответа
-	rawBody, err := io.ReadAll(resp.Body)
+	rawBody, err := io.ReadAll(io.LimitReader(resp.Body, cbrMaxBodySize))
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при чтении ответа: %v", err)
+		return nil, true, fmt.Errorf("ошибка при чтении ответа: %w", err)
 	}
 
-	return rawBody, nil
+	// SOAP 1.2 возвращает Fault вместе с кодом 500, поэтому тело проверяется до статуса
+	if fault := parseSOAPFault(rawBody); fault != nil {
+		return nil, fault.temporary(), fault
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("ЦБ РФ вернул статус %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("ЦБ РФ вернул статус %d", resp.StatusCode)
+	}
+
+	return rawBody, false, nil
 }
 
-// parseXMLResponse парсит XML-ответ и извлекает значение ключевой ставки
-func parseXMLResponse(rawBody []byte) (float64, error) {
+// parseSOAPFault извлекает SOAP Fault (версий 1.1 и 1.2) из ответа. Если Fault нет, возвращает nil.
+func parseSOAPFault(rawBody []byte) *SOAPFault {
 	doc := etree.NewDocument()
 	if err := doc.ReadFromBytes(rawBody); err != nil {
-		return 0, fmt.Errorf("ошибка при разборе XML: %v", err)
+		return nil
 	}
 
-	// Поиск всех элементов ставки
-	krElements := doc.FindElements("//diffgram/KeyRate/KR")
-	if len(krElements) == 0 {
-		return 0, errors.New("данные по ключевой ставке не найдены")
+	fault := doc.FindElement("//Body/Fault")
+	if fault == nil {
+		return nil
 	}
 
-	latestKR := krElements[0]
-	rateElement := latestKR.FindElement("./Rate")
-	if rateElement == nil {
-		return 0, errors.New("элемент <Rate> отсутствует в XML-ответе")
+	result := &SOAPFault{}
+	// SOAP 1.2: Code/Value и Reason/Text
+	if el := fault.FindElement("./Code/Value"); el != nil {
+		result.Code = strings.TrimSpace(el.Text())
+	}
+	if el := fault.FindElement("./Reason/Text"); el != nil {
+		result.Reason = strings.TrimSpace(el.Text())
+	}
+	// SOAP 1.1: faultcode и faultstring
+	if el := fault.FindElement("./faultcode"); el != nil {
+		result.Code = strings.TrimSpace(el.Text())
 	}
+	if el := fault.FindElement("./faultstring"); el != nil {
+		result.Reason = strings.TrimSpace(el.Text())
+	}
+	return result
+}
 
-	rateStr := rateElement.Text()
+// parseKeyRates извлекает из XML-ответа все значения ключевой ставки с датами.
+// Пустой результат не считается ошибкой: за выходные дни ЦБ данных не публикует.
+func parseKeyRates(rawBody []byte) ([]model.KeyRate, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(rawBody); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе XML: %v", err)
+	}
 
-	var rate float64
-	// Преобразование строки в число
-	if _, err := fmt.Sscanf(rateStr, "%f", &rate); err != nil {
-		return 0, fmt.Errorf("ошибка при преобразовании ставки: %v", err)
+	// Поиск всех элементов ставки
+	krElements := doc.FindElements("//diffgram/KeyRate/KR")
+
+	rates := make([]model.KeyRate, 0, len(krElements))
+	for _, kr := range krElements {
+		rateElement := kr.FindElement("./Rate")
+		if rateElement == nil {
+			return nil, errors.New("элемент <Rate> отсутствует в XML-ответе")
+		}
+
+		var rate float64
+		// Преобразование строки в число
+		if _, err := fmt.Sscanf(rateElement.Text(), "%f", &rate); err != nil {
+			return nil, fmt.Errorf("ошибка при преобразовании ставки: %v", err)
+		}
+
+		dtElement := kr.FindElement("./DT")
+		if dtElement == nil {
+			return nil, errors.New("элемент <DT> отсутствует в XML-ответе")
+		}
+		date, err := parseCBRDate(dtElement.Text())
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при преобразовании даты ставки: %v", err)
+		}
+
+		rates = append(rates, model.KeyRate{Date: date, Rate: rate})
 	}
 
-	return rate, nil
+	return rates, nil
 }
 
-// GetCentralBankRate получает актуальную ключевую ставку из ЦБ РФ
-func (c CBRClient) GetCentralBankRate() (float64, error) {
-	c.logger.Info("Формирование SOAP-запроса к ЦБ РФ для получения ключевой ставки...")
-	soapRequest := buildSOAPRequest()
+// parseCBRDate разбирает дату из ответа ЦБ (с часовым поясом или без него)
+func parseCBRDate(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04:05", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// GetKeyRateHistory получает значения ключевой ставки за период
+func (c *CBRClient) GetKeyRateHistory(ctx context.Context, from, to time.Time) ([]model.KeyRate, error) {
+	c.logger.WithFields(logrus.Fields{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+	}).Info("Запрос истории ключевой ставки в ЦБ РФ...")
 
-	c.logger.Info("Отправка запроса в ЦБ РФ...")
-	rawBody, err := sendRequest(soapRequest)
+	rawBody, err := c.sendRequest(ctx, buildSOAPRequest(from, to))
 	if err != nil {
 		c.logger.WithError(err).Error("Ошибка при отправке запроса в ЦБ РФ")
-		return 0, err
+		return nil, err
 	}
-	c.logger.Debug("Ответ от ЦБ РФ успешно получен")
 
-	c.logger.Info("Анализ XML-ответа от ЦБ РФ...")
-	rate, err := parseXMLResponse(rawBody)
+	rates, err := parseKeyRates(rawBody)
 	if err != nil {
 		c.logger.WithError(err).Error("Ошибка при разборе XML-ответа от ЦБ РФ")
-		return 0, err
+		return nil, err
 	}
 
-	c.logger.WithField("key_rate", rate).Info("Ключевая ставка успешно получена")
-	return rate, nil
+	c.logger.WithField("count", len(rates)).Info("История ключевой ставки успешно получена")
+	return rates, nil
 }
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+// cbrResponse формирует ответ KeyRate в формате ЦБ из готовых строк KR
+func cbrResponse(rows ...string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+    <soap:Body>
+        <KeyRateResponse xmlns="http://web.cbr.ru/">
+            <KeyRateResult>
+                <diffgr:diffgram xmlns:msdata="urn:schemas-microsoft-com:xml-msdata" xmlns:diffgr="urn:schemas-microsoft-com:xml-diffgram-v1">
+                    <KeyRate xmlns="">` + strings.Join(rows, "") + `
+                    </KeyRate>
+                </diffgr:diffgram>
+            </KeyRateResult>
+        </KeyRateResponse>
+    </soap:Body>
+</soap:Envelope>`
+}
+
+func cbrRow(date, rate string) string {
+	return fmt.Sprintf(`
+                        <KR diffgr:id="KR1" msdata:rowOrder="0">
+                            <DT>%s</DT>
+                            <Rate>%s</Rate>
+                        </KR>`, date, rate)
+}
+
+func cbrFault(code string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+    <soap:Body>
+        <soap:Fault>
+            <soap:Code><soap:Value>soap:%s</soap:Value></soap:Code>
+            <soap:Reason><soap:Text xml:lang="ru">Ошибка сервиса</soap:Text></soap:Reason>
+        </soap:Fault>
+    </soap:Body>
+</soap:Envelope>`, code)
+}
+
+// cbrServer отвечает на запросы по очереди: i-й запрос получает responses[i],
+// все последующие - последний ответ. Возвращает сервер и счетчик запросов.
+func cbrServer(t *testing.T, responses ...func(w http.ResponseWriter)) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(responses) {
+			n = len(responses) - 1
+		}
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		responses[n](w)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func respond(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+func newTestCBRClient(url string, maxRetries int) *CBRClient {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewCBRClient(url, 5*time.Second, maxRetries, logger)
+}
+
+var (
+	cbrFrom = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cbrTo   = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+)
+
+func TestCBRClientRetriesUnavailable(t *testing.T) {
+	server, calls := cbrServer(t,
+		respond(http.StatusServiceUnavailable, "Service Unavailable"),
+		respond(http.StatusOK, cbrResponse(cbrRow("2024-03-04T00:00:00+03:00", "16.00"))),
+	)
+
+	rates, err := newTestCBRClient(server.URL, 2).GetKeyRateHistory(context.Background(), cbrFrom, cbrTo)
+	if err != nil {
+		t.Fatalf("ответ после 503 не получен: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("запросов %d, ожидалось 2", got)
+	}
+	if len(rates) != 1 || rates[0].Rate != 16 {
+		t.Fatalf("неожиданные ставки: %+v", rates)
+	}
+}
+
+func TestCBRClientDoesNotRetrySenderFault(t *testing.T) {
+	server, calls := cbrServer(t, respond(http.StatusInternalServerError, cbrFault("Sender")))
+
+	_, err := newTestCBRClient(server.URL, 2).GetKeyRateHistory(context.Background(), cbrFrom, cbrTo)
+	var fault *SOAPFault
+	if !errors.As(err, &fault) {
+		t.Fatalf("ожидалась ошибка SOAPFault, получено: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("запросов %d, ошибка в запросе не должна повторяться", got)
+	}
+}
+
+func TestCBRClientRetriesReceiverFault(t *testing.T) {
+	server, calls := cbrServer(t,
+		respond(http.StatusInternalServerError, cbrFault("Receiver")),
+		respond(http.StatusOK, cbrResponse(cbrRow("2024-03-04T00:00:00+03:00", "16.00"))),
+	)
+
+	if _, err := newTestCBRClient(server.URL, 2).GetKeyRateHistory(context.Background(), cbrFrom, cbrTo); err != nil {
+		t.Fatalf("ответ после Receiver Fault не получен: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("запросов %d, ожидалось 2", got)
+	}
+}
+
+func TestCBRClientRetriesExhausted(t *testing.T) {
+	server, calls := cbrServer(t, respond(http.StatusInternalServerError, cbrFault("Receiver")))
+
+	_, err := newTestCBRClient(server.URL, 1).GetKeyRateHistory(context.Background(), cbrFrom, cbrTo)
+	var fault *SOAPFault
+	if !errors.As(err, &fault) {
+		t.Fatalf("ожидалась последняя ошибка SOAPFault, получено: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("запросов %d, ожидалось 2", got)
+	}
+}
+
+func TestParseKeyRates(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []model.KeyRate
+		wantErr bool
+	}{
+		{
+			name: "несколько дней",
+			body: cbrResponse(
+				cbrRow("2024-03-04T00:00:00+03:00", "16.00"),
+				cbrRow("2024-03-01T00:00:00+03:00", "16.00"),
+				cbrRow("2023-12-15T00:00:00", "15.5"),
+			),
+			want: []model.KeyRate{
+				{Date: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), Rate: 16},
+				{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Rate: 16},
+				{Date: time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), Rate: 15.5},
+			},
+		},
+		{
+			name: "выходные без данных",
+			body: cbrResponse(),
+			want: []model.KeyRate{},
+		},
+		{
+			name: "строка без даты",
+			body: cbrResponse(`
+                        <KR diffgr:id="KR1" msdata:rowOrder="0">
+                            <Rate>16.00</Rate>
+                        </KR>`),
+			wantErr: true,
+		},
+		{
+			name:    "строка без ставки",
+			body:    cbrResponse(`<KR><DT>2024-03-04T00:00:00+03:00</DT></KR>`),
+			wantErr: true,
+		},
+		{
+			name:    "неверная дата",
+			body:    cbrResponse(cbrRow("04.03.2024", "16.00")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeyRates([]byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получено: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ошибка разбора: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("получено ставок %d, ожидалось %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if !got[i].Date.Equal(tt.want[i].Date) || got[i].Rate != tt.want[i].Rate {
+					t.Errorf("ставка %d: получено %+v, ожидалось %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+// collectionReminderDays - дни просрочки, на которых заемщику отправляется напоминание
+var collectionReminderDays = []int{1, 7, 30}
+
+// collectionDefaultDays - через сколько дней просрочки кредит переводится в статус defaulted
+const collectionDefaultDays = 90
+
+type CollectionService struct {
+	collectionRepo  *repository.CollectionRepository
+	creditRepo      *repository.CreditRepository
+	accountRepo     *repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+	outboxRepo      *repository.OutboxRepository
+	webhooks        *WebhookService
+	events          *EventPublisher
+	logger          *logrus.Logger
+}
+
+func NewCollectionService(
+	collectionRepo *repository.CollectionRepository,
+	creditRepo *repository.CreditRepository,
+	accountRepo *repository.AccountRepository,
+	transactionRepo *repository.TransactionRepository,
+	outboxRepo *repository.OutboxRepository,
+	webhooks *WebhookService,
+	events *EventPublisher,
+	logger *logrus.Logger,
+) *CollectionService {
+	return &CollectionService{
+		collectionRepo:  collectionRepo,
+		creditRepo:      creditRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		outboxRepo:      outboxRepo,
+		webhooks:        webhooks,
+		events:          events,
+		logger:          logger,
+	}
+}
+
+// ProcessCollections - ежедневная обработка просроченной задолженности: открытие дел,
+// списание с других счетов заемщика, напоминания и перевод в дефолт
+func (s *CollectionService) ProcessCollections(ctx context.Context) error {
+	overdue, err := s.collectionRepo.GetOverdueCredits(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения просроченных кредитов")
+		return fmt.Errorf("ошибка получения просроченных кредитов: %w", err)
+	}
+
+	s.logger.Infof("Найдено %d кредитов с просроченной задолженностью", len(overdue))
+	withOverdue := make(map[uuid.UUID]bool, len(overdue))
+	for _, item := range overdue {
+		withOverdue[item.CreditID] = true
+		if err := s.processOverdueCredit(ctx, item); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка обработки просрочки по кредиту %s", item.CreditID)
+		}
+	}
+
+	// Дела, по которым задолженность погашена самим заемщиком
+	openCases, err := s.collectionRepo.ListCases(ctx, "open")
+	if err != nil {
+		return fmt.Errorf("ошибка получения открытых дел: %w", err)
+	}
+	for i := range openCases {
+		if withOverdue[openCases[i].CreditID] {
+			continue
+		}
+		if err := s.resolveCase(ctx, &openCases[i], "Просроченная задолженность погашена"); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка закрытия дела %s", openCases[i].ID)
+		}
+	}
+
+	return nil
+}
+
+func (s *CollectionService) processOverdueCredit(ctx context.Context, item model.OverdueCredit) error {
+	credit, err := s.creditRepo.GetCreditByID(ctx, item.CreditID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.Status == "defaulted" {
+		// Дефолтные кредиты сопровождаются сотрудниками вручную
+		return nil
+	}
+
+	c, err := s.openCase(ctx, credit, item)
+	if err != nil {
+		return err
+	}
+
+	if credit.AutoSweepAllowed {
+		cleared, err := s.sweep(ctx, credit, c)
+		if err != nil {
+			s.logger.WithError(err).Errorf("Ошибка списания просроченной задолженности по кредиту %s", credit.ID)
+		} else if cleared {
+			return s.resolveCase(ctx, c, "Просроченная задолженность списана со счетов заемщика")
+		}
+	}
+
+	daysPastDue := int(time.Since(c.OverdueSince).Hours() / 24)
+	if daysPastDue >= collectionDefaultDays {
+		return s.defaultCase(ctx, credit, c, daysPastDue)
+	}
+
+	stage := 0
+	for _, day := range collectionReminderDays {
+		if daysPastDue >= day {
+			stage = day
+		}
+	}
+	if stage > c.LastReminderDay {
+		return s.sendReminder(ctx, credit, c, stage, daysPastDue)
+	}
+
+	return nil
+}
+
+// openCase возвращает открытое дело по кредиту, создавая его при первой просрочке.
+// Дело, запись о его открытии и статус кредита overdue сохраняются в одной транзакции.
+func (s *CollectionService) openCase(ctx context.Context, credit *model.Credit, item model.OverdueCredit) (*model.CollectionCase, error) {
+	c, err := s.collectionRepo.GetOpenCaseByCreditID(ctx, credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения дела: %w", err)
+	}
+
+	if c != nil {
+		c.OverdueSince = item.OverdueSince
+		c.OverdueAmount = roundMoney(item.OverdueAmount)
+		if err := s.collectionRepo.UpdateCase(ctx, c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	now := time.Now()
+	c = &model.CollectionCase{
+		ID:            uuid.New(),
+		CreditID:      credit.ID,
+		UserID:        credit.UserID,
+		Status:        "open",
+		OverdueSince:  item.OverdueSince,
+		OverdueAmount: roundMoney(item.OverdueAmount),
+		OpenedAt:      now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	tx, err := s.collectionRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.collectionRepo.CreateCaseTx(ctx, tx, c); err != nil {
+		return nil, err
+	}
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    c.ID,
+		EventType: "opened",
+		Message:   fmt.Sprintf("Открыто дело: просрочено %.2f RUB", c.OverdueAmount),
+		CreatedAt: now,
+	}
+	if err := s.collectionRepo.AddEventTx(ctx, tx, event); err != nil {
+		return nil, err
+	}
+	if err := s.creditRepo.UpdateCreditStatusTx(ctx, tx, credit.ID, "overdue"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Открыто дело %s по кредиту %s", c.ID, credit.ID)
+	return c, nil
+}
+
+// sweep списывает просроченные платежи (с учетом штрафа) со счетов заемщика:
+// сначала со счета кредита, затем с остальных. Платежи гасятся по порядку, начиная с самого старого.
+// Возвращает true, если просроченных платежей не осталось.
+func (s *CollectionService) sweep(ctx context.Context, credit *model.Credit, c *model.CollectionCase) (bool, error) {
+	accounts, err := s.accountRepo.GetUserAccounts(ctx, credit.UserID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка получения счетов: %w", err)
+	}
+
+	tx, err := s.creditRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	payments, err := s.creditRepo.GetOverduePaymentsForUpdate(ctx, tx, credit.ID)
+	if err != nil {
+		return false, err
+	}
+	if len(payments) == 0 {
+		return true, nil
+	}
+
+	// Блокируем счета в порядке ID, чтобы не получить взаимную блокировку с переводами
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID.String() < accounts[j].ID.String() })
+	var locked []*model.Account
+	for _, a := range accounts {
+		account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, a.ID)
+		if err != nil {
+			return false, fmt.Errorf("ошибка получения счета: %w", err)
+		}
+		// Заблокированные сотрудником счета в списании не участвуют
+		if account.IsFrozen() {
+			continue
+		}
+		locked = append(locked, account)
+	}
+	sort.SliceStable(locked, func(i, j int) bool { return locked[i].ID == credit.AccountID && locked[j].ID != credit.AccountID })
+
+	var available float64
+	for _, a := range locked {
+		if a.Balance > 0 {
+			available += a.Balance
+		}
+	}
+
+	now := time.Now()
+	var swept, principalCleared float64
+	var transactions []*model.Transaction
+	paid := 0
+	for _, payment := range payments {
+		due := roundMoney(payment.Amount + calculatePenalty(credit, payment.Amount))
+		if available < due {
+			break
+		}
+
+		remaining := due
+		for _, account := range locked {
+			if remaining <= 0 {
+				break
+			}
+			take := roundMoney(math.Min(account.Balance, remaining))
+			if take <= 0 {
+				continue
+			}
+			if err := s.accountRepo.UpdateBalanceTx(ctx, tx, account.ID, -take); err != nil {
+				return false, fmt.Errorf("ошибка списания средств: %w", err)
+			}
+			transaction := &model.Transaction{
+				ID:              uuid.New(),
+				AccountID:       account.ID,
+				Amount:          take,
+				TransactionType: model.TransactionTypeCollectionSweep,
+				ReferenceID:     &payment.ID,
+				CreatedAt:       now,
+			}
+			if err := s.transactionRepo.CreateTx(ctx, tx, transaction); err != nil {
+				return false, fmt.Errorf("ошибка записи транзакции: %w", err)
+			}
+			transactions = append(transactions, transaction)
+			account.Balance -= take
+			remaining = roundMoney(remaining - take)
+		}
+
+		if err := s.creditRepo.UpdatePaymentStatusTx(ctx, tx, payment.ID, "paid", &now); err != nil {
+			return false, err
+		}
+		available -= due
+		swept += due
+		principalCleared += payment.Amount
+		paid++
+	}
+
+	if paid == 0 {
+		return false, nil
+	}
+
+	c.SweptAmount = roundMoney(c.SweptAmount + swept)
+	c.OverdueAmount = roundMoney(c.OverdueAmount - principalCleared)
+	if err := s.collectionRepo.UpdateCaseTx(ctx, tx, c); err != nil {
+		return false, err
+	}
+
+	amount := roundMoney(swept)
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    c.ID,
+		EventType: "sweep",
+		Message:   fmt.Sprintf("Списано со счетов заемщика: погашено платежей %d из %d (включая штрафы)", paid, len(payments)),
+		Amount:    &amount,
+		CreatedAt: now,
+	}
+	if err := s.collectionRepo.AddEventTx(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	s.events.Transactions(ctx, transactions...)
+
+	s.logger.WithFields(logrus.Fields{
+		"credit_id": credit.ID,
+		"case_id":   c.ID,
+		"paid":      paid,
+		"amount":    amount,
+	}).Info("Просроченная задолженность списана со счетов заемщика")
+	return paid == len(payments), nil
+}
+
+// sendReminder отмечает этап напоминания и записывает уведомление заемщику в outbox
+// в одной транзакции: напоминание этапа отправляется ровно один раз
+func (s *CollectionService) sendReminder(ctx context.Context, credit *model.Credit, c *model.CollectionCase, stage, daysPastDue int) error {
+	tx, err := s.collectionRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	c.LastReminderDay = stage
+	if err := s.collectionRepo.UpdateCaseTx(ctx, tx, c); err != nil {
+		return err
+	}
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    c.ID,
+		EventType: "reminder",
+		Message:   fmt.Sprintf("Напоминание о просрочке (%d дн.)", daysPastDue),
+		CreatedAt: time.Now(),
+	}
+	if err := s.collectionRepo.AddEventTx(ctx, tx, event); err != nil {
+		return err
+	}
+
+	reminder, err := newOutboxEvent(model.OutboxCollectionReminder, event.ID, model.CollectionReminderEvent{
+		UserID:        credit.UserID,
+		CreditID:      credit.ID,
+		CaseID:        c.ID,
+		OverdueAmount: c.OverdueAmount,
+		DaysPastDue:   daysPastDue,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, reminder); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.publishOverdue(ctx, model.WebhookCreditPaymentOverdue, event.ID, credit, c, daysPastDue)
+
+	s.logger.Infof("Отправлено напоминание по кредиту %s: просрочка %d дн.", credit.ID, daysPastDue)
+	return nil
+}
+
+func (s *CollectionService) defaultCase(ctx context.Context, credit *model.Credit, c *model.CollectionCase, daysPastDue int) error {
+	tx, err := s.collectionRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	c.Status = "defaulted"
+	c.ClosedAt = &now
+	if err := s.collectionRepo.UpdateCaseTx(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := s.creditRepo.UpdateCreditStatusTx(ctx, tx, credit.ID, "defaulted"); err != nil {
+		return err
+	}
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    c.ID,
+		EventType: "defaulted",
+		Message:   fmt.Sprintf("Кредит переведен в дефолт: просрочка %d дн.", daysPastDue),
+		Amount:    &c.OverdueAmount,
+		CreatedAt: now,
+	}
+	if err := s.collectionRepo.AddEventTx(ctx, tx, event); err != nil {
+		return err
+	}
+
+	// Уведомление заемщику записывается в той же транзакции
+	notice, err := newOutboxEvent(model.OutboxCreditDefaulted, event.ID, model.CreditDefaultedEvent{
+		UserID:        credit.UserID,
+		CreditID:      credit.ID,
+		CaseID:        c.ID,
+		OverdueAmount: c.OverdueAmount,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, notice); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.publishOverdue(ctx, model.WebhookCreditDefaulted, event.ID, credit, c, daysPastDue)
+
+	s.logger.Warnf("Кредит %s переведен в дефолт (просрочка %d дн.)", credit.ID, daysPastDue)
+	return nil
+}
+
+// publishOverdue ставит в очередь webhook о просрочке. Ошибка публикации не прерывает обработку дела.
+func (s *CollectionService) publishOverdue(ctx context.Context, eventType string, eventID uuid.UUID, credit *model.Credit, c *model.CollectionCase, daysPastDue int) {
+	data := model.CreditOverdueWebhookData{
+		UserID:        credit.UserID,
+		CreditID:      credit.ID,
+		CaseID:        c.ID,
+		OverdueAmount: c.OverdueAmount,
+		DaysPastDue:   daysPastDue,
+	}
+	if err := s.webhooks.Publish(ctx, credit.UserID, eventID, eventType, time.Now(), data); err != nil {
+		s.logger.WithError(err).WithField("credit_id", credit.ID).Warn("Не удалось опубликовать webhook")
+	}
+}
+
+// resolveCase закрывает дело и возвращает кредиту статус active или paid
+func (s *CollectionService) resolveCase(ctx context.Context, c *model.CollectionCase, message string) error {
+	schedule, err := s.creditRepo.GetPaymentSchedule(ctx, c.CreditID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения графика платежей: %w", err)
+	}
+	creditStatus := "paid"
+	for _, p := range schedule {
+		if p.Status == "pending" || p.Status == "overdue" {
+			creditStatus = "active"
+			break
+		}
+	}
+
+	tx, err := s.collectionRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	c.Status = "resolved"
+	c.OverdueAmount = 0
+	c.ClosedAt = &now
+	if err := s.collectionRepo.UpdateCaseTx(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := s.creditRepo.UpdateCreditStatusTx(ctx, tx, c.CreditID, creditStatus); err != nil {
+		return err
+	}
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    c.ID,
+		EventType: "resolved",
+		Message:   message,
+		CreatedAt: now,
+	}
+	if err := s.collectionRepo.AddEventTx(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Дело %s по кредиту %s закрыто", c.ID, c.CreditID)
+	return nil
+}
+
+func (s *CollectionService) addEvent(ctx context.Context, caseID uuid.UUID, eventType, message string, amount *float64, authorID *uuid.UUID) {
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    caseID,
+		EventType: eventType,
+		Message:   message,
+		Amount:    amount,
+		AuthorID:  authorID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.collectionRepo.AddEvent(ctx, event); err != nil {
+		s.logger.WithError(err).Errorf("Ошибка записи в журнал дела %s", caseID)
+	}
+}
+
+// ListCases возвращает дела по статусу (по умолчанию открытые)
+func (s *CollectionService) ListCases(ctx context.Context, status string) ([]model.CollectionCase, error) {
+	if status == "" {
+		status = "open"
+	}
+
+	cases, err := s.collectionRepo.ListCases(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения дел: %w", err)
+	}
+	return cases, nil
+}
+
+// GetCase возвращает дело вместе с журналом
+func (s *CollectionService) GetCase(ctx context.Context, caseID uuid.UUID) (*model.CollectionCaseDetails, error) {
+	c, err := s.collectionRepo.GetCaseByID(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения дела: %w", err)
+	}
+
+	events, err := s.collectionRepo.GetEvents(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения журнала дела: %w", err)
+	}
+
+	return &model.CollectionCaseDetails{Case: *c, Events: events}, nil
+}
+
+// AddNote добавляет в журнал дела заметку сотрудника
+func (s *CollectionService) AddNote(ctx context.Context, caseID, authorID uuid.UUID, message string) (*model.CollectionEvent, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("текст заметки не может быть пустым")
+	}
+
+	if _, err := s.collectionRepo.GetCaseByID(ctx, caseID); err != nil {
+		return nil, fmt.Errorf("ошибка получения дела: %w", err)
+	}
+
+	event := &model.CollectionEvent{
+		ID:        uuid.New(),
+		CaseID:    caseID,
+		EventType: "note",
+		Message:   message,
+		AuthorID:  &authorID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.collectionRepo.AddEvent(ctx, event); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения заметки: %w", err)
+	}
+
+	s.logger.Infof("Сотрудник %s добавил заметку к делу %s", authorID, caseID)
+	return event, nil
+}
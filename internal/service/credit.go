@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -13,31 +15,43 @@ import (
 	"banking-api/internal/repository"
 )
 
+// keyRateBackfillDays - глубина загрузки истории ключевой ставки при первой синхронизации
+const keyRateBackfillDays = 365
+
+// errPaymentLocked - платеж уже обрабатывается другой транзакцией или уже оплачен
+var errPaymentLocked = errors.New("платеж уже обрабатывается или оплачен")
+
 type CreditService struct {
-	userRepo        *repository.UserRepository
 	creditRepo      *repository.CreditRepository
+	productRepo     *repository.CreditProductRepository
+	keyRateRepo     *repository.KeyRateRepository
 	accountRepo     *repository.AccountRepository
 	transactionRepo *repository.TransactionRepository
-	emailSender     *EmailSender
+	outboxRepo      *repository.OutboxRepository
+	events          *EventPublisher
 	cbrClient       *CBRClient
 	logger          *logrus.Logger
 }
 
 func NewCreditService(
-	userRepo *repository.UserRepository,
 	creditRepo *repository.CreditRepository,
+	productRepo *repository.CreditProductRepository,
+	keyRateRepo *repository.KeyRateRepository,
 	accountRepo *repository.AccountRepository,
 	transactionRepo *repository.TransactionRepository,
-	emailSender *EmailSender,
+	outboxRepo *repository.OutboxRepository,
+	events *EventPublisher,
 	cbrClient *CBRClient,
 	logger *logrus.Logger,
 ) *CreditService {
 	return &CreditService{
-		userRepo:        userRepo,
 		creditRepo:      creditRepo,
+		productRepo:     productRepo,
+		keyRateRepo:     keyRateRepo,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
-		emailSender:     emailSender,
+		outboxRepo:      outboxRepo,
+		events:          events,
 		cbrClient:       cbrClient,
 		logger:          logger,
 	}
@@ -52,8 +66,26 @@ func (s *CreditService) CalculateMonthlyPayment(amount float64, termMonths int,
 }
 
 func (s *CreditService) CreateCredit(ctx context.Context, req model.CreateCreditRequest, userID uuid.UUID) (*model.Credit, error) {
-	s.logger.Infof("Создание кредита для пользователя %s, сумма: %.2f, срок: %d мес.",
-		userID, req.Amount, req.TermMonths)
+	s.logger.Infof("Создание кредита для пользователя %s, продукт: %s, сумма: %.2f, срок: %d мес.",
+		userID, req.ProductID, req.Amount, req.TermMonths)
+
+	// Проверяем параметры кредита по условиям продукта
+	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Ошибка получения кредитного продукта %s", req.ProductID)
+		return nil, fmt.Errorf("ошибка получения кредитного продукта: %w", err)
+	}
+	if !product.Active {
+		return nil, fmt.Errorf("кредитный продукт недоступен для оформления")
+	}
+	if req.Amount < product.MinAmount || req.Amount > product.MaxAmount {
+		return nil, fmt.Errorf("сумма кредита по продукту «%s» должна быть от %.2f до %.2f",
+			product.Name, product.MinAmount, product.MaxAmount)
+	}
+	if req.TermMonths < product.MinTermMonths || req.TermMonths > product.MaxTermMonths {
+		return nil, fmt.Errorf("срок кредита по продукту «%s» должен быть от %d до %d мес.",
+			product.Name, product.MinTermMonths, product.MaxTermMonths)
+	}
 
 	// Получаем счет и проверяем владельца
 	account, err := s.accountRepo.GetByID(ctx, req.AccountID)
@@ -67,40 +99,47 @@ func (s *CreditService) CreateCredit(ctx context.Context, req model.CreateCredit
 			userID, account.UserID)
 		return nil, fmt.Errorf("счет не принадлежит пользователю")
 	}
+	if account.IsFrozen() {
+		return nil, errAccountFrozen
+	}
 
-	// Получаем текущую ставку ЦБ
-	rate, err := s.cbrClient.GetCentralBankRate()
+	interestRate, err := s.productRate(ctx, product)
 	if err != nil {
-		s.logger.WithError(err).Warn("Не удалось получить ставку ЦБ, используется значение по умолчанию")
-		rate = 22.0 // дефолтная ставка, если ЦБ недоступен
+		return nil, err
 	}
+	s.logger.Infof("Рассчитанная ставка по кредиту: %.2f%% (продукт: %s, тип ставки: %s, маржа: %.2f%%)",
+		interestRate, product.Name, product.RateType, product.RateMargin)
 
-	// Добавляем маржу к ключевой ставке
-	interestRate := rate + 5.0 // маржа 5%
-	s.logger.Infof("Рассчитанная ставка по кредиту: %.2f%% (ставка ЦБ: %.2f%%, маржа: 5%%)",
-		interestRate, rate)
-
-	// Рассчитываем ежемесячный платеж
-	monthlyPayment := s.CalculateMonthlyPayment(req.Amount, req.TermMonths, interestRate)
-	s.logger.Infof("Ежемесячный платеж: %.2f, сумма кредита: %.2f, срок: %d мес.",
-		monthlyPayment, req.Amount, req.TermMonths)
+	// Рассчитываем график и ежемесячный платеж (для дифференцированного графика - первый платеж)
+	rows := s.buildSchedule(product.ScheduleType, req.Amount, interestRate, req.TermMonths)
+	monthlyPayment := rows[0].Amount
+	s.logger.Infof("Ежемесячный платеж: %.2f, сумма кредита: %.2f, срок: %d мес., график: %s",
+		monthlyPayment, req.Amount, req.TermMonths, product.ScheduleType)
 
 	now := time.Now()
 	endDate := now.AddDate(0, req.TermMonths, 0)
 
 	credit := &model.Credit{
-		ID:             uuid.New(),
-		AccountID:      req.AccountID,
-		UserID:         userID,
-		Amount:         req.Amount,
-		InterestRate:   interestRate,
-		TermMonths:     req.TermMonths,
-		MonthlyPayment: monthlyPayment,
-		StartDate:      now,
-		EndDate:        endDate,
-		Status:         "active",
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		ID:               uuid.New(),
+		AccountID:        req.AccountID,
+		UserID:           userID,
+		Amount:           req.Amount,
+		InterestRate:     interestRate,
+		TermMonths:       req.TermMonths,
+		MonthlyPayment:   monthlyPayment,
+		StartDate:        now,
+		EndDate:          endDate,
+		Status:           "active",
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		RateType:         product.RateType,
+		RateMargin:       product.RateMargin,
+		ProductID:        &product.ID,
+		PenaltyType:      product.PenaltyType,
+		PenaltyValue:     product.PenaltyValue,
+		ScheduleType:     product.ScheduleType,
+		ScheduleVersion:  1,
+		AutoSweepAllowed: req.AutoSweepConsent,
 	}
 
 	// Начинаем транзакцию
@@ -119,13 +158,13 @@ func (s *CreditService) CreateCredit(ctx context.Context, req model.CreateCredit
 	}
 
 	// Создаем запись о кредите
-	if err := s.creditRepo.CreateCredit(ctx, credit); err != nil {
+	if err := s.creditRepo.CreateCreditTx(ctx, tx, credit); err != nil {
 		s.logger.WithError(err).Error("Ошибка создания записи о кредите")
 		return nil, fmt.Errorf("ошибка создания кредита: %w", err)
 	}
 
 	// Генерируем график платежей
-	if err := s.generatePaymentSchedule(ctx, credit); err != nil {
+	if err := s.generatePaymentSchedule(ctx, tx, credit, rows); err != nil {
 		s.logger.WithError(err).Error("Ошибка генерации графика платежей")
 		return nil, fmt.Errorf("ошибка создания графика платежей: %w", err)
 	}
@@ -151,53 +190,70 @@ func (s *CreditService) CreateCredit(ctx context.Context, req model.CreateCredit
 		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
 		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
 	}
+	s.events.Transactions(ctx, transaction)
 
 	s.logger.Infof("Кредит %s успешно создан для пользователя %s", credit.ID, userID)
 	return credit, nil
 }
 
-func (s *CreditService) generatePaymentSchedule(ctx context.Context, credit *model.Credit) error {
+func (s *CreditService) generatePaymentSchedule(ctx context.Context, tx *sql.Tx, credit *model.Credit, rows []scheduleRow) error {
 	s.logger.Infof("Генерация графика платежей для кредита %s", credit.ID)
-	remainingPrincipal := credit.Amount
-	monthlyRate := credit.InterestRate / 12 / 100
-
-	for i := 1; i <= credit.TermMonths; i++ {
-		interest := remainingPrincipal * monthlyRate
-		principal := credit.MonthlyPayment - interest
-		if i == credit.TermMonths {
-			// Корректировка последнего платежа для устранения погрешностей округления
-			principal = remainingPrincipal
-		}
 
-		paymentDate := credit.StartDate.AddDate(0, i, 0)
+	for i, row := range rows {
+		paymentDate := credit.StartDate.AddDate(0, i+1, 0)
 		now := time.Now()
 
 		schedule := &model.PaymentSchedule{
 			ID:            uuid.New(),
 			CreditID:      credit.ID,
-			PaymentNumber: i,
+			PaymentNumber: i + 1,
 			PaymentDate:   paymentDate,
-			Amount:        credit.MonthlyPayment,
-			Principal:     principal,
-			Interest:      interest,
+			Amount:        row.Amount,
+			Principal:     row.Principal,
+			Interest:      row.Interest,
 			Status:        "pending",
 			CreatedAt:     now,
 			UpdatedAt:     now,
+			Version:       credit.ScheduleVersion,
 		}
 
-		if err := s.creditRepo.CreatePaymentSchedule(ctx, schedule); err != nil {
-			s.logger.WithError(err).Errorf("Ошибка создания записи о платеже №%d", i)
+		if err := s.creditRepo.CreatePaymentScheduleTx(ctx, tx, schedule); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка создания записи о платеже №%d", i+1)
 			return fmt.Errorf("ошибка создания платежа: %w", err)
 		}
-
-		remainingPrincipal -= principal
 	}
 
 	s.logger.Infof("График платежей для кредита %s успешно сгенерирован (%d платежей)",
-		credit.ID, credit.TermMonths)
+		credit.ID, len(rows))
 	return nil
 }
 
+// productRate возвращает ставку по продукту на текущую дату
+func (s *CreditService) productRate(ctx context.Context, product *model.CreditProduct) (float64, error) {
+	if product.FixedRate != nil {
+		return *product.FixedRate, nil
+	}
+
+	keyRate, err := effectiveKeyRate(ctx, s.keyRateRepo, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return roundMoney(keyRate + product.RateMargin), nil
+}
+
+// effectiveKeyRate возвращает ключевую ставку ЦБ, действующую на дату, из сохраненной истории.
+// История пополняется ежедневной синхронизацией; без нее выдача кредита невозможна.
+func effectiveKeyRate(ctx context.Context, keyRateRepo *repository.KeyRateRepository, date time.Time) (float64, error) {
+	rate, err := keyRateRepo.GetEffective(ctx, date)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения ключевой ставки: %w", err)
+	}
+	if rate == nil {
+		return 0, fmt.Errorf("ключевая ставка ЦБ недоступна, попробуйте позже")
+	}
+	return rate.Rate, nil
+}
+
 func (s *CreditService) GetUserCredits(ctx context.Context, userID uuid.UUID) ([]model.Credit, error) {
 	s.logger.Infof("Получение списка кредитов пользователя %s", userID)
 	credits, err := s.creditRepo.GetUserCredits(ctx, userID)
@@ -233,34 +289,49 @@ func (s *CreditService) GetPaymentSchedule(ctx context.Context, creditID uuid.UU
 	return schedule, nil
 }
 
-func (s *CreditService) ProcessPayments(ctx context.Context) error {
+// ProcessPayments списывает наступившие платежи. Каждый платеж обрабатывается в отдельной
+// транзакции, поэтому падение посреди запуска не оставляет частично обработанных платежей,
+// а повторный запуск продолжает с того же места. При отмене контекста обработка прекращается.
+func (s *CreditService) ProcessPayments(ctx context.Context) (model.JobStats, error) {
+	var stats model.JobStats
+
 	s.logger.Info("Автоматическая обработка платежей по кредитам")
 	pendingPayments, err := s.creditRepo.GetPendingPayments(ctx, time.Now())
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка получения ожидающих платежей")
-		return fmt.Errorf("ошибка получения платежей: %w", err)
+		return stats, fmt.Errorf("ошибка получения платежей: %w", err)
 	}
 
 	s.logger.Infof("Найдено %d платежей для обработки", len(pendingPayments))
 	for _, payment := range pendingPayments {
-		if err := s.processPayment(ctx, payment); err != nil {
+		if err := ctx.Err(); err != nil {
+			s.logger.Warnf("Обработка платежей прервана, обработано %d из %d", stats.Processed, len(pendingPayments))
+			return stats, err
+		}
+
+		stats.Processed++
+		if err := s.processPayment(ctx, payment.ID, true); err != nil {
+			if errors.Is(err, errPaymentLocked) {
+				stats.Skipped++
+				continue
+			}
+			stats.Failed++
 			s.logger.WithError(err).Errorf("Ошибка обработки платежа %s", payment.ID)
 			continue
 		}
+		stats.Succeeded++
 	}
 
-	return nil
+	return stats, nil
 }
 
-func (s *CreditService) processPayment(ctx context.Context, payment model.PaymentSchedule) error {
-	s.logger.Infof("Обработка платежа %s по кредиту %s", payment.ID, payment.CreditID)
-
-	credit, err := s.creditRepo.GetCreditByID(ctx, payment.CreditID)
-	if err != nil {
-		return fmt.Errorf("ошибка получения кредита: %w", err)
-	}
+// processPayment списывает платеж со счета кредита. Все изменения выполняются в одной
+// транзакции, а сам платеж захватывается через SKIP LOCKED, поэтому два экземпляра
+// не могут списать один платеж дважды. Если средств недостаточно, при markOverdue платеж
+// помечается просроченным; деньги при этом не двигаются, и транзакция не записывается.
+func (s *CreditService) processPayment(ctx context.Context, paymentID uuid.UUID, markOverdue bool) error {
+	s.logger.Infof("Обработка платежа %s", paymentID)
 
-	// Начинаем транзакцию ДО получения счета
 	db := s.creditRepo.GetDB()
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -268,67 +339,88 @@ func (s *CreditService) processPayment(ctx context.Context, payment model.Paymen
 	}
 	defer tx.Rollback()
 
+	payment, err := s.creditRepo.ClaimPaymentTx(ctx, tx, paymentID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения платежа: %w", err)
+	}
+	if payment == nil {
+		s.logger.Infof("Платеж %s уже обрабатывается или оплачен, пропускаем", paymentID)
+		return errPaymentLocked
+	}
+
+	credit, err := s.creditRepo.GetCreditByIDForUpdate(ctx, tx, payment.CreditID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+
 	// Получаем счет ВНУТРИ транзакции с блокировкой
 	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, credit.AccountID)
 	if err != nil {
 		return fmt.Errorf("ошибка получения счета: %w", err)
 	}
 
-	var status string
-	var paidAt *time.Time
-	var penalty float64
+	// Списание с заблокированного счета невозможно, платеж просрочивается как при нехватке средств
+	if account.Balance < payment.Amount || account.IsFrozen() {
+		if !markOverdue {
+			if account.IsFrozen() {
+				return errAccountFrozen
+			}
+			return fmt.Errorf("недостаточно средств на счете")
+		}
+		if payment.Status == "overdue" {
+			return nil
+		}
 
-	if account.Balance >= payment.Amount {
-		if err := s.accountRepo.UpdateBalanceTx(ctx, tx, account.ID, -payment.Amount); err != nil {
-			return fmt.Errorf("ошибка списания средств: %w", err)
+		// Штраф начисляется при взыскании просрочки, здесь только меняется статус
+		if err := s.creditRepo.UpdatePaymentStatusTx(ctx, tx, payment.ID, "overdue", nil); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка обновления статуса платежа %s", payment.ID)
+			return fmt.Errorf("ошибка обновления платежа: %w", err)
 		}
-		status = "paid"
-		now := time.Now()
-		paidAt = &now
-	} else {
-		penalty = payment.Amount * 0.1
-		status = "overdue"
+		if err := tx.Commit(); err != nil {
+			s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
+			return fmt.Errorf("ошибка подтверждения операции: %w", err)
+		}
+
+		s.events.CreditPayment(ctx, credit.UserID, model.CreditPaymentOutcome{
+			CreditID:  credit.ID,
+			PaymentID: payment.ID,
+			Amount:    payment.Amount,
+			Status:    "overdue",
+		})
+
+		s.logger.Warnf("Недостаточно средств для платежа %s по кредиту %s, платеж просрочен", payment.ID, credit.ID)
+		return nil
+	}
+
+	if err := s.accountRepo.UpdateBalanceTx(ctx, tx, account.ID, -payment.Amount); err != nil {
+		return fmt.Errorf("ошибка списания средств: %w", err)
 	}
 
-	// Обновляем статус платежа
-	if err := s.creditRepo.UpdatePaymentStatus(ctx, payment.ID, status, paidAt); err != nil {
+	now := time.Now()
+	if err := s.creditRepo.UpdatePaymentStatusTx(ctx, tx, payment.ID, "paid", &now); err != nil {
 		s.logger.WithError(err).Errorf("Ошибка обновления статуса платежа %s", payment.ID)
 		return fmt.Errorf("ошибка обновления платежа: %w", err)
 	}
 
-	// Если платеж успешен, проверяем полностью ли погашен кредит
-	if status == "paid" {
-		remainingPayments, err := s.creditRepo.GetPaymentSchedule(ctx, credit.ID)
-		if err != nil {
-			s.logger.WithError(err).Errorf("Ошибка получения оставшихся платежей по кредиту %s", credit.ID)
-			return fmt.Errorf("ошибка получения платежей: %w", err)
-		}
-
-		allPaid := true
-		for _, p := range remainingPayments {
-			if p.Status != "paid" && p.ID != payment.ID {
-				allPaid = false
-				break
-			}
-		}
-
-		if allPaid {
-			if err := s.creditRepo.UpdateCreditStatus(ctx, credit.ID, "paid"); err != nil {
-				s.logger.WithError(err).Errorf("Ошибка обновления статуса кредита %s", credit.ID)
-				return fmt.Errorf("ошибка обновления кредита: %w", err)
-			}
-			s.logger.Infof("Кредит %s полностью погашен", credit.ID)
+	// Проверяем, полностью ли погашен кредит
+	unpaid, err := s.creditRepo.CountUnpaidPaymentsTx(ctx, tx, credit.ID)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Ошибка получения оставшихся платежей по кредиту %s", credit.ID)
+		return fmt.Errorf("ошибка получения платежей: %w", err)
+	}
+	if unpaid == 0 {
+		if err := s.creditRepo.UpdateCreditStatusTx(ctx, tx, credit.ID, "paid"); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка обновления статуса кредита %s", credit.ID)
+			return fmt.Errorf("ошибка обновления кредита: %w", err)
 		}
+		s.logger.Infof("Кредит %s полностью погашен", credit.ID)
 	}
 
 	// Создаем запись о транзакции
-	transactionID := uuid.New()
-	now := time.Now()
-
 	transaction := &model.Transaction{
-		ID:              transactionID,
+		ID:              uuid.New(),
 		AccountID:       account.ID,
-		Amount:          payment.Amount + penalty,
+		Amount:          payment.Amount,
 		TransactionType: model.TransactionTypeCreditPayment,
 		ReferenceID:     &payment.ID,
 		CreatedAt:       now,
@@ -339,28 +431,33 @@ func (s *CreditService) processPayment(ctx context.Context, payment model.Paymen
 		return fmt.Errorf("ошибка записи транзакции: %w", err)
 	}
 
+	// Уведомление о списании записывается в той же транзакции
+	event, err := newOutboxEvent(model.OutboxCreditPayment, payment.ID, model.CreditPaymentEvent{
+		UserID:    credit.UserID,
+		CreditID:  credit.ID,
+		PaymentID: payment.ID,
+		Amount:    payment.Amount,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		s.logger.WithError(err).Error("Ошибка записи события платежа по кредиту")
+		return fmt.Errorf("ошибка записи уведомления: %w", err)
+	}
+
 	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
 		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
 		return fmt.Errorf("ошибка подтверждения операции: %w", err)
 	}
-
-	// Отправка email уведомления
-	if status == "paid" {
-		// Получаем email пользователя
-		user, err := s.userRepo.GetByID(ctx, credit.UserID)
-		if err == nil && user.Email != "" {
-			go func() {
-				if err := s.emailSender.SendCreditPaymentNotification(
-					user.Email,
-					payment.Amount,
-					credit.ID,
-				); err != nil {
-					s.logger.WithError(err).Warn("Не удалось отправить email уведомление")
-				}
-			}()
-		}
-	}
+	s.events.Transactions(ctx, transaction)
+	s.events.CreditPayment(ctx, credit.UserID, model.CreditPaymentOutcome{
+		CreditID:  credit.ID,
+		PaymentID: payment.ID,
+		Amount:    payment.Amount,
+		Status:    "paid",
+	})
 
 	return nil
 }
@@ -373,8 +470,9 @@ func (s *CreditService) GetNextPayment(ctx context.Context, creditID uuid.UUID)
 		return nil, fmt.Errorf("ошибка получения платежей: %w", err)
 	}
 
+	// График упорядочен по номеру платежа, поэтому просроченные платежи погашаются первыми
 	for _, p := range payments {
-		if p.Status == "pending" {
+		if p.Status == "pending" || p.Status == "overdue" {
 			s.logger.Infof("Найден ожидающий платеж %s по кредиту %s", p.ID, creditID)
 			return &p, nil
 		}
@@ -397,8 +495,14 @@ func (s *CreditService) ProcessPayment(ctx context.Context, paymentID uuid.UUID,
 		return fmt.Errorf("сумма платежа меньше требуемой")
 	}
 
-	// Используем логику из шедулера
-	return s.processPayment(ctx, *payment)
+	// Используем логику из шедулера; при нехватке средств платеж не помечается просроченным
+	if err := s.processPayment(ctx, payment.ID, false); err != nil {
+		if errors.Is(err, errPaymentLocked) {
+			return fmt.Errorf("платеж уже обрабатывается, попробуйте позже")
+		}
+		return err
+	}
+	return nil
 }
 
 // GetCreditByID возвращает кредит по ID с проверкой принадлежности пользователю
@@ -411,3 +515,418 @@ func (s *CreditService) GetCreditByID(ctx context.Context, creditID uuid.UUID) (
 	}
 	return credit, nil
 }
+
+// SyncKeyRate загружает новые значения ключевой ставки ЦБ, сохраняет их в истории
+// и приводит ставки кредитов с плавающей ставкой к последней сохраненной ключевой.
+// Пересмотр выполняется при каждом запуске, а не только при изменении ставки: кредиты,
+// которые не удалось пересмотреть раньше, пересматриваются при следующем запуске.
+func (s *CreditService) SyncKeyRate(ctx context.Context) (model.JobStats, error) {
+	latest, err := s.keyRateRepo.GetLatest(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения сохраненной ключевой ставки")
+		return model.JobStats{}, fmt.Errorf("ошибка получения ключевой ставки: %w", err)
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -keyRateBackfillDays)
+	if latest != nil {
+		from = latest.Date.AddDate(0, 0, 1)
+	}
+
+	rates, err := s.cbrClient.GetKeyRateHistory(ctx, from, now)
+	if err != nil {
+		return model.JobStats{}, fmt.Errorf("ошибка загрузки ключевой ставки: %w", err)
+	}
+
+	for _, rate := range rates {
+		if err := s.keyRateRepo.Upsert(ctx, rate); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка сохранения ключевой ставки на %s", rate.Date.Format("2006-01-02"))
+			return model.JobStats{}, fmt.Errorf("ошибка сохранения ключевой ставки: %w", err)
+		}
+		if latest == nil || rate.Date.After(latest.Date) {
+			rate := rate
+			latest = &rate
+		}
+	}
+
+	if len(rates) == 0 {
+		s.logger.Info("Новых значений ключевой ставки нет")
+	} else {
+		s.logger.WithFields(logrus.Fields{
+			"saved":    len(rates),
+			"key_rate": latest.Rate,
+			"date":     latest.Date.Format("2006-01-02"),
+		}).Info("История ключевой ставки обновлена")
+	}
+
+	if latest == nil {
+		return model.JobStats{}, nil
+	}
+	return s.RepriceFloatingCredits(ctx, *latest)
+}
+
+// RepriceFloatingCredits пересчитывает ставку и оставшийся график платежей
+// для всех активных кредитов с плавающей ставкой. Кредиты, ставка которых уже
+// соответствует ключевой, учитываются как пропущенные.
+func (s *CreditService) RepriceFloatingCredits(ctx context.Context, keyRate model.KeyRate) (model.JobStats, error) {
+	var stats model.JobStats
+
+	credits, err := s.creditRepo.GetActiveFloatingCredits(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения кредитов с плавающей ставкой")
+		return stats, fmt.Errorf("ошибка получения кредитов: %w", err)
+	}
+
+	s.logger.Infof("Пересмотр ставки по %d кредитам с плавающей ставкой", len(credits))
+	for _, credit := range credits {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		stats.Processed++
+		changed, err := s.repriceCredit(ctx, credit, keyRate.Rate)
+		if err != nil {
+			stats.Failed++
+			s.logger.WithError(err).Errorf("Ошибка пересмотра ставки по кредиту %s", credit.ID)
+			continue
+		}
+		if !changed {
+			stats.Skipped++
+			continue
+		}
+		stats.Succeeded++
+	}
+
+	if stats.Failed > 0 {
+		return stats, fmt.Errorf("не удалось пересмотреть ставку по %d кредитам из %d", stats.Failed, stats.Processed)
+	}
+	return stats, nil
+}
+
+// repriceCredit приводит ставку кредита к ключевой ставке с маржой кредита и сообщает,
+// изменилась ли ставка
+func (s *CreditService) repriceCredit(ctx context.Context, credit model.Credit, keyRate float64) (bool, error) {
+	newRate := roundMoney(keyRate + credit.RateMargin)
+	if newRate == credit.InterestRate {
+		return false, nil
+	}
+
+	tx, err := s.creditRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Пересчитываются только платежи следующих периодов
+	now := time.Now()
+	payments, err := s.creditRepo.GetFuturePaymentsForUpdate(ctx, tx, credit.ID, now)
+	if err != nil {
+		return false, err
+	}
+	if len(payments) == 0 {
+		return false, nil
+	}
+
+	var remainingPrincipal float64
+	for _, p := range payments {
+		remainingPrincipal += p.Principal
+	}
+
+	rows := s.buildSchedule(credit.ScheduleType, remainingPrincipal, newRate, len(payments))
+	for i, p := range payments {
+		if err := s.creditRepo.UpdatePaymentAmountsTx(ctx, tx, p.ID, rows[i].Amount, rows[i].Principal, rows[i].Interest); err != nil {
+			return false, err
+		}
+	}
+
+	newPayment := rows[0].Amount
+	if err := s.creditRepo.UpdateCreditRateTx(ctx, tx, credit.ID, newRate, newPayment); err != nil {
+		return false, err
+	}
+
+	change := &model.CreditRateChange{
+		ID:            uuid.New(),
+		CreditID:      credit.ID,
+		KeyRate:       keyRate,
+		OldRate:       credit.InterestRate,
+		NewRate:       newRate,
+		OldPayment:    credit.MonthlyPayment,
+		NewPayment:    newPayment,
+		EffectiveFrom: payments[0].PaymentDate,
+		CreatedAt:     now,
+	}
+	if err := s.creditRepo.CreateRateChangeTx(ctx, tx, change); err != nil {
+		return false, err
+	}
+
+	// Уведомление о новой ставке записывается в той же транзакции
+	event, err := newOutboxEvent(model.OutboxCreditRateChanged, change.ID, model.CreditRateChangedEvent{
+		UserID:        credit.UserID,
+		CreditID:      credit.ID,
+		OldRate:       change.OldRate,
+		NewRate:       change.NewRate,
+		NewPayment:    change.NewPayment,
+		EffectiveFrom: change.EffectiveFrom,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"credit_id":   credit.ID,
+		"old_rate":    change.OldRate,
+		"new_rate":    change.NewRate,
+		"new_payment": change.NewPayment,
+		"payments":    len(payments),
+	}).Info("Ставка по кредиту пересмотрена")
+	return true, nil
+}
+
+// GetRateChanges возвращает историю пересмотра ставки по кредиту пользователя
+func (s *CreditService) GetRateChanges(ctx context.Context, creditID, userID uuid.UUID) ([]model.CreditRateChange, error) {
+	credit, err := s.creditRepo.GetCreditByID(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.UserID != userID {
+		return nil, fmt.Errorf("кредит не принадлежит пользователю")
+	}
+
+	changes, err := s.creditRepo.GetRateChanges(ctx, creditID)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Ошибка получения истории ставки по кредиту %s", creditID)
+		return nil, fmt.Errorf("ошибка получения истории ставки: %w", err)
+	}
+	return changes, nil
+}
+
+// EarlyRepayment досрочно погашает часть или весь остаток основного долга со счета кредита.
+// При частичном погашении срок сохраняется, а оставшиеся платежи уменьшаются.
+func (s *CreditService) EarlyRepayment(ctx context.Context, creditID, userID uuid.UUID, amount float64) (*model.EarlyRepaymentResult, error) {
+	s.logger.Infof("Досрочное погашение кредита %s на сумму %.2f (пользователь %s)", creditID, amount, userID)
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("сумма погашения должна быть положительной")
+	}
+
+	credit, err := s.creditRepo.GetCreditByID(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.UserID != userID {
+		return nil, fmt.Errorf("кредит не принадлежит пользователю")
+	}
+	if credit.Status != "active" {
+		return nil, fmt.Errorf("досрочное погашение доступно только для активного кредита")
+	}
+
+	// Кредиты, выданные до появления продуктов, погашаются без ограничений и комиссии
+	var feePercent float64
+	if credit.ProductID != nil {
+		product, err := s.productRepo.GetByID(ctx, *credit.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения кредитного продукта: %w", err)
+		}
+		if !product.EarlyRepaymentAllowed {
+			return nil, fmt.Errorf("условия продукта не допускают досрочное погашение")
+		}
+		allowedFrom := credit.StartDate.AddDate(0, product.EarlyRepaymentMinMonths, 0)
+		if time.Now().Before(allowedFrom) {
+			return nil, fmt.Errorf("досрочное погашение доступно с %s", allowedFrom.Format("02.01.2006"))
+		}
+		feePercent = product.EarlyRepaymentFeePercent
+	}
+
+	now := time.Now()
+	schedule, err := s.creditRepo.GetPaymentSchedule(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения графика платежей: %w", err)
+	}
+	for _, p := range schedule {
+		if p.Status == "overdue" || (p.Status == "pending" && !p.PaymentDate.After(now)) {
+			return nil, fmt.Errorf("перед досрочным погашением необходимо погасить текущую задолженность")
+		}
+	}
+
+	tx, err := s.creditRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, credit.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения счета: %w", err)
+	}
+	if account.IsFrozen() {
+		return nil, errAccountFrozen
+	}
+
+	payments, err := s.creditRepo.GetFuturePaymentsForUpdate(ctx, tx, credit.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("нет платежей для досрочного погашения")
+	}
+
+	var remainingPrincipal float64
+	for _, p := range payments {
+		remainingPrincipal += p.Principal
+	}
+	remainingPrincipal = roundMoney(remainingPrincipal)
+
+	amount = math.Min(roundMoney(amount), remainingPrincipal)
+	fee := roundMoney(amount * feePercent / 100)
+	if account.Balance < amount+fee {
+		return nil, fmt.Errorf("недостаточно средств на счете: требуется %.2f", amount+fee)
+	}
+
+	if err := s.accountRepo.UpdateBalanceTx(ctx, tx, account.ID, -(amount + fee)); err != nil {
+		return nil, fmt.Errorf("ошибка списания средств: %w", err)
+	}
+
+	result := &model.EarlyRepaymentResult{
+		CreditID:           credit.ID,
+		Amount:             amount,
+		Fee:                fee,
+		RemainingPrincipal: roundMoney(remainingPrincipal - amount),
+		CreditStatus:       credit.Status,
+	}
+
+	if result.RemainingPrincipal == 0 {
+		// Полное погашение: оставшиеся платежи закрываются без начисления процентов
+		for _, p := range payments {
+			if err := s.creditRepo.UpdatePaymentStatusTx(ctx, tx, p.ID, "prepaid", &now); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.creditRepo.UpdateCreditStatusTx(ctx, tx, credit.ID, "paid"); err != nil {
+			return nil, err
+		}
+		result.CreditStatus = "paid"
+	} else {
+		rows := s.buildSchedule(credit.ScheduleType, result.RemainingPrincipal, credit.InterestRate, len(payments))
+		for i, p := range payments {
+			if err := s.creditRepo.UpdatePaymentAmountsTx(ctx, tx, p.ID, rows[i].Amount, rows[i].Principal, rows[i].Interest); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.creditRepo.UpdateCreditRateTx(ctx, tx, credit.ID, credit.InterestRate, rows[0].Amount); err != nil {
+			return nil, err
+		}
+		result.MonthlyPayment = rows[0].Amount
+	}
+
+	transaction := &model.Transaction{
+		ID:              uuid.New(),
+		AccountID:       account.ID,
+		Amount:          amount + fee,
+		TransactionType: model.TransactionTypeCreditPayment,
+		ReferenceID:     &credit.ID,
+		CreatedAt:       now,
+	}
+	if err := s.transactionRepo.CreateTx(ctx, tx, transaction); err != nil {
+		s.logger.WithError(err).Error("Ошибка создания записи о транзакции")
+		return nil, fmt.Errorf("ошибка записи транзакции: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	s.events.Transactions(ctx, transaction)
+
+	s.logger.WithFields(logrus.Fields{
+		"credit_id":           credit.ID,
+		"amount":              amount,
+		"fee":                 fee,
+		"remaining_principal": result.RemainingPrincipal,
+		"credit_status":       result.CreditStatus,
+	}).Info("Досрочное погашение выполнено")
+	return result, nil
+}
+
+// scheduleRow - строка пересчитанного графика платежей
+type scheduleRow struct {
+	Amount    float64
+	Principal float64
+	Interest  float64
+}
+
+// buildSchedule рассчитывает график выбранного типа для остатка основного долга
+func (s *CreditService) buildSchedule(scheduleType string, principal, interestRate float64, periods int) []scheduleRow {
+	if scheduleType == "differentiated" {
+		return s.differentiatedSchedule(principal, interestRate, periods)
+	}
+	return s.annuitySchedule(principal, interestRate, periods)
+}
+
+// annuitySchedule рассчитывает аннуитетный график для остатка основного долга
+func (s *CreditService) annuitySchedule(principal, interestRate float64, periods int) []scheduleRow {
+	monthlyRate := interestRate / 12 / 100
+	payment := roundMoney(s.CalculateMonthlyPayment(principal, periods, interestRate))
+
+	rows := make([]scheduleRow, periods)
+	remaining := principal
+	for i := 0; i < periods; i++ {
+		interest := roundMoney(remaining * monthlyRate)
+		part := payment - interest
+		if i == periods-1 {
+			// Последний платеж закрывает остаток долга целиком
+			part = roundMoney(remaining)
+		}
+		rows[i] = scheduleRow{
+			Amount:    roundMoney(part + interest),
+			Principal: part,
+			Interest:  interest,
+		}
+		remaining -= part
+	}
+
+	return rows
+}
+
+// differentiatedSchedule рассчитывает график с равными долями основного долга
+// и процентами, начисляемыми на остаток
+func (s *CreditService) differentiatedSchedule(principal, interestRate float64, periods int) []scheduleRow {
+	monthlyRate := interestRate / 12 / 100
+	part := roundMoney(principal / float64(periods))
+
+	rows := make([]scheduleRow, periods)
+	remaining := principal
+	for i := 0; i < periods; i++ {
+		interest := roundMoney(remaining * monthlyRate)
+		current := part
+		if i == periods-1 {
+			current = roundMoney(remaining)
+		}
+		rows[i] = scheduleRow{
+			Amount:    roundMoney(current + interest),
+			Principal: current,
+			Interest:  interest,
+		}
+		remaining -= current
+	}
+
+	return rows
+}
+
+// calculatePenalty рассчитывает штраф за просроченный платеж по условиям кредита
+func calculatePenalty(credit *model.Credit, amount float64) float64 {
+	switch credit.PenaltyType {
+	case "percent":
+		return roundMoney(amount * credit.PenaltyValue / 100)
+	case "fixed":
+		return credit.PenaltyValue
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	creditLineMargin            = 10.0      // маржа к ключевой ставке, %
+	creditLineMaxLimit          = 1000000.0 // максимальный кредитный лимит
+	creditLineMinPaymentPercent = 5.0       // минимальный платеж, % от задолженности по выписке
+	creditLineMinPaymentFloor   = 100.0     // минимальный платеж не меньше этой суммы
+	creditLineGracePeriodDays   = 55        // льготный период от начала расчетного периода, дней
+)
+
+type CreditLineService struct {
+	creditLineRepo  *repository.CreditLineRepository
+	cardRepo        *repository.CardRepository
+	accountRepo     *repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+	outboxRepo      *repository.OutboxRepository
+	events          *EventPublisher
+	keyRateRepo     *repository.KeyRateRepository
+	logger          *logrus.Logger
+}
+
+func NewCreditLineService(
+	creditLineRepo *repository.CreditLineRepository,
+	cardRepo *repository.CardRepository,
+	accountRepo *repository.AccountRepository,
+	transactionRepo *repository.TransactionRepository,
+	outboxRepo *repository.OutboxRepository,
+	events *EventPublisher,
+	keyRateRepo *repository.KeyRateRepository,
+	logger *logrus.Logger,
+) *CreditLineService {
+	return &CreditLineService{
+		creditLineRepo:  creditLineRepo,
+		cardRepo:        cardRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		outboxRepo:      outboxRepo,
+		events:          events,
+		keyRateRepo:     keyRateRepo,
+		logger:          logger,
+	}
+}
+
+// OpenCreditLine открывает возобновляемую кредитную линию для карты пользователя
+func (s *CreditLineService) OpenCreditLine(ctx context.Context, userID uuid.UUID, req model.CreateCreditLineRequest) (*model.CreditLine, error) {
+	s.logger.Infof("Открытие кредитной линии для карты %s, лимит: %.2f", req.CardID, req.CreditLimit)
+
+	if req.CreditLimit <= 0 || req.CreditLimit > creditLineMaxLimit {
+		return nil, fmt.Errorf("кредитный лимит должен быть от 0 до %.0f", creditLineMaxLimit)
+	}
+
+	card, err := s.cardRepo.GetByIDAndUser(ctx, req.CardID, userID)
+	if err != nil {
+		s.logger.WithError(err).Warnf("Карта %s не найдена у пользователя %s", req.CardID, userID)
+		return nil, fmt.Errorf("карта не найдена")
+	}
+
+	rate, err := effectiveKeyRate(ctx, s.keyRateRepo, time.Now())
+	if err != nil {
+		s.logger.WithError(err).Error("Не удалось получить ключевую ставку ЦБ")
+		return nil, err
+	}
+	interestRate := rate + creditLineMargin
+
+	now := time.Now()
+	line := &model.CreditLine{
+		ID:                uuid.New(),
+		UserID:            userID,
+		CardID:            card.ID,
+		AccountID:         card.AccountID,
+		CreditLimit:       req.CreditLimit,
+		InterestRate:      interestRate,
+		MinPaymentPercent: creditLineMinPaymentPercent,
+		GracePeriodDays:   creditLineGracePeriodDays,
+		GraceActive:       true,
+		LastAccruedAt:     startOfDay(now),
+		NextStatementDate: now.AddDate(0, 1, 0),
+		Status:            "active",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := s.creditLineRepo.Create(ctx, line); err != nil {
+		s.logger.WithError(err).Error("Ошибка создания кредитной линии")
+		return nil, fmt.Errorf("ошибка открытия кредитной линии: %w", err)
+	}
+
+	s.logger.Infof("Кредитная линия %s открыта, ставка %.2f%%", line.ID, interestRate)
+	return line, nil
+}
+
+func (s *CreditLineService) GetUserCreditLines(ctx context.Context, userID uuid.UUID) ([]model.CreditLine, error) {
+	lines, err := s.creditLineRepo.GetUserCreditLines(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения кредитных линий пользователя")
+		return nil, fmt.Errorf("ошибка получения кредитных линий: %w", err)
+	}
+	return lines, nil
+}
+
+// GetCreditLine возвращает кредитную линию с проверкой принадлежности пользователю
+func (s *CreditLineService) GetCreditLine(ctx context.Context, lineID, userID uuid.UUID) (*model.CreditLine, error) {
+	line, err := s.creditLineRepo.GetByID(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.UserID != userID {
+		s.logger.Warnf("Попытка доступа к чужой кредитной линии: пользователь %s, владелец %s", userID, line.UserID)
+		return nil, fmt.Errorf("credit line not found")
+	}
+	return line, nil
+}
+
+func (s *CreditLineService) GetStatements(ctx context.Context, lineID, userID uuid.UUID) ([]model.CreditLineStatement, error) {
+	if _, err := s.GetCreditLine(ctx, lineID, userID); err != nil {
+		return nil, err
+	}
+
+	statements, err := s.creditLineRepo.GetStatements(ctx, lineID)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Ошибка получения выписок по линии %s", lineID)
+		return nil, fmt.Errorf("ошибка получения выписок: %w", err)
+	}
+	return statements, nil
+}
+
+// Repay погашает задолженность по кредитной линии со счета пользователя
+func (s *CreditLineService) Repay(ctx context.Context, lineID, userID uuid.UUID, req model.CreditLineRepaymentRequest) error {
+	s.logger.Infof("Погашение кредитной линии %s на сумму %.2f со счета %s", lineID, req.Amount, req.AccountID)
+
+	if req.Amount <= 0 {
+		return fmt.Errorf("сумма погашения должна быть положительной")
+	}
+	amount := roundMoney(req.Amount)
+
+	tx, err := s.creditLineRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка начала транзакции")
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := s.creditLineRepo.GetByIDForUpdate(ctx, tx, lineID)
+	if err != nil {
+		return err
+	}
+	if line.UserID != userID {
+		s.logger.Warnf("Попытка погашения чужой кредитной линии: пользователь %s, владелец %s", userID, line.UserID)
+		return fmt.Errorf("credit line not found")
+	}
+	if amount > line.UsedAmount {
+		return fmt.Errorf("сумма погашения превышает задолженность (%.2f)", line.UsedAmount)
+	}
+
+	account, err := s.accountRepo.GetByIDForUpdate(ctx, tx, req.AccountID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения счета: %w", err)
+	}
+	if account.UserID != userID {
+		return fmt.Errorf("недостаточно прав: счет не принадлежит пользователю")
+	}
+	if account.IsFrozen() {
+		return errAccountFrozen
+	}
+	if account.Balance < amount {
+		return fmt.Errorf("недостаточно средств на счете")
+	}
+
+	if err := s.accountRepo.UpdateBalanceTx(ctx, tx, account.ID, -amount); err != nil {
+		return fmt.Errorf("ошибка списания средств: %w", err)
+	}
+	if err := s.creditLineRepo.UpdateUsedAmountTx(ctx, tx, line.ID, -amount); err != nil {
+		return fmt.Errorf("ошибка погашения задолженности: %w", err)
+	}
+
+	now := time.Now()
+	operationID := uuid.New()
+	if err := s.creditLineRepo.CreateOperationTx(ctx, tx, &model.CreditLineOperation{
+		ID:            operationID,
+		CreditLineID:  line.ID,
+		OperationType: "repayment",
+		Amount:        amount,
+		CreatedAt:     now,
+	}); err != nil {
+		return err
+	}
+
+	// Платеж засчитывается в текущую выписку для проверки льготного периода
+	statement, err := s.creditLineRepo.GetOpenStatementForUpdate(ctx, tx, line.ID)
+	if err != nil {
+		return err
+	}
+	if statement != nil {
+		if err := s.creditLineRepo.AddStatementPaymentTx(ctx, tx, statement.ID, amount); err != nil {
+			return err
+		}
+	}
+
+	transaction := &model.Transaction{
+		ID:              uuid.New(),
+		AccountID:       account.ID,
+		Amount:          amount,
+		TransactionType: model.TransactionTypeCreditLinePayment,
+		ReferenceID:     &operationID,
+		CreatedAt:       now,
+	}
+	if err := s.transactionRepo.CreateTx(ctx, tx, transaction); err != nil {
+		return fmt.Errorf("ошибка записи транзакции: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	s.events.Transactions(ctx, transaction)
+
+	s.logger.Infof("Кредитная линия %s погашена на %.2f", line.ID, amount)
+	return nil
+}
+
+// ProcessCreditLines выполняет ежедневную обработку кредитных линий:
+// начисление процентов, формирование выписок и проверку сроков оплаты
+func (s *CreditLineService) ProcessCreditLines(ctx context.Context) error {
+	if err := s.AccrueInterest(ctx); err != nil {
+		return err
+	}
+	if err := s.GenerateStatements(ctx); err != nil {
+		return err
+	}
+	return s.ProcessDueStatements(ctx)
+}
+
+// AccrueInterest начисляет ежедневные проценты на использованную часть лимита.
+// Дата последнего начисления хранится в линии, поэтому пропущенные дни доначисляются.
+func (s *CreditLineService) AccrueInterest(ctx context.Context) error {
+	today := startOfDay(time.Now())
+	lines, err := s.creditLineRepo.GetLinesForAccrual(ctx, today)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения кредитных линий для начисления процентов")
+		return fmt.Errorf("ошибка получения кредитных линий: %w", err)
+	}
+
+	s.logger.Infof("Начисление процентов по %d кредитным линиям", len(lines))
+	for _, line := range lines {
+		if err := s.accrueLineInterest(ctx, line.ID, today); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка начисления процентов по линии %s", line.ID)
+		}
+	}
+	return nil
+}
+
+func (s *CreditLineService) accrueLineInterest(ctx context.Context, lineID uuid.UUID, today time.Time) error {
+	tx, err := s.creditLineRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := s.creditLineRepo.GetByIDForUpdate(ctx, tx, lineID)
+	if err != nil {
+		return err
+	}
+
+	days := int(today.Sub(startOfDay(line.LastAccruedAt)).Hours() / 24)
+	if days <= 0 {
+		return nil
+	}
+
+	interest := roundMoney(line.UsedAmount * line.InterestRate / 100 / 365 * float64(days))
+	if err := s.creditLineRepo.AccrueInterestTx(ctx, tx, line.ID, interest, today); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GenerateStatements формирует ежемесячные выписки. Если льготный период активен,
+// проценты за период откладываются и списываются только при неполном погашении выписки.
+func (s *CreditLineService) GenerateStatements(ctx context.Context) error {
+	now := time.Now()
+	lines, err := s.creditLineRepo.GetLinesDueForStatement(ctx, now)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения кредитных линий для формирования выписок")
+		return fmt.Errorf("ошибка получения кредитных линий: %w", err)
+	}
+
+	s.logger.Infof("Формирование выписок по %d кредитным линиям", len(lines))
+	for _, line := range lines {
+		if err := s.generateStatement(ctx, line.ID, now); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка формирования выписки по линии %s", line.ID)
+		}
+	}
+	return nil
+}
+
+// generateStatement формирует выписку и записывает уведомление о ней в outbox в той же транзакции
+func (s *CreditLineService) generateStatement(ctx context.Context, lineID uuid.UUID, now time.Time) error {
+	tx, err := s.creditLineRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := s.creditLineRepo.GetByIDForUpdate(ctx, tx, lineID)
+	if err != nil {
+		return err
+	}
+	if line.NextStatementDate.After(now) {
+		return nil
+	}
+
+	periodEnd := line.NextStatementDate
+	periodStart := periodEnd.AddDate(0, -1, 0)
+
+	var capitalized, deferred float64
+	if line.GraceActive {
+		deferred = line.AccruedInterest
+	} else {
+		capitalized = line.AccruedInterest
+	}
+
+	if capitalized > 0 {
+		if err := s.creditLineRepo.CreateOperationTx(ctx, tx, &model.CreditLineOperation{
+			ID:            uuid.New(),
+			CreditLineID:  line.ID,
+			OperationType: "interest",
+			Amount:        capitalized,
+			CreatedAt:     now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := s.creditLineRepo.CloseStatementPeriodTx(ctx, tx, line.ID, capitalized, periodEnd.AddDate(0, 1, 0)); err != nil {
+		return err
+	}
+
+	balance := roundMoney(line.UsedAmount + capitalized)
+	if balance <= 0 && deferred <= 0 {
+		// Задолженности нет - выписка не нужна, только переносим расчетный период
+		return tx.Commit()
+	}
+
+	dueDate := periodStart.AddDate(0, 0, line.GracePeriodDays)
+	if dueDate.Before(periodEnd) {
+		dueDate = periodEnd
+	}
+
+	statement := &model.CreditLineStatement{
+		ID:               uuid.New(),
+		CreditLineID:     line.ID,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		StatementBalance: balance,
+		MinPayment:       minimumPayment(balance, line.MinPaymentPercent),
+		DeferredInterest: deferred,
+		DueDate:          dueDate,
+		Status:           "open",
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.creditLineRepo.CreateStatementTx(ctx, tx, statement); err != nil {
+		return err
+	}
+
+	event, err := newOutboxEvent(model.OutboxCreditLineStatement, statement.ID, model.CreditLineStatementEvent{
+		UserID:           line.UserID,
+		CreditLineID:     line.ID,
+		StatementID:      statement.ID,
+		StatementBalance: statement.StatementBalance,
+		MinPayment:       statement.MinPayment,
+		DueDate:          statement.DueDate,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.outboxRepo.CreateTx(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Сформирована выписка %s по линии %s: задолженность %.2f, минимальный платеж %.2f",
+		statement.ID, line.ID, statement.StatementBalance, statement.MinPayment)
+	return nil
+}
+
+// ProcessDueStatements закрывает выписки с наступившим сроком оплаты. При полном погашении
+// отложенные проценты прощаются, иначе списываются и льготный период прекращается.
+func (s *CreditLineService) ProcessDueStatements(ctx context.Context) error {
+	statements, err := s.creditLineRepo.GetStatementsDue(ctx, time.Now())
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения выписок с наступившим сроком оплаты")
+		return fmt.Errorf("ошибка получения выписок: %w", err)
+	}
+
+	s.logger.Infof("Проверка оплаты %d выписок", len(statements))
+	for _, statement := range statements {
+		if err := s.settleStatement(ctx, statement.ID); err != nil {
+			s.logger.WithError(err).Errorf("Ошибка обработки выписки %s", statement.ID)
+		}
+	}
+	return nil
+}
+
+func (s *CreditLineService) settleStatement(ctx context.Context, statementID uuid.UUID) error {
+	tx, err := s.creditLineRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	statement, err := s.creditLineRepo.GetStatementForUpdate(ctx, tx, statementID)
+	if err != nil {
+		return err
+	}
+	if statement.Status != "open" {
+		return nil
+	}
+
+	var status string
+	graceActive := true
+	switch {
+	case statement.PaidAmount >= statement.StatementBalance:
+		status = "paid"
+	case statement.PaidAmount >= statement.MinPayment:
+		status = "partially_paid"
+		graceActive = false
+	default:
+		status = "overdue"
+		graceActive = false
+	}
+
+	if !graceActive && statement.DeferredInterest > 0 {
+		if err := s.creditLineRepo.UpdateUsedAmountTx(ctx, tx, statement.CreditLineID, statement.DeferredInterest); err != nil {
+			return err
+		}
+		if err := s.creditLineRepo.CreateOperationTx(ctx, tx, &model.CreditLineOperation{
+			ID:            uuid.New(),
+			CreditLineID:  statement.CreditLineID,
+			OperationType: "interest",
+			Amount:        statement.DeferredInterest,
+			ReferenceID:   &statement.ID,
+			CreatedAt:     time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := s.creditLineRepo.SetGraceActiveTx(ctx, tx, statement.CreditLineID, graceActive); err != nil {
+		return err
+	}
+	if err := s.creditLineRepo.UpdateStatementStatusTx(ctx, tx, statement.ID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"statement_id": statement.ID,
+		"status":       status,
+		"grace_active": graceActive,
+	}).Info("Выписка по кредитной линии закрыта")
+	return nil
+}
+
+// minimumPayment рассчитывает минимальный платеж по выписке
+func minimumPayment(balance, percent float64) float64 {
+	payment := roundMoney(balance * percent / 100)
+	if payment < creditLineMinPaymentFloor {
+		payment = math.Min(balance, creditLineMinPaymentFloor)
+	}
+	return payment
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
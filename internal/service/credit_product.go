@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+type CreditProductService struct {
+	productRepo *repository.CreditProductRepository
+	logger      *logrus.Logger
+}
+
+func NewCreditProductService(productRepo *repository.CreditProductRepository, logger *logrus.Logger) *CreditProductService {
+	return &CreditProductService{
+		productRepo: productRepo,
+		logger:      logger,
+	}
+}
+
+// ListProducts возвращает каталог продуктов. Клиентам показываются только активные продукты.
+func (s *CreditProductService) ListProducts(ctx context.Context, activeOnly bool) ([]model.CreditProduct, error) {
+	products, err := s.productRepo.List(ctx, activeOnly)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения кредитных продуктов")
+		return nil, fmt.Errorf("ошибка получения кредитных продуктов: %w", err)
+	}
+	return products, nil
+}
+
+func (s *CreditProductService) GetProduct(ctx context.Context, id uuid.UUID) (*model.CreditProduct, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредитного продукта: %w", err)
+	}
+	return product, nil
+}
+
+func (s *CreditProductService) CreateProduct(ctx context.Context, req model.CreditProductRequest) (*model.CreditProduct, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректные параметры продукта: %w", err)
+	}
+
+	now := time.Now()
+	product := &model.CreditProduct{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyProductRequest(product, req)
+
+	if err := s.productRepo.Create(ctx, product); err != nil {
+		s.logger.WithError(err).Error("Ошибка создания кредитного продукта")
+		return nil, fmt.Errorf("ошибка создания кредитного продукта: %w", err)
+	}
+
+	s.logger.Infof("Создан кредитный продукт %s (%s)", product.ID, product.Name)
+	return product, nil
+}
+
+// UpdateProduct меняет условия продукта. Уже выданные кредиты сохраняют условия на дату выдачи.
+func (s *CreditProductService) UpdateProduct(ctx context.Context, id uuid.UUID, req model.CreditProductRequest) (*model.CreditProduct, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректные параметры продукта: %w", err)
+	}
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредитного продукта: %w", err)
+	}
+	applyProductRequest(product, req)
+
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		s.logger.WithError(err).Errorf("Ошибка обновления кредитного продукта %s", id)
+		return nil, fmt.Errorf("ошибка обновления кредитного продукта: %w", err)
+	}
+
+	s.logger.Infof("Кредитный продукт %s обновлен", id)
+	return s.productRepo.GetByID(ctx, id)
+}
+
+// DeactivateProduct снимает продукт с продажи
+func (s *CreditProductService) DeactivateProduct(ctx context.Context, id uuid.UUID) error {
+	if err := s.productRepo.SetActive(ctx, id, false); err != nil {
+		s.logger.WithError(err).Errorf("Ошибка отключения кредитного продукта %s", id)
+		return fmt.Errorf("ошибка отключения кредитного продукта: %w", err)
+	}
+
+	s.logger.Infof("Кредитный продукт %s снят с продажи", id)
+	return nil
+}
+
+func applyProductRequest(product *model.CreditProduct, req model.CreditProductRequest) {
+	product.Name = req.Name
+	product.Description = req.Description
+	product.MinAmount = req.MinAmount
+	product.MaxAmount = req.MaxAmount
+	product.MinTermMonths = req.MinTermMonths
+	product.MaxTermMonths = req.MaxTermMonths
+	product.RateType = req.RateType
+	product.FixedRate = req.FixedRate
+	product.RateMargin = req.RateMargin
+	product.PenaltyType = req.PenaltyType
+	product.PenaltyValue = req.PenaltyValue
+	product.ScheduleType = req.ScheduleType
+	product.EarlyRepaymentAllowed = req.EarlyRepaymentAllowed
+	product.EarlyRepaymentFeePercent = req.EarlyRepaymentFeePercent
+	product.EarlyRepaymentMinMonths = req.EarlyRepaymentMinMonths
+	product.Active = req.Active
+}
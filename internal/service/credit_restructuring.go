@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+)
+
+const (
+	// maxHolidayMonths - максимальное количество платежей, которые можно отсрочить
+	maxHolidayMonths = 6
+	// maxExtraTermMonths - максимальное продление срока при реструктуризации
+	maxExtraTermMonths = 60
+)
+
+// RequestRestructuring регистрирует заявку заемщика на кредитные каникулы или реструктуризацию
+func (s *CreditService) RequestRestructuring(
+	ctx context.Context,
+	creditID, userID uuid.UUID,
+	req model.CreateRestructuringRequest,
+) (*model.CreditRestructuringRequest, error) {
+	credit, err := s.creditRepo.GetCreditByID(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.UserID != userID {
+		return nil, fmt.Errorf("кредит не принадлежит пользователю")
+	}
+	if credit.Status != "active" && credit.Status != "overdue" {
+		return nil, fmt.Errorf("изменить условия можно только по непогашенному кредиту")
+	}
+
+	switch req.RequestType {
+	case "holiday":
+		if req.HolidayMonths < 1 || req.HolidayMonths > maxHolidayMonths {
+			return nil, fmt.Errorf("количество отсрочиваемых платежей должно быть от 1 до %d", maxHolidayMonths)
+		}
+		if req.ExtraTermMonths != 0 || req.NewRate != nil {
+			return nil, fmt.Errorf("кредитные каникулы не меняют срок и ставку кредита")
+		}
+	case "restructuring":
+		if req.HolidayMonths != 0 {
+			return nil, fmt.Errorf("для отсрочки платежей оформите кредитные каникулы")
+		}
+		if req.ExtraTermMonths < 0 || req.ExtraTermMonths > maxExtraTermMonths {
+			return nil, fmt.Errorf("продление срока должно быть от 0 до %d мес.", maxExtraTermMonths)
+		}
+		if req.ExtraTermMonths == 0 && req.NewRate == nil {
+			return nil, fmt.Errorf("укажите продление срока или новую ставку")
+		}
+		if req.NewRate != nil {
+			if err := validateRestructuringRate(credit, *req.NewRate); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный тип заявки: %s", req.RequestType)
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("укажите причину обращения")
+	}
+
+	now := time.Now()
+	request := &model.CreditRestructuringRequest{
+		ID:              uuid.New(),
+		CreditID:        credit.ID,
+		UserID:          userID,
+		RequestType:     req.RequestType,
+		HolidayMonths:   req.HolidayMonths,
+		ExtraTermMonths: req.ExtraTermMonths,
+		NewRate:         req.NewRate,
+		Reason:          strings.TrimSpace(req.Reason),
+		Status:          "pending",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.creditRepo.CreateRestructuringRequest(ctx, request); err != nil {
+		s.logger.WithError(err).Errorf("Ошибка создания заявки на реструктуризацию кредита %s", creditID)
+		return nil, fmt.Errorf("ошибка создания заявки: %w", err)
+	}
+
+	s.logger.Infof("Создана заявка %s (%s) по кредиту %s", request.ID, request.RequestType, creditID)
+	return request, nil
+}
+
+// GetRestructuringRequests возвращает заявки по кредиту пользователя
+func (s *CreditService) GetRestructuringRequests(ctx context.Context, creditID, userID uuid.UUID) ([]model.CreditRestructuringRequest, error) {
+	credit, err := s.creditRepo.GetCreditByID(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.UserID != userID {
+		return nil, fmt.Errorf("кредит не принадлежит пользователю")
+	}
+
+	requests, err := s.creditRepo.GetRestructuringRequests(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения заявок: %w", err)
+	}
+	return requests, nil
+}
+
+// ListRestructuringRequests возвращает заявки для рассмотрения сотрудником банка
+func (s *CreditService) ListRestructuringRequests(ctx context.Context, status string) ([]model.CreditRestructuringRequest, error) {
+	if status == "" {
+		status = "pending"
+	}
+
+	requests, err := s.creditRepo.ListRestructuringRequests(ctx, status)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения заявок на реструктуризацию")
+		return nil, fmt.Errorf("ошибка получения заявок: %w", err)
+	}
+	return requests, nil
+}
+
+// ApproveRestructuring одобряет заявку и перестраивает график. Будущие платежи текущей версии
+// помечаются как superseded и остаются в истории, новый график записывается следующей версией.
+func (s *CreditService) ApproveRestructuring(
+	ctx context.Context,
+	requestID, reviewerID uuid.UUID,
+	review model.ReviewRestructuringRequest,
+) (*model.CreditRestructuringRequest, error) {
+	tx, err := s.creditRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	request, err := s.creditRepo.GetRestructuringRequestForUpdate(ctx, tx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения заявки: %w", err)
+	}
+	if request.Status != "pending" {
+		return nil, fmt.Errorf("заявка уже рассмотрена")
+	}
+
+	credit, err := s.creditRepo.GetCreditByIDForUpdate(ctx, tx, request.CreditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+	if credit.Status != "active" && credit.Status != "overdue" {
+		return nil, fmt.Errorf("изменить условия можно только по непогашенному кредиту")
+	}
+
+	rate := credit.InterestRate
+	if review.NewRate != nil {
+		request.NewRate = review.NewRate
+	}
+	if request.NewRate != nil {
+		if err := validateRestructuringRate(credit, *request.NewRate); err != nil {
+			return nil, err
+		}
+		rate = *request.NewRate
+	}
+
+	now := time.Now()
+	payments, err := s.creditRepo.GetFuturePaymentsForUpdate(ctx, tx, credit.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("по кредиту нет будущих платежей для перестроения графика")
+	}
+
+	var principal float64
+	for _, p := range payments {
+		principal += p.Principal
+	}
+	principal = roundMoney(principal)
+
+	firstDate := payments[0].PaymentDate
+	periods := len(payments)
+	extraMonths := request.ExtraTermMonths
+	if request.RequestType == "holiday" {
+		// Проценты за период каникул капитализируются, платежи сдвигаются на срок каникул
+		principal = roundMoney(principal * math.Pow(1+rate/12/100, float64(request.HolidayMonths)))
+		firstDate = firstDate.AddDate(0, request.HolidayMonths, 0)
+		extraMonths = request.HolidayMonths
+	} else {
+		periods += request.ExtraTermMonths
+	}
+
+	version := credit.ScheduleVersion + 1
+	for _, p := range payments {
+		if err := s.creditRepo.UpdatePaymentStatusTx(ctx, tx, p.ID, "superseded", nil); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := s.buildSchedule(credit.ScheduleType, principal, rate, periods)
+	var lastDate time.Time
+	for i, row := range rows {
+		lastDate = firstDate.AddDate(0, i, 0)
+		schedule := &model.PaymentSchedule{
+			ID:            uuid.New(),
+			CreditID:      credit.ID,
+			PaymentNumber: payments[0].PaymentNumber + i,
+			PaymentDate:   lastDate,
+			Amount:        row.Amount,
+			Principal:     row.Principal,
+			Interest:      row.Interest,
+			Status:        "pending",
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			Version:       version,
+		}
+		if err := s.creditRepo.CreatePaymentScheduleTx(ctx, tx, schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	credit.InterestRate = rate
+	credit.TermMonths += extraMonths
+	credit.MonthlyPayment = rows[0].Amount
+	credit.EndDate = lastDate
+	credit.ScheduleVersion = version
+	if err := s.creditRepo.UpdateCreditTermsTx(ctx, tx, credit); err != nil {
+		return nil, err
+	}
+
+	request.Status = "approved"
+	request.ReviewerID = &reviewerID
+	request.ReviewComment = review.Comment
+	request.ReviewedAt = &now
+	request.ScheduleVersion = &version
+	if err := s.creditRepo.ReviewRestructuringRequestTx(ctx, tx, request); err != nil {
+		return nil, err
+	}
+	if err := s.createRestructuringDecisionEventTx(ctx, tx, request, credit.MonthlyPayment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("Ошибка подтверждения транзакции")
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"request_id":  request.ID,
+		"credit_id":   credit.ID,
+		"type":        request.RequestType,
+		"version":     version,
+		"rate":        rate,
+		"new_payment": credit.MonthlyPayment,
+		"payments":    len(rows),
+	}).Info("Заявка на изменение условий кредита одобрена")
+	return request, nil
+}
+
+// RejectRestructuring отклоняет заявку без изменения графика
+func (s *CreditService) RejectRestructuring(
+	ctx context.Context,
+	requestID, reviewerID uuid.UUID,
+	review model.ReviewRestructuringRequest,
+) (*model.CreditRestructuringRequest, error) {
+	tx, err := s.creditRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	request, err := s.creditRepo.GetRestructuringRequestForUpdate(ctx, tx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения заявки: %w", err)
+	}
+	if request.Status != "pending" {
+		return nil, fmt.Errorf("заявка уже рассмотрена")
+	}
+
+	now := time.Now()
+	request.Status = "rejected"
+	request.ReviewerID = &reviewerID
+	request.ReviewComment = review.Comment
+	request.ReviewedAt = &now
+	if err := s.creditRepo.ReviewRestructuringRequestTx(ctx, tx, request); err != nil {
+		return nil, err
+	}
+	if err := s.createRestructuringDecisionEventTx(ctx, tx, request, 0); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Заявка %s по кредиту %s отклонена", request.ID, request.CreditID)
+	return request, nil
+}
+
+// GetScheduleOverview возвращает действующий график вместе с первоначальным
+// и списком изменений условий кредита
+func (s *CreditService) GetScheduleOverview(ctx context.Context, creditID, userID uuid.UUID) (*model.CreditScheduleOverview, error) {
+	current, err := s.GetPaymentSchedule(ctx, creditID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := s.creditRepo.GetCreditByID(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредита: %w", err)
+	}
+
+	original, err := s.creditRepo.GetScheduleVersion(ctx, creditID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения первоначального графика: %w", err)
+	}
+
+	requests, err := s.creditRepo.GetRestructuringRequests(ctx, creditID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории изменений: %w", err)
+	}
+
+	changes := []model.CreditRestructuringRequest{}
+	for _, r := range requests {
+		if r.Status == "approved" {
+			changes = append(changes, r)
+		}
+	}
+
+	return &model.CreditScheduleOverview{
+		CreditID:       creditID,
+		CurrentVersion: credit.ScheduleVersion,
+		Current:        current,
+		Original:       original,
+		Changes:        changes,
+	}, nil
+}
+
+// validateRestructuringRate проверяет ставку, запрошенную при реструктуризации
+func validateRestructuringRate(credit *model.Credit, rate float64) error {
+	if credit.RateType == "floating" {
+		return fmt.Errorf("ставка по кредиту с плавающей ставкой пересматривается только при изменении ключевой ставки")
+	}
+	if rate <= 0 || rate > 100 {
+		return fmt.Errorf("некорректная ставка: %.2f", rate)
+	}
+	return nil
+}
+
+// createRestructuringDecisionEventTx записывает уведомление заемщику о решении по заявке
+// в outbox в транзакции рассмотрения. newPayment - новый ежемесячный платеж при одобрении.
+func (s *CreditService) createRestructuringDecisionEventTx(ctx context.Context, tx *sql.Tx, request *model.CreditRestructuringRequest, newPayment float64) error {
+	event, err := newOutboxEvent(model.OutboxRestructuringDecision, request.ID, model.RestructuringDecisionEvent{
+		UserID:     request.UserID,
+		CreditID:   request.CreditID,
+		RequestID:  request.ID,
+		Approved:   request.Status == "approved",
+		Comment:    request.ReviewComment,
+		NewPayment: newPayment,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outboxRepo.CreateTx(ctx, tx, event)
+}
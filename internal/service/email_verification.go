@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"banking-api/internal/model"
+)
+
+const (
+	emailVerificationValid   = 48 * time.Hour // срок действия ссылки подтверждения email
+	emailVerificationPurpose = "email_verification"
+	passwordResetValid       = time.Hour // срок действия токена сброса пароля
+	passwordResetBytes       = 32        // длина случайного токена сброса пароля
+	passwordResetWindow      = 60        // окно ограничения запросов сброса, минут
+	passwordResetMaxRequests = 3         // максимум запросов сброса в окне
+)
+
+// SendEmailVerification отправляет пользователю подписанную ссылку подтверждения email.
+// Ссылка не хранится в БД: в ней закодированы пользователь, адрес и срок действия.
+func (s *AuthService) SendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if user.EmailVerified {
+		return fmt.Errorf("email уже подтвержден")
+	}
+
+	expiresAt := time.Now().Add(emailVerificationValid)
+	link := s.appBaseURL + "/auth/verify-email?token=" + url.QueryEscape(s.signEmailVerification(user, expiresAt))
+
+	if !s.notifications.EmailEnabled() {
+		s.logger.Infof("Отправка писем отключена, ссылка подтверждения для %s: %s", user.Email, link)
+		return nil
+	}
+	if err := s.notifications.SendEmailVerification(RecipientOf(user), link, expiresAt); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+
+	s.logger.WithField("user_id", user.ID).Info("Отправлена ссылка подтверждения email")
+	return nil
+}
+
+// VerifyEmail проверяет подпись и срок действия ссылки и отмечает email подтвержденным.
+// Ссылка, выданная на прежний адрес, после смены email недействительна. Ссылка, выданная
+// на новый адрес при смене email, заменяет им текущий.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := s.parseEmailVerification(token)
+	if err != nil {
+		s.logger.WithError(err).Warn("Недействительная ссылка подтверждения email")
+		return fmt.Errorf("недействительная или истекшая ссылка подтверждения")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("недействительная или истекшая ссылка подтверждения")
+	}
+	if user.EmailVerified && user.Email == email {
+		return nil
+	}
+
+	if user.PendingEmail != nil && *user.PendingEmail == email {
+		return s.confirmEmailChange(ctx, user, email)
+	}
+
+	updated, err := s.userRepo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return fmt.Errorf("ошибка подтверждения email: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("недействительная или истекшая ссылка подтверждения")
+	}
+
+	s.logger.WithField("user_id", userID).Info("Email пользователя подтвержден")
+	return nil
+}
+
+// RequestEmailChange запоминает новый email и отправляет на него ссылку подтверждения.
+// До перехода по ссылке вход и уведомления продолжают работать на прежний адрес.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID uuid.UUID, input model.ChangeEmailInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		s.logger.WithField("user_id", userID).Warn("Неверный пароль при смене email")
+		return fmt.Errorf("неверный пароль")
+	}
+	if strings.EqualFold(user.Email, input.Email) {
+		return fmt.Errorf("новый email совпадает с текущим")
+	}
+	if existing, err := s.userRepo.FindByEmail(ctx, input.Email); err == nil && existing.ID != userID {
+		return fmt.Errorf("email уже используется")
+	}
+
+	if err := s.userRepo.SetPendingEmail(ctx, userID, input.Email); err != nil {
+		return fmt.Errorf("ошибка смены email: %w", err)
+	}
+
+	// Ссылка подписывается новым адресом: запрос, сделанный позже, делает ее недействительной
+	pending := *user
+	pending.Email = input.Email
+	expiresAt := time.Now().Add(emailVerificationValid)
+	link := s.appBaseURL + "/auth/verify-email?token=" + url.QueryEscape(s.signEmailVerification(&pending, expiresAt))
+
+	s.logger.WithField("user_id", userID).Info("Запрошена смена email")
+
+	if !s.notifications.EmailEnabled() {
+		s.logger.Infof("Отправка писем отключена, ссылка подтверждения для %s: %s", input.Email, link)
+		return nil
+	}
+	go func() {
+		if err := s.notifications.SendEmailChangeConfirmation(Recipient{Email: input.Email, Locale: user.Locale}, link, expiresAt); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить ссылку подтверждения нового email")
+		}
+	}()
+	return nil
+}
+
+// confirmEmailChange заменяет email подтвержденным новым адресом и уведомляет прежний
+func (s *AuthService) confirmEmailChange(ctx context.Context, user *model.User, email string) error {
+	updated, err := s.userRepo.ConfirmEmailChange(ctx, user.ID, email)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("email уже используется")
+		}
+		return fmt.Errorf("ошибка смены email: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("недействительная или истекшая ссылка подтверждения")
+	}
+
+	s.logger.WithField("user_id", user.ID).Info("Email пользователя изменен")
+
+	previous := RecipientOf(user)
+	go func() {
+		if err := s.notifications.SendEmailChanged(previous, email); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить уведомление о смене email")
+		}
+	}()
+	return nil
+}
+
+// ChangePassword меняет пароль после проверки текущего. Неверный текущий пароль
+// учитывается как неудачная попытка входа. Все сессии, кроме текущей, завершаются.
+func (s *AuthService) ChangePassword(
+	ctx context.Context,
+	userID uuid.UUID,
+	currentSessionID string,
+	input model.ChangePasswordInput,
+	device model.DeviceInfo,
+) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	if err := s.loginGuard.Check(ctx, user.Email, device.IP); err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		s.logger.WithField("user_id", userID).Warn("Неверный текущий пароль при смене пароля")
+		s.registerLoginFailure(ctx, user.Email, device.IP, user)
+		return fmt.Errorf("неверный текущий пароль")
+	}
+	if err := s.loginGuard.Succeed(ctx, user.Email); err != nil {
+		s.logger.WithError(err).Error("Не удалось сбросить счетчик попыток входа")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	// Текущая сессия остается, если токен выдан с идентификатором сессии
+	keepSession, _ := uuid.Parse(currentSessionID)
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.ChangePasswordTx(ctx, tx, userID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %w", err)
+	}
+	if err := s.passwordResetRepo.UseAllForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка обновления токенов сброса: %w", err)
+	}
+	revoked, err := s.refreshTokenRepo.RevokeOtherSessionsTx(ctx, tx, userID, keepSession, "password_changed")
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":          userID,
+		"ip":               device.IP,
+		"revoked_sessions": revoked,
+	}).Info("Пароль пользователя изменен")
+
+	go func() {
+		if err := s.notifications.SendPasswordChanged(RecipientOf(user), device.IP); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить уведомление о смене пароля")
+		}
+	}()
+
+	return nil
+}
+
+// IsEmailVerified сообщает, подтвердил ли пользователь email
+func (s *AuthService) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
+
+// ForgotPassword выпускает одноразовый токен сброса пароля и отправляет его на email.
+// Для неизвестного адреса ничего не происходит, чтобы по ответу нельзя было узнать о регистрации.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string, device model.DeviceInfo) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		s.logger.WithField("email", email).Info("Запрошен сброс пароля для неизвестного email")
+		return nil
+	}
+
+	recent, err := s.passwordResetRepo.CountRecent(ctx, user.ID, passwordResetWindow)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки запросов сброса: %w", err)
+	}
+	if recent >= passwordResetMaxRequests {
+		s.logger.WithField("user_id", user.ID).Warn("Превышено число запросов сброса пароля")
+		return nil
+	}
+
+	raw := make([]byte, passwordResetBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	record := &model.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		IP:        truncate(device.IP, 64),
+		ExpiresAt: now.Add(passwordResetValid),
+		CreatedAt: now,
+	}
+	if err := s.passwordResetRepo.Create(ctx, record); err != nil {
+		return fmt.Errorf("ошибка создания токена: %w", err)
+	}
+
+	go func() {
+		if err := s.notifications.SendPasswordReset(RecipientOf(user), token, record.ExpiresAt); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить письмо для сброса пароля")
+		}
+	}()
+
+	s.logger.WithField("user_id", user.ID).Info("Выпущен токен сброса пароля")
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма. Токен одноразовый;
+// все остальные токены сброса и все сессии пользователя становятся недействительными.
+func (s *AuthService) ResetPassword(ctx context.Context, input model.ResetPasswordInput, device model.DeviceInfo) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	tx, err := s.passwordResetRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	token, err := s.passwordResetRepo.GetByHashForUpdate(ctx, tx, hashToken(input.Token))
+	if err != nil {
+		s.logger.WithError(err).Warn("Предъявлен неизвестный токен сброса пароля")
+		return fmt.Errorf("недействительный или истекший токен сброса пароля")
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return fmt.Errorf("недействительный или истекший токен сброса пароля")
+	}
+
+	if err := s.userRepo.UpdatePasswordTx(ctx, tx, token.UserID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %w", err)
+	}
+	if err := s.passwordResetRepo.UseAllForUserTx(ctx, tx, token.UserID); err != nil {
+		return fmt.Errorf("ошибка обновления токена: %w", err)
+	}
+	if _, err := s.refreshTokenRepo.RevokeAllForUserTx(ctx, tx, token.UserID, "password_reset"); err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": token.UserID,
+		"ip":      device.IP,
+	}).Info("Пароль пользователя сброшен, все сессии завершены")
+
+	go func() {
+		user, err := s.userRepo.GetByID(context.Background(), token.UserID)
+		if err != nil {
+			s.logger.WithError(err).Error("Не удалось получить пользователя для уведомления о смене пароля")
+			return
+		}
+		if err := s.notifications.SendPasswordChanged(RecipientOf(user), device.IP); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить уведомление о смене пароля")
+		}
+	}()
+
+	return nil
+}
+
+// signEmailVerification формирует токен вида base64url(userID|email|exp).hex(hmac)
+func (s *AuthService) signEmailVerification(user *model.User, expiresAt time.Time) string {
+	payload := user.ID.String() + "|" + user.Email + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.emailVerificationMAC(payload)
+}
+
+// parseEmailVerification проверяет подпись и срок действия токена подтверждения
+func (s *AuthService) parseEmailVerification(token string) (uuid.UUID, string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("malformed token")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("malformed token: %w", err)
+	}
+	payload := string(raw)
+
+	if !hmac.Equal([]byte(signature), []byte(s.emailVerificationMAC(payload))) {
+		return uuid.Nil, "", fmt.Errorf("invalid signature")
+	}
+
+	// Символ "|" допустим в локальной части email: ID пользователя отделяется
+	// по первому разделителю, срок действия - по последнему
+	id, rest, ok := strings.Cut(payload, "|")
+	sep := strings.LastIndex(rest, "|")
+	if !ok || sep < 0 {
+		return uuid.Nil, "", fmt.Errorf("malformed payload")
+	}
+	email, expiry := rest[:sep], rest[sep+1:]
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("invalid user id: %w", err)
+	}
+	exp, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("invalid expiry: %w", err)
+	}
+	if time.Now().After(time.Unix(exp, 0)) {
+		return uuid.Nil, "", fmt.Errorf("token expired")
+	}
+
+	return userID, email, nil
+}
+
+func (s *AuthService) emailVerificationMAC(payload string) string {
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write([]byte(emailVerificationPurpose + "|" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
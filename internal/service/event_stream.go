@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	eventSubscriptionBuffer = 64 // событий в очереди подписчика, при переполнении подписка закрывается
+	maxEventSubscriptions   = 5  // одновременных подключений одного пользователя на экземпляре
+	eventNotifyChannel      = "banking_events"
+	eventNotifyMaxPayload   = 7900 // NOTIFY принимает не более 8000 байт
+	eventListenerPing       = 90 * time.Second
+)
+
+// errTooManySubscriptions возвращается при превышении числа подключений пользователя
+var errTooManySubscriptions = fmt.Errorf("превышено число одновременных подключений к потоку событий (%d)", maxEventSubscriptions)
+
+// EventHub рассылает события потока подписчикам текущего экземпляра сервиса
+type EventHub struct {
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*EventSubscription]struct{}
+	closed bool
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{subs: make(map[uuid.UUID]map[*EventSubscription]struct{})}
+}
+
+// EventSubscription - подключение пользователя к потоку. Канал Events закрывается при
+// отписке, остановке сервиса или если клиент не успевает читать события.
+type EventSubscription struct {
+	Events <-chan model.StreamEvent
+
+	hub    *EventHub
+	userID uuid.UUID
+	ch     chan model.StreamEvent
+}
+
+// Subscribe подключает пользователя к потоку событий
+func (h *EventHub) Subscribe(userID uuid.UUID) (*EventSubscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("сервис останавливается")
+	}
+	if len(h.subs[userID]) >= maxEventSubscriptions {
+		return nil, errTooManySubscriptions
+	}
+
+	ch := make(chan model.StreamEvent, eventSubscriptionBuffer)
+	sub := &EventSubscription{Events: ch, hub: h, userID: userID, ch: ch}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*EventSubscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+// Close отписывает подписчика. Повторный вызов ничего не делает.
+func (s *EventSubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove удаляет подписку и закрывает ее канал. Вызывается под h.mu.
+func (h *EventHub) remove(sub *EventSubscription) {
+	subs, ok := h.subs[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	close(sub.ch)
+}
+
+// Deliver передает событие подключениям его получателя. Отправка не блокируется:
+// подписка, очередь которой заполнена, закрывается, и клиент переподключается.
+func (h *EventHub) Deliver(event model.StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[event.UserID] {
+		select {
+		case sub.ch <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Close закрывает все подписки и запрещает новые. Вызывается при остановке HTTP сервера,
+// чтобы потоковые ответы завершились и не задерживали остановку.
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// EventBroker доставляет события потока подписчикам. MemoryEventBroker передает события
+// только подключениям своего экземпляра, PostgresEventBroker - всех экземпляров через
+// LISTEN/NOTIFY.
+type EventBroker interface {
+	// Publish отправляет событие. Событие не сохраняется: если получатель не подключен, оно теряется.
+	Publish(ctx context.Context, event model.StreamEvent) error
+	// Start принимает события других экземпляров до отмены контекста
+	Start(ctx context.Context)
+}
+
+// NewEventBroker выбирает способ доставки событий: "memory" или "postgres"
+func NewEventBroker(kind string, db *sql.DB, dsn string, hub *EventHub, logger *logrus.Logger) (EventBroker, error) {
+	switch kind {
+	case "memory", "":
+		return &MemoryEventBroker{hub: hub}, nil
+	case "postgres":
+		return NewPostgresEventBroker(db, dsn, hub, logger)
+	default:
+		return nil, fmt.Errorf("неизвестный тип доставки событий: %s", kind)
+	}
+}
+
+// MemoryEventBroker доставляет события в пределах одного экземпляра сервиса
+type MemoryEventBroker struct {
+	hub *EventHub
+}
+
+func (b *MemoryEventBroker) Publish(_ context.Context, event model.StreamEvent) error {
+	b.hub.Deliver(event)
+	return nil
+}
+
+func (b *MemoryEventBroker) Start(ctx context.Context) {}
+
+// PostgresEventBroker публикует события через NOTIFY и получает их через LISTEN,
+// поэтому клиент получает событие, к какому бы экземпляру он ни был подключен.
+// Событие доставляется и отправившему экземпляру - через ту же подписку LISTEN.
+type PostgresEventBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	hub      *EventHub
+	logger   *logrus.Logger
+}
+
+func NewPostgresEventBroker(db *sql.DB, dsn string, hub *EventHub, logger *logrus.Logger) (*PostgresEventBroker, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logger.WithError(err).Warn("Потеряно соединение LISTEN потока событий")
+		case pq.ListenerEventReconnected:
+			logger.Info("Соединение LISTEN потока событий восстановлено")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logger.WithError(err).Warn("Не удалось подключиться для LISTEN потока событий")
+		}
+	})
+	if err := listener.Listen(eventNotifyChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("ошибка подписки на канал %s: %w", eventNotifyChannel, err)
+	}
+	return &PostgresEventBroker{db: db, listener: listener, hub: hub, logger: logger}, nil
+}
+
+func (b *PostgresEventBroker) Publish(ctx context.Context, event model.StreamEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события %s: %w", event.Type, err)
+	}
+	if len(payload) > eventNotifyMaxPayload {
+		return fmt.Errorf("событие %s превышает допустимый размер NOTIFY (%d байт)", event.Type, len(payload))
+	}
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, eventNotifyChannel, string(payload)); err != nil {
+		return fmt.Errorf("ошибка отправки события: %w", err)
+	}
+	return nil
+}
+
+// Start передает полученные через LISTEN события подписчикам экземпляра
+func (b *PostgresEventBroker) Start(ctx context.Context) {
+	defer b.listener.Close()
+
+	ticker := time.NewTicker(eventListenerPing)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("Прием событий через LISTEN остановлен")
+			return
+		case n := <-b.listener.Notify:
+			// nil приходит после переподключения: события за время разрыва потеряны,
+			// клиенты получат актуальное состояние при следующем запросе к API
+			if n == nil {
+				continue
+			}
+			var event model.StreamEvent
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				b.logger.WithError(err).Warn("Получено событие неизвестного формата")
+				continue
+			}
+			b.hub.Deliver(event)
+		case <-ticker.C:
+			go b.listener.Ping()
+		}
+	}
+}
+
+// EventPublisher формирует события потока по результатам операций. Вызывается после
+// подтверждения транзакции; ошибка публикации записывается в журнал и не влияет на операцию.
+type EventPublisher struct {
+	broker      EventBroker
+	accountRepo *repository.AccountRepository
+	logger      *logrus.Logger
+}
+
+func NewEventPublisher(broker EventBroker, accountRepo *repository.AccountRepository, logger *logrus.Logger) *EventPublisher {
+	return &EventPublisher{broker: broker, accountRepo: accountRepo, logger: logger}
+}
+
+// Transactions публикует новые операции и баланс затронутых счетов. Получатель события -
+// владелец счета, поэтому при переводе на чужой счет получатель тоже видит зачисление.
+func (p *EventPublisher) Transactions(ctx context.Context, transactions ...*model.Transaction) {
+	accounts := make(map[uuid.UUID]*model.Account)
+	var order []uuid.UUID
+
+	for _, transaction := range transactions {
+		account, ok := accounts[transaction.AccountID]
+		if !ok {
+			var err error
+			if account, err = p.accountRepo.GetByID(ctx, transaction.AccountID); err != nil {
+				p.logger.WithError(err).WithField("account_id", transaction.AccountID).Warn("Не удалось получить счет для события")
+				continue
+			}
+			accounts[transaction.AccountID] = account
+			order = append(order, transaction.AccountID)
+		}
+		p.publish(ctx, account.UserID, model.StreamTransactionCreated, transaction)
+	}
+
+	for _, accountID := range order {
+		account := accounts[accountID]
+		p.publish(ctx, account.UserID, model.StreamBalanceUpdated, model.BalanceUpdatedData{
+			AccountID: account.ID,
+			Balance:   account.Balance,
+		})
+	}
+}
+
+// CreditPayment публикует результат списания платежа по кредиту
+func (p *EventPublisher) CreditPayment(ctx context.Context, userID uuid.UUID, outcome model.CreditPaymentOutcome) {
+	p.publish(ctx, userID, model.StreamCreditPayment, outcome)
+}
+
+func (p *EventPublisher) publish(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		p.logger.WithError(err).Errorf("Ошибка сериализации события %s", eventType)
+		return
+	}
+	event := model.StreamEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		Data:      raw,
+	}
+	if err := p.broker.Publish(ctx, event); err != nil {
+		p.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":    userID,
+			"event_type": eventType,
+		}).Warn("Не удалось опубликовать событие потока")
+	}
+}
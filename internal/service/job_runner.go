@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	defaultJobRunsLimit = 50
+	maxJobRunsLimit     = 500
+)
+
+// JobFunc - тело фоновой задачи. Должна прекращать работу при отмене контекста.
+type JobFunc func(ctx context.Context) (model.JobStats, error)
+
+// JobRunner запускает фоновые задачи так, чтобы при нескольких экземплярах сервиса
+// каждая задача выполнялась только на одном из них, и записывает результаты запусков.
+type JobRunner struct {
+	jobRepo  *repository.JobRunRepository
+	instance string
+	logger   *logrus.Logger
+}
+
+func NewJobRunner(jobRepo *repository.JobRunRepository, logger *logrus.Logger) *JobRunner {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &JobRunner{
+		jobRepo:  jobRepo,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		logger:   logger,
+	}
+}
+
+// Run выполняет задачу под advisory-блокировкой. Если задачу уже выполняет другой
+// экземпляр, запуск пропускается.
+func (r *JobRunner) Run(ctx context.Context, name string, job JobFunc) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	release, ok, err := r.jobRepo.TryLock(ctx, name)
+	if err != nil {
+		r.logger.WithError(err).Errorf("Ошибка захвата блокировки задачи %s", name)
+		return
+	}
+	if !ok {
+		r.logger.Infof("Задача %s уже выполняется другим экземпляром, запуск пропущен", name)
+		return
+	}
+	defer release()
+
+	run := &model.JobRun{
+		ID:        uuid.New(),
+		JobName:   name,
+		Instance:  r.instance,
+		Status:    "running",
+		StartedAt: time.Now(),
+	}
+	if err := r.jobRepo.Create(ctx, run); err != nil {
+		r.logger.WithError(err).Errorf("Ошибка записи запуска задачи %s", name)
+		return
+	}
+
+	r.logger.Infof("Запуск задачи %s (%s)", name, run.ID)
+	stats, jobErr := job(ctx)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Processed = stats.Processed
+	run.Succeeded = stats.Succeeded
+	run.Failed = stats.Failed
+	run.Skipped = stats.Skipped
+
+	switch {
+	case ctx.Err() != nil:
+		run.Status = "cancelled"
+	case jobErr != nil:
+		run.Status = "failed"
+	default:
+		run.Status = "succeeded"
+	}
+	if jobErr != nil {
+		msg := jobErr.Error()
+		run.Error = &msg
+	}
+
+	// Контекст задачи может быть отменен при остановке сервиса, а результат нужно сохранить
+	if err := r.jobRepo.Finish(context.Background(), run); err != nil {
+		r.logger.WithError(err).Errorf("Ошибка сохранения результата задачи %s", name)
+	}
+
+	entry := r.logger.WithFields(logrus.Fields{
+		"job":       name,
+		"status":    run.Status,
+		"processed": run.Processed,
+		"succeeded": run.Succeeded,
+		"failed":    run.Failed,
+		"skipped":   run.Skipped,
+		"duration":  finishedAt.Sub(run.StartedAt).String(),
+	})
+	if jobErr != nil {
+		entry.WithError(jobErr).Error("Задача завершилась с ошибкой")
+		return
+	}
+	entry.Info("Задача завершена")
+}
+
+// ListRuns возвращает последние запуски задач
+func (r *JobRunner) ListRuns(ctx context.Context, jobName string, limit int) ([]model.JobRun, error) {
+	if limit <= 0 {
+		limit = defaultJobRunsLimit
+	}
+	if limit > maxJobRunsLimit {
+		limit = maxJobRunsLimit
+	}
+
+	runs, err := r.jobRepo.List(ctx, jobName, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения журнала задач")
+		return nil, fmt.Errorf("ошибка получения журнала задач: %w", err)
+	}
+	return runs, nil
+}
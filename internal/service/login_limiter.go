@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+// LoginLimiter хранит счетчики неудачных попыток входа. MemoryLoginLimiter подходит
+// для одного экземпляра сервиса, repository.LoginAttemptRepository - для нескольких.
+type LoginLimiter interface {
+	// Get возвращает счетчик по ключу или nil, если неудачных попыток не было
+	Get(ctx context.Context, key string) (*model.LoginAttempts, error)
+	// Increment увеличивает счетчик и возвращает число попыток в текущей серии.
+	// Серия начинается заново, если с последней попытки прошло больше resetAfter.
+	Increment(ctx context.Context, key string, resetAfter time.Duration) (int, error)
+	// Block запрещает вход по ключу до указанного момента
+	Block(ctx context.Context, key string, until time.Time) error
+	// Reset сбрасывает счетчик
+	Reset(ctx context.Context, key string) error
+	// DeleteStale удаляет неактивные счетчики без действующей блокировки
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+var _ LoginLimiter = (*repository.LoginAttemptRepository)(nil)
+
+// NewLoginLimiter выбирает хранилище счетчиков: "memory" или "postgres"
+func NewLoginLimiter(kind string, repo *repository.LoginAttemptRepository) (LoginLimiter, error) {
+	switch kind {
+	case "memory":
+		return NewMemoryLoginLimiter(), nil
+	case "postgres", "":
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("неизвестный тип ограничителя входа: %s", kind)
+	}
+}
+
+// MemoryLoginLimiter хранит счетчики в памяти процесса
+type MemoryLoginLimiter struct {
+	mu       sync.Mutex
+	attempts map[string]*model.LoginAttempts
+}
+
+func NewMemoryLoginLimiter() *MemoryLoginLimiter {
+	return &MemoryLoginLimiter{attempts: make(map[string]*model.LoginAttempts)}
+}
+
+func (l *MemoryLoginLimiter) Get(_ context.Context, key string) (*model.LoginAttempts, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (l *MemoryLoginLimiter) Increment(_ context.Context, key string, resetAfter time.Duration) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	a, ok := l.attempts[key]
+	if !ok {
+		a = &model.LoginAttempts{Key: key}
+		l.attempts[key] = a
+	}
+	if now.Sub(a.LastFailureAt) > resetAfter {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	return a.Failures, nil
+}
+
+func (l *MemoryLoginLimiter) Block(_ context.Context, key string, until time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[key]
+	if !ok {
+		return nil
+	}
+	if a.BlockedUntil == nil || a.BlockedUntil.Before(until) {
+		a.BlockedUntil = &until
+	}
+	return nil
+}
+
+func (l *MemoryLoginLimiter) Reset(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+	return nil
+}
+
+func (l *MemoryLoginLimiter) DeleteStale(_ context.Context, before time.Time) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for key, a := range l.attempts {
+		if a.LastFailureAt.Before(before) && (a.BlockedUntil == nil || a.BlockedUntil.Before(now)) {
+			delete(l.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// LoginPolicy задает ограничения для одного вида ключей (email или IP).
+// Первые FreeAttempts неудачных попыток не ограничиваются, затем каждая следующая
+// попытка откладывается на BaseDelay*2^n (не более MaxDelay). После LockoutAfter
+// неудачных попыток вход блокируется на LockoutDuration.
+type LoginPolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration // серия неудачных попыток забывается после этого периода без ошибок
+}
+
+// blockFor возвращает длительность запрета входа после failures неудачных попыток
+// и признак полной блокировки
+func (p LoginPolicy) blockFor(failures int) (time.Duration, bool) {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration, true
+	}
+	if failures <= p.FreeAttempts {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < failures-p.FreeAttempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, false
+}
+
+// LoginThrottledError возвращается, когда вход временно запрещен
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("слишком много неудачных попыток входа, повторите через %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard защищает вход от подбора пароля: считает неудачные попытки по email
+// и по IP адресу, откладывает повторные попытки и временно блокирует учетную запись
+type LoginGuard struct {
+	limiter       LoginLimiter
+	accountPolicy LoginPolicy
+	ipPolicy      LoginPolicy
+	notifications *NotificationSender
+	logger        *logrus.Logger
+}
+
+func NewLoginGuard(
+	limiter LoginLimiter,
+	accountPolicy LoginPolicy,
+	ipPolicy LoginPolicy,
+	notifications *NotificationSender,
+	logger *logrus.Logger,
+) *LoginGuard {
+	return &LoginGuard{
+		limiter:       limiter,
+		accountPolicy: accountPolicy,
+		ipPolicy:      ipPolicy,
+		notifications: notifications,
+		logger:        logger,
+	}
+}
+
+// Check возвращает LoginThrottledError, если вход для email или IP временно запрещен
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, key := range g.keys(email, ip) {
+		a, err := g.limiter.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки попыток входа: %w", err)
+		}
+		if a != nil && a.BlockedUntil != nil && a.BlockedUntil.After(now) {
+			if wait := a.BlockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		g.logger.WithFields(logrus.Fields{
+			"email": email,
+			"ip":    ip,
+		}).Warn("Попытка входа во время блокировки")
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Fail учитывает неудачную попытку входа. user равен nil, если email не зарегистрирован:
+// счетчик ведется и в этом случае, чтобы по ответам нельзя было определить наличие учетной записи.
+func (g *LoginGuard) Fail(ctx context.Context, email, ip string, user *model.User) error {
+	now := time.Now()
+	keys := g.keys(email, ip)
+
+	for i, key := range keys {
+		policy := g.accountPolicy
+		if i == 1 {
+			policy = g.ipPolicy
+		}
+
+		failures, err := g.limiter.Increment(ctx, key, policy.ResetAfter)
+		if err != nil {
+			return fmt.Errorf("ошибка учета попытки входа: %w", err)
+		}
+
+		delay, locked := policy.blockFor(failures)
+		if delay == 0 {
+			continue
+		}
+		until := now.Add(delay)
+		if err := g.limiter.Block(ctx, key, until); err != nil {
+			return fmt.Errorf("ошибка блокировки входа: %w", err)
+		}
+
+		if !locked {
+			continue
+		}
+		g.logger.WithFields(logrus.Fields{
+			"key":      key,
+			"failures": failures,
+			"until":    until,
+		}).Warn("Вход временно заблокирован после серии неудачных попыток")
+
+		// Письмо отправляется один раз за серию, при достижении порога
+		if i == 0 && user != nil && failures == policy.LockoutAfter {
+			go func(to Recipient) {
+				if err := g.notifications.SendAccountLockedNotification(to, until, ip); err != nil {
+					g.logger.WithError(err).Error("Не удалось отправить уведомление о блокировке входа")
+				}
+			}(RecipientOf(user))
+		}
+	}
+
+	return nil
+}
+
+// Succeed сбрасывает счетчик учетной записи после успешного входа.
+// Счетчик IP адреса не сбрасывается, иначе атакующий мог бы обнулять его своим аккаунтом.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) error {
+	return g.limiter.Reset(ctx, g.keys(email, "")[0])
+}
+
+// Cleanup удаляет неактивные счетчики
+func (g *LoginGuard) Cleanup(ctx context.Context) (model.JobStats, error) {
+	resetAfter := g.accountPolicy.ResetAfter
+	if g.ipPolicy.ResetAfter > resetAfter {
+		resetAfter = g.ipPolicy.ResetAfter
+	}
+
+	deleted, err := g.limiter.DeleteStale(ctx, time.Now().Add(-resetAfter))
+	if err != nil {
+		return model.JobStats{}, fmt.Errorf("ошибка очистки счетчиков входа: %w", err)
+	}
+	return model.JobStats{Processed: int(deleted), Succeeded: int(deleted)}, nil
+}
+
+// keys возвращает ключи счетчиков: учетной записи и IP адреса
+func (g *LoginGuard) keys(email, ip string) []string {
+	return []string{
+		"email:" + strings.ToLower(strings.TrimSpace(email)),
+		"ip:" + ip,
+	}
+}
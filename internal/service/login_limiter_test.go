@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var testLoginPolicy = LoginPolicy{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        10 * time.Second,
+	LockoutAfter:    10,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+func TestLoginPolicyBlockFor(t *testing.T) {
+	tests := []struct {
+		failures   int
+		wantDelay  time.Duration
+		wantLocked bool
+	}{
+		{failures: 1},
+		{failures: 3},
+		{failures: 4, wantDelay: time.Second},
+		{failures: 5, wantDelay: 2 * time.Second},
+		{failures: 6, wantDelay: 4 * time.Second},
+		{failures: 7, wantDelay: 8 * time.Second},
+		{failures: 8, wantDelay: 10 * time.Second},
+		{failures: 9, wantDelay: 10 * time.Second},
+		{failures: 10, wantDelay: 15 * time.Minute, wantLocked: true},
+		{failures: 25, wantDelay: 15 * time.Minute, wantLocked: true},
+	}
+
+	for _, tt := range tests {
+		delay, locked := testLoginPolicy.blockFor(tt.failures)
+		if delay != tt.wantDelay || locked != tt.wantLocked {
+			t.Errorf("попыток %d: получено (%s, %v), ожидалось (%s, %v)",
+				tt.failures, delay, locked, tt.wantDelay, tt.wantLocked)
+		}
+	}
+}
+
+func TestLoginPolicyBlockForWithoutLockout(t *testing.T) {
+	policy := testLoginPolicy
+	policy.LockoutAfter = 0
+
+	delay, locked := policy.blockFor(1000)
+	if delay != policy.MaxDelay || locked {
+		t.Fatalf("получено (%s, %v), без блокировки ожидалась задержка %s", delay, locked, policy.MaxDelay)
+	}
+}
+
+func TestMemoryLoginLimiterIncrement(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLoginLimiter()
+
+	if a, _ := limiter.Get(ctx, "email:a@example.com"); a != nil {
+		t.Fatalf("счетчик до первой ошибки: %+v", a)
+	}
+	for want := 1; want <= 3; want++ {
+		got, err := limiter.Increment(ctx, "email:a@example.com", time.Hour)
+		if err != nil {
+			t.Fatalf("увеличение счетчика: %v", err)
+		}
+		if got != want {
+			t.Fatalf("попыток %d, ожидалось %d", got, want)
+		}
+	}
+	if got, _ := limiter.Increment(ctx, "email:b@example.com", time.Hour); got != 1 {
+		t.Fatalf("счетчики разных ключей не должны смешиваться: %d", got)
+	}
+}
+
+func TestMemoryLoginLimiterSeriesReset(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLoginLimiter()
+	key := "email:a@example.com"
+
+	limiter.Increment(ctx, key, time.Hour)
+	limiter.Increment(ctx, key, time.Hour)
+
+	// Последняя ошибка была раньше ResetAfter: серия начинается заново
+	limiter.attempts[key].LastFailureAt = time.Now().Add(-2 * time.Hour)
+	if got, _ := limiter.Increment(ctx, key, time.Hour); got != 1 {
+		t.Fatalf("после паузы попыток %d, ожидалось 1", got)
+	}
+
+	limiter.attempts[key].LastFailureAt = time.Now().Add(-30 * time.Minute)
+	if got, _ := limiter.Increment(ctx, key, time.Hour); got != 2 {
+		t.Fatalf("в пределах ResetAfter попыток %d, ожидалось 2", got)
+	}
+}
+
+func TestMemoryLoginLimiterBlock(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLoginLimiter()
+	key := "ip:192.0.2.1"
+	now := time.Now()
+
+	// Без неудачных попыток блокировать нечего
+	limiter.Block(ctx, key, now.Add(time.Hour))
+	if a, _ := limiter.Get(ctx, key); a != nil {
+		t.Fatalf("блокировка без попыток создала счетчик: %+v", a)
+	}
+
+	limiter.Increment(ctx, key, time.Hour)
+	limiter.Block(ctx, key, now.Add(time.Hour))
+	// Более короткая задержка не сокращает действующую блокировку
+	limiter.Block(ctx, key, now.Add(time.Minute))
+
+	a, _ := limiter.Get(ctx, key)
+	if a == nil || a.BlockedUntil == nil || !a.BlockedUntil.Equal(now.Add(time.Hour)) {
+		t.Fatalf("неожиданная блокировка: %+v", a)
+	}
+
+	limiter.Reset(ctx, key)
+	if a, _ := limiter.Get(ctx, key); a != nil {
+		t.Fatalf("счетчик после сброса: %+v", a)
+	}
+}
+
+func TestMemoryLoginLimiterDeleteStale(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLoginLimiter()
+	now := time.Now()
+
+	for _, key := range []string{"stale", "blocked", "fresh"} {
+		limiter.Increment(ctx, key, time.Hour)
+	}
+	limiter.attempts["stale"].LastFailureAt = now.Add(-2 * time.Hour)
+	limiter.attempts["blocked"].LastFailureAt = now.Add(-2 * time.Hour)
+	limiter.Block(ctx, "blocked", now.Add(time.Hour))
+
+	deleted, err := limiter.DeleteStale(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("очистка: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("удалено %d, ожидалось 1", deleted)
+	}
+	for key, want := range map[string]bool{"stale": false, "blocked": true, "fresh": true} {
+		if a, _ := limiter.Get(ctx, key); (a != nil) != want {
+			t.Errorf("%s: счетчик сохранен = %v, ожидалось %v", key, a != nil, want)
+		}
+	}
+}
+
+// TestLoginGuardLockout проверяет, что после LockoutAfter ошибок вход запрещается
+// на LockoutDuration, а успешный вход сбрасывает счетчик учетной записи
+func TestLoginGuardLockout(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	policy := testLoginPolicy
+	policy.FreeAttempts = 10
+	ipPolicy := policy
+	ipPolicy.LockoutAfter = 0
+	guard := NewLoginGuard(NewMemoryLoginLimiter(), policy, ipPolicy, nil, logger)
+
+	for i := 1; i < policy.LockoutAfter; i++ {
+		guard.Fail(ctx, "A@example.com", "192.0.2.1", nil)
+		if err := guard.Check(ctx, "a@example.com", "192.0.2.2"); err != nil {
+			t.Fatalf("попытка %d: вход запрещен до блокировки: %v", i, err)
+		}
+	}
+
+	guard.Fail(ctx, "a@example.com", "192.0.2.1", nil)
+	var throttled *LoginThrottledError
+	if err := guard.Check(ctx, "a@example.com", "192.0.2.2"); !errors.As(err, &throttled) {
+		t.Fatalf("ожидалась блокировка, получено: %v", err)
+	}
+	if throttled.RetryAfter <= policy.LockoutDuration-time.Minute || throttled.RetryAfter > policy.LockoutDuration {
+		t.Fatalf("блокировка на %s, ожидалось %s", throttled.RetryAfter, policy.LockoutDuration)
+	}
+
+	guard.Succeed(ctx, "a@example.com")
+	if err := guard.Check(ctx, "a@example.com", "192.0.2.2"); err != nil {
+		t.Fatalf("после сброса вход запрещен: %v", err)
+	}
+}
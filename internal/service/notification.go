@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/notify"
+	"banking-api/internal/repository"
+)
+
+const (
+	maxAlertsPerUser      = 20
+	defaultInboxListLimit = 50
+	maxInboxListLimit     = 200
+	inboxRetention        = 180 * 24 * time.Hour // срок хранения входящих уведомлений
+)
+
+// NotificationService применяет настройки уведомлений пользователя: выбирает личные каналы,
+// откладывает уведомления до ежедневной сводки и проверяет пороговые оповещения.
+// Также управляет входящими уведомлениями в приложении.
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	accountRepo      *repository.AccountRepository
+	userRepo         *repository.UserRepository
+	notifications    *NotificationSender
+	logger           *logrus.Logger
+}
+
+func NewNotificationService(
+	notificationRepo *repository.NotificationRepository,
+	accountRepo *repository.AccountRepository,
+	userRepo *repository.UserRepository,
+	notifications *NotificationSender,
+	logger *logrus.Logger,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		accountRepo:      accountRepo,
+		userRepo:         userRepo,
+		notifications:    notifications,
+		logger:           logger,
+	}
+}
+
+// GetSettings возвращает настройки уведомлений: все типы и каналы с учетом значений по умолчанию
+func (s *NotificationService) GetSettings(ctx context.Context, userID uuid.UUID) (*model.NotificationSettings, error) {
+	chatID, err := s.notificationRepo.GetTelegramChatID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := &model.NotificationSettings{Preferences: stored}
+	settings := &model.NotificationSettings{TelegramChatID: chatID}
+	for _, eventType := range model.ConfigurableNotifications {
+		for _, channel := range model.NotificationChannels {
+			settings.Preferences = append(settings.Preferences, model.NotificationPreference{
+				EventType: eventType,
+				Channel:   channel,
+				Mode:      saved.Mode(eventType, channel),
+			})
+		}
+	}
+	return settings, nil
+}
+
+// UpdateSettings сохраняет личный чат Telegram и способы доставки уведомлений
+func (s *NotificationService) UpdateSettings(ctx context.Context, userID uuid.UUID, input model.UpdateNotificationSettingsInput) (*model.NotificationSettings, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем итоговые настройки: Telegram нельзя включить без личного чата
+	chatID := current.TelegramChatID
+	if input.TelegramChatID != nil {
+		chatID = input.TelegramChatID
+		if *chatID == "" {
+			chatID = nil
+		}
+	}
+	result := &model.NotificationSettings{Preferences: append(input.Preferences, current.Preferences...)}
+	if chatID == nil {
+		for _, eventType := range model.ConfigurableNotifications {
+			if result.Mode(eventType, model.NotificationChannelTelegram) != model.NotificationModeOff {
+				return nil, fmt.Errorf("для уведомлений в Telegram укажите telegram_chat_id")
+			}
+		}
+	}
+
+	tx, err := s.notificationRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if input.TelegramChatID != nil {
+		if err := s.notificationRepo.SetTelegramChatIDTx(ctx, tx, userID, chatID); err != nil {
+			return nil, err
+		}
+	}
+	for _, pref := range input.Preferences {
+		if err := s.notificationRepo.UpsertPreferenceTx(ctx, tx, userID, pref); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения настроек: %w", err)
+	}
+
+	s.logger.WithField("user_id", userID).Info("Настройки уведомлений изменены")
+	return s.GetSettings(ctx, userID)
+}
+
+// ListAlerts возвращает пороговые оповещения пользователя
+func (s *NotificationService) ListAlerts(ctx context.Context, userID uuid.UUID) ([]model.NotificationAlert, error) {
+	return s.notificationRepo.ListAlerts(ctx, userID, "")
+}
+
+// CreateAlert создает пороговое оповещение
+func (s *NotificationService) CreateAlert(ctx context.Context, userID uuid.UUID, input model.CreateAlertInput) (*model.NotificationAlert, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if input.AccountID != nil {
+		account, err := s.accountRepo.GetByID(ctx, *input.AccountID)
+		if err != nil || account.UserID != userID {
+			return nil, fmt.Errorf("счет не найден")
+		}
+	}
+
+	existing, err := s.notificationRepo.ListAlerts(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAlertsPerUser {
+		return nil, fmt.Errorf("достигнуто максимальное число оповещений (%d)", maxAlertsPerUser)
+	}
+
+	alert := &model.NotificationAlert{
+		ID:        uuid.New(),
+		UserID:    userID,
+		AlertType: input.AlertType,
+		AccountID: input.AccountID,
+		Threshold: math.Round(input.Threshold*100) / 100,
+		CreatedAt: time.Now(),
+	}
+	if err := s.notificationRepo.CreateAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"alert_id":   alert.ID,
+		"alert_type": alert.AlertType,
+	}).Info("Создано пороговое оповещение")
+	return alert, nil
+}
+
+// DeleteAlert удаляет пороговое оповещение
+func (s *NotificationService) DeleteAlert(ctx context.Context, userID, alertID uuid.UUID) error {
+	return s.notificationRepo.DeleteAlert(ctx, userID, alertID)
+}
+
+// ListInbox возвращает входящие уведомления пользователя, новые первыми
+func (s *NotificationService) ListInbox(ctx context.Context, userID uuid.UUID, filter model.InboxFilter) ([]model.InboxItem, error) {
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultInboxListLimit
+	case filter.Limit > maxInboxListLimit:
+		filter.Limit = maxInboxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.notificationRepo.ListInbox(ctx, userID, filter)
+}
+
+// UnreadCount возвращает число непрочитанных уведомлений
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uuid.UUID) (*model.InboxUnreadCount, error) {
+	count, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.InboxUnreadCount{Unread: count}, nil
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (s *NotificationService) MarkRead(ctx context.Context, userID, itemID uuid.UUID) (*model.InboxItem, error) {
+	return s.notificationRepo.MarkRead(ctx, userID, itemID, time.Now())
+}
+
+// MarkAllRead отмечает прочитанными все уведомления и возвращает оставшееся число
+// непрочитанных (уведомления, полученные во время запроса, остаются непрочитанными)
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (*model.InboxUnreadCount, error) {
+	marked, err := s.notificationRepo.MarkAllRead(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{"user_id": userID, "marked": marked}).Debug("Входящие уведомления отмечены прочитанными")
+	return s.UnreadCount(ctx, userID)
+}
+
+// CleanupInbox удаляет входящие уведомления старше inboxRetention
+func (s *NotificationService) CleanupInbox(ctx context.Context) (model.JobStats, error) {
+	deleted, err := s.notificationRepo.DeleteInboxBefore(ctx, time.Now().Add(-inboxRetention))
+	if err != nil {
+		return model.JobStats{}, err
+	}
+	return model.JobStats{Processed: int(deleted), Succeeded: int(deleted)}, nil
+}
+
+// Notify доставляет уведомление с учетом настроек пользователя: сразу по выбранным личным
+// каналам и (или) в ежедневную сводку. sourceID - событие outbox, из которого получено
+// уведомление; повторная обработка события не дублирует уведомление в сводке.
+// Каналы, не являющиеся личными (webhook, журнал), получают уведомление по своему маршруту.
+// Во входящих уведомление сохраняется независимо от настроек каналов.
+func (s *NotificationService) Notify(ctx context.Context, user *model.User, sourceID uuid.UUID, msg notify.Message) error {
+	settings, err := s.GetSettings(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	channels := []string{}
+	for _, channel := range model.NotificationChannels {
+		switch settings.Mode(msg.Event, channel) {
+		case model.NotificationModeImmediate:
+			if channel == model.NotificationChannelTelegram {
+				if settings.TelegramChatID == nil {
+					continue
+				}
+				msg.ChatID = *settings.TelegramChatID
+			}
+			channels = append(channels, channel)
+		case model.NotificationModeDigest:
+			err := s.notificationRepo.AddDigestItem(ctx, &model.DigestItem{
+				ID:        uuid.New(),
+				UserID:    user.ID,
+				SourceID:  sourceID,
+				EventType: msg.Event,
+				Subject:   msg.Subject,
+				Body:      msg.Summary,
+				CreatedAt: msg.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	msg.Channels = channels
+	msg.SourceID = sourceID
+	return s.notifications.Deliver(msg)
+}
+
+// OnBalanceChanged уведомляет о зачислении и проверяет оповещения о снижении баланса.
+// Оповещение срабатывает, когда баланс пересекает порог сверху вниз, поэтому при
+// дальнейших списаниях ниже порога оно не повторяется.
+func (s *NotificationService) OnBalanceChanged(ctx context.Context, user *model.User, sourceID uuid.UUID, event model.BalanceChangedEvent) error {
+	if event.Amount > 0 {
+		msg, err := s.notifications.IncomingFundsMessage(RecipientOf(user), event.AccountID, event.Amount, event.Balance)
+		if err != nil {
+			return err
+		}
+		if err := s.Notify(ctx, user, sourceID, msg); err != nil {
+			return err
+		}
+	}
+	if event.Amount >= 0 {
+		return nil
+	}
+
+	alerts, err := s.notificationRepo.ListAlerts(ctx, user.ID, model.AlertBalanceBelow)
+	if err != nil {
+		return err
+	}
+
+	// При пересечении нескольких порогов одним списанием отправляется одно оповещение
+	// по наименьшему из них
+	after := toKopecks(event.Balance)
+	before := after - toKopecks(event.Amount)
+	var crossed *model.NotificationAlert
+	for i, alert := range alerts {
+		if alert.AccountID != nil && *alert.AccountID != event.AccountID {
+			continue
+		}
+		threshold := toKopecks(alert.Threshold)
+		if before >= threshold && after < threshold && (crossed == nil || alert.Threshold < crossed.Threshold) {
+			crossed = &alerts[i]
+		}
+	}
+	if crossed == nil {
+		return nil
+	}
+
+	msg, err := s.notifications.BalanceAlertMessage(RecipientOf(user), event.AccountID, crossed.Threshold, event.Balance)
+	if err != nil {
+		return err
+	}
+	return s.Notify(ctx, user, sourceID, msg)
+}
+
+// OnCardPayment уведомляет об оплате картой и проверяет оповещения о крупных оплатах
+func (s *NotificationService) OnCardPayment(ctx context.Context, user *model.User, sourceID uuid.UUID, event model.CardPaymentEvent) error {
+	msg, err := s.notifications.PaymentMessage(RecipientOf(user), event.Amount)
+	if err != nil {
+		return err
+	}
+	if err := s.Notify(ctx, user, sourceID, msg); err != nil {
+		return err
+	}
+
+	alerts, err := s.notificationRepo.ListAlerts(ctx, user.ID, model.AlertCardPaymentAbove)
+	if err != nil {
+		return err
+	}
+
+	// Срабатывает оповещение с наибольшим превышенным порогом
+	var exceeded *model.NotificationAlert
+	for i, alert := range alerts {
+		if toKopecks(event.Amount) > toKopecks(alert.Threshold) && (exceeded == nil || alert.Threshold > exceeded.Threshold) {
+			exceeded = &alerts[i]
+		}
+	}
+	if exceeded == nil {
+		return nil
+	}
+
+	msg, err = s.notifications.CardPaymentAlertMessage(RecipientOf(user), event.CardID, event.Amount, exceeded.Threshold)
+	if err != nil {
+		return err
+	}
+	return s.Notify(ctx, user, sourceID, msg)
+}
+
+// SendDigests отправляет ежедневные сводки отложенных уведомлений. Уведомления удаляются
+// в одной транзакции с отправкой: при ошибке они попадут в сводку следующего запуска.
+func (s *NotificationService) SendDigests(ctx context.Context) (model.JobStats, error) {
+	var stats model.JobStats
+
+	userIDs, err := s.notificationRepo.DigestUsers(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+		stats.Processed++
+		if err := s.sendDigest(ctx, userID); err != nil {
+			s.logger.WithError(err).WithField("user_id", userID).Error("Не удалось отправить сводку уведомлений")
+			stats.Failed++
+			continue
+		}
+		stats.Succeeded++
+	}
+
+	return stats, nil
+}
+
+func (s *NotificationService) sendDigest(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+
+	tx, err := s.notificationRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	items, err := s.notificationRepo.TakeDigestItemsTx(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Пользователь удален: накопленные уведомления отбрасываются
+	if len(items) > 0 && user != nil && user.DeletedAt == nil {
+		msg, err := s.notifications.DigestMessage(RecipientOf(user), items)
+		if err != nil {
+			return err
+		}
+		msg.Channels = []string{model.NotificationChannelEmail}
+		if err := s.notifications.Deliver(msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+	return nil
+}
+
+// toKopecks переводит сумму в копейки для точного сравнения с порогом
+func toKopecks(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-api/internal/model"
+	"banking-api/internal/notify"
+)
+
+// NotificationPreview - уведомление, формируемое на тестовых данных. Name совпадает
+// с именем шаблона.
+type NotificationPreview struct {
+	Name  string
+	Build func(s *NotificationSender, to Recipient) (notify.Message, error)
+}
+
+var (
+	// PreviewClock - время, подставляемое в уведомления на тестовых данных
+	PreviewClock      = time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+	previewAccountID  = uuid.MustParse("0b6c7a52-3f0e-4d8a-9a51-2c1f8e6d4b10")
+	previewAccount2ID = uuid.MustParse("5d2e9f41-7a3b-4c6d-8e1f-9a0b1c2d3e4f")
+	previewCardID     = uuid.MustParse("9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a")
+	previewCreditID   = uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+	previewBudgetID   = uuid.MustParse("6c5b4a39-2817-4f06-9e5d-4c3b2a190817")
+)
+
+// recordedMessage возвращает уведомление, переданное в канал методом Send*
+func recordedMessage(recorder *notify.Recorder, send func() error) (notify.Message, error) {
+	recorder.Reset()
+	if err := send(); err != nil {
+		return notify.Message{}, err
+	}
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		return notify.Message{}, fmt.Errorf("ожидалось одно уведомление, отправлено %d", len(messages))
+	}
+	return messages[0], nil
+}
+
+// NotificationPreviews возвращает все уведомления на тестовых данных: по одному на каждый
+// шаблон. Уведомления, которые отправитель передает в канал сразу (методы Send*),
+// перехватываются recorder, поэтому отправитель должен использовать его как канал.
+func NotificationPreviews(recorder *notify.Recorder) []NotificationPreview {
+	return []NotificationPreview{
+		{model.NotificationCardPayment, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.PaymentMessage(to, 1250.5)
+		}},
+		{model.NotificationTransferCompleted, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.TransferMessage(to, 5000, previewAccountID.String(), previewAccount2ID.String())
+		}},
+		{model.NotificationCreditPayment, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CreditPaymentMessage(to, 18432.17, previewCreditID)
+		}},
+		{model.NotificationIncomingFunds, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.IncomingFundsMessage(to, previewAccountID, 75000, 81234.56)
+		}},
+		{alertTemplate(model.AlertBalanceBelow), func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.BalanceAlertMessage(to, previewAccountID, 1000, 742.3)
+		}},
+		{alertTemplate(model.AlertCardPaymentAbove), func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CardPaymentAlertMessage(to, previewCardID, 30000, 10000)
+		}},
+		{model.NotificationBudgetAlert, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			category := model.CategoryRestaurants
+			return s.BudgetAlertMessage(to, model.BudgetProgress{
+				Budget:      model.Budget{ID: previewBudgetID, Category: &category, Amount: 15000},
+				Month:       "2025-03",
+				Spent:       12340.5,
+				Remaining:   2659.5,
+				PercentUsed: 82.27,
+				Projected:   27330.25,
+			}, model.BudgetAlertWarning)
+		}},
+		{model.NotificationDailyDigest, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.DigestMessage(to, []model.DigestItem{
+				{
+					EventType: model.NotificationCardPayment,
+					Subject:   "Уведомление о платеже (оплата картой)",
+					Body:      "Тип платежа: оплата картой\nСумма: 1250.50 RUB",
+					CreatedAt: PreviewClock.Add(-20 * time.Hour),
+				},
+				{
+					EventType: model.NotificationIncomingFunds,
+					Subject:   "Зачисление 75000.00 RUB",
+					Body:      "На счет " + previewAccountID.String() + " зачислено 75000.00 RUB\nБаланс: <81234.56> RUB",
+					CreatedAt: PreviewClock.Add(-3 * time.Hour),
+				},
+			})
+		}},
+		{model.NotificationCreditLineStatement, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CreditLineStatementMessage(to, 42000, 2100, time.Date(2025, 4, 8, 0, 0, 0, 0, time.UTC))
+		}},
+		{model.NotificationCreditRateChanged, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CreditRateChangeMessage(to, previewCreditID, 19.5, 21, 26540.12, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+		}},
+		{model.NotificationRestructuringDecision, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.RestructuringDecisionMessage(to, previewCreditID, true, `Срок увеличен до 36 мес. <script>alert("x")</script> & ставка без изменений`, 15230.4)
+		}},
+		{model.NotificationCollectionReminder, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CollectionReminderMessage(to, previewCreditID, 36864.34, 15)
+		}},
+		{model.NotificationCreditDefaulted, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return s.CreditDefaultMessage(to, previewCreditID, 110592.99)
+		}},
+		{model.NotificationLoginLocked, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendAccountLockedNotification(to, PreviewClock.Add(15*time.Minute), "203.0.113.7")
+			})
+		}},
+		{model.NotificationEmailVerification, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendEmailVerification(to, "https://bank.example/auth/verify-email?token=abc.def&lang=ru", PreviewClock.Add(24*time.Hour))
+			})
+		}},
+		{model.NotificationPasswordReset, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendPasswordReset(to, "K7Q2-M9XP", PreviewClock.Add(30*time.Minute))
+			})
+		}},
+		{model.NotificationPasswordChanged, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendPasswordChanged(to, "198.51.100.23")
+			})
+		}},
+		{model.NotificationEmailChangeConfirmation, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendEmailChangeConfirmation(to, "https://bank.example/auth/verify-email?token=ghi.jkl", PreviewClock.Add(24*time.Hour))
+			})
+		}},
+		{model.NotificationEmailChanged, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendEmailChanged(to, "new.address@example.com")
+			})
+		}},
+		{model.NotificationAccountDeleted, func(s *NotificationSender, to Recipient) (notify.Message, error) {
+			return recordedMessage(recorder, func() error {
+				return s.SendAccountDeleted(to)
+			})
+		}},
+	}
+}
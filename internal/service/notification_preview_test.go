@@ -0,0 +1,122 @@
+package service
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/notify"
+)
+
+// Обновление эталонов после намеренного изменения шаблонов:
+//
+//	go test ./internal/service -run TestNotificationTemplates -update
+var updateGolden = flag.Bool("update", false, "перезаписать эталонные файлы уведомлений")
+
+const goldenDir = "testdata/notifications"
+
+// TestNotificationTemplates формирует все уведомления на тестовых данных для каждого языка
+// и сравнивает их с эталонами, чтобы изменения шаблонов и отправителя не меняли текст
+// писем незаметно
+func TestNotificationTemplates(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	templates, err := notify.NewTemplates("", logger)
+	if err != nil {
+		t.Fatalf("загрузка шаблонов: %v", err)
+	}
+	recorder := notify.NewRecorder("")
+	sender := NewNotificationSender(recorder, templates, nil, true, logger)
+	sender.SetClock(func() time.Time { return PreviewClock })
+
+	for _, locale := range notify.Locales {
+		to := Recipient{Email: "client@example.com", Locale: locale}
+		for _, preview := range NotificationPreviews(recorder) {
+			t.Run(locale+"/"+preview.Name, func(t *testing.T) {
+				msg, err := preview.Build(sender, to)
+				if err != nil {
+					t.Fatalf("формирование уведомления: %v", err)
+				}
+				got := formatGolden(msg)
+
+				path := filepath.Join(goldenDir, locale, preview.Name+".golden")
+				if *updateGolden {
+					if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("эталон не найден (обновите флагом -update): %v", err)
+				}
+				if line, w, g := firstDiff(string(want), got); line > 0 {
+					t.Errorf("строка %d отличается от эталона\n  эталон:   %q\n  получено: %q", line, w, g)
+				}
+			})
+		}
+	}
+}
+
+// TestNotificationPreviewsCoverTemplates проверяет, что для каждого встроенного шаблона
+// есть уведомление на тестовых данных, а значит и эталон
+func TestNotificationPreviewsCoverTemplates(t *testing.T) {
+	templates, err := notify.NewTemplates("", nil)
+	if err != nil {
+		t.Fatalf("загрузка шаблонов: %v", err)
+	}
+
+	covered := make(map[string]bool)
+	for _, preview := range NotificationPreviews(notify.NewRecorder("")) {
+		covered[preview.Name] = true
+	}
+	for _, name := range templates.Names() {
+		if !covered[name] {
+			t.Errorf("нет уведомления на тестовых данных для шаблона %s", name)
+		}
+	}
+}
+
+// formatGolden представляет уведомление в виде, который хранится в эталонном файле
+func formatGolden(msg notify.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Event: %s\n", msg.Event)
+	fmt.Fprintf(&b, "To: %s\n", msg.Email)
+	fmt.Fprintf(&b, "Subject: %s\n", msg.Subject)
+	fmt.Fprintf(&b, "Sensitive: %t\n", msg.Sensitive)
+	b.WriteString("\n--- text ---\n")
+	b.WriteString(msg.Text)
+	b.WriteString("\n\n--- html ---\n")
+	b.WriteString(msg.HTML)
+	return b.String()
+}
+
+// firstDiff возвращает номер первой различающейся строки и сами строки
+func firstDiff(want, got string) (int, string, string) {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return i + 1, w, g
+		}
+	}
+	return 0, "", ""
+}
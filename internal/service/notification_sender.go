@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/notify"
+	"banking-api/internal/repository"
+)
+
+// notificationTimeout ограничивает доставку одного уведомления по всем каналам
+const notificationTimeout = 30 * time.Second
+
+// inboxSkipped - события, уведомления о которых не сохраняются во входящих: сводка повторяет
+// уже сохраненные уведомления, а после удаления учетной записи входящие недоступны
+var inboxSkipped = map[string]bool{
+	model.NotificationDailyDigest:    true,
+	model.NotificationAccountDeleted: true,
+}
+
+// Recipient - получатель уведомления: пользователь, адрес и язык, на котором формируется текст.
+// Без UserID уведомление не сохраняется во входящих.
+type Recipient struct {
+	UserID uuid.UUID
+	Email  string
+	Locale string
+}
+
+// RecipientOf возвращает получателя уведомлений для пользователя
+func RecipientOf(user *model.User) Recipient {
+	return Recipient{UserID: user.ID, Email: user.Email, Locale: user.Locale}
+}
+
+// NotificationSender формирует уведомления о событиях по шаблонам на языке получателя
+// и передает их в каналы доставки. Какие каналы (email, webhook, Telegram, журнал)
+// получают событие, определяет маршрутизатор. Кроме того, уведомление сохраняется
+// во входящих получателя в приложении.
+type NotificationSender struct {
+	notifier     notify.Notifier
+	templates    *notify.Templates
+	inbox        *repository.NotificationRepository
+	emailEnabled bool
+	now          func() time.Time
+	logger       *logrus.Logger
+}
+
+// NewNotificationSender создает отправителя уведомлений. emailEnabled сообщает, настроен ли
+// канал email: без него ссылки подтверждения выводятся в журнал для локальной разработки.
+// inbox может быть nil - тогда уведомления не сохраняются во входящих.
+func NewNotificationSender(
+	notifier notify.Notifier,
+	templates *notify.Templates,
+	inbox *repository.NotificationRepository,
+	emailEnabled bool,
+	logger *logrus.Logger,
+) *NotificationSender {
+	return &NotificationSender{
+		notifier:     notifier,
+		templates:    templates,
+		inbox:        inbox,
+		emailEnabled: emailEnabled,
+		now:          time.Now,
+		logger:       logger,
+	}
+}
+
+// SetClock заменяет источник текущего времени, которое подставляется в уведомления.
+// Используется для формирования эталонных уведомлений.
+func (s *NotificationSender) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// PaymentMessage формирует уведомление об оплате картой
+func (s *NotificationSender) PaymentMessage(to Recipient, amount float64) (notify.Message, error) {
+	return s.compose(to, model.NotificationCardPayment, model.NotificationCardPayment, map[string]interface{}{
+		"Amount": amount,
+		"Date":   s.now(),
+	}, false, map[string]string{
+		"payment_type": "card",
+		"amount":       formatAmount(amount),
+	})
+}
+
+// TransferMessage формирует уведомление о переводе между счетами
+func (s *NotificationSender) TransferMessage(to Recipient, amount float64, from, toAccount string) (notify.Message, error) {
+	return s.compose(to, model.NotificationTransferCompleted, model.NotificationTransferCompleted, map[string]interface{}{
+		"Amount":        amount,
+		"FromAccountID": from,
+		"ToAccountID":   toAccount,
+		"Date":          s.now(),
+	}, false, map[string]string{
+		"amount":          formatAmount(amount),
+		"from_account_id": from,
+		"to_account_id":   toAccount,
+	})
+}
+
+// CreditPaymentMessage формирует уведомление о списании платежа по кредиту
+func (s *NotificationSender) CreditPaymentMessage(to Recipient, amount float64, creditID uuid.UUID) (notify.Message, error) {
+	return s.compose(to, model.NotificationCreditPayment, model.NotificationCreditPayment, map[string]interface{}{
+		"CreditID": creditID.String(),
+		"Amount":   amount,
+		"Date":     s.now(),
+	}, false, map[string]string{
+		"credit_id": creditID.String(),
+		"amount":    formatAmount(amount),
+	})
+}
+
+// IncomingFundsMessage формирует уведомление о зачислении на счет
+func (s *NotificationSender) IncomingFundsMessage(to Recipient, accountID uuid.UUID, amount, balance float64) (notify.Message, error) {
+	return s.compose(to, model.NotificationIncomingFunds, model.NotificationIncomingFunds, map[string]interface{}{
+		"AccountID": accountID.String(),
+		"Amount":    amount,
+		"Balance":   balance,
+		"Date":      s.now(),
+	}, false, map[string]string{
+		"account_id": accountID.String(),
+		"amount":     formatAmount(amount),
+		"balance":    formatAmount(balance),
+	})
+}
+
+// BalanceAlertMessage формирует оповещение о снижении баланса ниже порога
+func (s *NotificationSender) BalanceAlertMessage(to Recipient, accountID uuid.UUID, threshold, balance float64) (notify.Message, error) {
+	return s.compose(to, model.NotificationThresholdAlert, alertTemplate(model.AlertBalanceBelow), map[string]interface{}{
+		"AccountID": accountID.String(),
+		"Threshold": threshold,
+		"Balance":   balance,
+		"Date":      s.now(),
+	}, false, map[string]string{
+		"alert_type": model.AlertBalanceBelow,
+		"account_id": accountID.String(),
+		"threshold":  formatAmount(threshold),
+		"balance":    formatAmount(balance),
+	})
+}
+
+// CardPaymentAlertMessage формирует оповещение об оплате картой на сумму выше порога
+func (s *NotificationSender) CardPaymentAlertMessage(to Recipient, cardID uuid.UUID, amount, threshold float64) (notify.Message, error) {
+	return s.compose(to, model.NotificationThresholdAlert, alertTemplate(model.AlertCardPaymentAbove), map[string]interface{}{
+		"CardID":    cardID.String(),
+		"Amount":    amount,
+		"Threshold": threshold,
+		"Date":      s.now(),
+	}, false, map[string]string{
+		"alert_type": model.AlertCardPaymentAbove,
+		"card_id":    cardID.String(),
+		"amount":     formatAmount(amount),
+		"threshold":  formatAmount(threshold),
+	})
+}
+
+// BudgetAlertMessage формирует оповещение о расходовании threshold процентов месячного бюджета
+func (s *NotificationSender) BudgetAlertMessage(to Recipient, progress model.BudgetProgress, threshold int) (notify.Message, error) {
+	category := ""
+	if progress.Category != nil {
+		category = *progress.Category
+	}
+	return s.compose(to, model.NotificationBudgetAlert, model.NotificationBudgetAlert, map[string]interface{}{
+		"Category":    category,
+		"Month":       progress.Month,
+		"Threshold":   threshold,
+		"Amount":      progress.Amount,
+		"Spent":       progress.Spent,
+		"Remaining":   progress.Remaining,
+		"Overspent":   roundMoney(progress.Spent - progress.Amount),
+		"PercentUsed": progress.PercentUsed,
+		"Projected":   progress.Projected,
+		"Date":        s.now(),
+	}, false, map[string]string{
+		"budget_id": progress.ID.String(),
+		"category":  category,
+		"month":     progress.Month,
+		"threshold": strconv.Itoa(threshold),
+		"amount":    formatAmount(progress.Amount),
+		"spent":     formatAmount(progress.Spent),
+	})
+}
+
+// DigestMessage формирует ежедневную сводку из отложенных уведомлений
+func (s *NotificationSender) DigestMessage(to Recipient, items []model.DigestItem) (notify.Message, error) {
+	return s.compose(to, model.NotificationDailyDigest, model.NotificationDailyDigest, map[string]interface{}{
+		"Items": items,
+		"Date":  s.now(),
+	}, false, map[string]string{
+		"items": strconv.Itoa(len(items)),
+	})
+}
+
+// CreditLineStatementMessage формирует уведомление о выписке по кредитной линии
+func (s *NotificationSender) CreditLineStatementMessage(to Recipient, balance, minPayment float64, dueDate time.Time) (notify.Message, error) {
+	return s.compose(to, model.NotificationCreditLineStatement, model.NotificationCreditLineStatement, map[string]interface{}{
+		"Balance":    balance,
+		"MinPayment": minPayment,
+		"DueDate":    dueDate,
+	}, false, map[string]string{
+		"balance":     formatAmount(balance),
+		"min_payment": formatAmount(minPayment),
+		"due_date":    dueDate.Format("2006-01-02"),
+	})
+}
+
+// CreditRateChangeMessage формирует уведомление о пересмотре плавающей ставки по кредиту
+func (s *NotificationSender) CreditRateChangeMessage(
+	to Recipient,
+	creditID uuid.UUID,
+	oldRate, newRate, newPayment float64,
+	effectiveFrom time.Time,
+) (notify.Message, error) {
+	return s.compose(to, model.NotificationCreditRateChanged, model.NotificationCreditRateChanged, map[string]interface{}{
+		"CreditID":      creditID.String(),
+		"OldRate":       oldRate,
+		"NewRate":       newRate,
+		"NewPayment":    newPayment,
+		"EffectiveFrom": effectiveFrom,
+	}, false, map[string]string{
+		"credit_id":      creditID.String(),
+		"old_rate":       formatAmount(oldRate),
+		"new_rate":       formatAmount(newRate),
+		"new_payment":    formatAmount(newPayment),
+		"effective_from": effectiveFrom.Format("2006-01-02"),
+	})
+}
+
+// RestructuringDecisionMessage формирует уведомление о решении по заявке на изменение условий кредита
+func (s *NotificationSender) RestructuringDecisionMessage(
+	to Recipient,
+	creditID uuid.UUID,
+	approved bool,
+	comment string,
+	newPayment float64,
+) (notify.Message, error) {
+	return s.compose(to, model.NotificationRestructuringDecision, model.NotificationRestructuringDecision, map[string]interface{}{
+		"CreditID":   creditID.String(),
+		"Approved":   approved,
+		"Comment":    comment,
+		"NewPayment": newPayment,
+	}, false, map[string]string{
+		"credit_id": creditID.String(),
+		"approved":  strconv.FormatBool(approved),
+	})
+}
+
+// CollectionReminderMessage формирует напоминание о просроченной задолженности
+func (s *NotificationSender) CollectionReminderMessage(to Recipient, creditID uuid.UUID, overdueAmount float64, daysPastDue int) (notify.Message, error) {
+	return s.compose(to, model.NotificationCollectionReminder, model.NotificationCollectionReminder, map[string]interface{}{
+		"CreditID":      creditID.String(),
+		"OverdueAmount": overdueAmount,
+		"DaysPastDue":   daysPastDue,
+	}, false, map[string]string{
+		"credit_id":      creditID.String(),
+		"overdue_amount": formatAmount(overdueAmount),
+		"days_past_due":  strconv.Itoa(daysPastDue),
+	})
+}
+
+// CreditDefaultMessage формирует уведомление о переводе кредита в дефолт
+func (s *NotificationSender) CreditDefaultMessage(to Recipient, creditID uuid.UUID, overdueAmount float64) (notify.Message, error) {
+	return s.compose(to, model.NotificationCreditDefaulted, model.NotificationCreditDefaulted, map[string]interface{}{
+		"CreditID":      creditID.String(),
+		"OverdueAmount": overdueAmount,
+	}, false, map[string]string{
+		"credit_id":      creditID.String(),
+		"overdue_amount": formatAmount(overdueAmount),
+	})
+}
+
+func (s *NotificationSender) SendAccountLockedNotification(to Recipient, until time.Time, ip string) error {
+	return s.send(to, model.NotificationLoginLocked, map[string]interface{}{
+		"IP":    ip,
+		"Until": until,
+	}, false, map[string]string{
+		"ip":    ip,
+		"until": until.Format(time.RFC3339),
+	})
+}
+
+// EmailEnabled сообщает, включена ли отправка писем
+func (s *NotificationSender) EmailEnabled() bool {
+	return s.emailEnabled
+}
+
+func (s *NotificationSender) SendEmailVerification(to Recipient, link string, expiresAt time.Time) error {
+	return s.send(to, model.NotificationEmailVerification, map[string]interface{}{
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+	}, true, nil)
+}
+
+func (s *NotificationSender) SendPasswordReset(to Recipient, token string, expiresAt time.Time) error {
+	return s.send(to, model.NotificationPasswordReset, map[string]interface{}{
+		"Code":      token,
+		"ExpiresAt": expiresAt,
+	}, true, nil)
+}
+
+func (s *NotificationSender) SendPasswordChanged(to Recipient, ip string) error {
+	return s.send(to, model.NotificationPasswordChanged, map[string]interface{}{
+		"IP":   ip,
+		"Date": s.now(),
+	}, false, map[string]string{
+		"ip": ip,
+	})
+}
+
+func (s *NotificationSender) SendEmailChangeConfirmation(to Recipient, link string, expiresAt time.Time) error {
+	return s.send(to, model.NotificationEmailChangeConfirmation, map[string]interface{}{
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+	}, true, nil)
+}
+
+// SendEmailChanged уведомляет прежний адрес to о смене email на newEmail
+func (s *NotificationSender) SendEmailChanged(to Recipient, newEmail string) error {
+	return s.send(to, model.NotificationEmailChanged, map[string]interface{}{
+		"NewEmail": newEmail,
+		"Date":     s.now(),
+	}, false, map[string]string{
+		"new_email": newEmail,
+	})
+}
+
+func (s *NotificationSender) SendAccountDeleted(to Recipient) error {
+	return s.send(to, model.NotificationAccountDeleted, map[string]interface{}{
+		"Date": s.now(),
+	}, false, nil)
+}
+
+// send формирует уведомление по шаблону события и передает его в каналы, выбранные для события
+func (s *NotificationSender) send(to Recipient, event string, values map[string]interface{}, sensitive bool, data map[string]string) error {
+	msg, err := s.compose(to, event, event, values, sensitive, data)
+	if err != nil {
+		return err
+	}
+	return s.Deliver(msg)
+}
+
+// Deliver сохраняет уведомление во входящих получателя и передает его в каналы, выбранные
+// для события. Уведомление сохраняется до отправки: если сохранить не удалось, оно не
+// отправляется, и повторная обработка события outbox не дублирует его в каналах.
+func (s *NotificationSender) Deliver(msg notify.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+	defer cancel()
+
+	if err := s.saveToInbox(ctx, msg); err != nil {
+		s.logger.WithError(err).WithField("event", msg.Event).Error("Ошибка сохранения уведомления во входящих")
+		return fmt.Errorf("не удалось сохранить уведомление: %w", err)
+	}
+
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		s.logger.WithError(err).WithField("event", msg.Event).Error("Ошибка отправки уведомления")
+		return fmt.Errorf("не удалось отправить уведомление: %w", err)
+	}
+
+	s.logger.WithField("event", msg.Event).Debugf("Уведомление отправлено для %s", msg.Email)
+	return nil
+}
+
+// saveToInbox сохраняет уведомление во входящих получателя. Уведомления с секретами
+// не сохраняются: ссылки и коды подтверждения доставляются только лично получателю.
+func (s *NotificationSender) saveToInbox(ctx context.Context, msg notify.Message) error {
+	if s.inbox == nil || msg.UserID == uuid.Nil || msg.Sensitive || inboxSkipped[msg.Event] {
+		return nil
+	}
+
+	item := &model.InboxItem{
+		ID:        uuid.New(),
+		UserID:    msg.UserID,
+		EventType: msg.Event,
+		Subject:   msg.Subject,
+		Body:      msg.Summary,
+		CreatedAt: msg.CreatedAt,
+	}
+	if msg.SourceID != uuid.Nil {
+		sourceID := msg.SourceID
+		item.SourceID = &sourceID
+	}
+	if len(msg.Data) > 0 {
+		data, err := json.Marshal(msg.Data)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации данных уведомления: %w", err)
+		}
+		item.Data = data
+	}
+	return s.inbox.AddInboxItem(ctx, item)
+}
+
+// compose формирует уведомление по шаблону на языке получателя. Sensitive уведомления
+// содержат секреты и доставляются только лично получателю.
+func (s *NotificationSender) compose(
+	to Recipient,
+	event, template string,
+	values map[string]interface{},
+	sensitive bool,
+	data map[string]string,
+) (notify.Message, error) {
+	rendered, err := s.templates.Render(template, to.Locale, values)
+	if err != nil {
+		s.logger.WithError(err).WithField("template", template).Error("Ошибка формирования уведомления")
+		return notify.Message{}, fmt.Errorf("не удалось сформировать уведомление: %w", err)
+	}
+
+	return notify.Message{
+		Event:     event,
+		Email:     to.Email,
+		UserID:    to.UserID,
+		Subject:   rendered.Subject,
+		Text:      rendered.Text,
+		HTML:      rendered.HTML,
+		Summary:   rendered.Summary,
+		Data:      data,
+		Sensitive: sensitive,
+		CreatedAt: s.now(),
+	}, nil
+}
+
+// alertTemplate возвращает имя шаблона порогового оповещения
+func alertTemplate(alertType string) string {
+	return "alert." + alertType
+}
+
+// formatAmount форматирует сумму для структурированных полей уведомления
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	outboxBatchSize     = 50
+	outboxLease         = 2 * time.Minute  // время, на которое обработчик захватывает событие
+	outboxBaseDelay     = 30 * time.Second // задержка перед первым повтором, далее удваивается
+	outboxMaxDelay      = time.Hour
+	outboxMaxAttempts   = 10
+	outboxRetention     = 7 * 24 * time.Hour // срок хранения доставленных событий
+	defaultOutboxLimit  = 50
+	maxOutboxListLimit  = 500
+	outboxLastErrorSize = 1000
+)
+
+// errOutboxPermanent помечает ошибку, повтор которой не поможет: событие сразу переводится в dead
+var errOutboxPermanent = errors.New("permanent outbox error")
+
+// newOutboxEvent формирует событие для записи в транзакции вместе с изменением данных
+func newOutboxEvent(eventType string, aggregateID uuid.UUID, payload interface{}) (*model.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации события %s: %w", eventType, err)
+	}
+	return &model.OutboxEvent{
+		ID:          uuid.New(),
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		Status:      model.OutboxStatusPending,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// OutboxDispatcher доставляет события outbox. Событие захватывается на время доставки,
+// поэтому несколько экземпляров сервиса могут работать одновременно. При ошибке доставка
+// повторяется с экспоненциальной задержкой, после outboxMaxAttempts попыток событие
+// переводится в статус dead.
+type OutboxDispatcher struct {
+	outboxRepo          *repository.OutboxRepository
+	userRepo            *repository.UserRepository
+	notifications       *NotificationSender
+	notificationService *NotificationService
+	webhooks            *WebhookService
+	budgets             *BudgetService
+	logger              *logrus.Logger
+}
+
+func NewOutboxDispatcher(
+	outboxRepo *repository.OutboxRepository,
+	userRepo *repository.UserRepository,
+	notifications *NotificationSender,
+	notificationService *NotificationService,
+	webhooks *WebhookService,
+	budgets *BudgetService,
+	logger *logrus.Logger,
+) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxRepo:          outboxRepo,
+		userRepo:            userRepo,
+		notifications:       notifications,
+		notificationService: notificationService,
+		webhooks:            webhooks,
+		budgets:             budgets,
+		logger:              logger,
+	}
+}
+
+// Start опрашивает outbox с интервалом interval до отмены контекста
+func (d *OutboxDispatcher) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			d.logger.WithError(err).Error("Ошибка обработки outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Обработчик outbox остановлен")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch доставляет все готовые к отправке события
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (model.JobStats, error) {
+	var stats model.JobStats
+
+	for ctx.Err() == nil {
+		events, err := d.outboxRepo.Claim(ctx, outboxBatchSize, outboxLease)
+		if err != nil {
+			return stats, fmt.Errorf("ошибка захвата событий: %w", err)
+		}
+
+		for _, event := range events {
+			stats.Processed++
+			if d.process(ctx, event) {
+				stats.Succeeded++
+			} else {
+				stats.Failed++
+			}
+		}
+
+		if len(events) < outboxBatchSize {
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// process доставляет событие и сохраняет результат. Возвращает true, если событие доставлено.
+func (d *OutboxDispatcher) process(ctx context.Context, event model.OutboxEvent) bool {
+	entry := d.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.EventType,
+		"attempt":    event.Attempts,
+	})
+
+	// Результат сохраняется и при остановке сервиса, иначе доставленное событие
+	// будет отправлено повторно после истечения захвата
+	deliverErr := d.deliver(ctx, event)
+	if deliverErr == nil {
+		marked, err := d.outboxRepo.MarkSent(context.Background(), event.ID, event.Attempts)
+		if err != nil {
+			entry.WithError(err).Error("Не удалось отметить событие доставленным")
+			return false
+		}
+		if !marked {
+			entry.Warn("Захват события истек до завершения доставки")
+		}
+		return true
+	}
+
+	dead := errors.Is(deliverErr, errOutboxPermanent) || event.Attempts >= outboxMaxAttempts
+	nextAttempt := time.Now().Add(outboxRetryDelay(event.Attempts))
+	if err := d.outboxRepo.MarkFailed(context.Background(), event.ID, event.Attempts, truncate(deliverErr.Error(), outboxLastErrorSize), nextAttempt, dead); err != nil {
+		entry.WithError(err).Error("Не удалось сохранить ошибку доставки события")
+	}
+
+	if dead {
+		entry.WithError(deliverErr).Error("Событие не доставлено, попытки исчерпаны")
+	} else {
+		entry.WithError(deliverErr).Warnf("Ошибка доставки события, повтор в %s", nextAttempt.Format(time.RFC3339))
+	}
+	return false
+}
+
+// deliver ставит событие в очередь webhook и отправляет уведомление с учетом настроек
+// получателя. Повторная публикация при повторе доставки не создает дублей webhook.
+func (d *OutboxDispatcher) deliver(ctx context.Context, event model.OutboxEvent) error {
+	if err := d.webhooks.PublishOutboxEvent(ctx, event); err != nil {
+		return fmt.Errorf("ошибка публикации webhook: %w", err)
+	}
+
+	switch event.EventType {
+	case model.OutboxTransferCompleted:
+		var payload model.TransferCompletedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.TransferMessage(RecipientOf(user), payload.Amount, payload.FromAccountID.String(), payload.ToAccountID.String())
+		if err != nil {
+			return err
+		}
+		return d.notificationService.Notify(ctx, user, event.ID, msg)
+
+	case model.OutboxCardPayment:
+		var payload model.CardPaymentEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		if err := d.notificationService.OnCardPayment(ctx, user, event.ID, payload); err != nil {
+			return err
+		}
+		return d.budgets.OnCardPayment(ctx, user, event.ID, payload, event.CreatedAt)
+
+	case model.OutboxCreditPayment:
+		var payload model.CreditPaymentEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.CreditPaymentMessage(RecipientOf(user), payload.Amount, payload.CreditID)
+		if err != nil {
+			return err
+		}
+		return d.notificationService.Notify(ctx, user, event.ID, msg)
+
+	case model.OutboxCreditLineStatement:
+		var payload model.CreditLineStatementEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.CreditLineStatementMessage(RecipientOf(user), payload.StatementBalance, payload.MinPayment, payload.DueDate)
+		if err != nil {
+			return err
+		}
+		msg.SourceID = event.ID
+		return d.notifications.Deliver(msg)
+
+	case model.OutboxCreditRateChanged:
+		var payload model.CreditRateChangedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.CreditRateChangeMessage(
+			RecipientOf(user),
+			payload.CreditID,
+			payload.OldRate,
+			payload.NewRate,
+			payload.NewPayment,
+			payload.EffectiveFrom,
+		)
+		if err != nil {
+			return err
+		}
+		// Уведомление об изменении условий кредита не отключается в настройках
+		msg.SourceID = event.ID
+		return d.notifications.Deliver(msg)
+
+	case model.OutboxRestructuringDecision:
+		var payload model.RestructuringDecisionEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.RestructuringDecisionMessage(RecipientOf(user), payload.CreditID, payload.Approved, payload.Comment, payload.NewPayment)
+		if err != nil {
+			return err
+		}
+		msg.SourceID = event.ID
+		return d.notifications.Deliver(msg)
+
+	case model.OutboxCollectionReminder:
+		var payload model.CollectionReminderEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.CollectionReminderMessage(RecipientOf(user), payload.CreditID, payload.OverdueAmount, payload.DaysPastDue)
+		if err != nil {
+			return err
+		}
+		msg.SourceID = event.ID
+		return d.notifications.Deliver(msg)
+
+	case model.OutboxBudgetAlert:
+		var payload model.BudgetAlertEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.BudgetAlertMessage(RecipientOf(user), payload.Progress, payload.Threshold)
+		if err != nil {
+			return err
+		}
+		return d.notificationService.Notify(ctx, user, event.ID, msg)
+
+	case model.OutboxCreditDefaulted:
+		var payload model.CreditDefaultedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		msg, err := d.notifications.CreditDefaultMessage(RecipientOf(user), payload.CreditID, payload.OverdueAmount)
+		if err != nil {
+			return err
+		}
+		msg.SourceID = event.ID
+		return d.notifications.Deliver(msg)
+
+	case model.OutboxBalanceChanged:
+		var payload model.BalanceChangedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		user, err := d.recipient(ctx, payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		return d.notificationService.OnBalanceChanged(ctx, user, event.ID, payload)
+	}
+
+	return fmt.Errorf("%w: неизвестный тип события %s", errOutboxPermanent, event.EventType)
+}
+
+// recipient возвращает получателя уведомления. Для удаленного пользователя возвращается
+// nil: событие считается обработанным без отправки.
+func (d *OutboxDispatcher) recipient(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := d.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("%w: %v", errOutboxPermanent, err)
+		}
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// outboxRetryDelay возвращает задержку перед следующей попыткой
+func outboxRetryDelay(attempt int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempt && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
+
+// ListEvents возвращает события outbox с указанным статусом (по умолчанию dead)
+func (d *OutboxDispatcher) ListEvents(ctx context.Context, status string, limit int) ([]model.OutboxEvent, error) {
+	if status == "" {
+		status = model.OutboxStatusDead
+	}
+	if status != model.OutboxStatusPending && status != model.OutboxStatusSent && status != model.OutboxStatusDead {
+		return nil, fmt.Errorf("неизвестный статус события: %s", status)
+	}
+	if limit <= 0 {
+		limit = defaultOutboxLimit
+	}
+	if limit > maxOutboxListLimit {
+		limit = maxOutboxListLimit
+	}
+	return d.outboxRepo.List(ctx, status, limit)
+}
+
+// Retry возвращает недоставленное событие в очередь
+func (d *OutboxDispatcher) Retry(ctx context.Context, id uuid.UUID) error {
+	if err := d.outboxRepo.Requeue(ctx, id); err != nil {
+		return err
+	}
+	d.logger.WithField("event_id", id).Info("Событие outbox возвращено в очередь")
+	return nil
+}
+
+// Cleanup удаляет доставленные события старше outboxRetention
+func (d *OutboxDispatcher) Cleanup(ctx context.Context) (model.JobStats, error) {
+	deleted, err := d.outboxRepo.DeleteSentBefore(ctx, time.Now().Add(-outboxRetention))
+	if err != nil {
+		return model.JobStats{}, err
+	}
+	return model.JobStats{Processed: int(deleted), Succeeded: int(deleted)}, nil
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+// ProfileBlockedError возвращается, если учетную запись нельзя удалить из-за остатков или долгов
+type ProfileBlockedError struct {
+	Blockers model.ProfileDeletionBlockers
+}
+
+func (e *ProfileBlockedError) Error() string {
+	return "учетную запись нельзя удалить: есть остатки на счетах или непогашенная задолженность"
+}
+
+// ProfileService управляет профилем пользователя: просмотр, смена имени, выгрузка
+// персональных данных и удаление учетной записи
+type ProfileService struct {
+	userRepo          *repository.UserRepository
+	accountRepo       *repository.AccountRepository
+	transactionRepo   *repository.TransactionRepository
+	creditRepo        *repository.CreditRepository
+	creditLineRepo    *repository.CreditLineRepository
+	refreshTokenRepo  *repository.RefreshTokenRepository
+	passwordResetRepo *repository.PasswordResetRepository
+	twoFactorRepo     *repository.TwoFactorRepository
+	apiKeyRepo        *repository.APIKeyRepository
+	notificationRepo  *repository.NotificationRepository
+	webhookRepo       *repository.WebhookRepository
+	categoryRepo      *repository.CategoryRepository
+	budgetRepo        *repository.BudgetRepository
+	cardService       *CardService
+	twoFactorService  *TwoFactorService
+	notifications     *NotificationSender
+	logger            *logrus.Logger
+}
+
+func NewProfileService(
+	userRepo *repository.UserRepository,
+	accountRepo *repository.AccountRepository,
+	transactionRepo *repository.TransactionRepository,
+	creditRepo *repository.CreditRepository,
+	creditLineRepo *repository.CreditLineRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	passwordResetRepo *repository.PasswordResetRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	apiKeyRepo *repository.APIKeyRepository,
+	notificationRepo *repository.NotificationRepository,
+	webhookRepo *repository.WebhookRepository,
+	categoryRepo *repository.CategoryRepository,
+	budgetRepo *repository.BudgetRepository,
+	cardService *CardService,
+	twoFactorService *TwoFactorService,
+	notifications *NotificationSender,
+	logger *logrus.Logger,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:          userRepo,
+		accountRepo:       accountRepo,
+		transactionRepo:   transactionRepo,
+		creditRepo:        creditRepo,
+		creditLineRepo:    creditLineRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		passwordResetRepo: passwordResetRepo,
+		twoFactorRepo:     twoFactorRepo,
+		apiKeyRepo:        apiKeyRepo,
+		notificationRepo:  notificationRepo,
+		webhookRepo:       webhookRepo,
+		categoryRepo:      categoryRepo,
+		budgetRepo:        budgetRepo,
+		cardService:       cardService,
+		twoFactorService:  twoFactorService,
+		notifications:     notifications,
+		logger:            logger,
+	}
+}
+
+// GetProfile возвращает профиль пользователя
+func (s *ProfileService) GetProfile(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+// UpdateUsername меняет имя пользователя
+func (s *ProfileService) UpdateUsername(ctx context.Context, userID uuid.UUID, input model.UpdateUsernameInput) (*model.User, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateUsername(ctx, userID, input.Username); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return nil, fmt.Errorf("имя пользователя уже занято")
+		}
+		return nil, err
+	}
+
+	s.logger.WithField("user_id", userID).Info("Имя пользователя изменено")
+	return s.GetProfile(ctx, userID)
+}
+
+// UpdateLocale меняет язык уведомлений
+func (s *ProfileService) UpdateLocale(ctx context.Context, userID uuid.UUID, input model.UpdateLocaleInput) (*model.User, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateLocale(ctx, userID, input.Locale); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{"user_id": userID, "locale": input.Locale}).Info("Язык уведомлений изменен")
+	return s.GetProfile(ctx, userID)
+}
+
+// Export собирает выгрузку персональных данных: профиль, счета, карты (с маскированными номерами),
+// кредиты с графиками, кредитные линии, все операции по счетам, активные сессии, webhook подписки,
+// входящие уведомления и правила категорий
+func (s *ProfileService) Export(ctx context.Context, userID uuid.UUID) (*model.PersonalDataExport, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	export := &model.PersonalDataExport{
+		ExportedAt:   now,
+		Profile:      *user,
+		Accounts:     []model.Account{},
+		Cards:        []model.CardResponse{},
+		Credits:      []model.CreditExport{},
+		CreditLines:  []model.CreditLine{},
+		Transactions: []model.Transaction{},
+		Sessions:     []model.Session{},
+		Webhooks:     []model.WebhookEndpoint{},
+	}
+
+	accounts, err := s.accountRepo.GetUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения счетов: %w", err)
+	}
+	export.Accounts = append(export.Accounts, accounts...)
+
+	for _, account := range accounts {
+		transactions, err := s.transactionRepo.GetByAccountAndPeriod(ctx, account.ID, time.Time{}, now)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения операций по счету %s: %w", account.ID, err)
+		}
+		export.Transactions = append(export.Transactions, transactions...)
+	}
+
+	cards, err := s.cardService.ListUserCards(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения карт: %w", err)
+	}
+	export.Cards = append(export.Cards, cards...)
+
+	credits, err := s.creditRepo.GetUserCredits(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредитов: %w", err)
+	}
+	for _, credit := range credits {
+		schedule, err := s.creditRepo.GetPaymentSchedule(ctx, credit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения графика платежей по кредиту %s: %w", credit.ID, err)
+		}
+		export.Credits = append(export.Credits, model.CreditExport{Credit: credit, Schedule: schedule})
+	}
+
+	lines, err := s.creditLineRepo.GetUserCreditLines(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кредитных линий: %w", err)
+	}
+	export.CreditLines = append(export.CreditLines, lines...)
+
+	sessions, err := s.refreshTokenRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сессий: %w", err)
+	}
+	export.Sessions = append(export.Sessions, sessions...)
+
+	export.Notifications.TelegramChatID, err = s.notificationRepo.GetTelegramChatID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек уведомлений: %w", err)
+	}
+	if export.Notifications.Preferences, err = s.notificationRepo.GetPreferences(ctx, userID); err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек уведомлений: %w", err)
+	}
+	if export.NotificationAlerts, err = s.notificationRepo.ListAlerts(ctx, userID, ""); err != nil {
+		return nil, fmt.Errorf("ошибка получения оповещений: %w", err)
+	}
+	if export.NotificationInbox, err = s.notificationRepo.ListInbox(ctx, userID, model.InboxFilter{}); err != nil {
+		return nil, fmt.Errorf("ошибка получения входящих уведомлений: %w", err)
+	}
+
+	webhooks, err := s.webhookRepo.ListEndpoints(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения webhook подписок: %w", err)
+	}
+	export.Webhooks = append(export.Webhooks, webhooks...)
+
+	if export.CategoryRules, err = s.categoryRepo.ListRules(ctx, userID); err != nil {
+		return nil, fmt.Errorf("ошибка получения правил категорий: %w", err)
+	}
+	if export.Budgets, err = s.budgetRepo.ListByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("ошибка получения бюджетов: %w", err)
+	}
+
+	s.logger.WithField("user_id", userID).Info("Сформирована выгрузка персональных данных")
+	return export, nil
+}
+
+// Delete удаляет учетную запись. Удаление запрещено, пока на счетах есть остаток или
+// не погашены кредиты и кредитные линии. Записи не удаляются: счета и линии закрываются,
+// персональные данные обезличиваются, история операций сохраняется.
+func (s *ProfileService) Delete(ctx context.Context, userID uuid.UUID, input model.DeleteProfileInput) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role != model.RoleCustomer {
+		return fmt.Errorf("учетную запись сотрудника нельзя удалить самостоятельно, обратитесь к администратору")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		s.logger.WithField("user_id", userID).Warn("Неверный пароль при удалении учетной записи")
+		return fmt.Errorf("неверный пароль")
+	}
+
+	twoFactor, err := s.twoFactorService.IsEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if twoFactor {
+		if input.Code == "" {
+			return fmt.Errorf("требуется код двухфакторной аутентификации")
+		}
+		if err := s.twoFactorService.Verify(ctx, userID, input.Code); err != nil {
+			return err
+		}
+	}
+
+	// Кредиты проверяются до транзакции: выдача нового кредита зачисляет средства на счет,
+	// а счета ниже блокируются, поэтому гонка приведет к ненулевому остатку
+	credits, err := s.creditRepo.GetUserCredits(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кредитов: %w", err)
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.userRepo.GetByIDForUpdate(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	var blockers model.ProfileDeletionBlockers
+
+	accounts, err := s.accountRepo.GetUserAccountsForUpdate(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения счетов: %w", err)
+	}
+	for _, account := range accounts {
+		if account.Balance != 0 {
+			blockers.AccountsWithBalance = append(blockers.AccountsWithBalance, account.ID.String())
+		}
+	}
+
+	for _, credit := range credits {
+		if credit.Status == "active" || credit.Status == "overdue" || credit.Status == "defaulted" {
+			blockers.ActiveCredits = append(blockers.ActiveCredits, credit.ID.String())
+		}
+	}
+
+	lines, err := s.creditLineRepo.GetUserCreditLinesForUpdate(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кредитных линий: %w", err)
+	}
+	for _, line := range lines {
+		if line.UsedAmount > 0 || line.AccruedInterest > 0 {
+			blockers.CreditLinesWithDebt = append(blockers.CreditLinesWithDebt, line.ID.String())
+		}
+	}
+
+	if !blockers.Empty() {
+		s.logger.WithField("user_id", userID).Warn("Удаление учетной записи отклонено: есть остатки или задолженность")
+		return &ProfileBlockedError{Blockers: blockers}
+	}
+
+	if err := s.accountRepo.CloseUserAccountsTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка закрытия счетов: %w", err)
+	}
+	if err := s.creditLineRepo.CloseUserLinesTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка закрытия кредитных линий: %w", err)
+	}
+	if _, err := s.refreshTokenRepo.RevokeAllForUserTx(ctx, tx, userID, "account_deleted"); err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+	if err := s.refreshTokenRepo.EraseDeviceInfoTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления сведений о сессиях: %w", err)
+	}
+	if err := s.passwordResetRepo.DeleteForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления токенов сброса пароля: %w", err)
+	}
+	if err := s.twoFactorRepo.DeleteTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка отключения 2FA: %w", err)
+	}
+	if err := s.apiKeyRepo.DisableOwnerServiceAccountsTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка отключения сервисных учетных записей: %w", err)
+	}
+	if err := s.notificationRepo.DeleteForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления настроек уведомлений: %w", err)
+	}
+	if err := s.webhookRepo.DeleteForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления webhook подписок: %w", err)
+	}
+	if err := s.categoryRepo.DeleteForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления правил категорий: %w", err)
+	}
+	if err := s.budgetRepo.DeleteForUserTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка удаления бюджетов: %w", err)
+	}
+	if err := s.userRepo.AnonymizeTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка обезличивания данных: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"accounts": len(accounts),
+	}).Warn("Учетная запись удалена, персональные данные обезличены")
+
+	to := RecipientOf(user)
+	go func() {
+		if err := s.notifications.SendAccountDeleted(to); err != nil {
+			s.logger.WithError(err).Error("Не удалось отправить уведомление об удалении учетной записи")
+		}
+	}()
+
+	return nil
+}
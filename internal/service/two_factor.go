@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/openpgp"
+
+	"banking-api/internal/crypto"
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	totpSkew             = 1  // допустимое расхождение часов, интервалов по 30 секунд
+	recoveryCodesCount   = 10 // количество кодов восстановления
+	recoveryCodeLength   = 10 // символов в коде восстановления (без дефиса)
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type TwoFactorService struct {
+	userRepo      *repository.UserRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	pgpKey        *openpgp.Entity
+	hmacKey       []byte
+	issuer        string
+	logger        *logrus.Logger
+}
+
+func NewTwoFactorService(
+	userRepo *repository.UserRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	pgpKey *openpgp.Entity,
+	hmacKey []byte,
+	issuer string,
+	logger *logrus.Logger,
+) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		pgpKey:        pgpKey,
+		hmacKey:       hmacKey,
+		issuer:        issuer,
+		logger:        logger,
+	}
+}
+
+// Setup создает новый секрет и коды восстановления. 2FA начинает действовать
+// только после подтверждения первым кодом из приложения.
+func (s *TwoFactorService) Setup(ctx context.Context, userID uuid.UUID) (*model.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := crypto.EncryptMessage(s.pgpKey, []byte(secret))
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка шифрования секрета 2FA")
+		return nil, fmt.Errorf("ошибка шифрования секрета: %w", err)
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.twoFactorRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	tf := &model.TwoFactor{
+		UserID:          userID,
+		EncryptedSecret: encrypted,
+		SecretHMAC:      s.secretMAC(userID, secret),
+		CreatedAt:       time.Now(),
+	}
+	if err := s.twoFactorRepo.SavePendingTx(ctx, tx, tf); err != nil {
+		if strings.Contains(err.Error(), "already enabled") {
+			return nil, fmt.Errorf("двухфакторная аутентификация уже подключена")
+		}
+		return nil, fmt.Errorf("ошибка сохранения секрета: %w", err)
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения кодов восстановления: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Начато подключение 2FA для пользователя %s", userID)
+	return &model.TwoFactorSetup{
+		Secret:        secret,
+		OTPAuthURI:    crypto.TOTPURI(s.issuer, user.Email, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Confirm подключает 2FA, если код из приложения совпадает с новым секретом
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uuid.UUID, code string) error {
+	tx, err := s.twoFactorRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	tf, err := s.twoFactorRepo.GetByUserIDForUpdate(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+	if tf == nil {
+		return fmt.Errorf("двухфакторная аутентификация не настраивалась")
+	}
+	if tf.Enabled {
+		return fmt.Errorf("двухфакторная аутентификация уже подключена")
+	}
+
+	secret, err := s.decryptSecret(tf)
+	if err != nil {
+		return err
+	}
+
+	step, ok := crypto.ValidateTOTP(secret, code, time.Now(), totpSkew, tf.LastUsedStep)
+	if !ok {
+		return fmt.Errorf("неверный код")
+	}
+
+	if err := s.twoFactorRepo.EnableTx(ctx, tx, userID, step); err != nil {
+		return fmt.Errorf("ошибка подключения 2FA: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("2FA подключена для пользователя %s", userID)
+	return nil
+}
+
+// Disable отключает 2FA. Требуются пароль и действующий код (или код восстановления).
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, password, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return fmt.Errorf("неверный пароль")
+	}
+
+	tx, err := s.twoFactorRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.verifyTx(ctx, tx, userID, code); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.DeleteTx(ctx, tx, userID); err != nil {
+		return fmt.Errorf("ошибка отключения 2FA: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("2FA отключена для пользователя %s", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления взамен прежних
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	tx, err := s.twoFactorRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.verifyTx(ctx, tx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения кодов восстановления: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения операции: %w", err)
+	}
+
+	s.logger.Infof("Коды восстановления 2FA перевыпущены для пользователя %s", userID)
+	return codes, nil
+}
+
+func (s *TwoFactorService) Status(ctx context.Context, userID uuid.UUID) (*model.TwoFactorStatus, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+
+	status := &model.TwoFactorStatus{}
+	if tf == nil || !tf.Enabled {
+		return status, nil
+	}
+
+	left, err := s.twoFactorRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения кодов восстановления: %w", err)
+	}
+
+	status.Enabled = true
+	status.ConfirmedAt = tf.ConfirmedAt
+	status.RecoveryCodesLeft = left
+	return status, nil
+}
+
+// IsEnabled сообщает, требуется ли пользователю второй фактор при входе
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// Verify проверяет код из приложения или одноразовый код восстановления
+func (s *TwoFactorService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	tx, err := s.twoFactorRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.verifyTx(ctx, tx, userID, code); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// verifyTx проверяет код подключенной 2FA. Принятый TOTP код запоминается,
+// использованный код восстановления погашается.
+func (s *TwoFactorService) verifyTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, code string) error {
+	tf, err := s.twoFactorRepo.GetByUserIDForUpdate(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+	if tf == nil || !tf.Enabled {
+		return fmt.Errorf("двухфакторная аутентификация не подключена")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == crypto.TOTPDigits {
+		secret, err := s.decryptSecret(tf)
+		if err != nil {
+			return err
+		}
+		step, ok := crypto.ValidateTOTP(secret, code, time.Now(), totpSkew, tf.LastUsedStep)
+		if !ok {
+			s.logger.Warnf("Неверный код 2FA для пользователя %s", userID)
+			return fmt.Errorf("неверный код")
+		}
+		return s.twoFactorRepo.UpdateLastUsedStepTx(ctx, tx, userID, step)
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCodeTx(ctx, tx, userID, s.recoveryCodeHash(userID, code))
+	if err != nil {
+		return fmt.Errorf("ошибка проверки кода восстановления: %w", err)
+	}
+	if !used {
+		s.logger.Warnf("Неверный код восстановления для пользователя %s", userID)
+		return fmt.Errorf("неверный код")
+	}
+
+	s.logger.Warnf("Пользователь %s вошел по коду восстановления", userID)
+	return nil
+}
+
+// decryptSecret расшифровывает секрет и проверяет, что он принадлежит пользователю
+func (s *TwoFactorService) decryptSecret(tf *model.TwoFactor) (string, error) {
+	plaintext, err := crypto.DecryptMessage(s.pgpKey, tf.EncryptedSecret)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Ошибка расшифровки секрета 2FA пользователя %s", tf.UserID)
+		return "", fmt.Errorf("ошибка проверки кода")
+	}
+
+	secret := string(plaintext)
+	if !hmac.Equal([]byte(s.secretMAC(tf.UserID, secret)), []byte(tf.SecretHMAC)) {
+		s.logger.Errorf("Нарушена целостность секрета 2FA пользователя %s", tf.UserID)
+		return "", fmt.Errorf("ошибка проверки кода")
+	}
+	return secret, nil
+}
+
+// secretMAC привязывает секрет к пользователю, чтобы зашифрованный секрет нельзя было перенести другому
+func (s *TwoFactorService) secretMAC(userID uuid.UUID, secret string) string {
+	h := hmac.New(sha256.New, s.hmacKey)
+	h.Write([]byte(userID.String() + "|" + secret))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// generateRecoveryCodes возвращает коды для показа пользователю и их хеши для хранения
+func (s *TwoFactorService) generateRecoveryCodes(userID uuid.UUID) ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < recoveryCodesCount; i++ {
+		code := make([]byte, 0, recoveryCodeLength+1)
+		for j := 0; j < recoveryCodeLength; j++ {
+			if j == recoveryCodeLength/2 {
+				code = append(code, '-')
+			}
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, fmt.Errorf("ошибка генерации кодов восстановления: %w", err)
+			}
+			code = append(code, recoveryCodeAlphabet[n.Int64()])
+		}
+
+		codes = append(codes, string(code))
+		hashes = append(hashes, s.recoveryCodeHash(userID, string(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// recoveryCodeHash нормализует код (регистр, дефисы, пробелы) и возвращает его HMAC
+func (s *TwoFactorService) recoveryCodeHash(userID uuid.UUID, code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := hmac.New(sha256.New, s.hmacKey)
+	h.Write([]byte(userID.String() + "|" + normalized))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
+
+	"banking-api/internal/crypto"
+	"banking-api/internal/model"
+	"banking-api/internal/repository"
+)
+
+const (
+	// WebhookSecretPrefix - начало секрета подписи webhook
+	WebhookSecretPrefix = "whsec_"
+
+	webhookSecretBytes       = 32
+	maxWebhooksPerUser       = 10
+	webhookBatchSize         = 20
+	webhookLease             = 2 * time.Minute
+	webhookRequestTimeout    = 10 * time.Second
+	webhookBaseDelay         = 30 * time.Second // задержка перед первым повтором, далее удваивается
+	webhookMaxDelay          = 6 * time.Hour
+	webhookMaxAttempts       = 8
+	webhookRetention         = 30 * 24 * time.Hour // срок хранения завершенных доставок
+	webhookDialTimeout       = 5 * time.Second
+	defaultWebhookDeliveries = 50
+	maxWebhookDeliveries     = 200
+)
+
+// outboxWebhookEvents - события outbox, которые публикуются в webhook
+var outboxWebhookEvents = map[string]string{
+	model.OutboxBalanceChanged:    model.WebhookTransactionCreated,
+	model.OutboxTransferCompleted: model.WebhookTransferCompleted,
+	model.OutboxCardPayment:       model.WebhookCardPaymentCompleted,
+	model.OutboxCreditPayment:     model.WebhookCreditPaymentCompleted,
+}
+
+// errWebhookAddressBlocked - адрес подписки относится к внутренней сети
+var errWebhookAddressBlocked = errors.New("адрес webhook не должен указывать на внутреннюю сеть")
+
+// webhookBlockedNetworks - диапазоны, не покрытые проверками net.IP: общий адрес
+// операторов (CGNAT), "эта сеть" и сеть тестирования производительности
+var webhookBlockedNetworks = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// webhookIPBlocked сообщает, что доставка на адрес ip запрещена: loopback, частные,
+// link-local, unique-local, multicast и неопределенные адреса. allowLoopback разрешает
+// loopback для локальной разработки.
+func webhookIPBlocked(ip net.IP, allowLoopback bool) bool {
+	if ip.IsLoopback() {
+		return !allowLoopback
+	}
+	if ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range webhookBlockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookService управляет webhook подписками пользователей и доставляет им события.
+// Доставка выполняется фоновым обработчиком: запрос подписывается HMAC-SHA256 от
+// метки времени и тела, при ошибке повторяется с экспоненциальной задержкой,
+// каждая попытка записывается в журнал.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	pgpKey      *openpgp.Entity
+	client      *http.Client
+	resolver    *net.Resolver
+	// allowLocalhost разрешает подписки на http://localhost для локальной разработки
+	allowLocalhost bool
+	logger         *logrus.Logger
+}
+
+// NewWebhookService создает сервис webhook. Адреса внутренней сети запрещены при создании
+// подписки и повторно проверяются при каждом соединении: имя узла может указывать
+// на другой адрес к моменту доставки.
+func NewWebhookService(
+	webhookRepo *repository.WebhookRepository,
+	pgpKey *openpgp.Entity,
+	allowLocalhost bool,
+	logger *logrus.Logger,
+) *WebhookService {
+	dialer := &net.Dialer{
+		Timeout: webhookDialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || webhookIPBlocked(ip, allowLocalhost) {
+				return errWebhookAddressBlocked
+			}
+			return nil
+		},
+	}
+
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		pgpKey:      pgpKey,
+		client: &http.Client{
+			Timeout: webhookRequestTimeout,
+			// Прокси не используется: адрес соединения должен проверяться при подключении
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   webhookDialTimeout,
+				ResponseHeaderTimeout: webhookRequestTimeout,
+				MaxIdleConns:          20,
+				IdleConnTimeout:       90 * time.Second,
+				ForceAttemptHTTP2:     true,
+			},
+			// Перенаправления не выполняются: подпись относится к адресу подписки
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		resolver:       net.DefaultResolver,
+		allowLocalhost: allowLocalhost,
+		logger:         logger,
+	}
+}
+
+// checkURL проверяет, что доставка на адрес разрешена: https (http только для localhost
+// при allowLocalhost) и все адреса узла вне внутренней сети
+func (s *WebhookService) checkURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("неверный адрес webhook")
+	}
+	host := u.Hostname()
+	local := s.allowLocalhost && (host == "localhost" || host == "127.0.0.1" || host == "::1")
+	if u.Scheme != "https" && !local {
+		return fmt.Errorf("адрес webhook должен использовать https")
+	}
+
+	addrs, err := s.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("не удалось определить адрес узла %s", host)
+	}
+	for _, addr := range addrs {
+		if webhookIPBlocked(addr.IP, local) {
+			return errWebhookAddressBlocked
+		}
+	}
+	return nil
+}
+
+// CreateEndpoint создает подписку. Секрет подписи возвращается только в этом ответе.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID uuid.UUID, input model.CreateWebhookEndpointInput) (*model.CreatedWebhookEndpoint, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(ctx, input.URL); err != nil {
+		return nil, err
+	}
+
+	count, err := s.webhookRepo.CountEndpoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, fmt.Errorf("достигнуто максимальное число webhook подписок (%d)", maxWebhooksPerUser)
+	}
+
+	secret, encrypted, err := s.newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	endpoint := &model.WebhookEndpoint{
+		ID:              uuid.New(),
+		UserID:          userID,
+		URL:             input.URL,
+		Description:     input.Description,
+		EventTypes:      input.EventTypes,
+		EncryptedSecret: encrypted,
+		Active:          true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.webhookRepo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("ошибка создания подписки: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"endpoint_id": endpoint.ID,
+		"event_types": endpoint.EventTypes,
+	}).Info("Создана webhook подписка")
+	return &model.CreatedWebhookEndpoint{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// ListEndpoints возвращает подписки пользователя
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID uuid.UUID) ([]model.WebhookEndpoint, error) {
+	return s.webhookRepo.ListEndpoints(ctx, userID)
+}
+
+// GetEndpoint возвращает подписку пользователя
+func (s *WebhookService) GetEndpoint(ctx context.Context, userID, endpointID uuid.UUID) (*model.WebhookEndpoint, error) {
+	return s.webhookRepo.GetEndpoint(ctx, userID, endpointID)
+}
+
+// UpdateEndpoint меняет адрес, описание, типы событий или активность подписки.
+// Доставки отключенной подписки ждут ее включения.
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, userID, endpointID uuid.UUID, input model.UpdateWebhookEndpointInput) (*model.WebhookEndpoint, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if input.URL != nil {
+		if err := s.checkURL(ctx, *input.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint, err := s.webhookRepo.GetEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	input.Apply(endpoint)
+	if err := s.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"endpoint_id": endpointID,
+		"active":      endpoint.Active,
+	}).Info("Webhook подписка изменена")
+	return s.webhookRepo.GetEndpoint(ctx, userID, endpointID)
+}
+
+// DeleteEndpoint удаляет подписку вместе с историей доставок
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, userID, endpointID uuid.UUID) error {
+	if err := s.webhookRepo.DeleteEndpoint(ctx, userID, endpointID); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{"user_id": userID, "endpoint_id": endpointID}).Info("Webhook подписка удалена")
+	return nil
+}
+
+// RotateSecret выпускает новый секрет подписи. Прежний секрет перестает действовать сразу.
+func (s *WebhookService) RotateSecret(ctx context.Context, userID, endpointID uuid.UUID) (*model.CreatedWebhookEndpoint, error) {
+	secret, encrypted, err := s.newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.UpdateSecret(ctx, userID, endpointID, encrypted); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.webhookRepo.GetEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{"user_id": userID, "endpoint_id": endpointID}).Info("Секрет webhook подписки заменен")
+	return &model.CreatedWebhookEndpoint{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// ListDeliveries возвращает доставки подписки (?status=failed&limit=50)
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, endpointID uuid.UUID, status string, limit int) ([]model.WebhookDelivery, error) {
+	if status != "" && status != model.WebhookDeliveryPending && status != model.WebhookDeliveryDelivered && status != model.WebhookDeliveryFailed {
+		return nil, fmt.Errorf("неизвестный статус доставки: %s", status)
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveries
+	}
+	if limit > maxWebhookDeliveries {
+		limit = maxWebhookDeliveries
+	}
+
+	if _, err := s.webhookRepo.GetEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, endpointID, status, limit)
+}
+
+// GetDelivery возвращает доставку с журналом попыток
+func (s *WebhookService) GetDelivery(ctx context.Context, userID, endpointID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.webhookRepo.GetDelivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.AttemptLog, err = s.webhookRepo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver повторно отправляет событие: доставка возвращается в очередь со сброшенным
+// счетчиком попыток. Тело запроса и ID события не меняются.
+func (s *WebhookService) Redeliver(ctx context.Context, userID, endpointID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookRepo.GetDelivery(ctx, endpointID, deliveryID); err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.Redeliver(ctx, endpointID, deliveryID); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"endpoint_id": endpointID,
+		"delivery_id": deliveryID,
+	}).Info("Доставка webhook возвращена в очередь")
+	return s.webhookRepo.GetDelivery(ctx, endpointID, deliveryID)
+}
+
+// Publish ставит событие в очередь доставки на все активные подписки пользователя.
+// eventID одинаков для всех попыток; повторная публикация события не создает новых доставок.
+func (s *WebhookService) Publish(ctx context.Context, userID, eventID uuid.UUID, eventType string, createdAt time.Time, data interface{}) error {
+	endpoints, err := s.webhookRepo.SubscribedEndpoints(ctx, userID, eventType)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	raw, ok := data.(json.RawMessage)
+	if !ok {
+		if raw, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("ошибка сериализации события %s: %w", eventType, err)
+		}
+	}
+	payload, err := json.Marshal(model.WebhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: createdAt,
+		Data:      raw,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события %s: %w", eventType, err)
+	}
+
+	for _, endpoint := range endpoints {
+		delivery := &model.WebhookDelivery{
+			ID:         uuid.New(),
+			EndpointID: endpoint.ID,
+			EventID:    eventID,
+			EventType:  eventType,
+			Payload:    payload,
+			CreatedAt:  time.Now(),
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishOutboxEvent публикует событие outbox, если у него есть webhook аналог.
+// Данные события передаются получателю без изменений.
+func (s *WebhookService) PublishOutboxEvent(ctx context.Context, event model.OutboxEvent) error {
+	eventType, ok := outboxWebhookEvents[event.EventType]
+	if !ok {
+		return nil
+	}
+
+	var owner struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &owner); err != nil {
+		return fmt.Errorf("%w: %v", errOutboxPermanent, err)
+	}
+	return s.Publish(ctx, owner.UserID, event.ID, eventType, event.CreatedAt, event.Payload)
+}
+
+// Start опрашивает очередь доставок с интервалом interval до отмены контекста
+func (s *WebhookService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Error("Ошибка доставки webhook")
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Обработчик webhook остановлен")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch выполняет все готовые к отправке доставки
+func (s *WebhookService) Dispatch(ctx context.Context) (model.JobStats, error) {
+	var stats model.JobStats
+
+	for ctx.Err() == nil {
+		deliveries, err := s.webhookRepo.ClaimDeliveries(ctx, webhookBatchSize, webhookLease)
+		if err != nil {
+			return stats, fmt.Errorf("ошибка захвата доставок: %w", err)
+		}
+
+		endpoints := make(map[uuid.UUID]*model.WebhookEndpoint)
+		for _, delivery := range deliveries {
+			stats.Processed++
+			endpoint, ok := endpoints[delivery.EndpointID]
+			if !ok {
+				if endpoint, err = s.webhookRepo.GetEndpointByID(ctx, delivery.EndpointID); err != nil {
+					s.logger.WithError(err).WithField("delivery_id", delivery.ID).Error("Не удалось получить webhook подписку")
+					stats.Failed++
+					continue
+				}
+				endpoints[delivery.EndpointID] = endpoint
+			}
+
+			if s.process(ctx, endpoint, delivery) {
+				stats.Succeeded++
+			} else {
+				stats.Failed++
+			}
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// process отправляет событие и сохраняет результат. Возвращает true, если получатель ответил 2xx.
+func (s *WebhookService) process(ctx context.Context, endpoint *model.WebhookEndpoint, delivery model.WebhookDelivery) bool {
+	entry := s.logger.WithFields(logrus.Fields{
+		"endpoint_id": endpoint.ID,
+		"delivery_id": delivery.ID,
+		"event_type":  delivery.EventType,
+		"attempt":     delivery.Attempts,
+	})
+
+	started := time.Now()
+	statusCode, sendErr := s.send(ctx, endpoint, delivery)
+
+	// Результат сохраняется и при остановке сервиса, иначе выполненная доставка
+	// будет отправлена повторно после истечения захвата
+	attempt := &model.WebhookDeliveryAttempt{
+		ID:         uuid.New(),
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		DurationMs: int(time.Since(started).Milliseconds()),
+		CreatedAt:  started,
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if sendErr != nil {
+		message := truncate(sendErr.Error(), outboxLastErrorSize)
+		attempt.Error = &message
+	}
+	if err := s.webhookRepo.AddAttempt(context.Background(), attempt); err != nil {
+		entry.WithError(err).Error("Не удалось записать попытку доставки webhook")
+	}
+
+	if sendErr == nil {
+		if err := s.webhookRepo.MarkDelivered(context.Background(), delivery.ID, delivery.Attempts, statusCode); err != nil {
+			entry.WithError(err).Error("Не удалось отметить доставку webhook выполненной")
+		}
+		return true
+	}
+
+	failed := delivery.Attempts >= webhookMaxAttempts
+	nextAttempt := time.Now().Add(webhookRetryDelay(delivery.Attempts))
+	err := s.webhookRepo.MarkFailed(context.Background(), delivery.ID, delivery.Attempts, attempt.StatusCode,
+		*attempt.Error, nextAttempt, failed)
+	if err != nil {
+		entry.WithError(err).Error("Не удалось сохранить ошибку доставки webhook")
+	}
+
+	if failed {
+		entry.WithError(sendErr).Warn("Доставка webhook не выполнена, попытки исчерпаны")
+	} else {
+		entry.WithError(sendErr).Infof("Ошибка доставки webhook, повтор в %s", nextAttempt.Format(time.RFC3339))
+	}
+	return false
+}
+
+// send выполняет запрос к адресу подписки и возвращает код ответа; ошибка означает,
+// что доставку нужно повторить. Тело ответа не сохраняется.
+func (s *WebhookService) send(ctx context.Context, endpoint *model.WebhookEndpoint, delivery model.WebhookDelivery) (int, error) {
+	secret, err := crypto.DecryptMessage(s.pgpKey, endpoint.EncryptedSecret)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка расшифровки секрета подписи: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка формирования запроса: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "banking-api-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+WebhookSignature(secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("получатель вернул статус %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// WebhookSignature возвращает подпись запроса: HMAC-SHA256 с секретом подписки от строки
+// "<timestamp>.<тело запроса>" в шестнадцатеричном виде. Метка времени в подписи не дает
+// повторно использовать перехваченный запрос.
+func WebhookSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay возвращает задержку перед следующей попыткой
+func webhookRetryDelay(attempt int) time.Duration {
+	delay := webhookBaseDelay
+	for i := 1; i < attempt && delay < webhookMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxDelay {
+		delay = webhookMaxDelay
+	}
+	return delay
+}
+
+// Cleanup удаляет завершенные доставки старше webhookRetention
+func (s *WebhookService) Cleanup(ctx context.Context) (model.JobStats, error) {
+	deleted, err := s.webhookRepo.DeleteFinishedBefore(ctx, time.Now().Add(-webhookRetention))
+	if err != nil {
+		return model.JobStats{}, err
+	}
+	return model.JobStats{Processed: int(deleted), Succeeded: int(deleted)}, nil
+}
+
+// newSecret создает секрет подписи и возвращает его вместе с зашифрованной копией для БД
+func (s *WebhookService) newSecret() (string, string, error) {
+	raw := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("ошибка генерации секрета: %w", err)
+	}
+	secret := WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	encrypted, err := crypto.EncryptMessage(s.pgpKey, []byte(secret))
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка шифрования секрета webhook")
+		return "", "", fmt.Errorf("ошибка шифрования секрета: %w", err)
+	}
+	return secret, encrypted, nil
+}
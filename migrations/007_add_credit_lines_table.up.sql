@@ -0,0 +1,64 @@
+CREATE TABLE credit_lines
+(
+    id                  UUID PRIMARY KEY,
+    user_id             UUID           NOT NULL REFERENCES users (id),
+    card_id             UUID           NOT NULL UNIQUE REFERENCES cards (id),
+    account_id          UUID           NOT NULL REFERENCES accounts (id),
+    credit_limit        DECIMAL(15, 2) NOT NULL,
+    used_amount         DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    accrued_interest    DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    interest_rate       DECIMAL(5, 2)  NOT NULL,
+    min_payment_percent DECIMAL(5, 2)  NOT NULL,
+    grace_period_days   INTEGER        NOT NULL,
+    grace_active        BOOLEAN        NOT NULL DEFAULT TRUE,
+    last_accrued_at     TIMESTAMP      NOT NULL,
+    next_statement_date TIMESTAMP      NOT NULL,
+    status              VARCHAR(20)    NOT NULL,
+    created_at          TIMESTAMP      NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP      NOT NULL DEFAULT NOW(),
+    CHECK (used_amount >= 0)
+);
+
+CREATE INDEX idx_credit_lines_user_id ON credit_lines (user_id);
+CREATE INDEX idx_credit_lines_next_statement_date ON credit_lines (next_statement_date);
+
+CREATE TABLE credit_line_statements
+(
+    id                UUID PRIMARY KEY,
+    credit_line_id    UUID           NOT NULL REFERENCES credit_lines (id),
+    period_start      TIMESTAMP      NOT NULL,
+    period_end        TIMESTAMP      NOT NULL,
+    statement_balance DECIMAL(15, 2) NOT NULL,
+    min_payment       DECIMAL(15, 2) NOT NULL,
+    deferred_interest DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    paid_amount       DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    due_date          TIMESTAMP      NOT NULL,
+    status            VARCHAR(20)    NOT NULL,
+    created_at        TIMESTAMP      NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_credit_line_statements_credit_line_id ON credit_line_statements (credit_line_id);
+CREATE INDEX idx_credit_line_statements_due_date ON credit_line_statements (due_date);
+
+CREATE TABLE credit_line_operations
+(
+    id             UUID PRIMARY KEY,
+    credit_line_id UUID           NOT NULL REFERENCES credit_lines (id),
+    operation_type VARCHAR(20)    NOT NULL, -- draw, repayment, interest
+    amount         DECIMAL(15, 2) NOT NULL,
+    reference_id   UUID,
+    created_at     TIMESTAMP      NOT NULL DEFAULT NOW(),
+    CHECK (amount > 0)
+);
+
+CREATE INDEX idx_credit_line_operations_credit_line_id ON credit_line_operations (credit_line_id);
+
+-- Часть оплаты картой, покрытая кредитной линией. amount - списание со счета, поэтому
+-- оплата, полностью покрытая линией, записывается с нулевой суммой по счету.
+ALTER TABLE transactions ADD COLUMN credit_line_amount DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_amount_check;
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_amount_check
+        CHECK (amount >= 0 AND credit_line_amount >= 0 AND amount + credit_line_amount > 0);
@@ -0,0 +1,27 @@
+ALTER TABLE credits
+    ADD COLUMN rate_type   VARCHAR(20)   NOT NULL DEFAULT 'fixed',
+    ADD COLUMN rate_margin DECIMAL(5, 2) NOT NULL DEFAULT 5.00;
+
+CREATE INDEX idx_credits_rate_type ON credits (rate_type);
+
+CREATE TABLE key_rates
+(
+    rate_date  DATE PRIMARY KEY,
+    rate       DECIMAL(5, 2) NOT NULL,
+    created_at TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE credit_rate_changes
+(
+    id             UUID PRIMARY KEY,
+    credit_id      UUID           NOT NULL REFERENCES credits (id),
+    key_rate       DECIMAL(5, 2)  NOT NULL,
+    old_rate       DECIMAL(5, 2)  NOT NULL,
+    new_rate       DECIMAL(5, 2)  NOT NULL,
+    old_payment    DECIMAL(15, 2) NOT NULL,
+    new_payment    DECIMAL(15, 2) NOT NULL,
+    effective_from TIMESTAMP      NOT NULL,
+    created_at     TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_credit_rate_changes_credit_id ON credit_rate_changes (credit_id);
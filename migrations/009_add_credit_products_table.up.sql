@@ -0,0 +1,40 @@
+CREATE TABLE credit_products
+(
+    id                          UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    name                        VARCHAR(100)   NOT NULL UNIQUE,
+    description                 TEXT           NOT NULL DEFAULT '',
+    min_amount                  DECIMAL(15, 2) NOT NULL,
+    max_amount                  DECIMAL(15, 2) NOT NULL,
+    min_term_months             INTEGER        NOT NULL,
+    max_term_months             INTEGER        NOT NULL,
+    rate_type                   VARCHAR(20)    NOT NULL, -- fixed, floating
+    fixed_rate                  DECIMAL(5, 2),           -- NULL: ключевая ставка + маржа на дату выдачи
+    rate_margin                 DECIMAL(5, 2)  NOT NULL DEFAULT 0,
+    penalty_type                VARCHAR(20)    NOT NULL, -- none, percent, fixed
+    penalty_value               DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    schedule_type               VARCHAR(20)    NOT NULL, -- annuity, differentiated
+    early_repayment_allowed     BOOLEAN        NOT NULL DEFAULT TRUE,
+    early_repayment_fee_percent DECIMAL(5, 2)  NOT NULL DEFAULT 0,
+    early_repayment_min_months  INTEGER        NOT NULL DEFAULT 0,
+    active                      BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at                  TIMESTAMP      NOT NULL DEFAULT NOW(),
+    updated_at                  TIMESTAMP      NOT NULL DEFAULT NOW(),
+    CHECK (min_amount > 0 AND max_amount >= min_amount),
+    CHECK (min_term_months > 0 AND max_term_months >= min_term_months)
+);
+
+-- Продукты, повторяющие прежние условия выдачи кредитов
+INSERT INTO credit_products (name, description, min_amount, max_amount, min_term_months, max_term_months,
+                             rate_type, rate_margin, penalty_type, penalty_value, schedule_type)
+VALUES ('Потребительский кредит', 'Фиксированная ставка: ключевая ставка ЦБ на дату выдачи + 5%',
+        1000, 5000000, 6, 60, 'fixed', 5.00, 'percent', 10, 'annuity'),
+       ('Кредит с плавающей ставкой', 'Ставка пересматривается при изменении ключевой ставки ЦБ: ключевая + 5%',
+        1000, 5000000, 6, 60, 'floating', 5.00, 'percent', 10, 'annuity');
+
+ALTER TABLE credits
+    ADD COLUMN product_id    UUID REFERENCES credit_products (id),
+    ADD COLUMN penalty_type  VARCHAR(20)    NOT NULL DEFAULT 'percent',
+    ADD COLUMN penalty_value DECIMAL(15, 2) NOT NULL DEFAULT 10,
+    ADD COLUMN schedule_type VARCHAR(20)    NOT NULL DEFAULT 'annuity';
+
+CREATE INDEX idx_credits_product_id ON credits (product_id);
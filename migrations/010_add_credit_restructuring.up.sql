@@ -0,0 +1,36 @@
+-- Версионирование графика платежей: при реструктуризации будущие платежи текущей версии
+-- переводятся в статус superseded, а новый график записывается со следующим номером версии
+ALTER TABLE payment_schedules
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE credits
+    ADD COLUMN schedule_version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_payment_schedules_credit_version ON payment_schedules (credit_id, version);
+
+CREATE TABLE credit_restructuring_requests
+(
+    id                UUID PRIMARY KEY,
+    credit_id         UUID         NOT NULL REFERENCES credits (id),
+    user_id           UUID         NOT NULL REFERENCES users (id),
+    request_type      VARCHAR(20)  NOT NULL, -- holiday, restructuring
+    holiday_months    INTEGER      NOT NULL DEFAULT 0,
+    extra_term_months INTEGER      NOT NULL DEFAULT 0,
+    new_rate          DECIMAL(5, 2),
+    reason            TEXT         NOT NULL DEFAULT '',
+    status            VARCHAR(20)  NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    reviewer_id       UUID REFERENCES users (id),
+    review_comment    TEXT         NOT NULL DEFAULT '',
+    reviewed_at       TIMESTAMP,
+    schedule_version  INTEGER,                                -- версия графика, созданная при одобрении
+    created_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+    CHECK (holiday_months >= 0 AND extra_term_months >= 0)
+);
+
+CREATE INDEX idx_restructuring_requests_credit_id ON credit_restructuring_requests (credit_id);
+CREATE INDEX idx_restructuring_requests_status ON credit_restructuring_requests (status);
+
+-- По кредиту может рассматриваться только одна заявка
+CREATE UNIQUE INDEX idx_restructuring_requests_pending
+    ON credit_restructuring_requests (credit_id) WHERE status = 'pending';
@@ -0,0 +1,38 @@
+-- Согласие заемщика на безакцептное списание просроченной задолженности с других счетов
+ALTER TABLE credits
+    ADD COLUMN auto_sweep_allowed BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE collection_cases
+(
+    id                UUID PRIMARY KEY,
+    credit_id         UUID           NOT NULL REFERENCES credits (id),
+    user_id           UUID           NOT NULL REFERENCES users (id),
+    status            VARCHAR(20)    NOT NULL DEFAULT 'open', -- open, resolved, defaulted
+    overdue_since     TIMESTAMP      NOT NULL,
+    overdue_amount    DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    swept_amount      DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    last_reminder_day INTEGER        NOT NULL DEFAULT 0,      -- последний отправленный этап: 1, 7, 30
+    opened_at         TIMESTAMP      NOT NULL DEFAULT NOW(),
+    closed_at         TIMESTAMP,
+    created_at        TIMESTAMP      NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_collection_cases_status ON collection_cases (status);
+
+-- По кредиту может быть открыто только одно дело
+CREATE UNIQUE INDEX idx_collection_cases_open_credit
+    ON collection_cases (credit_id) WHERE status = 'open';
+
+CREATE TABLE collection_events
+(
+    id         UUID PRIMARY KEY,
+    case_id    UUID        NOT NULL REFERENCES collection_cases (id),
+    event_type VARCHAR(20) NOT NULL, -- opened, reminder, sweep, note, defaulted, resolved
+    message    TEXT        NOT NULL DEFAULT '',
+    amount     DECIMAL(15, 2),
+    author_id  UUID REFERENCES users (id),
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_collection_events_case_id ON collection_events (case_id, created_at);
@@ -0,0 +1,17 @@
+-- Журнал запусков фоновых задач планировщика
+CREATE TABLE job_runs
+(
+    id          UUID PRIMARY KEY,
+    job_name    VARCHAR(50)  NOT NULL,
+    instance    VARCHAR(255) NOT NULL,                   -- хост и PID экземпляра, выполнявшего задачу
+    status      VARCHAR(20)  NOT NULL DEFAULT 'running', -- running, succeeded, failed, cancelled
+    processed   INTEGER      NOT NULL DEFAULT 0,
+    succeeded   INTEGER      NOT NULL DEFAULT 0,
+    failed      INTEGER      NOT NULL DEFAULT 0,
+    skipped     INTEGER      NOT NULL DEFAULT 0,         -- записи, уже захваченные другим экземпляром
+    error       TEXT,
+    started_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_job_runs_job_name ON job_runs (job_name, started_at DESC);
@@ -0,0 +1,32 @@
+-- Refresh токены. Токены одного входа образуют семейство (family_id): при каждом обновлении
+-- выдается новый токен, а предыдущий помечается использованным. Повторное использование
+-- токена означает его утечку, и все семейство отзывается.
+CREATE TABLE refresh_tokens
+(
+    id                UUID PRIMARY KEY,
+    user_id           UUID         NOT NULL REFERENCES users (id),
+    family_id         UUID         NOT NULL,
+    parent_id         UUID REFERENCES refresh_tokens (id),
+    token_hash        VARCHAR(64)  NOT NULL UNIQUE, -- SHA-256 от токена, сам токен не хранится
+    user_agent        VARCHAR(512) NOT NULL DEFAULT '',
+    ip                VARCHAR(64)  NOT NULL DEFAULT '',
+    access_jti        UUID         NOT NULL,        -- access токен, выданный вместе с refresh токеном
+    access_expires_at TIMESTAMP    NOT NULL,
+    created_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+    expires_at        TIMESTAMP    NOT NULL,
+    used_at           TIMESTAMP,
+    revoked_at        TIMESTAMP,
+    revoke_reason     VARCHAR(20)                   -- logout, reuse, session_deleted
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Отозванные access токены (denylist по jti). Записи удаляются после истечения срока токена.
+CREATE TABLE revoked_access_tokens
+(
+    jti        UUID PRIMARY KEY,
+    user_id    UUID      NOT NULL REFERENCES users (id),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+-- Двухфакторная аутентификация (TOTP, RFC 6238)
+CREATE TABLE user_two_factor
+(
+    user_id          UUID PRIMARY KEY REFERENCES users (id),
+    encrypted_secret TEXT        NOT NULL,           -- секрет, зашифрованный PGP ключом сервера
+    secret_hmac      VARCHAR(64) NOT NULL,           -- HMAC-SHA256 от user_id и секрета, защищает от подмены
+    enabled          BOOLEAN     NOT NULL DEFAULT FALSE,
+    last_used_step   BIGINT      NOT NULL DEFAULT 0, -- последний принятый интервал, защита от повтора кода
+    created_at       TIMESTAMP   NOT NULL DEFAULT NOW(),
+    confirmed_at     TIMESTAMP
+);
+
+-- Одноразовые коды восстановления, хранятся в виде HMAC-SHA256
+CREATE TABLE user_recovery_codes
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id),
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_user_recovery_codes_hash ON user_recovery_codes (user_id, code_hash);
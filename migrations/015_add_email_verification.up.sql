@@ -0,0 +1,22 @@
+ALTER TABLE users
+    ADD COLUMN email_verified    BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Пользователи, зарегистрированные до введения подтверждения email, считаются подтвержденными
+UPDATE users
+SET email_verified    = TRUE,
+    email_verified_at = NOW();
+
+-- Одноразовые токены сброса пароля, хранятся в виде SHA-256 хеша
+CREATE TABLE password_reset_tokens
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    ip         VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP   NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
@@ -0,0 +1,11 @@
+-- Счетчики неудачных попыток входа по email и IP адресу.
+-- Используются PostgresLoginLimiter, чтобы ограничение действовало на всех экземплярах сервиса.
+CREATE TABLE login_attempts
+(
+    key             VARCHAR(320) PRIMARY KEY, -- email:<адрес> или ip:<адрес>
+    failures        INT          NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    blocked_until   TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_last_failure_at ON login_attempts (last_failure_at);
@@ -0,0 +1,32 @@
+-- Роли пользователей: customer - клиент, operator - сотрудник поддержки, admin - администратор
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('customer', 'operator', 'admin'));
+
+-- Блокировка счета сотрудником банка
+ALTER TABLE accounts
+    ADD COLUMN status        VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen')),
+    ADD COLUMN frozen_at     TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN freeze_reason VARCHAR(50);
+
+-- Журнал действий сотрудников
+CREATE TABLE audit_log
+(
+    id          UUID PRIMARY KEY,
+    actor_id    UUID                     NOT NULL REFERENCES users (id),
+    actor_role  VARCHAR(20)              NOT NULL,
+    action      VARCHAR(100)             NOT NULL,
+    target_type VARCHAR(50)              NOT NULL DEFAULT '',
+    target_id   VARCHAR(100)             NOT NULL DEFAULT '',
+    reason_code VARCHAR(50)              NOT NULL DEFAULT '',
+    comment     TEXT                     NOT NULL DEFAULT '',
+    details     JSONB,
+    ip          VARCHAR(64)              NOT NULL DEFAULT '',
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_actor_id ON audit_log (actor_id, created_at);
+CREATE INDEX idx_audit_log_target ON audit_log (target_type, target_id, created_at);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+
+CREATE INDEX idx_transactions_account_created ON transactions (account_id, created_at);
@@ -0,0 +1,33 @@
+-- Сервисные учетные записи для интеграций. Действуют от имени владельца,
+-- но обращаются к API только по ключам с ограниченным набором прав.
+CREATE TABLE service_accounts
+(
+    id          UUID PRIMARY KEY,
+    owner_id    UUID         NOT NULL REFERENCES users (id),
+    name        VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    disabled_at TIMESTAMP
+);
+
+CREATE INDEX idx_service_accounts_owner_id ON service_accounts (owner_id);
+
+-- API ключи. Хранится только SHA-256 от ключа; префикс показывается пользователю,
+-- чтобы отличать ключи друг от друга.
+CREATE TABLE api_keys
+(
+    id                 UUID PRIMARY KEY,
+    service_account_id UUID         NOT NULL REFERENCES service_accounts (id),
+    name               VARCHAR(100) NOT NULL,
+    prefix             VARCHAR(16)  NOT NULL,
+    key_hash           VARCHAR(64)  NOT NULL UNIQUE,
+    scopes             TEXT[]       NOT NULL,
+    account_id         UUID REFERENCES accounts (id), -- счет, с которого разрешены переводы
+    created_at         TIMESTAMP    NOT NULL DEFAULT NOW(),
+    expires_at         TIMESTAMP    NOT NULL,
+    last_used_at       TIMESTAMP,
+    last_used_ip       VARCHAR(64),
+    revoked_at         TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_service_account_id ON api_keys (service_account_id);
@@ -0,0 +1,9 @@
+-- Смена email: новый адрес хранится до подтверждения ссылкой из письма
+ALTER TABLE users
+    ADD COLUMN pending_email VARCHAR(255),
+    ADD COLUMN deleted_at    TIMESTAMP WITH TIME ZONE; -- учетная запись удалена, персональные данные обезличены
+
+-- Счета удаленных пользователей закрываются, история операций сохраняется
+ALTER TABLE accounts DROP CONSTRAINT accounts_status_check;
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_status_check CHECK (status IN ('active', 'frozen', 'closed'));
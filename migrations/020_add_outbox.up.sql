@@ -0,0 +1,20 @@
+-- Transactional outbox: события записываются в той же транзакции, что и движение средств,
+-- и доставляются фоновым обработчиком с повторами. Событие, исчерпавшее попытки,
+-- переводится в статус dead и ждет ручного повтора.
+CREATE TABLE outbox_events
+(
+    id              UUID PRIMARY KEY,
+    event_type      VARCHAR(50) NOT NULL,
+    aggregate_id    UUID        NOT NULL, -- перевод, платеж или кредит, к которому относится событие
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMP, -- событие захвачено обработчиком до этого момента
+    last_error      TEXT,
+    created_at      TIMESTAMP   NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_events_status_created_at ON outbox_events (status, created_at);
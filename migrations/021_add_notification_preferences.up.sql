@@ -0,0 +1,49 @@
+-- Настройки уведомлений пользователя: личный чат Telegram
+CREATE TABLE notification_settings
+(
+    user_id          UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    telegram_chat_id VARCHAR(64),
+    updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Способ доставки уведомлений по типу события и каналу. Если строки нет,
+-- используется значение по умолчанию (model.DefaultNotificationMode).
+CREATE TABLE notification_preferences
+(
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    channel    VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'telegram')),
+    mode       VARCHAR(20) NOT NULL CHECK (mode IN ('immediate', 'digest', 'off')),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, event_type, channel),
+    CHECK (mode <> 'digest' OR channel = 'email') -- сводка отправляется только письмом
+);
+
+-- Пороговые оповещения: баланс счета ниже порога, оплата картой выше порога
+CREATE TABLE notification_alerts
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    alert_type VARCHAR(30)              NOT NULL CHECK (alert_type IN ('balance_below', 'card_payment_above')),
+    account_id UUID REFERENCES accounts (id) ON DELETE CASCADE, -- NULL - любой счет пользователя
+    threshold  DECIMAL(15, 2)           NOT NULL CHECK (threshold > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_alerts_user_id ON notification_alerts (user_id);
+
+-- Уведомления, ожидающие ежедневной сводки. source_id - событие outbox, из которого
+-- получено уведомление: повторная обработка события не добавляет его в сводку дважды.
+CREATE TABLE notification_digest_items
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    source_id  UUID                     NOT NULL,
+    event_type VARCHAR(50)              NOT NULL,
+    subject    VARCHAR(255)             NOT NULL,
+    body       TEXT                     NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (source_id, event_type)
+);
+
+CREATE INDEX idx_notification_digest_items_user_id ON notification_digest_items (user_id, created_at);
@@ -0,0 +1,3 @@
+-- Язык уведомлений пользователя
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(5) NOT NULL DEFAULT 'ru' CHECK (locale IN ('ru', 'en'));
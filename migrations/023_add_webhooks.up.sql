@@ -0,0 +1,55 @@
+-- Webhook подписки пользователей и интеграций: события по счетам, картам и кредитам
+-- отправляются POST запросом с подписью HMAC-SHA256. Секрет подписи хранится
+-- зашифрованным ключом PGP сервера, так как нужен для формирования подписи.
+CREATE TABLE webhook_endpoints
+(
+    id               UUID PRIMARY KEY,
+    user_id          UUID         NOT NULL REFERENCES users (id),
+    url              VARCHAR(500) NOT NULL,
+    description      VARCHAR(500) NOT NULL DEFAULT '',
+    event_types      TEXT[]       NOT NULL,
+    encrypted_secret TEXT         NOT NULL,
+    active           BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoints_user_id ON webhook_endpoints (user_id);
+
+-- Доставка события на адрес подписки. Повторная публикация того же события
+-- (повтор обработки outbox) не создает вторую доставку.
+CREATE TABLE webhook_deliveries
+(
+    id               UUID PRIMARY KEY,
+    endpoint_id      UUID        NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_id         UUID        NOT NULL,
+    event_type       VARCHAR(50) NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts         INT         NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP   NOT NULL DEFAULT NOW(),
+    locked_until     TIMESTAMP, -- доставка захвачена обработчиком до этого момента
+    last_status_code INT,
+    last_error       TEXT,
+    created_at       TIMESTAMP   NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMP,
+    UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint_created_at ON webhook_deliveries (endpoint_id, created_at);
+
+-- Журнал попыток доставки: код ответа и ошибка. Тело ответа не сохраняется: адрес
+-- подписки задает пользователь, и ответ не должен возвращаться ему через журнал.
+CREATE TABLE webhook_delivery_attempts
+(
+    id          UUID PRIMARY KEY,
+    delivery_id UUID      NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt     INT       NOT NULL,
+    status_code INT,
+    error       TEXT,
+    duration_ms INT       NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id);
@@ -0,0 +1,21 @@
+-- Входящие уведомления в приложении. source_id - событие outbox, из которого получено
+-- уведомление: повторная обработка события не добавляет его дважды. Для уведомлений,
+-- отправленных не через outbox, source_id не заполняется.
+CREATE TABLE notification_inbox
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    source_id  UUID,
+    event_type VARCHAR(50)              NOT NULL,
+    subject    VARCHAR(255)             NOT NULL,
+    body       TEXT                     NOT NULL,
+    data       JSONB,
+    read_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_notification_inbox_source ON notification_inbox (source_id, event_type)
+    WHERE source_id IS NOT NULL;
+CREATE INDEX idx_notification_inbox_user_id ON notification_inbox (user_id, created_at DESC);
+CREATE INDEX idx_notification_inbox_unread ON notification_inbox (user_id) WHERE read_at IS NULL;
+CREATE INDEX idx_notification_inbox_created_at ON notification_inbox (created_at);
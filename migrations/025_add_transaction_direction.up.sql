@@ -0,0 +1,16 @@
+-- Направление операции: in - зачисление на счет, out - списание. Сумма операции всегда
+-- положительна, поэтому для расчета доходов и расходов направление хранится отдельно.
+ALTER TABLE transactions ADD COLUMN direction VARCHAR(3) CHECK (direction IN ('in', 'out'));
+
+-- Направление определяется типом операции для всех типов, кроме переводов. У переводов,
+-- записанных до этой миграции, части списания и зачисления не различаются: направление
+-- остается пустым, и такие операции не учитываются в доходах и расходах.
+UPDATE transactions
+SET direction = CASE
+                    WHEN transaction_type IN ('deposit', 'credit', 'adjustment_credit') THEN 'in'
+                    ELSE 'out'
+    END
+WHERE transaction_type <> 'transfer';
+
+-- Временные ряды аналитики выбирают операции счета за период
+CREATE INDEX idx_transactions_account_created_at ON transactions (account_id, created_at);
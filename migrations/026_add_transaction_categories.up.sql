@@ -0,0 +1,29 @@
+-- Продавец, MCC и категория операции. Категория оплаты картой определяется правилами
+-- пользователя и MCC, остальных операций - типом операции.
+ALTER TABLE transactions
+    ADD COLUMN merchant_name VARCHAR(255),
+    ADD COLUMN mcc           VARCHAR(4),
+    ADD COLUMN category      VARCHAR(50);
+
+-- Оплаты картой, проведенные до миграции, не содержат MCC и относятся к категории other
+UPDATE transactions
+SET category = CASE WHEN transaction_type = 'card_payment' THEN 'other' ELSE transaction_type END;
+
+ALTER TABLE transactions ALTER COLUMN category SET NOT NULL;
+
+-- Правила категорий пользователя: срабатывает правило с наибольшим приоритетом,
+-- при равном приоритете - созданное позже
+CREATE TABLE category_rules
+(
+    id                UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id           UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    merchant_contains VARCHAR(100),
+    mcc               VARCHAR(4),
+    category          VARCHAR(50)              NOT NULL,
+    priority          INT                      NOT NULL DEFAULT 0,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (merchant_contains IS NOT NULL OR mcc IS NOT NULL)
+);
+
+CREATE INDEX idx_category_rules_user_id ON category_rules (user_id);
@@ -0,0 +1,24 @@
+-- Месячные бюджеты пользователя: по категории расходов или общий (category IS NULL)
+-- на все оплаты картой. У пользователя один бюджет на категорию.
+CREATE TABLE budgets
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category   VARCHAR(50),
+    amount     DECIMAL(15, 2)           NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_budgets_user_category ON budgets (user_id, COALESCE(category, ''));
+
+-- Отправленные оповещения о расходовании бюджета: каждый порог (80% и 100%)
+-- срабатывает не больше одного раза за месяц
+CREATE TABLE budget_alerts
+(
+    budget_id  UUID                     NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+    month      DATE                     NOT NULL,
+    threshold  INT                      NOT NULL CHECK (threshold IN (80, 100)),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (budget_id, month, threshold)
+);